@@ -1,11 +1,15 @@
 package main
 
 import (
+	"errors"
 	"log"
 	"os"
 
 	"github.com/urfave/cli/v2"
 
+	vaultapp "github.com/razzkumar/vlt/internal/app"
+	"github.com/razzkumar/vlt/internal/audit"
+	"github.com/razzkumar/vlt/internal/buildinfo"
 	vaultcli "github.com/razzkumar/vlt/pkg/cli"
 )
 
@@ -15,13 +19,35 @@ func main() {
 		Usage: "Minimal secrets management with Vault (optionally with Transit encryption)",
 		Description: `vlt is a CLI tool for managing secrets with HashiCorp Vault using optional Transit encryption.
 It supports storing and retrieving single values or multiple key-value pairs, with smart merging capabilities.`,
-		Version: "2.0.0",
+		Version: buildinfo.Version,
 		Authors: []*cli.Author{
 			{
 				Name: "vlt contributors",
 			},
 		},
 		Commands: vaultcli.GetCommands(),
+		OnUsageError: func(ctx *cli.Context, err error, isSubcommand bool) error {
+			return vaultapp.WithExitCode(vaultapp.ExitUsage, err)
+		},
+		// Exit codes are handled below rather than by urfave/cli
+		ExitErrHandler: func(ctx *cli.Context, err error) {},
+		Before: func(ctx *cli.Context) error {
+			vaultapp.SetQuiet(ctx.Bool("quiet"))
+
+			path := ctx.String("audit-log")
+			if path == "" {
+				return nil
+			}
+			args := ctx.Args().Slice()
+			command := ""
+			if len(args) > 0 {
+				command, args = args[0], args[1:]
+				if cmd := ctx.App.Command(command); cmd != nil {
+					command = cmd.Name
+				}
+			}
+			return audit.Start(path, command, audit.FlagNames(args))
+		},
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "vault-addr",
@@ -38,6 +64,41 @@ It supports storing and retrieving single values or multiple key-value pairs, wi
 				Usage:   "Vault namespace",
 				EnvVars: []string{"VAULT_NAMESPACE"},
 			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "Connection profile from ~/.config/vault-env/profiles.yaml (address, namespace, auth method, mounts)",
+				EnvVars: []string{"VAULT_ENV_PROFILE"},
+			},
+			&cli.BoolFlag{
+				Name:    "read-only",
+				Usage:   "Refuse operations that write to Vault (put, import, apply, restore-snapshot), e.g. on production bastions",
+				EnvVars: []string{"VAULT_ENV_READ_ONLY"},
+			},
+			&cli.StringFlag{
+				Name:    "proxy",
+				Usage:   "HTTP(S) or SOCKS5 proxy URL for Vault requests (default: HTTPS_PROXY, honoring NO_PROXY)",
+				EnvVars: []string{"VAULT_PROXY"},
+			},
+			&cli.StringFlag{
+				Name:    "tls-min-version",
+				Usage:   "Minimum TLS version for Vault connections: 1.2 or 1.3",
+				EnvVars: []string{"VAULT_TLS_MIN_VERSION"},
+			},
+			&cli.StringFlag{
+				Name:    "vault-timeout",
+				Usage:   "Timeout of each Vault request, in seconds or as a duration like 2m (default: 15s)",
+				EnvVars: []string{"VAULT_TIMEOUT"},
+			},
+			&cli.StringFlag{
+				Name:    "vault-connect-timeout",
+				Usage:   "Timeout for connecting to Vault and for failover health checks (default: 5s)",
+				EnvVars: []string{"VAULT_CONNECT_TIMEOUT"},
+			},
+			&cli.GenericFlag{
+				Name:  "header",
+				Usage: "Add a \"Name: value\" header to every Vault request (repeatable), e.g. for a gateway in front of Vault",
+				Value: &vaultcli.HeaderList{},
+			},
 			&cli.StringFlag{
 				Name:    "encryption-key",
 				Usage:   "Default transit encryption key",
@@ -69,50 +130,81 @@ It supports storing and retrieving single values or multiple key-value pairs, wi
 				Usage:   "Vault Kubernetes auth role",
 				EnvVars: []string{"VAULT_K8S_ROLE"},
 			},
-		},
-		Before: func(ctx *cli.Context) error {
-			// Set environment variables from flags if provided
-			if addr := ctx.String("vault-addr"); addr != "" {
-				os.Setenv("VAULT_ADDR", addr)
-			}
-			if token := ctx.String("vault-token"); token != "" {
-				os.Setenv("VAULT_TOKEN", token)
-			}
-			if namespace := ctx.String("vault-namespace"); namespace != "" {
-				os.Setenv("VAULT_NAMESPACE", namespace)
-			}
-			if encKey := ctx.String("encryption-key"); encKey != "" {
-				os.Setenv("ENCRYPTION_KEY", encKey)
-			}
-			// Auth method environment variables
-			if authMethod := ctx.String("vault-auth-method"); authMethod != "" {
-				os.Setenv("VAULT_AUTH_METHOD", authMethod)
-			}
-			if roleID := ctx.String("vault-role-id"); roleID != "" {
-				os.Setenv("VAULT_ROLE_ID", roleID)
-			}
-			if secretID := ctx.String("vault-secret-id"); secretID != "" {
-				os.Setenv("VAULT_SECRET_ID", secretID)
-			}
-			if githubToken := ctx.String("vault-github-token"); githubToken != "" {
-				os.Setenv("VAULT_GITHUB_TOKEN", githubToken)
-			}
-			if k8sRole := ctx.String("vault-k8s-role"); k8sRole != "" {
-				os.Setenv("VAULT_K8S_ROLE", k8sRole)
-			}
-			return nil
+			&cli.StringFlag{
+				Name:    "vault-token-role",
+				Usage:   "After login, create the token used for Vault calls against this token role",
+				EnvVars: []string{"VAULT_TOKEN_ROLE"},
+			},
+			&cli.BoolFlag{
+				Name:    "batch-token",
+				Usage:   "After login, use a batch token (short-lived, non-renewable) for Vault calls",
+				EnvVars: []string{"VAULT_BATCH_TOKEN"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "mfa",
+				Usage:   "MFA credential as method[:passcode], e.g. corp-totp:123456 or corp-okta for a push (repeatable)",
+				EnvVars: []string{"VAULT_MFA"},
+			},
+			&cli.StringFlag{
+				Name:    "control-group-wait",
+				Usage:   "How long to wait for control group approval of a read, e.g. 10m; 0 fails at once (default 5m)",
+				EnvVars: []string{"VAULT_CONTROL_GROUP_WAIT"},
+			},
+			&cli.StringFlag{
+				Name:    "audit-log",
+				Usage:   "Append a JSONL audit record of this command (user, command, Vault paths; never values) to this file",
+				EnvVars: []string{"VLT_AUDIT_LOG"},
+			},
+			&cli.StringFlag{
+				Name:    "record",
+				Usage:   "Record sanitized Vault responses to this fixture file for --replay",
+				EnvVars: []string{"VLT_RECORD"},
+			},
+			&cli.StringFlag{
+				Name:    "replay",
+				Usage:   "Answer Vault requests from a fixture file written by --record, without network access",
+				EnvVars: []string{"VLT_REPLAY"},
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "Suppress informational messages and warnings so stdout carries only data",
+				EnvVars: []string{"VLT_QUIET"},
+			},
 		},
 		UsageText: `vlt [global options] command [command options] [arguments...]
 
 ENVIRONMENT VARIABLES:
-  VAULT_ADDR         Vault server address (required)
+  VAULT_ADDR         Vault server address (required; comma-separated for failover;
+                     unix:///path/to.sock for a local Vault Agent listener)
   VAULT_TOKEN        Vault authentication token (required for token auth)
   VAULT_NAMESPACE    Vault namespace (optional)
+  VAULT_ENV_PROFILE  Connection profile of ~/.config/vault-env/profiles.yaml, same as --profile
+                     (optional)
+  VAULT_ENV_READ_ONLY
+                     Refuse operations that write to Vault, like --read-only (optional)
   VAULT_CACERT       CA certificate path (optional)
+  VAULT_CAPATH       Directory of CA certificates (optional)
+  VAULT_TLS_SERVER_NAME
+                     Server name to verify the certificate against (optional)
+  VAULT_TLS_MIN_VERSION
+                     Minimum TLS version: 1.2 (default) or 1.3 (optional)
+  VAULT_TIMEOUT      Timeout of each Vault request, e.g. 30 or 2m (default: 15s)
+  VAULT_CONNECT_TIMEOUT
+                     Connect and health check timeout (default: 5s)
   VAULT_SKIP_VERIFY  Skip TLS verification (optional)
+  VAULT_AGENT_ADDR   Vault Agent listener, used instead of VAULT_ADDR (optional)
+  VAULT_AGENT_TOKEN_FILE
+                     Vault Agent sink file to read the token from when VAULT_TOKEN is unset,
+                     re-read when the agent rotates it (optional)
+  VAULT_PROXY        HTTP(S) or SOCKS5 proxy URL, e.g. socks5://bastion:1080 (optional;
+                     defaults to HTTPS_PROXY/HTTP_PROXY, hosts in NO_PROXY bypass it)
   ENCRYPTION_KEY     Default transit encryption key (defaults to "app-secrets" when TRANSIT=true)
   TRANSIT            Enable/disable transit encryption: true/false, 1/0, yes/no, on/off (optional)
-  TRANSIT_MOUNT      Transit mount path (defaults to "transit" when TRANSIT=true)
+  TRANSIT_MOUNT      Transit mount path, below --transit-mount and above the config's
+                     transit.mount (default: "transit")
+  KV_MOUNT           KV v2 mount path, below --kv-mount and above the config's kv.mount
+                     (default: "kv")
   
   Authentication (auto-detected or explicit):
   VAULT_AUTH_METHOD  Auth method: token, approle, github, kubernetes (optional)
@@ -129,6 +221,40 @@ ENVIRONMENT VARIABLES:
   VAULT_K8S_JWT_PATH Kubernetes service account token path (default: /var/run/secrets/kubernetes.io/serviceaccount/token)
   VAULT_K8S_AUTH_PATH Kubernetes auth mount path (default: kubernetes)
 
+  Working token (created after login and used for every other call):
+  VAULT_TOKEN_ROLE   Create it against this token role, like --vault-token-role (optional)
+  VAULT_BATCH_TOKEN  Make it a batch token, like --batch-token (optional)
+
+  Step-up authorization (Vault Enterprise):
+  VAULT_MFA          Comma-separated MFA credentials as method[:passcode], like --mfa (optional)
+  VAULT_CONTROL_GROUP_WAIT
+                     How long to wait for control group approval (default: 5m; 0 fails at once)
+
+  Auditing:
+  VLT_AUDIT_LOG      Append a JSONL record of every command to this file (optional)
+  VLT_CORRELATION_ID ID sent as X-Correlation-ID with every Vault request (optional;
+                     a random one is generated per command)
+
+  Output:
+  VLT_QUIET          Suppress informational messages and warnings, like --quiet (optional)
+
+  Testing:
+  VLT_RECORD         Record sanitized Vault responses to this fixture file, like --record (optional)
+  VLT_REPLAY         Answer Vault requests from this fixture file, like --replay (optional)
+
+EXIT CODES:
+  0    Success
+  1    Unclassified failure
+  2    Usage error (invalid flags/arguments, missing required input)
+  3    Authentication to Vault failed
+  4    Permission denied by Vault
+  5    Secret path or key not found
+  6    Transit decryption failed
+  7    Command given to run could not be started
+  8    Differences found (drift, verify, apply against a changed plan, self-update --check)
+  124  Command given to run --timeout timed out
+  A command started by run that exits non-zero passes its exit status through.
+
 EXAMPLES:
   # Token authentication (default)
   VAULT_ADDR=https://vault.example.com VAULT_TOKEN=hvs.xxx vlt get --path secrets/app
@@ -194,7 +320,20 @@ EXAMPLES:
   vlt completion fish > ~/.config/fish/completions/vlt.fish`,
 	}
 
-	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+	err := app.Run(os.Args)
+	code := vaultcli.ExitCode(err)
+	if err != nil {
+		// A failing child command already reported its own error
+		var childErr *vaultapp.ChildExitError
+		if !errors.As(err, &childErr) {
+			log.Println(err)
+		}
+	}
+	if err := audit.Finish(code); err != nil {
+		log.Println(err)
+		if code == vaultapp.ExitOK {
+			code = vaultapp.ExitFailure
+		}
 	}
+	os.Exit(code)
 }
@@ -1,16 +1,26 @@
 package app
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	"gopkg.in/yaml.v3"
 
+	"github.com/razzkumar/vlt/internal/audit"
 	"github.com/razzkumar/vlt/internal/utils"
 	"github.com/razzkumar/vlt/pkg/config"
 	"github.com/razzkumar/vlt/pkg/vault"
@@ -18,22 +28,340 @@ import (
 
 // App represents the main application
 type App struct {
-	vaultClient *vault.Client
+	vaultClient   *vault.Client
+	encryptionKey string          // default transit key from global options
+	localEncrypt  bool            // the config encrypts values client-side (encryption: local)
+	defaults      *config.Config  // user-level defaults, layered under project configs
+	profile       *config.Config  // connection profile selected with --profile, layered over project configs
+	reads         *readCache      // KV reads of the secrets being loaded; nil outside a load
+	versions      *versionTracker // incremental sync: KV versions of the entries being loaded
+	logins        *loginCache     // clients of the config's auth profiles
+	readOnly      bool            // refuse operations that write to Vault
 }
 
+// Options contains global settings passed down from the CLI.
+// Non-empty values take precedence over the corresponding environment variables.
+type Options struct {
+	VaultAddr      string
+	VaultToken     string
+	VaultNamespace string
+	EncryptionKey  string   // default transit key when a command does not set one
+	Proxy          string   // proxy URL for Vault requests
+	TLSMinVersion  string   // minimum TLS version for Vault connections
+	Timeout        string   // Vault request timeout, in seconds or as a duration
+	ConnectTimeout string   // Vault connect and health check timeout
+	Headers        []string // "Name: value" headers added to every Vault request
+	ConfigFile     string   // project config whose vault block applies under the flags and environment
+	Profile        string   // connection profile of the profiles file, applied over the config files
+	ReadOnly       bool     // refuse operations that write to Vault (put, import, apply, restore-snapshot)
+
+	// Client-side request limits (bulk commands)
+	RateLimit   float64 // requests per second; 0 means unlimited
+	Burst       int
+	MaxRequests int // total Vault requests; 0 means unlimited
+
+	// Authentication
+	AuthMethod  string
+	RoleID      string
+	SecretID    string
+	GitHubToken string
+	K8sRole     string
+	TokenRole   string // create the working token against this token role after login
+	BatchToken  bool   // create a batch token after login for the actual Vault calls
+
+	// Step-up authorization
+	MFA              []string // "method[:passcode]" MFA credentials sent with every Vault request
+	ControlGroupWait string   // how long to wait for control group approval, in seconds or as a duration
+
+	// Fixtures for tests without Vault access
+	RecordFile string // record sanitized Vault responses to this file
+	ReplayFile string // answer Vault requests from this file
+}
+
+// Default request limits of the bulk commands (tree, search, snapshot,
+// restore-snapshot, drift), which can touch thousands of paths
+const (
+	DefaultBulkRateLimit   = 50.0
+	DefaultBulkBurst       = 10
+	DefaultBulkMaxRequests = 10000
+)
+
 // New creates a new application instance
-func New() (*App, error) {
-	vaultConfig := config.GetVaultConfigFromEnv()
+func New(opts *Options) (*App, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	defaults, profile, vaultConfig, err := loadVaultConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	applyStoredToken(vaultConfig)
+
 	client, err := vault.NewClient(vaultConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create vault client: %w", err)
 	}
 
+	encryption := encryptionConfig(opts.ConfigFile, defaults)
+	cipher, err := newLocalCipher(encryption)
+	if err != nil {
+		return nil, err
+	}
+	if cipher != nil {
+		client = client.WithLocalCipher(cipher, encryption.UsesLocalEncryption())
+	}
+
 	return &App{
-		vaultClient: client,
+		vaultClient:   client,
+		encryptionKey: opts.EncryptionKey,
+		localEncrypt:  encryption.UsesLocalEncryption(),
+		defaults:      defaults,
+		profile:       profile,
+		logins:        newLoginCache(),
+		readOnly:      opts.ReadOnly,
 	}, nil
 }
 
+// loadVaultConfig returns the user defaults, the selected connection profile
+// (nil without one), and the Vault client configuration built from the
+// environment and opts, then the profile, then the vault block of
+// opts.ConfigFile, then the user defaults
+func loadVaultConfig(opts *Options) (*config.Config, *config.Config, *config.VaultConfig, error) {
+	defaults, err := config.LoadUserDefaults()
+	if err != nil {
+		return nil, nil, nil, WithExitCode(ExitUsage, err)
+	}
+	var profile *config.Config
+	if opts.Profile != "" {
+		if profile, err = config.LoadProfile(opts.Profile); err != nil {
+			return nil, nil, nil, WithExitCode(ExitUsage, err)
+		}
+	}
+
+	vaultConfig := config.GetVaultConfigFromEnv()
+	opts.applyTo(vaultConfig)
+	if vaultConfig.Record != "" && vaultConfig.Replay != "" {
+		return nil, nil, nil, WithExitCode(ExitUsage, fmt.Errorf("--record and --replay cannot be combined"))
+	}
+	if err := opts.applyTimeouts(vaultConfig); err != nil {
+		return nil, nil, nil, WithExitCode(ExitUsage, err)
+	}
+	if err := opts.applyHeaders(vaultConfig); err != nil {
+		return nil, nil, nil, WithExitCode(ExitUsage, err)
+	}
+	if utils.IsTerminal(os.Stdin) {
+		vaultConfig.MFAPrompt = func(method string) (string, error) {
+			return utils.ReadSecret(fmt.Sprintf("Passcode for %s: ", method))
+		}
+	}
+	if profile != nil {
+		if vaultConfig.Addr != "" && len(profile.Vault.Addr) > 0 {
+			warnf("VAULT_ADDR (or --vault-addr) overrides the addr of profile %s\n", opts.Profile)
+		}
+		profile.ApplyVaultDefaults(vaultConfig)
+	}
+	if opts.ConfigFile != "" {
+		// A config that cannot be read is reported by the command that loads it
+		if project, err := connectionConfig(opts.ConfigFile); err == nil {
+			project.ApplyVaultDefaults(vaultConfig)
+		}
+	}
+	defaults.ApplyVaultDefaults(vaultConfig)
+	if err := vaultConfig.ValidateHeaders(); err != nil {
+		return nil, nil, nil, WithExitCode(ExitUsage, fmt.Errorf("vault.headers: %w", err))
+	}
+	return defaults, profile, vaultConfig, nil
+}
+
+// connectionConfig loads the layers of a project config that can be read
+// before connecting to Vault; vault:// layers are left out
+func connectionConfig(path string) (*config.Config, error) {
+	var files []configFile
+	return (&App{}).loadConfigLayers(path, nil, &files)
+}
+
+// encryptionConfig returns the encryption and local_encryption settings of
+// the project config at path layered over the user defaults
+func encryptionConfig(path string, defaults *config.Config) *config.Config {
+	cfg := &config.Config{}
+	layers := []*config.Config{defaults}
+	if path != "" {
+		// A config that cannot be read is reported by the command that loads it
+		if project, err := connectionConfig(path); err == nil {
+			layers = append(layers, project)
+		}
+	}
+	for _, layer := range layers {
+		if layer != nil {
+			cfg.Merge(&config.Config{Encryption: layer.Encryption, LocalEncryption: layer.LocalEncryption})
+		}
+	}
+	return cfg
+}
+
+// hasCredentials reports whether cfg names a token or the credentials of
+// another auth method
+func hasCredentials(cfg *config.VaultConfig) bool {
+	if cfg.AuthMethod != "" && cfg.AuthMethod != "token" {
+		return true
+	}
+	return cfg.Token != "" || cfg.UsesAgentToken() || cfg.DetectAuthMethod() != "token"
+}
+
+// applyStoredToken falls back to the token saved by 'vlt login' (or the
+// Vault CLI's ~/.vault-token) when cfg has no credentials, and returns where
+// that token was found
+func applyStoredToken(cfg *config.VaultConfig) string {
+	account := keychainAccount(cfg)
+	if account == "" || hasCredentials(cfg) {
+		return ""
+	}
+	token, source := storedToken(account)
+	cfg.Token = token
+	return source
+}
+
+// keychainAccount returns the keychain account a token for the configured
+// Vault server is stored under: its first address
+func keychainAccount(cfg *config.VaultConfig) string {
+	addrs := cfg.Addresses()
+	if len(addrs) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(addrs[0], "/")
+}
+
+// applyTo overrides the Vault configuration with any values set in the options
+func (o *Options) applyTo(cfg *config.VaultConfig) {
+	if o.VaultAddr != "" {
+		// An explicit address also bypasses VAULT_AGENT_ADDR
+		cfg.Addr, cfg.AgentAddr = o.VaultAddr, ""
+	}
+	cfg.Token = config.NonEmpty(o.VaultToken, cfg.Token)
+	cfg.Namespace = config.NonEmpty(o.VaultNamespace, cfg.Namespace)
+	cfg.Proxy = config.NonEmpty(o.Proxy, cfg.Proxy)
+	cfg.TLSMinVersion = config.NonEmpty(o.TLSMinVersion, cfg.TLSMinVersion)
+	cfg.RateLimit = o.RateLimit
+	cfg.Burst = o.Burst
+	cfg.MaxRequests = o.MaxRequests
+	cfg.AuthMethod = config.NonEmpty(strings.ToLower(o.AuthMethod), cfg.AuthMethod)
+	cfg.RoleID = config.NonEmpty(o.RoleID, cfg.RoleID)
+	cfg.SecretID = config.NonEmpty(o.SecretID, cfg.SecretID)
+	cfg.GitHubToken = config.NonEmpty(o.GitHubToken, cfg.GitHubToken)
+	cfg.K8sRole = config.NonEmpty(o.K8sRole, cfg.K8sRole)
+	cfg.TokenRole = config.NonEmpty(o.TokenRole, cfg.TokenRole)
+	cfg.BatchToken = cfg.BatchToken || o.BatchToken
+	if len(o.MFA) > 0 {
+		cfg.MFA = o.MFA
+	}
+	cfg.Record = o.RecordFile
+	cfg.Replay = o.ReplayFile
+}
+
+// applyTimeouts overrides the Vault client timeouts with the ones set in
+// the options
+func (o *Options) applyTimeouts(cfg *config.VaultConfig) error {
+	if o.Timeout != "" {
+		timeout, err := config.ParseTimeout(o.Timeout)
+		if err != nil {
+			return fmt.Errorf("--vault-timeout: %w", err)
+		}
+		cfg.Timeout = timeout
+	}
+	if o.ConnectTimeout != "" {
+		timeout, err := config.ParseTimeout(o.ConnectTimeout)
+		if err != nil {
+			return fmt.Errorf("--vault-connect-timeout: %w", err)
+		}
+		cfg.ConnectTimeout = timeout
+	}
+	if o.ControlGroupWait != "" {
+		wait, err := config.ParseWait(o.ControlGroupWait)
+		if err != nil {
+			return fmt.Errorf("--control-group-wait: %w", err)
+		}
+		cfg.ControlGroupWait = wait
+	}
+	return nil
+}
+
+// applyHeaders adds the --header values and this invocation's correlation ID
+// to the Vault configuration
+func (o *Options) applyHeaders(cfg *config.VaultConfig) error {
+	for _, header := range o.Headers {
+		name, value, err := config.ParseHeader(header)
+		if err != nil {
+			return fmt.Errorf("--header: %w", err)
+		}
+		cfg.SetHeader(name, value, true)
+	}
+	cfg.CorrelationID = correlationID()
+	if _, _, err := config.ParseHeader(config.CorrelationHeader + ": " + cfg.CorrelationID); err != nil {
+		return fmt.Errorf("VLT_CORRELATION_ID: %w", err)
+	}
+	audit.SetCorrelationID(cfg.CorrelationID)
+	return nil
+}
+
+// correlationID returns the ID sent with every Vault request of this
+// invocation: VLT_CORRELATION_ID when set, so a pipeline can tie several
+// commands together, or else a random one
+var correlationID = sync.OnceValue(func() string {
+	if id := os.Getenv("VLT_CORRELATION_ID"); id != "" {
+		return id
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+})
+
+// effectiveEncryptionKey resolves the transit key from the command flag,
+// the global option, and finally the environment
+func (a *App) effectiveEncryptionKey(flagValue string) string {
+	key := config.GetEncryptionKey(config.NonEmpty(flagValue, a.encryptionKey))
+	if key == "" && a.localEncrypt {
+		return config.LocalKeyName
+	}
+	return key
+}
+
+// pathConfig returns the config whose kv.mount and transit.mount apply to
+// commands that take Vault paths rather than a config: the nearest vlt.yaml
+// layered over the user defaults, or the user defaults alone, with the
+// selected profile on top
+func (a *App) pathConfig() *config.Config {
+	if path, ok := config.FindConfigFile(config.DefaultConfigFile); ok {
+		cfg, err := a.LoadConfig(path, false)
+		if err == nil {
+			return cfg
+		}
+		warnf("ignoring the mounts of %s: %v\n", path, err)
+	}
+	cfg := &config.Config{}
+	if a.defaults != nil {
+		cfg.Merge(a.defaults)
+	}
+	if a.profile != nil {
+		cfg.Merge(a.profile)
+	}
+	return cfg
+}
+
+// pathMounts resolves the KV and Transit mounts of a command that takes
+// Vault paths: the flag or KV_MOUNT/TRANSIT_MOUNT value, else the mounts of
+// pathConfig, else the built-in defaults
+func (a *App) pathMounts(kvMount, transitMount string) (string, string) {
+	if kvMount != "" && transitMount != "" {
+		return kvMount, transitMount
+	}
+	cfg := a.pathConfig()
+	return cfg.KVMount(kvMount), cfg.TransitMount(transitMount)
+}
+
 // PutOptions contains options for the Put operation
 type PutOptions struct {
 	KVMount       string
@@ -41,24 +369,71 @@ type PutOptions struct {
 	TransitMount  string
 	EncryptionKey string
 	Key           string
-	Value         string
+	Value         string // the value itself, or "-" to read it from stdin
+	ValueFile     string // read the value (not base64-encoded) from this file
 	FromEnv       string
 	FromFile      string
+	FromStdin     string   // read a multi-key payload from stdin: "auto", "json", or "env"
+	FromK8sSecret string   // import a Kubernetes Secret given as namespace/name
+	K8sContext    string   // kubeconfig context for FromK8sSecret (default: in-cluster or current)
+	FromSOPS      string   // import the top-level values of a SOPS-encrypted file
+	EncryptKeys   []string // encrypt only these keys; the others are stored as plaintext
+	KeyVersion    int      // encrypt with this Transit key version instead of the latest
+	Namespace     string   // overrides the client namespace for this command
+	DryRun        bool     // print the keys that would be created or updated without writing
+	Shard         bool     // split the keys across path/part1..N when they exceed the size limit of one secret
+
+	// OverridePolicy writes values that violate the value policy anyway; the
+	// reason is recorded in the audit log
+	OverridePolicy string
+
+	value []byte // a value generated by another command, such as random
 }
 
 // Put stores secrets in Vault with optional encryption
 func (a *App) Put(opts *PutOptions) error {
-	effectiveEncryptionKey := config.GetEncryptionKey(opts.EncryptionKey)
+	if !opts.DryRun {
+		if err := a.requireWritable("put"); err != nil {
+			return err
+		}
+	}
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
 	useEncryption := effectiveEncryptionKey != ""
+	if len(opts.EncryptKeys) > 0 && !useEncryption {
+		return WithExitCode(ExitUsage, fmt.Errorf("--encrypt-keys requires an encryption key"))
+	}
+	if opts.KeyVersion > 0 && !useEncryption {
+		return WithExitCode(ExitUsage, fmt.Errorf("--key-version requires an encryption key"))
+	}
+	a = a.withKeyVersion(opts.KeyVersion)
 
-	// Get existing data to merge with
-	existingData, err := a.vaultClient.KVGet(opts.KVMount, opts.KVPath)
-	if err != nil {
-		// If secret doesn't exist, start with empty data
-		existingData = make(map[string]interface{})
+	if opts.Value != "" && opts.Value != "-" && utils.IsTerminal(os.Stdin) {
+		warnf("--value leaves the secret in your shell history and the process list; use --value-file, --value -, or stdin instead\n")
 	}
 
-	var finalData map[string]interface{}
+	// Get existing data to merge with, and the version to check-and-set
+	// against; a missing secret starts with empty data
+	existingData, version := a.readForWrite(opts.KVMount, opts.KVPath)
+
+	var (
+		finalData map[string]interface{}
+		err       error
+	)
+
+	// A secret already split into parts is merged with, and written back as,
+	// its parts
+	oldParts := utils.ShardCount(existingData)
+	if oldParts > 0 {
+		if existingData, err = a.joinShards(opts.KVMount, opts.KVPath, existingData); err != nil {
+			return err
+		}
+	}
+	shard := opts.Shard || oldParts > 0
+	if manifest, ok := utils.ReadFileManifest(existingData); ok && manifest.Chunks > 0 && opts.FromFile == "" {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s/%s holds %s stored in chunks; replace it with --from-file, or delete it first", opts.KVMount, opts.KVPath, manifest.Name))
+	}
 
 	// Handle different data structures in existing data
 	if utils.IsEncryptedSingleValue(existingData) || utils.IsPlaintextSingleValue(existingData) {
@@ -71,13 +446,97 @@ func (a *App) Put(opts *PutOptions) error {
 
 	if opts.FromEnv != "" {
 		// Load from .env file
-		newData, err = utils.LoadEnvFile(opts.FromEnv, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, useEncryption)
+		values, err := utils.ReadEnvFile(opts.FromEnv)
+		if err != nil {
+			return fmt.Errorf("load env file: %w", err)
+		}
+		if err := a.enforceValuePolicy(opts.KVPath, values, opts.OverridePolicy); err != nil {
+			return err
+		}
+		newData, err = utils.EncodeSelectedValues(values, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, opts.EncryptKeys)
 		if err != nil {
 			return fmt.Errorf("load env file: %w", err)
 		}
 		// Merge with existing data
 		finalData = utils.MergeData(finalData, newData)
+	} else if opts.FromStdin != "" {
+		// Load a multi-key payload from stdin without touching disk
+		payload, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read stdin: %w", err)
+		}
+		values, err := utils.ParsePayload(payload, opts.FromStdin)
+		if err != nil {
+			return WithExitCode(ExitUsage, err)
+		}
+		if len(values) == 0 {
+			return WithExitCode(ExitUsage, fmt.Errorf("no secrets found on stdin"))
+		}
+		if err := a.enforceValuePolicy(opts.KVPath, values, opts.OverridePolicy); err != nil {
+			return err
+		}
+		newData, err = utils.EncodeSelectedValues(values, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, opts.EncryptKeys)
+		if err != nil {
+			return fmt.Errorf("encode stdin payload: %w", err)
+		}
+		finalData = utils.MergeData(finalData, newData)
+	} else if opts.FromK8sSecret != "" {
+		// Import every key of a Kubernetes Secret
+		values, err := utils.ReadK8sSecret(opts.FromK8sSecret, opts.K8sContext)
+		if err != nil {
+			return fmt.Errorf("read kubernetes secret: %w", err)
+		}
+		if len(values) == 0 {
+			return WithExitCode(ExitNotFound, fmt.Errorf("kubernetes secret %s has no data", opts.FromK8sSecret))
+		}
+		if err := a.enforceValuePolicy(opts.KVPath, values, opts.OverridePolicy); err != nil {
+			return err
+		}
+		newData, err = utils.EncodeSelectedValues(values, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, opts.EncryptKeys)
+		if err != nil {
+			return fmt.Errorf("encode kubernetes secret: %w", err)
+		}
+		finalData = utils.MergeData(finalData, newData)
+	} else if opts.FromSOPS != "" {
+		// Import the decrypted contents of a SOPS file
+		values, err := utils.ReadSOPSFile(opts.FromSOPS)
+		if err != nil {
+			return fmt.Errorf("read sops file: %w", err)
+		}
+		if len(values) == 0 {
+			return WithExitCode(ExitNotFound, fmt.Errorf("sops file %s has no values", opts.FromSOPS))
+		}
+		if err := a.enforceValuePolicy(opts.KVPath, values, opts.OverridePolicy); err != nil {
+			return err
+		}
+		newData, err = utils.EncodeSelectedValues(values, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, opts.EncryptKeys)
+		if err != nil {
+			return fmt.Errorf("encode sops values: %w", err)
+		}
+		finalData = utils.MergeData(finalData, newData)
 	} else if opts.FromFile != "" {
+		// A file larger than a chunk is streamed into chunks instead
+		file, err := os.Open(opts.FromFile)
+		if err != nil {
+			return fmt.Errorf("load file: %w", err)
+		}
+		defer file.Close()
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("load file: %w", err)
+		}
+		if chunkSize := int64(a.fileChunkSize()); info.Size() > chunkSize {
+			if opts.Shard {
+				return WithExitCode(ExitUsage, fmt.Errorf("--shard does not apply to --from-file; large files are split into chunks and parts as needed"))
+			}
+			if opts.DryRun {
+				infof("Dry run: %s/%s would hold %s (%s) in %d chunk(s); nothing was written\n", opts.KVMount, opts.KVPath,
+					filepath.Base(opts.FromFile), formatBytes(int(info.Size())), (info.Size()+chunkSize-1)/chunkSize)
+				return nil
+			}
+			return a.putFileChunks(file, opts.KVMount, opts.KVPath, opts.TransitMount, effectiveEncryptionKey, oldParts, version)
+		}
+
 		// Load file as base64
 		newData, err = utils.LoadFileAsBase64(opts.FromFile, a.vaultClient, opts.TransitMount, effectiveEncryptionKey, useEncryption)
 		if err != nil {
@@ -85,103 +544,216 @@ func (a *App) Put(opts *PutOptions) error {
 		}
 		finalData = newData
 	} else {
-		// Single value (from --value, stdin, or key update)
+		// Single value (from --value, --value-file, stdin, or key update)
 		var secretValue []byte
 
-		if opts.Value != "" {
+		switch {
+		case opts.value != nil:
+			secretValue = opts.value
+		case opts.ValueFile != "":
+			secretValue, err = os.ReadFile(opts.ValueFile)
+			if err != nil {
+				return fmt.Errorf("read value file: %w", err)
+			}
+			secretValue = trimNewline(secretValue)
+		case opts.Value != "" && opts.Value != "-":
 			secretValue = []byte(opts.Value)
-		} else {
-			// Read from stdin
+		case utils.IsTerminal(os.Stdin):
+			// Prompt without echo rather than waiting for EOF
+			line, err := utils.ReadSecret("Secret value: ")
+			if err != nil {
+				return err
+			}
+			secretValue = []byte(line)
+		default:
 			secretValue, err = io.ReadAll(os.Stdin)
 			if err != nil {
 				return fmt.Errorf("read stdin: %w", err)
 			}
-			// Remove trailing newline if reading from stdin
-			if len(secretValue) > 0 && secretValue[len(secretValue)-1] == '\n' {
-				secretValue = secretValue[:len(secretValue)-1]
-			}
+			secretValue = trimNewline(secretValue)
 		}
 
 		if len(secretValue) == 0 {
-			return fmt.Errorf("no secret value provided")
+			return WithExitCode(ExitUsage, fmt.Errorf("no secret value provided"))
 		}
 
 		// Handle key-specific update or single value storage
-		if opts.Key != "" {
-			// Update specific key in multi-value secret
-			if useEncryption {
-				ciphertext, err := a.vaultClient.TransitEncrypt(opts.TransitMount, effectiveEncryptionKey, secretValue)
-				if err != nil {
-					return fmt.Errorf("transit encrypt: %w", err)
-				}
-				finalData[opts.Key] = ciphertext
-			} else {
-				finalData[opts.Key] = string(secretValue)
-			}
-		} else {
+		if opts.Key == utils.MetaKey || opts.Key == utils.FileKey {
+			return WithExitCode(ExitUsage, fmt.Errorf("%s is reserved for vlt's own records", opts.Key))
+		}
+		key := opts.Key
+		if key == "" {
 			// Single value storage (backward compatibility)
+			key = "value"
 			if useEncryption {
-				ciphertext, err := a.vaultClient.TransitEncrypt(opts.TransitMount, effectiveEncryptionKey, secretValue)
-				if err != nil {
-					return fmt.Errorf("transit encrypt: %w", err)
-				}
-				finalData = map[string]interface{}{"ciphertext": ciphertext}
-			} else {
-				finalData = map[string]interface{}{"value": string(secretValue)}
+				key = "ciphertext"
 			}
+			finalData = make(map[string]interface{})
+		}
+		if err := a.enforceValuePolicy(opts.KVPath, map[string]string{config.NonEmpty(opts.Key, "value"): string(secretValue)}, opts.OverridePolicy); err != nil {
+			return err
 		}
+		encrypted := utils.EncryptedKeys(finalData)
+		if useEncryption && utils.EncryptsKey(opts.EncryptKeys, key) {
+			ciphertext, err := a.vaultClient.TransitEncrypt(opts.TransitMount, effectiveEncryptionKey, secretValue)
+			if err != nil {
+				return fmt.Errorf("transit encrypt: %w", err)
+			}
+			finalData[key] = ciphertext
+			encrypted[key] = utils.NewEncryptedValue(effectiveEncryptionKey, ciphertext)
+		} else {
+			finalData[key] = string(secretValue)
+			delete(encrypted, key)
+		}
+		utils.SetMeta(finalData, encrypted)
 	}
 
-	if err := a.vaultClient.KVPut(opts.KVMount, opts.KVPath, finalData); err != nil {
-		return fmt.Errorf("kv put: %w", err)
+	source := opts.KVMount + "/" + opts.KVPath
+	if isSingleValue(finalData) {
+		if opts.Shard {
+			return WithExitCode(ExitUsage, fmt.Errorf("%s: a single value cannot be split into parts; --shard needs keys", source))
+		}
+		// A single value replaces a split secret whole, parts included
+		shard = false
+	}
+	if !shard {
+		if err := a.checkSecretSize(source, finalData); err != nil {
+			return err
+		}
+	}
+
+	if opts.DryRun {
+		printKeyPlan(source, planKeys(existingData, finalData), finalData)
+		return nil
+	}
+
+	parts := 0
+	if shard {
+		if parts, err = a.putSharded(opts.KVMount, opts.KVPath, finalData, oldParts, version); err != nil {
+			return err
+		}
+	} else {
+		if finalData, err = a.putMerged(opts.KVMount, opts.KVPath, existingData, finalData, version); err != nil {
+			return err
+		}
+		a.deleteParts(opts.KVMount, opts.KVPath, 1, oldParts)
 	}
 
 	encryptionStatus := "plaintext"
-	if useEncryption {
+	switch {
+	case opts.Key != "" && useEncryption && !utils.EncryptsKey(opts.EncryptKeys, opts.Key):
+	case len(opts.EncryptKeys) > 0 && opts.Key == "":
+		encryptionStatus = fmt.Sprintf("plaintext except %s (encrypted)", strings.Join(opts.EncryptKeys, ", "))
+	case useEncryption:
 		encryptionStatus = "encrypted"
 	}
 
 	if opts.Key != "" {
-		fmt.Printf("Updated key '%s' as %s: %s/%s\n", opts.Key, encryptionStatus, opts.KVMount, opts.KVPath)
+		infof("Updated key '%s' as %s: %s/%s\n", opts.Key, encryptionStatus, opts.KVMount, opts.KVPath)
 	} else {
-		secretsCount := len(finalData)
-		fmt.Printf("Stored/updated %d secret(s) as %s: %s/%s\n", secretsCount, encryptionStatus, opts.KVMount, opts.KVPath)
+		secretsCount := len(utils.StripMeta(finalData))
+		infof("Stored/updated %d secret(s) as %s: %s/%s\n", secretsCount, encryptionStatus, opts.KVMount, opts.KVPath)
+	}
+	if parts > 0 {
+		infof("Split across %d part(s): %s/part1..part%d\n", parts, source, parts)
 	}
 
 	return nil
 }
 
-// GetOptions contains options for the Get operation
+// GetOptions contains options for the Get and GetFromConfig operations
 type GetOptions struct {
 	KVMount       string
 	KVPath        string
+	KVPaths       []string // used by GetPaths
+	ConfigFile    string   // used by GetFromConfig
+	EnvFile       string   // used by GetFromEnvFile
+	Identities    []string // used by GetFromEnvFile: age identity files for an encrypted EnvFile
+	Expired       string   // used by GetFromEnvFile: what to do when EnvFile has expired
+	Strict        bool     // treat skipped entries and config warnings as errors
+	OnlyTags      []string // used by GetFromConfig: keep only entries with one of these tags
+	SkipTags      []string // used by GetFromConfig: drop entries with any of these tags
+	Namespace     string   // overrides the client namespace for this command
 	TransitMount  string
 	EncryptionKey string
 	Key           string
 	OutputJSON    bool
+	PowerShell    bool          // Print PowerShell $env: assignments instead of .env lines
+	Reveal        bool          // Print plaintext values even when stdout is a terminal
+	Copy          bool          // Copy the value to the clipboard instead of printing it
+	ClipTimeout   time.Duration // Clear the clipboard after this duration (0 keeps the value)
+	WrapTTL       time.Duration // Print a single-use Vault wrapping token for the values instead of the values
+	OutFile       string        // Write the file stored with put --from-file here ("-" for stdout)
+	Raw           bool          // Print a binary file stored with put --from-file even to a terminal
+	Metadata      bool          // Print the name, size, SHA-256, and content type of a stored file instead of its content
 }
 
 // Get retrieves and optionally decrypts secrets from Vault
 func (a *App) Get(opts *GetOptions) error {
-	effectiveEncryptionKey := config.GetEncryptionKey(opts.EncryptionKey)
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+	mask := utils.ShouldMask(opts.Reveal)
+
+	if opts.WrapTTL > 0 {
+		data, err := a.readDecrypted(opts.KVMount, opts.KVPath, opts.TransitMount, effectiveEncryptionKey)
+		if err != nil {
+			return err
+		}
+		if opts.Key != "" {
+			value, ok := data[opts.Key]
+			if !ok {
+				return vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found", opts.Key))
+			}
+			data = map[string]interface{}{opts.Key: value}
+		}
+		return a.wrapValues(opts.KVMount+"/"+opts.KVPath, data, opts)
+	}
 
-	// Get from KV
+	// Get from KV; a file stored with put --from-file is printed as a file
 	data, err := a.vaultClient.KVGet(opts.KVMount, opts.KVPath)
 	if err != nil {
 		return fmt.Errorf("kv get: %w", err)
 	}
+	if manifest, ok := utils.ReadFileManifest(data); ok && opts.Key == "" && !opts.Copy {
+		return a.catFile(opts, data, manifest)
+	}
+	if opts.Metadata {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s/%s does not hold a file stored with put --from-file", opts.KVMount, opts.KVPath))
+	}
+	if data, err = a.joinShards(opts.KVMount, opts.KVPath, data); err != nil {
+		return fmt.Errorf("kv get: %w", err)
+	}
+	if manifest, ok := utils.ReadFileManifest(data); ok && manifest.Chunks > 0 {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s/%s holds a file stored in chunks; write it out with --out-file FILE", opts.KVMount, opts.KVPath))
+	}
+	var checked []string
+	if _, ok := data[opts.Key]; ok {
+		checked = append(checked, opts.Key)
+	}
+	if err := checkKeyVersion(a.pathConfig(), opts.KVMount+"/"+opts.KVPath, data, checked...); err != nil {
+		return err
+	}
 
 	// Try to get single encrypted data first
 	ciphertext, hasCiphertext := data["ciphertext"].(string)
-	if hasCiphertext && ciphertext != "" {
+	if hasCiphertext && utils.IsEncryptedValue(data, "ciphertext") {
 		// Single encrypted data - requires key
 		if effectiveEncryptionKey == "" {
-			return fmt.Errorf("--encryption-key is required for encrypted secrets")
+			return WithExitCode(ExitUsage, fmt.Errorf("--encryption-key is required for encrypted secrets"))
 		}
 		plaintext, err := a.vaultClient.TransitDecrypt(opts.TransitMount, effectiveEncryptionKey, ciphertext)
 		if err != nil {
 			return fmt.Errorf("transit decrypt: %w", err)
 		}
+		if opts.Copy {
+			return copyToClipboard(string(plaintext), opts.ClipTimeout)
+		}
+		if mask {
+			fmt.Print(utils.MaskValue(string(plaintext)))
+			return nil
+		}
+		audit.MarkDisplayed()
 		fmt.Print(string(plaintext))
 		return nil
 	}
@@ -189,19 +761,27 @@ func (a *App) Get(opts *GetOptions) error {
 	// Handle encrypted multi-value data
 	if utils.IsEncryptedMultiValue(data) {
 		if effectiveEncryptionKey == "" {
-			return fmt.Errorf("--encryption-key is required for encrypted secrets")
+			return WithExitCode(ExitUsage, fmt.Errorf("--encryption-key is required for encrypted secrets"))
 		}
 
 		decryptedData, err := utils.DecryptMultiValueData(data, a.vaultClient, opts.TransitMount, effectiveEncryptionKey)
 		if err != nil {
 			return fmt.Errorf("decrypt multi-value data: %w", err)
 		}
+		if opts.Copy {
+			return copySingleValue(decryptedData, opts)
+		}
+		if mask {
+			decryptedData = utils.MaskData(decryptedData)
+		} else {
+			audit.MarkDisplayed()
+		}
 
 		// Handle output for decrypted multi-value data
 		if opts.Key != "" {
 			value, ok := decryptedData[opts.Key]
 			if !ok {
-				return fmt.Errorf("key %q not found", opts.Key)
+				return vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found", opts.Key))
 			}
 			fmt.Print(value)
 		} else if opts.OutputJSON {
@@ -209,17 +789,26 @@ func (a *App) Get(opts *GetOptions) error {
 				return fmt.Errorf("output json: %w", err)
 			}
 		} else {
-			utils.OutputEnvFormat(decryptedData)
+			outputEnv(decryptedData, mask, opts)
 		}
 		return nil
 	}
 
 	// Handle plaintext data (single value or multiple values)
+	data = utils.StripMeta(data)
+	if opts.Copy {
+		return copySingleValue(data, opts)
+	}
+	if mask {
+		data = utils.MaskData(data)
+	} else {
+		audit.MarkDisplayed()
+	}
 	if opts.Key != "" {
 		// Get specific key
 		value, ok := data[opts.Key]
 		if !ok {
-			return fmt.Errorf("key %q not found", opts.Key)
+			return vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found", opts.Key))
 		}
 		fmt.Print(value)
 	} else if len(data) == 1 {
@@ -235,24 +824,203 @@ func (a *App) Get(opts *GetOptions) error {
 				return fmt.Errorf("output json: %w", err)
 			}
 		} else {
-			utils.OutputEnvFormat(data)
+			outputEnv(data, mask, opts)
+		}
+	}
+
+	return nil
+}
+
+// outputEnv prints data as .env lines, or as PowerShell assignments
+func outputEnv(data map[string]any, masked bool, opts *GetOptions) {
+	if opts.PowerShell {
+		utils.OutputPowerShell(data)
+		return
+	}
+	utils.OutputEnvFormat(data, masked)
+}
+
+// GetPaths retrieves the secrets at several paths. The .env output prefixes
+// each key with its path (myapp/db + USER → MYAPP_DB_USER, or MYAPP_DB for a
+// single value); JSON output groups the keys by path. Every path is attempted
+// and failures are reported together.
+func (a *App) GetPaths(opts *GetOptions) error {
+	if opts.Key != "" || opts.Copy {
+		return WithExitCode(ExitUsage, fmt.Errorf("--key and --copy require a single --path"))
+	}
+
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+	mask := utils.ShouldMask(opts.Reveal)
+
+	grouped := make(map[string]any)
+	merged := make(map[string]any)
+	loadErr := &LoadError{}
+	for _, path := range opts.KVPaths {
+		data, err := a.readDecrypted(opts.KVMount, path, opts.TransitMount, effectiveEncryptionKey)
+		if err != nil {
+			loadErr.Add(path, err)
+			continue
+		}
+		if mask {
+			data = utils.MaskData(data)
+		}
+		grouped[path] = data
+
+		prefix := config.SanitizeEnvName(strings.ToUpper(strings.Trim(path, "/")))
+		if utils.IsPlaintextSingleValue(data) {
+			merged[prefix] = data["value"]
+			continue
+		}
+		for k, v := range data {
+			merged[prefix+"_"+config.SanitizeEnvName(strings.ToUpper(k))] = v
+		}
+	}
+	if err := loadErr.ErrOrNil(); err != nil {
+		return err
+	}
+
+	if !mask {
+		audit.MarkDisplayed()
+	}
+	if opts.OutputJSON {
+		if err := utils.OutputJSON(grouped); err != nil {
+			return fmt.Errorf("output json: %w", err)
+		}
+		return nil
+	}
+	outputEnv(merged, mask, opts)
+	return nil
+}
+
+// readDecrypted reads the secret at a path and decrypts its values. A single
+// encrypted value is returned under the "value" key.
+func (a *App) readDecrypted(kvMount, kvPath, transitMount, encryptionKey string) (map[string]any, error) {
+	data, err := a.readJoined(kvMount, kvPath)
+	if err != nil {
+		return nil, fmt.Errorf("kv get: %w", err)
+	}
+	if err := checkKeyVersion(a.pathConfig(), kvMount+"/"+kvPath, data); err != nil {
+		return nil, err
+	}
+
+	if utils.IsEncryptedSingleValue(data) || utils.IsEncryptedMultiValue(data) {
+		if encryptionKey == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--encryption-key is required for encrypted secrets"))
+		}
+	}
+	if utils.IsEncryptedSingleValue(data) {
+		plaintext, err := a.vaultClient.TransitDecrypt(transitMount, encryptionKey, data["ciphertext"].(string))
+		if err != nil {
+			return nil, fmt.Errorf("transit decrypt: %w", err)
+		}
+		return map[string]any{"value": string(plaintext)}, nil
+	}
+	if utils.IsEncryptedMultiValue(data) {
+		decrypted, err := utils.DecryptMultiValueData(data, a.vaultClient, transitMount, encryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt multi-value data: %w", err)
+		}
+		return decrypted, nil
+	}
+	return utils.StripMeta(data), nil
+}
+
+// copySingleValue copies the selected value of a secret to the clipboard
+func copySingleValue(data map[string]interface{}, opts *GetOptions) error {
+	var value interface{}
+	if opts.Key != "" {
+		v, ok := data[opts.Key]
+		if !ok {
+			return vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found", opts.Key))
+		}
+		value = v
+	} else if len(data) == 1 {
+		for _, v := range data {
+			value = v
 		}
+	} else {
+		return WithExitCode(ExitUsage, fmt.Errorf("--copy requires a single value, use --key to select one"))
+	}
+
+	return copyToClipboard(fmt.Sprintf("%v", value), opts.ClipTimeout)
+}
+
+// wrapValues hands data to Vault response wrapping and prints the
+// single-use token that unwraps it instead of the values, so they can be
+// passed on through a ticket or chat without the plaintext appearing there
+func (a *App) wrapValues(source string, data map[string]interface{}, opts *GetOptions) error {
+	info, err := a.vaultClient.Wrap(data, opts.WrapTTL)
+	if err != nil {
+		return fmt.Errorf("wrap %s: %w", source, err)
+	}
+	expiresAt := info.CreationTime.Add(info.TTL).UTC()
+
+	if opts.OutputJSON {
+		return utils.OutputJSON(map[string]any{
+			"token":      info.Token,
+			"accessor":   info.Accessor,
+			"ttl":        int(info.TTL.Seconds()),
+			"expires_at": expiresAt.Format(time.RFC3339),
+		})
+	}
+	fmt.Println(info.Token)
+	statusf("Wrapped %d key(s) of %s; the token works once and expires at %s\n", len(data), source, expiresAt.Format(time.RFC3339))
+	statusf("Unwrap with: vault unwrap <token>\n")
+	return nil
+}
+
+// copyToClipboard places value on the clipboard and, if timeout is set, waits
+// and clears it again unless the clipboard has since been overwritten
+func copyToClipboard(value string, timeout time.Duration) error {
+	audit.MarkCopied()
+	if err := utils.CopyToClipboard(value); err != nil {
+		return fmt.Errorf("copy to clipboard: %w", err)
 	}
 
+	if timeout <= 0 {
+		statusf("Copied to clipboard\n")
+		return nil
+	}
+
+	statusf("Copied to clipboard, clearing in %s\n", timeout)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case <-time.After(timeout):
+	case <-sigCh:
+	}
+
+	if current, err := utils.ReadClipboard(); err == nil && current != value {
+		return nil
+	}
+	if err := utils.ClearClipboard(); err != nil {
+		return fmt.Errorf("clear clipboard: %w", err)
+	}
 	return nil
 }
 
 // GetFromConfig retrieves secrets from config file and displays them
-func (a *App) GetFromConfig(configPath, encryptionKey string, outputJSON bool) error {
-	cfg, err := a.LoadConfig(configPath)
+// Values are masked when stdout is a terminal unless Reveal is set.
+func (a *App) GetFromConfig(opts *GetOptions) error {
+	cfg, err := a.LoadConfig(opts.ConfigFile, opts.Strict)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
+	cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+	opts.KVMount, opts.TransitMount = cfg.KVMount(opts.KVMount), cfg.TransitMount(opts.TransitMount)
 
-	effectiveEncryptionKey := config.GetEncryptionKey(encryptionKey)
+	// The command's namespace takes precedence over the config file's
+	a = a.withNamespace(opts.Namespace)
+
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
 
 	// Use the shared logic for loading secrets
-	envVars, err := a.loadSecretsFromConfig(cfg, "kv", "transit", effectiveEncryptionKey)
+	envVars, err := a.loadSecretsFromConfig(cfg, opts.KVMount, opts.TransitMount, effectiveEncryptionKey)
 	if err != nil {
 		return fmt.Errorf("load secrets from config: %w", err)
 	}
@@ -262,237 +1030,913 @@ func (a *App) GetFromConfig(configPath, encryptionKey string, outputJSON bool) e
 	for k, v := range envVars {
 		data[k] = v
 	}
-
-	// Output in requested format
-	if outputJSON {
-		if err := utils.OutputJSON(data); err != nil {
-			return fmt.Errorf("output json: %w", err)
+	if opts.WrapTTL > 0 {
+		return a.wrapValues(opts.ConfigFile, data, opts)
+	}
+	mask := utils.ShouldMask(opts.Reveal)
+	if mask {
+		data = utils.MaskData(data)
+	} else {
+		audit.MarkDisplayed()
+	}
+
+	// Output in requested format
+	if opts.OutputJSON {
+		if err := utils.OutputJSON(data); err != nil {
+			return fmt.Errorf("output json: %w", err)
+		}
+	} else {
+		outputEnv(data, mask, opts)
+	}
+
+	return nil
+}
+
+// GetFromEnvFile prints the variables of a .env file, decrypting it first
+// when it was written by sync --encrypt-output
+func (a *App) GetFromEnvFile(opts *GetOptions) error {
+	envVars, err := a.readEnvFile(opts.EnvFile, opts.Identities, opts.Expired)
+	if err != nil {
+		return fmt.Errorf("load env file %s: %w", opts.EnvFile, err)
+	}
+
+	data := make(map[string]interface{}, len(envVars))
+	for k, v := range envVars {
+		data[k] = v
+	}
+	if opts.Copy {
+		return copySingleValue(data, opts)
+	}
+	mask := utils.ShouldMask(opts.Reveal)
+	if mask {
+		data = utils.MaskData(data)
+	} else {
+		audit.MarkDisplayed()
+	}
+
+	switch {
+	case opts.Key != "":
+		value, ok := data[opts.Key]
+		if !ok {
+			return WithExitCode(ExitNotFound, fmt.Errorf("key %q not found in %s", opts.Key, opts.EnvFile))
+		}
+		fmt.Print(value)
+	case opts.OutputJSON:
+		if err := utils.OutputJSON(data); err != nil {
+			return fmt.Errorf("output json: %w", err)
+		}
+	default:
+		outputEnv(data, mask, opts)
+	}
+	return nil
+}
+
+// LoadConfig loads configuration from a YAML file (or a vault:// or https://
+// source) and the files it includes.
+// In strict mode (the strict argument or `strict: true` in any layer) unknown fields are rejected.
+func (a *App) LoadConfig(path string, strict bool) (*config.Config, error) {
+	var files []configFile
+	project, err := a.loadConfigLayers(path, nil, &files)
+	if err != nil {
+		return nil, err
+	}
+
+	// User defaults sit underneath the project config, and the selected
+	// profile on top of it
+	cfg := &config.Config{}
+	if a.defaults != nil {
+		cfg.Merge(a.defaults)
+	}
+	cfg.Merge(project)
+	if a.profile != nil {
+		cfg.Merge(a.profile)
+	}
+	cfg.Strict = cfg.Strict || strict
+
+	if cfg.Strict {
+		for _, file := range files {
+			decoder := yaml.NewDecoder(bytes.NewReader(file.data))
+			decoder.KnownFields(true)
+			if err := decoder.Decode(&config.Config{}); err != nil && err != io.EOF {
+				return nil, WithExitCode(ExitUsage, fmt.Errorf("strict mode: %s: %w", file.path, err))
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// configFile is a raw config layer, kept for strict validation
+type configFile struct {
+	path string
+	data []byte
+}
+
+// loadConfigLayers reads a config and merges it on top of its includes.
+// Include paths are relative to the including config; stack detects cycles.
+func (a *App) loadConfigLayers(path string, stack []string, files *[]configFile) (*config.Config, error) {
+	id, err := configSourceID(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolve config path: %w", err)
+	}
+	for _, p := range stack {
+		if p == id {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("config include cycle: %s", strings.Join(append(stack, id), " -> ")))
+		}
+	}
+	stack = append(stack, id)
+
+	data, err := a.readConfigSource(path)
+	if err != nil {
+		return nil, err
+	}
+	*files = append(*files, configFile{path: path, data: data})
+
+	var layer config.Config
+	if err := yaml.Unmarshal(data, &layer); err != nil {
+		return nil, fmt.Errorf("parse yaml config %s: %w", path, err)
+	}
+	for i := range layer.Secrets {
+		layer.Secrets[i].Source = path
+	}
+
+	cfg := &config.Config{}
+	for _, include := range layer.Include {
+		include, err := resolveInclude(path, include)
+		if err != nil {
+			return nil, err
+		}
+		included, err := a.loadConfigLayers(include, stack, files)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", include, err)
+		}
+		cfg.Merge(included)
+	}
+	cfg.Merge(&layer)
+	cfg.Include = nil
+
+	return cfg, nil
+}
+
+// RunOptions contains options for the Run operation
+type RunOptions struct {
+	KVMount        string
+	TransitMount   string
+	EncryptionKey  string
+	ConfigFile     string
+	InjectSecrets  []string      // Format: "ENV_VAR=vault_path"
+	InjectFile     string        // File of InjectSecrets specs, one per line (# starts a comment)
+	EnvFile        string        // Additional .env file to load
+	Identities     []string      // age identity files for an encrypted EnvFile
+	Expired        string        // what to do when EnvFile has expired: ExpiredRefuse (default), ExpiredWarn, or ExpiredResync
+	Cleanup        bool          // Shred EnvFile (when sync wrote it) once the command has exited
+	DryRun         bool          // Show env vars without running
+	PreserveEnv    bool          // Preserve current environment
+	Isolate        bool          // Start from an empty environment, keeping only the AllowEnv host variables
+	AllowEnv       []string      // Isolate: host variables to keep (NAME, or PREFIX* for a prefix)
+	RedactVaultEnv bool          // Remove VAULT_* variables (credentials included) from the inherited environment
+	MaskOutput     bool          // Replace injected secret values in the command's stdout/stderr with ***
+	Shell          bool          // Run Command and Args joined as a script through $SHELL -c
+	Timeout        time.Duration // Terminate the command after this long (0 disables)
+	KillAfter      time.Duration // After Timeout, wait this long for the command to exit before killing it
+	Strict         bool          // Treat skipped entries and config warnings as errors
+	OnlyTags       []string      // Keep only config entries with one of these tags
+	SkipTags       []string      // Drop config entries with any of these tags
+	Namespace      string        // overrides the client namespace for this command
+	Command        string        // Command to execute
+	Args           []string      // Arguments for the command
+	Procfile       string        // Run the processes of this Procfile instead of Command
+	WaitAll        bool          // Procfile: keep running until every process exits instead of stopping all when one exits
+	Watch          bool          // Restart the command when its secrets change in Vault
+	WatchInterval  time.Duration // Watch: how often to poll when Vault events are unavailable
+	MetricsAddr    string        // Watch: serve Prometheus metrics on this address
+}
+
+// trimNewline removes the single line ending a file or pipe usually ends with
+func trimNewline(data []byte) []byte {
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	return bytes.TrimSuffix(data, []byte("\r"))
+}
+
+// Run executes a command with secrets injected as environment variables
+func (a *App) Run(opts *RunOptions) error {
+	a = a.withNamespace(opts.Namespace)
+
+	envVars, secretValues, err := a.runEnvironment(opts)
+	if err != nil {
+		return err
+	}
+
+	// If dry-run, just print the environment variables
+	if opts.DryRun {
+		var out io.Writer = os.Stdout
+		if opts.MaskOutput {
+			redacted := utils.NewRedactWriter(os.Stdout, secretValues)
+			defer redacted.Flush()
+			out = redacted
+		} else {
+			audit.MarkDisplayed()
+		}
+		fmt.Fprintln(out, "Environment variables that would be set:")
+		for k, v := range envVars {
+			fmt.Fprintf(out, "%s=%s\n", k, v)
+		}
+		if opts.Procfile != "" {
+			entries, err := parseProcfile(opts.Procfile)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "\nProcesses that would be started:")
+			for _, e := range entries {
+				fmt.Fprintf(out, "%s: %s\n", e.Name, e.Command)
+			}
+			return nil
+		}
+		fmt.Fprintf(out, "\nCommand that would be executed: %s %s\n", opts.Command, strings.Join(opts.Args, " "))
+		return nil
+	}
+
+	// Execute the command
+	if !opts.MaskOutput {
+		secretValues = nil
+	}
+	if opts.Cleanup {
+		defer cleanupEnvFile(opts.EnvFile)
+	}
+	if opts.Watch {
+		return a.runWatch(opts, envVars, secretValues)
+	}
+	return a.executeCommand(opts, envVars, secretValues)
+}
+
+// runEnvironment builds the command environment of opts. It also returns the
+// values injected from Vault, which MaskOutput hides.
+func (a *App) runEnvironment(opts *RunOptions) (map[string]string, []string, error) {
+	// The config and --inject share one read cache; each reload starts afresh
+	a = a.withReadCache()
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+
+	// Start with current environment if preserve-env is true
+	envVars := make(map[string]string)
+	if opts.PreserveEnv || opts.Isolate {
+		for _, env := range os.Environ() {
+			parts := strings.SplitN(env, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			if opts.Isolate && !envAllowed(parts[0], opts.AllowEnv) {
+				continue
+			}
+			// Keep Vault credentials away from the child unless explicitly requested
+			if opts.RedactVaultEnv && isVaultEnvVar(parts[0]) {
+				continue
+			}
+			envVars[parts[0]] = parts[1]
+		}
+	}
+
+	// Load from .env file if specified
+	if opts.EnvFile != "" {
+		fileEnvVars, err := a.readEnvFile(opts.EnvFile, opts.Identities, opts.Expired)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load env file %s: %w", opts.EnvFile, err)
+		}
+		for k, v := range fileEnvVars {
+			envVars[k] = v
+		}
+	}
+
+	// Failures from the config file and --inject are reported together
+	loadErr := &LoadError{}
+	var secretValues []string // injected from Vault, masked in output with MaskOutput
+
+	// Load from config file if specified
+	if opts.ConfigFile != "" {
+		cfg, err := a.LoadConfig(opts.ConfigFile, opts.Strict)
+		if err != nil {
+			return nil, nil, fmt.Errorf("load config: %w", err)
+		}
+		cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+		opts.KVMount, opts.TransitMount = cfg.KVMount(opts.KVMount), cfg.TransitMount(opts.TransitMount)
+
+		// The command's namespace takes precedence over the config file's
+		configApp := a.withNamespace(opts.Namespace)
+		configEnvVars, err := configApp.loadSecretsFromConfig(cfg, opts.KVMount, opts.TransitMount, effectiveEncryptionKey)
+		loadErr.Merge(err)
+		for k, v := range configEnvVars {
+			envVars[k] = v
+			secretValues = append(secretValues, v)
+		}
+	}
+
+	// Load inline injected secrets; --inject flags come after the file and win
+	injectSecrets := opts.InjectSecrets
+	if opts.InjectFile != "" {
+		specs, err := readInjectFile(opts.InjectFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		injectSecrets = append(specs, injectSecrets...)
+	}
+	if len(injectSecrets) > 0 {
+		// Without a config file, --inject paths use the mounts of the nearest vlt.yaml
+		kvMount, transitMount := a.pathMounts(opts.KVMount, opts.TransitMount)
+		injectEnvVars, err := a.loadInlineSecrets(injectSecrets, kvMount, transitMount, effectiveEncryptionKey, opts.Strict)
+		loadErr.Merge(err)
+		for k, v := range injectEnvVars {
+			envVars[k] = v
+			secretValues = append(secretValues, v)
+		}
+	}
+
+	if err := loadErr.ErrOrNil(); err != nil {
+		return nil, nil, err
+	}
+
+	return envVars, secretValues, nil
+}
+
+// isVaultEnvVar reports whether name is a Vault client variable. Every
+// credential vlt reads (VAULT_TOKEN, VAULT_ROLE_ID, VAULT_SECRET_ID,
+// VAULT_GITHUB_TOKEN, ...) uses this prefix.
+func isVaultEnvVar(name string) bool {
+	return strings.HasPrefix(strings.ToUpper(name), "VAULT_")
+}
+
+// envAllowed reports whether name matches one of the allowlist patterns: an
+// exact variable name, or a prefix followed by "*"
+func envAllowed(name string, allow []string) bool {
+	for _, pattern := range allow {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// SyncOptions contains options for the GenerateEnvFile and Verify operations
+type SyncOptions struct {
+	ConfigFile     string
+	OutputFile     string
+	EncryptionKey  string
+	KVMount        string
+	TransitMount   string
+	Namespace      string        // overrides the client namespace for this command
+	Strict         bool          // treat skipped entries and config warnings as errors
+	OnlyTags       []string      // keep only entries with one of these tags
+	SkipTags       []string      // drop entries with any of these tags
+	Format         string        // output format for OutputFile (see utils.OutputFormats)
+	MapName        string        // tfvars formats: nest values in a map variable; toml: in a table
+	SystemdDropIn  string        // systemd format: also write a unit drop-in loading OutputFile
+	Manifest       string        // sync: write SHA-256 hashes of the outputs here; verify: compare against it
+	DryRun         bool          // sync: print a masked diff of each output instead of writing it
+	Mode           os.FileMode   // sync: permissions of the written files (default 0600)
+	FixGitignore   bool          // sync: add outputs inside a git work tree to its .gitignore
+	InsecureOutput bool          // sync: skip the checks on the directories outputs are written to
+	EncryptOutput  string        // sync: encrypt written files, "age:<recipient>" or "transit:<key>"
+	Identities     []string      // verify: age identity files for encrypted outputs
+	TTL            time.Duration // sync: record an expiry this far ahead in each written file
+	ExpiryWindow   time.Duration // sync: warn about credentials in the values expiring within this window (0 to skip)
+	Vars           []string      // sync: refresh only the entries setting these variables (or named so) in the existing output
+	Paths          []string      // sync: refresh only the entries reading these Vault paths in the existing output
+	Incremental    bool          // sync: skip decryption of paths whose KV version is unchanged since the last sync
+	WorkDir        string        // resolve relative output paths against this directory (default: current)
+}
+
+// renderedOutput is a file sync would write
+type renderedOutput struct {
+	Path    string
+	Content []byte
+	Format  string // output format the content is rendered in
+	Secrets int    // number of variables rendered
+	DropIn  bool   // a systemd drop-in rather than a secrets file
+
+	versions *versionTracker // incremental sync: KV versions the content was loaded from
+}
+
+// GenerateEnvFile generates a .env file from multiple vault secrets. When no
+// output file is given and the config defines outputs, every output is written
+// from a single load of the secrets.
+func (a *App) GenerateEnvFile(opts *SyncOptions) error {
+	if opts.EncryptOutput != "" {
+		if _, _, err := parseEncryptOutput(opts.EncryptOutput); err != nil {
+			return err
+		}
+	}
+
+	outputs, err := a.renderSyncOutputs(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.DryRun {
+		for _, output := range outputs {
+			if err := printFileDiff(output); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := checkOutputs(outputs, opts); err != nil {
+		return err
+	}
+
+	var expiresAt *time.Time
+	if opts.TTL > 0 {
+		t := time.Now().Add(opts.TTL).UTC().Truncate(time.Second)
+		expiresAt = &t
+	}
+
+	// Parallel syncs of the same file take turns, each writing the file
+	// and its state record whole
+	paths := make([]string, 0, len(outputs))
+	for _, output := range outputs {
+		paths = append(paths, output.Path)
+	}
+	unlock, err := lockFiles(paths...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for _, output := range outputs {
+		if expiresAt != nil && !output.DropIn {
+			output.Content = withExpiryHeader(output.Content, *expiresAt)
+		}
+		if opts.EncryptOutput != "" && !output.DropIn {
+			encrypted, err := a.encryptContent(output.Content, opts.EncryptOutput, opts.TransitMount)
+			if err != nil {
+				return fmt.Errorf("encrypt %s: %w", output.Path, err)
+			}
+			output.Content = encrypted
+		}
+		if err := writeOutput(output, outputMode(opts)); err != nil {
+			return err
+		}
+		if output.versions != nil {
+			if err := output.versions.save(); err != nil {
+				return err
+			}
+		}
+	}
+
+	recordGenerated(outputs, opts, expiresAt)
+
+	if opts.Manifest != "" {
+		return writeManifest(opts.Manifest, outputs)
+	}
+	return nil
+}
+
+// renderSyncOutputs loads the secrets of a config and renders every file
+// sync would write for opts, without writing anything
+func (a *App) renderSyncOutputs(opts *SyncOptions) ([]renderedOutput, error) {
+	cfg, err := a.LoadConfig(opts.ConfigFile, opts.Strict)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+	opts.KVMount, opts.TransitMount = cfg.KVMount(opts.KVMount), cfg.TransitMount(opts.TransitMount)
+	if opts.TTL > 0 {
+		if err := checkTTLFormats(cfg, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// A partial sync loads only the selected entries and merges them into
+	// the existing output, so it needs a single file that can be read back
+	var partial *partialSync
+	if len(opts.Vars) > 0 || len(opts.Paths) > 0 {
+		if opts.OutputFile == "" && opts.Format == "" && len(cfg.Outputs) > 0 {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--vars and --paths refresh a single output; pass --output"))
+		}
+		if !partialFormat(opts.Format) || opts.SystemdDropIn != "" || opts.EncryptOutput != "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--vars and --paths only refresh unencrypted env files"))
+		}
+		if partial, err = selectEntries(cfg, opts.Vars, opts.Paths); err != nil {
+			return nil, err
+		}
+	}
+
+	outputPath := config.NonEmpty(opts.OutputFile, defaultOutputFile(opts.Format))
+	if opts.WorkDir != "" && !filepath.IsAbs(outputPath) {
+		outputPath = filepath.Join(opts.WorkDir, outputPath)
+	}
+
+	// An incremental sync takes the values of unchanged paths from the
+	// existing output, so it also needs a single file that can be read back
+	var versions *versionTracker
+	if opts.Incremental {
+		switch {
+		case partial != nil:
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--incremental cannot be combined with --vars or --paths"))
+		case opts.OutputFile == "" && opts.Format == "" && len(cfg.Outputs) > 0:
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--incremental syncs a single output; pass --output"))
+		case !partialFormat(opts.Format) || opts.EncryptOutput != "":
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("--incremental only syncs unencrypted env files"))
+		}
+		if versions, err = readVersions(outputPath); err != nil {
+			return nil, err
+		}
+		a = a.withVersions(versions)
+	}
+
+	// The command's namespace takes precedence over the config file's
+	a = a.withNamespace(opts.Namespace)
+
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+
+	// Use the shared logic for loading secrets
+	entryVars, err := a.loadConfigEntries(cfg, opts.KVMount, opts.TransitMount, effectiveEncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("load secrets from config: %w", err)
+	}
+	if partial != nil {
+		partial.filter(entryVars)
+	}
+	if opts.ExpiryWindow > 0 {
+		if err := checkExpiring(cfg, entryVars, opts.ExpiryWindow, cfg.Strict); err != nil {
+			return nil, err
 		}
-	} else {
-		utils.OutputEnvFormat(data)
 	}
 
-	return nil
-}
+	if opts.OutputFile == "" && opts.Format == "" && len(cfg.Outputs) > 0 {
+		return renderOutputs(cfg, entryVars, opts.ConfigFile)
+	}
 
-// LoadConfig loads configuration from a YAML file
-func (a *App) LoadConfig(path string) (*config.Config, error) {
-	data, err := os.ReadFile(path)
+	render := utils.RenderOptions{Format: opts.Format, MapName: opts.MapName}
+	vars := mergeEntryVars(cfg, entryVars, nil, nil)
+	if partial != nil {
+		if vars, err = partial.merge(outputPath, vars); err != nil {
+			return nil, err
+		}
+	}
+	output, err := renderOutput(outputPath, render, vars)
 	if err != nil {
-		return nil, fmt.Errorf("read config file: %w", err)
+		return nil, err
 	}
+	if versions != nil {
+		versions.summary()
+		output.versions = versions
+	}
+	outputs := []renderedOutput{output}
 
-	var cfg config.Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parse yaml config: %w", err)
+	if opts.SystemdDropIn != "" {
+		absEnvFile, err := filepath.Abs(outputPath)
+		if err != nil {
+			return nil, fmt.Errorf("resolve env file path: %w", err)
+		}
+		outputs = append(outputs, renderedOutput{Path: opts.SystemdDropIn, Content: utils.SystemdDropIn(absEnvFile), DropIn: true})
 	}
+	return outputs, nil
+}
 
-	return &cfg, nil
+// checkTTLFormats fails when sync --ttl would write an output in the
+// tfvars-json format, which has no comments to hold the expiry header. Every
+// output is checked before any secret is loaded or file written.
+func checkTTLFormats(cfg *config.Config, opts *SyncOptions) error {
+	if opts.OutputFile != "" || opts.Format != "" || len(cfg.Outputs) == 0 {
+		if opts.Format == utils.FormatTFVarsJSON {
+			return WithExitCode(ExitUsage, fmt.Errorf("--ttl cannot be used with the tfvars-json format, which has no comments"))
+		}
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Outputs))
+	for name, output := range cfg.Outputs {
+		if output.Format == utils.FormatTFVarsJSON {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		return WithExitCode(ExitUsage, fmt.Errorf("output %s: --ttl cannot be used with the tfvars-json format, which has no comments", strings.Join(names, ", ")))
+	}
+	return nil
 }
 
-// RunOptions contains options for the Run operation
-type RunOptions struct {
-	KVMount       string
-	TransitMount  string
-	EncryptionKey string
-	ConfigFile    string
-	InjectSecrets []string // Format: "ENV_VAR=vault_path"
-	EnvFile       string   // Additional .env file to load
-	DryRun        bool     // Show env vars without running
-	PreserveEnv   bool     // Preserve current environment
-	Command       string   // Command to execute
-	Args          []string // Arguments for the command
+// defaultOutputFile returns the file sync writes for a format when no output is given
+func defaultOutputFile(format string) string {
+	switch format {
+	case utils.FormatTFVars:
+		return "secrets.auto.tfvars"
+	case utils.FormatTFVarsJSON:
+		return "secrets.auto.tfvars.json"
+	case utils.FormatProperties:
+		return "application.properties"
+	case utils.FormatTOML:
+		return "config.toml"
+	case utils.FormatSystemd:
+		return "secrets.env"
+	case utils.FormatDockerArgs:
+		return "docker.env"
+	case utils.FormatCompose:
+		return "compose.env.yaml"
+	case utils.FormatPowerShell:
+		return "secrets.ps1"
+	default:
+		return ".env"
+	}
 }
 
-// Run executes a command with secrets injected as environment variables
-func (a *App) Run(opts *RunOptions) error {
-	effectiveEncryptionKey := config.GetEncryptionKey(opts.EncryptionKey)
+// renderOutputs renders every output defined in the config. Relative paths
+// are resolved against the directory of a local config file.
+func renderOutputs(cfg *config.Config, entryVars []map[string]string, configFile string) ([]renderedOutput, error) {
+	baseDir := ""
+	if !isRemoteConfig(configFile) {
+		baseDir = filepath.Dir(configFile)
+	}
 
-	// Start with current environment if preserve-env is true
-	envVars := make(map[string]string)
-	if opts.PreserveEnv {
-		for _, env := range os.Environ() {
-			parts := strings.SplitN(env, "=", 2)
-			if len(parts) == 2 {
-				envVars[parts[0]] = parts[1]
-			}
-		}
+	names := make([]string, 0, len(cfg.Outputs))
+	for name := range cfg.Outputs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	// Load from .env file if specified
-	if opts.EnvFile != "" {
-		fileEnvVars, err := a.loadEnvFileForRun(opts.EnvFile)
-		if err != nil {
-			return fmt.Errorf("load env file %s: %w", opts.EnvFile, err)
-		}
-		for k, v := range fileEnvVars {
-			envVars[k] = v
+	outputs := make([]renderedOutput, 0, len(names))
+	for _, name := range names {
+		output := cfg.Outputs[name]
+		if output.File == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("output %s: file is required", name))
 		}
-	}
 
-	// Load from config file if specified
-	if opts.ConfigFile != "" {
-		cfg, err := a.LoadConfig(opts.ConfigFile)
-		if err != nil {
-			return fmt.Errorf("load config: %w", err)
+		path := output.File
+		if !filepath.IsAbs(path) && baseDir != "" {
+			path = filepath.Join(baseDir, path)
 		}
 
-		configEnvVars, err := a.loadSecretsFromConfig(cfg, opts.KVMount, opts.TransitMount, effectiveEncryptionKey)
+		vars := mergeEntryVars(cfg, entryVars, output.Only, output.Skip)
+		render := utils.RenderOptions{Format: output.Format, MapName: output.Map}
+		rendered, err := renderOutput(path, render, vars)
 		if err != nil {
-			return fmt.Errorf("load secrets from config: %w", err)
-		}
-		for k, v := range configEnvVars {
-			envVars[k] = v
+			return nil, fmt.Errorf("output %s: %w", name, err)
 		}
+		outputs = append(outputs, rendered)
 	}
+	return outputs, nil
+}
 
-	// Load inline injected secrets
-	if len(opts.InjectSecrets) > 0 {
-		injectEnvVars, err := a.loadInlineSecrets(opts.InjectSecrets, opts.KVMount, opts.TransitMount, effectiveEncryptionKey)
-		if err != nil {
-			return fmt.Errorf("load inline secrets: %w", err)
-		}
-		for k, v := range injectEnvVars {
-			envVars[k] = v
-		}
+// renderOutput renders vars for the file at path
+func renderOutput(path string, render utils.RenderOptions, vars map[string]string) (renderedOutput, error) {
+	content, err := utils.Render(vars, render)
+	if err != nil {
+		return renderedOutput{}, WithExitCode(ExitUsage, err)
 	}
+	// A file saved with CRLF line endings by a Windows editor keeps them
+	if current, err := os.ReadFile(path); err == nil && utils.UsesCRLF(current) {
+		content = utils.ToCRLF(content)
+	}
+	return renderedOutput{Path: path, Content: content, Format: render.Format, Secrets: len(vars)}, nil
+}
 
-	// If dry-run, just print the environment variables
-	if opts.DryRun {
-		fmt.Println("Environment variables that would be set:")
-		for k, v := range envVars {
-			fmt.Printf("%s=%s\n", k, v)
+// writeOutput writes a rendered file with the given permissions, replacing
+// an existing file atomically
+func writeOutput(output renderedOutput, mode os.FileMode) error {
+	if dir := filepath.Dir(output.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
 		}
-		fmt.Printf("\nCommand that would be executed: %s %s\n", opts.Command, strings.Join(opts.Args, " "))
-		return nil
 	}
 
-	// Execute the command
-	return a.executeCommand(opts.Command, opts.Args, envVars)
-}
-
-// GenerateEnvFile generates a .env file from multiple vault secrets
-func (a *App) GenerateEnvFile(configPath, outputPath string, encryptionKey string) error {
-	cfg, err := a.LoadConfig(configPath)
-	if err != nil {
-		return fmt.Errorf("load config: %w", err)
+	if err := writeFileAtomic(output.Path, output.Content, mode); err != nil {
+		return fmt.Errorf("write output file: %w", err)
 	}
 
-	effectiveEncryptionKey := config.GetEncryptionKey(encryptionKey)
+	if output.DropIn {
+		infof("Generated %s (run 'systemctl daemon-reload' to apply)\n", output.Path)
+	} else {
+		infof("Generated %s with %d secrets\n", output.Path, output.Secrets)
+	}
+	return nil
+}
 
-	// Use the shared logic for loading secrets
-	envVars, err := a.loadSecretsFromConfig(cfg, "kv", "transit", effectiveEncryptionKey)
+// loadSecretsFromConfig loads secrets from YAML config and returns as env vars.
+// Every entry is attempted; failures are collected and returned together as a *LoadError.
+func (a *App) loadSecretsFromConfig(cfg *config.Config, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+	entryVars, err := a.loadConfigEntries(cfg, kvMount, transitMount, encryptionKey)
 	if err != nil {
-		return fmt.Errorf("load secrets from config: %w", err)
+		return nil, err
 	}
+	return mergeEntryVars(cfg, entryVars, nil, nil), nil
+}
 
-	// Convert to env file format
-	var envLines []string
-	for k, v := range envVars {
-		envLines = append(envLines, fmt.Sprintf("%s=%s", k, v))
-	}
+// loadConfigEntries loads every config entry and returns the env vars of each,
+// indexed like cfg.Secrets. Failures are collected into a *LoadError.
+func (a *App) loadConfigEntries(cfg *config.Config, kvMount, transitMount, encryptionKey string) ([]map[string]string, error) {
+	a = a.withReadCache()
+	entryVars := make([]map[string]string, len(cfg.Secrets))
+	loadErr := &LoadError{}
 
-	// Write to file
-	content := strings.Join(envLines, "\n")
-	if len(envLines) > 0 {
-		content += "\n" // Add final newline
+	for i, secret := range cfg.Secrets {
+		vars, err := a.loadConfigEntry(cfg, &secret, kvMount, transitMount, encryptionKey)
+		if err != nil {
+			loadErr.Add(secret.Describe(), err)
+			continue
+		}
+		entryVars[i] = vars
 	}
 
-	if err := os.WriteFile(outputPath, []byte(content), 0600); err != nil {
-		return fmt.Errorf("write output file: %w", err)
+	if err := loadErr.ErrOrNil(); err != nil {
+		return nil, err
 	}
-
-	fmt.Printf("Generated %s with %d secrets\n", outputPath, len(envLines))
-	return nil
+	return entryVars, nil
 }
 
-// Helper methods for Run command
+// mergeEntryVars merges the env vars of the entries matching the tag filters;
+// later entries win when two export the same variable
+func mergeEntryVars(cfg *config.Config, entryVars []map[string]string, only, skip []string) map[string]string {
+	envVars := make(map[string]string)
+	for i, vars := range entryVars {
+		if !cfg.Secrets[i].MatchesTags(only, skip) {
+			continue
+		}
+		for k, v := range vars {
+			envVars[k] = v
+		}
+	}
+	return envVars
+}
 
-// loadEnvFileForRun loads environment variables from a .env file
-func (a *App) loadEnvFileForRun(path string) (map[string]string, error) {
-	// Use godotenv to parse the .env file
-	envMap, err := godotenv.Read(path)
+// loadConfigEntry loads the env vars for a single config entry
+func (a *App) loadConfigEntry(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+	// Entries may target a different server or namespace than the client default
+	a, err := a.forEntry(cfg, secret)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read .env file: %w", err)
+		return nil, fmt.Errorf("failed to connect: %w", err)
 	}
-	return envMap, nil
-}
 
-// loadSecretsFromConfig loads secrets from YAML config and returns as env vars
-func (a *App) loadSecretsFromConfig(cfg *config.Config, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
-	envVars := make(map[string]string)
+	load := func() (map[string]string, error) {
+		vars, err := a.loadEntryVars(cfg, secret, kvMount, transitMount, encryptionKey)
+		if err != nil || len(secret.Transform) == 0 {
+			return vars, err
+		}
+		return transformVars(secret, vars)
+	}
+	if a.versions != nil {
+		return a.versions.load(a, cfg, secret, kvMount, load)
+	}
+	return load()
+}
 
-	for _, secret := range cfg.Secrets {
-		if secret.IsPathAllKeys() {
-			// New format: load all keys from a path as environment variables
-			pathEnvVars, err := a.loadAllKeysFromPath(cfg, secret.Path, kvMount, transitMount, encryptionKey)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load secrets from path %s: %w", secret.Path, err)
+// loadEntryVars loads the env vars of an entry with a client already
+// targeting the entry's server and namespace
+func (a *App) loadEntryVars(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+	if secret.IsLiteral() {
+		// Literal format: fixed value, no Vault lookup
+		return map[string]string{secret.EnvVar: *secret.Value}, nil
+	} else if secret.IsTemplate() {
+		// Template format: compose one value from several secret fields
+		value, err := a.loadTemplateEntry(cfg, secret, kvMount, transitMount, encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{secret.EnvVar: value}, nil
+	} else if secret.IsPathGlob() {
+		// Wildcard format: load all keys of every secret under a directory
+		if secret.Key != "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("'key' cannot be used with the wildcard path %s", secret.Path))
+		}
+		return a.loadGlobEntry(cfg, secret, kvMount, transitMount, encryptionKey)
+	} else if secret.IsPathAllKeys() {
+		// New format: load all keys from a path as environment variables
+		return a.loadAllKeysFromPath(cfg, secret, kvMount, transitMount, encryptionKey)
+	} else if secret.IsPathSingleKey() {
+		// Selective format: load single key from path
+		secretValue, err := a.loadSingleKeyFromPath(cfg, secret, kvMount, transitMount, encryptionKey)
+		if err != nil {
+			if secret.Default == nil || ExitCode(err) != ExitNotFound {
+				return nil, err
 			}
-			for k, v := range pathEnvVars {
-				envVars[k] = v
+			secretValue = *secret.Default
+		}
+		envName := secret.EnvKey
+		if envName == "" {
+			if envName, err = cfg.EnvNameFor(secret, secret.Key); err != nil {
+				return nil, WithExitCode(ExitUsage, err)
 			}
-		} else if secret.IsPathSingleKey() {
-			// Selective format: load single key from path
-			secretValue, err := a.loadSingleKeyFromPath(cfg, &secret, kvMount, transitMount, encryptionKey)
-			if err != nil {
-				return nil, fmt.Errorf("failed to load key %s from path %s: %w", secret.Key, secret.Path, err)
+		}
+		return map[string]string{envName: secretValue}, nil
+	} else if secret.IsIndividual() {
+		// Old format: individual secret mapping
+		secretValue, err := a.loadIndividualSecret(cfg, secret, kvMount, transitMount, encryptionKey)
+		if err != nil {
+			if secret.Default != nil && !secret.Required && ExitCode(err) == ExitNotFound {
+				return map[string]string{secret.EnvVar: *secret.Default}, nil
 			}
-			envVars[secret.GetEnvKeyName()] = secretValue
-		} else if secret.IsIndividual() {
-			// Old format: individual secret mapping
-			secretValue, err := a.loadIndividualSecret(cfg, &secret, kvMount, transitMount, encryptionKey)
-			if err != nil {
-				if secret.Required {
-					return nil, err
-				}
-				fmt.Printf("warning: %v\n", err)
-				continue
+			if secret.Required || cfg.Strict {
+				return nil, err
 			}
-			envVars[secret.EnvVar] = secretValue
-		} else {
-			fmt.Printf("skipping invalid secret entry: either 'path' or 'kv_path+env_var' must be specified\n")
-			continue
+			warnf("%v\n", err)
+			return nil, nil
 		}
+		return map[string]string{secret.EnvVar: secretValue}, nil
 	}
 
-	return envVars, nil
+	if cfg.Strict {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("invalid secret entry: either 'path', 'kv_path+env_var', 'env_var+template', or 'env_var+value' must be specified"))
+	}
+	warnf("skipping invalid secret entry: either 'path', 'kv_path+env_var', 'env_var+template', or 'env_var+value' must be specified\n")
+	return nil, nil
+}
+
+// withNamespace returns an app whose Vault client targets the given namespace
+func (a *App) withNamespace(namespace string) *App {
+	if namespace == "" {
+		return a
+	}
+	scoped := *a
+	scoped.vaultClient = a.vaultClient.WithNamespace(namespace)
+	return &scoped
+}
+
+// forEntry returns an app whose Vault client targets the server and
+// namespace configured on a secret entry, logged in with its auth profile
+func (a *App) forEntry(cfg *config.Config, secret *config.SecretEntry) (*App, error) {
+	if secret.Auth != "" {
+		var err error
+		if a, err = a.withAuthProfile(cfg, secret.Auth); err != nil {
+			return nil, err
+		}
+	}
+	if len(secret.Vault) == 0 {
+		return a.withNamespace(secret.Namespace), nil
+	}
+
+	client, err := a.vaultClient.WithAddress(secret.Vault)
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := *a
+	scoped.vaultClient = client.WithNamespace(secret.Namespace)
+	return &scoped, nil
 }
 
 // loadAllKeysFromPath loads all keys from a Vault path as environment variables
-func (a *App) loadAllKeysFromPath(cfg *config.Config, vaultPath, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+func (a *App) loadAllKeysFromPath(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+	return a.loadKeysFromPath(cfg, secret, secret.Path, "", kvMount, transitMount, encryptionKey)
+}
+
+// loadKeysFromPath loads all keys from a Vault path of a path entry. Env var
+// names are derived from namePrefix followed by each key; a single-value
+// secret is named after namePrefix, or the last path segment without one.
+func (a *App) loadKeysFromPath(cfg *config.Config, secret *config.SecretEntry, vaultPath, namePrefix, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
 	envVars := make(map[string]string)
 
 	// Get all data from the Vault path
-	data, err := a.vaultClient.KVGet(config.NonEmpty("", cfg.KV.Mount, kvMount), vaultPath)
+	raw, err := a.kvGet(cfg.GetKVMountFor(secret, kvMount), vaultPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get secrets from path %s: %w", vaultPath, err)
 	}
+	if err := checkKeyVersion(cfg, vaultPath, raw); err != nil {
+		return nil, err
+	}
+	if manifest, ok := utils.ReadFileManifest(raw); ok && manifest.Chunks > 0 {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("path %s holds a file stored in chunks, not variables; read it with vlt get --out-file", vaultPath))
+	}
+	data := utils.StripMeta(raw)
 
 	// Handle encrypted multi-value data
-	if utils.IsEncryptedMultiValue(data) {
+	if utils.IsEncryptedMultiValue(raw) {
 		encKeyForDecrypt := config.NonEmpty(encryptionKey, cfg.GetTransitKey(), "")
 		if encKeyForDecrypt == "" {
-			return nil, fmt.Errorf("encryption key required for encrypted secrets at path %s", vaultPath)
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secrets at path %s", vaultPath))
 		}
 
-		decryptedData, err := utils.DecryptMultiValueData(data, a.vaultClient, cfg.GetTransitMount(transitMount), encKeyForDecrypt)
+		decryptedData, err := utils.DecryptMultiValueData(raw, a.vaultClient, cfg.GetTransitMountFor(secret, transitMount), encKeyForDecrypt)
 		if err != nil {
 			return nil, fmt.Errorf("failed to decrypt secrets from path %s: %w", vaultPath, err)
 		}
 
 		// Convert all decrypted keys to env vars
 		for key, value := range decryptedData {
-			envVars[strings.ToUpper(key)] = fmt.Sprintf("%v", value)
+			envName, err := cfg.EnvNameFor(secret, namePrefix+key)
+			if err != nil {
+				return nil, WithExitCode(ExitUsage, err)
+			}
+			envVars[envName] = fmt.Sprintf("%v", value)
 		}
 	} else {
+		// A single-value field mixed with other keys is ambiguous
+		if _, hasValue := data["value"]; hasValue && len(data) > 1 && cfg.Strict {
+			return nil, fmt.Errorf("strict mode: path %s mixes a single 'value' field with other keys", vaultPath)
+		}
+
 		// Handle plaintext multi-value data
 		for key, value := range data {
 			// Skip metadata fields
 			if key == "ciphertext" || key == "value" {
 				continue
 			}
-			envVars[strings.ToUpper(key)] = fmt.Sprintf("%v", value)
+			envName, err := cfg.EnvNameFor(secret, namePrefix+key)
+			if err != nil {
+				return nil, WithExitCode(ExitUsage, err)
+			}
+			envVars[envName] = fmt.Sprintf("%v", value)
 		}
 
 		// Handle single value case
@@ -500,35 +1944,70 @@ func (a *App) loadAllKeysFromPath(cfg *config.Config, vaultPath, kvMount, transi
 			if value, ok := data["value"]; ok {
 				// Extract the base name from the path to use as env var name
 				pathParts := strings.Split(vaultPath, "/")
-				envVarName := strings.ToUpper(pathParts[len(pathParts)-1])
+				name := pathParts[len(pathParts)-1]
+				if namePrefix != "" {
+					name = strings.TrimSuffix(namePrefix, "_")
+				}
+				envVarName, err := cfg.EnvNameFor(secret, name)
+				if err != nil {
+					return nil, WithExitCode(ExitUsage, err)
+				}
 				envVars[envVarName] = fmt.Sprintf("%v", value)
 			}
 		}
 	}
 
 	if len(envVars) == 0 {
-		return nil, fmt.Errorf("no valid secrets found at path %s", vaultPath)
+		return nil, vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("no valid secrets found at path %s", vaultPath))
+	}
+
+	if missing := missingKeys(secret.RequireKeys, data, envVars); len(missing) > 0 {
+		return nil, vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("required keys missing at path %s: %s", vaultPath, strings.Join(missing, ", ")))
 	}
 
 	return envVars, nil
 }
 
+// missingKeys returns the required keys present neither in the raw secret
+// data nor among the exported env var names
+func missingKeys(required []string, data map[string]interface{}, envVars map[string]string) []string {
+	var missing []string
+	for _, key := range required {
+		if _, ok := data[key]; ok {
+			continue
+		}
+		if _, ok := envVars[key]; ok {
+			continue
+		}
+		missing = append(missing, key)
+	}
+	return missing
+}
+
 // loadIndividualSecret loads a single secret using the old format
 func (a *App) loadIndividualSecret(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (string, error) {
 	// Get secret from KV
-	data, err := a.vaultClient.KVGet(config.NonEmpty("", cfg.KV.Mount, kvMount), secret.KVPath)
+	raw, err := a.kvGet(cfg.GetKVMountFor(secret, kvMount), secret.KVPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to get secret %s: %w", secret.Name, err)
 	}
+	if err := checkKeyVersion(cfg, secret.KVPath, raw); err != nil {
+		return "", err
+	}
+	data := utils.StripMeta(raw)
+
+	if len(data) > 1 && cfg.Strict {
+		return "", fmt.Errorf("strict mode: secret %s contains multiple values, cannot use it for %s", secret.Name, secret.EnvVar)
+	}
 
 	// Handle different secret types
-	if ciphertext, ok := data["ciphertext"].(string); ok && strings.HasPrefix(ciphertext, "vault:v") {
+	if ciphertext, ok := data["ciphertext"].(string); ok && utils.IsEncryptedValue(raw, "ciphertext") {
 		// Single encrypted value
 		encKeyForDecrypt := config.NonEmpty(encryptionKey, cfg.GetTransitKey(), "")
 		if encKeyForDecrypt == "" {
-			return "", fmt.Errorf("encryption key required for encrypted secret %s", secret.Name)
+			return "", WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secret %s", secret.Name))
 		}
-		plaintext, err := a.vaultClient.TransitDecrypt(cfg.GetTransitMount(transitMount), encKeyForDecrypt, ciphertext)
+		plaintext, err := a.vaultClient.TransitDecrypt(cfg.GetTransitMountFor(secret, transitMount), encKeyForDecrypt, ciphertext)
 		if err != nil {
 			return "", fmt.Errorf("failed to decrypt secret %s: %w", secret.Name, err)
 		}
@@ -540,26 +2019,29 @@ func (a *App) loadIndividualSecret(cfg *config.Config, secret *config.SecretEntr
 		// Multi-value secret - shouldn't be used in individual format
 		return "", fmt.Errorf("secret %s contains multiple values, cannot determine which to use for %s", secret.Name, secret.EnvVar)
 	} else {
-		return "", fmt.Errorf("no valid data found for secret %s", secret.Name)
+		return "", vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("no valid data found for secret %s", secret.Name))
 	}
 }
 
 // loadSingleKeyFromPath loads a single key from a Vault path
 func (a *App) loadSingleKeyFromPath(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (string, error) {
 	// Get all data from the Vault path
-	data, err := a.vaultClient.KVGet(config.NonEmpty("", cfg.KV.Mount, kvMount), secret.Path)
+	data, err := a.kvGet(cfg.GetKVMountFor(secret, kvMount), secret.Path)
 	if err != nil {
 		return "", fmt.Errorf("failed to get secrets from path %s: %w", secret.Path, err)
 	}
+	if err := checkKeyVersion(cfg, secret.Path, data, secret.Key); err != nil {
+		return "", err
+	}
 
 	// Handle encrypted multi-value data
 	if utils.IsEncryptedMultiValue(data) {
 		encKeyForDecrypt := config.NonEmpty(encryptionKey, cfg.GetTransitKey(), "")
 		if encKeyForDecrypt == "" {
-			return "", fmt.Errorf("encryption key required for encrypted secrets at path %s", secret.Path)
+			return "", WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secrets at path %s", secret.Path))
 		}
 
-		decryptedData, err := utils.DecryptMultiValueData(data, a.vaultClient, cfg.GetTransitMount(transitMount), encKeyForDecrypt)
+		decryptedData, err := utils.DecryptMultiValueData(data, a.vaultClient, cfg.GetTransitMountFor(secret, transitMount), encKeyForDecrypt)
 		if err != nil {
 			return "", fmt.Errorf("failed to decrypt secrets from path %s: %w", secret.Path, err)
 		}
@@ -567,86 +2049,136 @@ func (a *App) loadSingleKeyFromPath(cfg *config.Config, secret *config.SecretEnt
 		// Extract the specific key
 		value, ok := decryptedData[secret.Key]
 		if !ok {
-			return "", fmt.Errorf("key %q not found at path %s", secret.Key, secret.Path)
+			return "", vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found at path %s", secret.Key, secret.Path))
 		}
 		return fmt.Sprintf("%v", value), nil
 	} else {
 		// Handle plaintext data
-		value, ok := data[secret.Key]
+		value, ok := utils.StripMeta(data)[secret.Key]
 		if !ok {
-			return "", fmt.Errorf("key %q not found at path %s", secret.Key, secret.Path)
+			return "", vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found at path %s", secret.Key, secret.Path))
 		}
 		return fmt.Sprintf("%v", value), nil
 	}
 }
 
-// loadInlineSecrets loads secrets specified via --inject flags
-func (a *App) loadInlineSecrets(injectSecrets []string, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+// readInjectFile reads ENV_VAR=vault_path specs, one per line, skipping
+// blank lines and # comments
+func readInjectFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read inject file: %w", err)
+	}
+	var specs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	return specs, nil
+}
+
+// loadInlineSecrets loads secrets specified via --inject flags.
+// Every injection is attempted; failures are collected and returned together as a *LoadError.
+func (a *App) loadInlineSecrets(injectSecrets []string, kvMount, transitMount, encryptionKey string, strict bool) (map[string]string, error) {
+	a = a.withReadCache()
 	envVars := make(map[string]string)
+	loadErr := &LoadError{}
 
 	for _, inject := range injectSecrets {
 		// Parse ENV_VAR=vault_path format
 		parts := strings.SplitN(inject, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid inject format: %s (expected ENV_VAR=vault_path)", inject)
+			loadErr.Add("--inject "+inject, fmt.Errorf("invalid inject format (expected ENV_VAR=vault_path)"))
+			continue
 		}
 
 		envVar := strings.TrimSpace(parts[0])
 		vaultPath := strings.TrimSpace(parts[1])
 
 		if envVar == "" || vaultPath == "" {
-			return nil, fmt.Errorf("invalid inject format: %s (empty env var or vault path)", inject)
+			loadErr.Add("--inject "+inject, fmt.Errorf("invalid inject format (empty env var or vault path)"))
+			continue
 		}
 
-		// Get secret from Vault
-		data, err := a.vaultClient.KVGet(kvMount, vaultPath)
+		secretValue, err := a.loadInlineSecret(vaultPath, kvMount, transitMount, encryptionKey, strict)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get secret %s: %w", vaultPath, err)
+			loadErr.Add("--inject "+inject, err)
+			continue
 		}
+		envVars[envVar] = secretValue
+	}
 
-		var secretValue string
+	if err := loadErr.ErrOrNil(); err != nil {
+		return nil, err
+	}
+	return envVars, nil
+}
 
-		// Handle different secret types
-		if ciphertext, ok := data["ciphertext"].(string); ok && strings.HasPrefix(ciphertext, "vault:v") {
-			// Single encrypted value
-			if encryptionKey == "" {
-				return nil, fmt.Errorf("encryption key required for encrypted secret %s", vaultPath)
-			}
-			plaintext, err := a.vaultClient.TransitDecrypt(transitMount, encryptionKey, ciphertext)
-			if err != nil {
-				return nil, fmt.Errorf("failed to decrypt secret %s: %w", vaultPath, err)
-			}
-			secretValue = string(plaintext)
-		} else if value, ok := data["value"].(string); ok {
-			// Single plaintext value
-			secretValue = value
-		} else if len(data) == 1 {
-			// Single value with any key
-			for _, v := range data {
-				secretValue = fmt.Sprintf("%v", v)
-				break
-			}
-		} else {
-			return nil, fmt.Errorf("secret %s contains multiple values, cannot inject as single environment variable", vaultPath)
-		}
+// loadInlineSecret loads the single value stored at a Vault path
+func (a *App) loadInlineSecret(vaultPath, kvMount, transitMount, encryptionKey string, strict bool) (string, error) {
+	// Get secret from Vault
+	raw, err := a.kvGet(kvMount, vaultPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", vaultPath, err)
+	}
+	data := utils.StripMeta(raw)
 
-		envVars[envVar] = secretValue
+	if len(data) > 1 && strict {
+		return "", fmt.Errorf("strict mode: secret %s contains multiple values, cannot inject as single environment variable", vaultPath)
 	}
 
-	return envVars, nil
+	// Handle different secret types
+	if ciphertext, ok := data["ciphertext"].(string); ok && utils.IsEncryptedValue(raw, "ciphertext") {
+		// Single encrypted value
+		if encryptionKey == "" {
+			return "", WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secret %s", vaultPath))
+		}
+		plaintext, err := a.vaultClient.TransitDecrypt(transitMount, encryptionKey, ciphertext)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt secret %s: %w", vaultPath, err)
+		}
+		return string(plaintext), nil
+	} else if value, ok := data["value"].(string); ok {
+		// Single plaintext value
+		return value, nil
+	} else if len(data) == 1 {
+		// Single value with any key
+		for _, v := range data {
+			return fmt.Sprintf("%v", v), nil
+		}
+	}
+
+	return "", fmt.Errorf("secret %s contains multiple values, cannot inject as single environment variable", vaultPath)
 }
 
 // JSONOptions contains options for the JSON operation
 type JSONOptions struct {
 	TransitMount  string
 	EncryptionKey string
-	EnvFile       string
+	EnvFile       string // input file; "-" reads standard input
+	Decrypt       bool   // decrypt a JSON document instead of encrypting an env file
+	OutputFormat  string // decrypt mode: "json" (default) or "env"
+	KeyVersion    int    // encrypt with this Transit key version instead of the latest
 }
 
 // JSON encrypts .env file content and outputs as JSON
 func (a *App) JSON(opts *JSONOptions) error {
-	effectiveEncryptionKey := config.GetEncryptionKey(opts.EncryptionKey)
+	if opts.TransitMount == "" {
+		opts.TransitMount = a.pathConfig().TransitMount("")
+	}
+	if opts.Decrypt {
+		return a.decryptJSON(opts)
+	}
+
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
 	useEncryption := config.ShouldUseEncryption(effectiveEncryptionKey)
+	if opts.KeyVersion > 0 && !useEncryption {
+		return WithExitCode(ExitUsage, fmt.Errorf("--key-version requires an encryption key"))
+	}
+	a = a.withKeyVersion(opts.KeyVersion)
 
 	// Default to .env if no file specified
 	envFile := opts.EnvFile
@@ -655,8 +2187,8 @@ func (a *App) JSON(opts *JSONOptions) error {
 	}
 
 	// Check if file exists
-	if _, err := os.Stat(envFile); os.IsNotExist(err) {
-		return fmt.Errorf("env file not found: %s", envFile)
+	if _, err := os.Stat(envFile); envFile != "-" && os.IsNotExist(err) {
+		return WithExitCode(ExitUsage, fmt.Errorf("env file not found: %s", envFile))
 	}
 
 	var data map[string]interface{}
@@ -684,32 +2216,211 @@ func (a *App) JSON(opts *JSONOptions) error {
 	return nil
 }
 
-// executeCommand runs the specified command with the provided environment variables
-func (a *App) executeCommand(command string, args []string, envVars map[string]string) error {
-	// Convert environment variables to []string format
-	envSlice := make([]string, 0, len(envVars))
+// decryptJSON reads a JSON document whose values may be transit ciphertexts
+// (vault:vN:...) and prints it with those values decrypted
+func (a *App) decryptJSON(opts *JSONOptions) error {
+	input := config.NonEmpty(opts.EnvFile, "-")
+	raw, err := utils.ReadInput(input)
+	if err != nil {
+		return fmt.Errorf("read input: %w", err)
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("parse json input: %w", err))
+	}
+
+	encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+	encrypted := utils.EncryptedKeys(data)
+	data = utils.StripMeta(data)
+	for key := range encrypted {
+		ciphertext := data[key].(string)
+		if encryptionKey == "" {
+			return WithExitCode(ExitUsage, fmt.Errorf("encryption key required to decrypt %s", key))
+		}
+		plaintext, err := a.vaultClient.TransitDecrypt(opts.TransitMount, encryptionKey, ciphertext)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", key, err)
+		}
+		data[key] = string(plaintext)
+	}
+
+	audit.MarkDisplayed()
+	switch opts.OutputFormat {
+	case "", "json":
+		if err := utils.OutputJSON(data); err != nil {
+			return fmt.Errorf("output json: %w", err)
+		}
+	case "env":
+		envMap := make(map[string]string, len(data))
+		for k, v := range data {
+			envMap[k] = fmt.Sprintf("%v", v)
+		}
+		content, err := godotenv.Marshal(envMap)
+		if err != nil {
+			return fmt.Errorf("marshal env: %w", err)
+		}
+		fmt.Println(content)
+	default:
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported output format %q (expected json or env)", opts.OutputFormat))
+	}
+
+	return nil
+}
+
+// executeCommand runs the command of opts with the provided environment variables
+func (a *App) executeCommand(opts *RunOptions, envVars map[string]string, maskValues []string) error {
+	if opts.Procfile != "" {
+		return a.runProcfile(opts, envSlice(envVars), maskValues)
+	}
+
+	cmd, flush := newRunCommand(opts, envVars, maskValues)
+	defer flush()
+
+	var err error
+	switch {
+	case opts.Timeout > 0:
+		err = runWithTimeout(cmd, opts.Timeout, opts.KillAfter)
+	case opts.Cleanup:
+		err = runForwardingSignals(cmd)
+	default:
+		if err = startCommand(cmd); err == nil {
+			err = cmd.Wait()
+		}
+	}
+	return commandError(err)
+}
+
+// envSlice converts environment variables to the KEY=value form of exec.Cmd
+func envSlice(envVars map[string]string) []string {
+	env := make([]string, 0, len(envVars))
 	for k, v := range envVars {
-		envSlice = append(envSlice, fmt.Sprintf("%s=%s", k, v))
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
+
+// newRunCommand creates the command of opts with the provided environment.
+// With maskValues its output is redacted; call flush once it has exited.
+func newRunCommand(opts *RunOptions, envVars map[string]string, maskValues []string) (cmd *exec.Cmd, flush func()) {
+	command, args := opts.Command, opts.Args
+	if opts.Shell {
+		command, args = shellCommand(strings.Join(append([]string{command}, args...), " "))
 	}
 
-	// Create the command
-	cmd := exec.Command(command, args...)
-	cmd.Env = envSlice
+	cmd = exec.Command(command, args...)
+	cmd.Env = envSlice(envVars)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	// Run the command and wait for it to complete
-	err := cmd.Run()
-	if err != nil {
-		// Check if it's an exit error to preserve the exit code
-		if exitError, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				os.Exit(status.ExitStatus())
+	if len(maskValues) == 0 {
+		return cmd, func() {}
+	}
+
+	// Output is piped through redacting writers; Wait waits for them to drain
+	stdout := utils.NewRedactWriter(os.Stdout, maskValues)
+	stderr := utils.NewRedactWriter(os.Stderr, maskValues)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	return cmd, func() {
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+	}
+}
+
+// commandError converts the result of running a command into the error Run
+// returns, preserving the command's exit status
+func commandError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*CodedError); ok {
+		return err
+	}
+	// Check if it's an exit error to preserve the exit code
+	if _, ok := err.(*exec.ExitError); ok {
+		return &ChildExitError{Status: exitStatus(err)}
+	}
+	return WithExitCode(ExitChild, fmt.Errorf("command execution failed: %w", err))
+}
+
+// runForwardingSignals runs cmd without letting signals terminate vlt, so
+// work after the command exits (run --cleanup) still happens. The command
+// shares the terminal's process group and receives Ctrl-C itself; SIGTERM
+// and SIGHUP are forwarded to it.
+func runForwardingSignals(cmd *exec.Cmd) error {
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case sig := <-sigCh:
+			// Windows cannot deliver SIGTERM (sent when the console closes)
+			if sig != os.Interrupt && cmd.Process.Signal(sig) != nil {
+				_ = signalProcessGroup(cmd.Process, os.Kill)
 			}
 		}
-		return fmt.Errorf("command execution failed: %w", err)
 	}
+}
 
-	return nil
+// forwardSignal sends sig to the process group led by p. A signal the
+// platform cannot deliver, such as SIGTERM on Windows, kills the group
+// instead; an interrupt already reaches it through the terminal.
+func forwardSignal(p *os.Process, sig os.Signal) {
+	if err := signalProcessGroup(p, sig); err != nil && sig != os.Interrupt {
+		_ = signalProcessGroup(p, os.Kill)
+	}
+}
+
+// runWithTimeout runs cmd in its own process group, forwarding interrupts to
+// it. After timeout the group is sent SIGTERM, and SIGKILL if it is still
+// running killAfter later; the result is then an ExitTimeout error.
+func runWithTimeout(cmd *exec.Cmd, timeout, killAfter time.Duration) error {
+	setProcessGroup(cmd)
+	if err := startCommand(cmd); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	// The child no longer shares the terminal's foreground process group
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case sig := <-sigCh:
+			forwardSignal(cmd.Process, sig)
+		case <-timer.C:
+			fmt.Fprintf(os.Stderr, "vlt: command timed out after %s, terminating\n", timeout)
+			if err := signalProcessGroup(cmd.Process, syscall.SIGTERM); err != nil {
+				_ = signalProcessGroup(cmd.Process, os.Kill)
+			}
+			select {
+			case <-done:
+			case <-time.After(killAfter):
+				fmt.Fprintf(os.Stderr, "vlt: command still running after %s, killing\n", killAfter)
+				_ = signalProcessGroup(cmd.Process, os.Kill)
+				<-done
+			}
+			return WithExitCode(ExitTimeout, fmt.Errorf("command timed out after %s", timeout))
+		}
+	}
 }
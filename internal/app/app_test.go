@@ -0,0 +1,754 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault/vaultfake"
+)
+
+// newTestApp returns an app talking to a fresh vaultfake server, with the
+// user's config, token store, and audit log kept out of the way
+func newTestApp(t *testing.T) (*App, *vaultfake.Server) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, ".state"))
+	t.Setenv("ENCRYPTION_KEY", "")
+	t.Setenv("VAULT_NAMESPACE", "")
+
+	s := vaultfake.New()
+	t.Cleanup(s.Close)
+	s.CreateTransitKey("transit", "app")
+
+	a, err := New(&Options{VaultAddr: s.URL, VaultToken: s.Token, AuthMethod: "token"})
+	if err != nil {
+		t.Fatalf("new app: %v", err)
+	}
+	return a, s
+}
+
+// captureStdout returns what fn prints to stdout, failing the test when fn
+// fails
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+	out, err := captureOutput(t, fn)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+// captureOutput returns what fn prints to stdout and the error it returns
+func captureOutput(t *testing.T, fn func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- string(out)
+	}()
+	err = fn()
+	os.Stdout = stdout
+	w.Close()
+	return <-done, err
+}
+
+func TestPutGetSync(t *testing.T) {
+	a, s := newTestApp(t)
+
+	// An encrypted key and a plaintext one at the same path
+	if err := a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/db", TransitMount: "transit", EncryptionKey: "app", Key: "PASSWORD", value: []byte("s3cret")}); err != nil {
+		t.Fatalf("put PASSWORD: %v", err)
+	}
+	if err := a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/db", TransitMount: "transit", Key: "USER", value: []byte("app")}); err != nil {
+		t.Fatalf("put USER: %v", err)
+	}
+
+	stored, ok := s.Get("kv", "myapp/db")
+	if !ok {
+		t.Fatal("put wrote nothing")
+	}
+	if password, _ := stored["PASSWORD"].(string); !strings.HasPrefix(password, "vault:v1:") {
+		t.Fatalf("PASSWORD is stored as %q, want Transit ciphertext", password)
+	}
+	if stored["USER"] != "app" {
+		t.Fatalf("USER is stored as %v, want app", stored["USER"])
+	}
+
+	out := captureStdout(t, func() error {
+		return a.Get(&GetOptions{KVMount: "kv", KVPath: "myapp/db", TransitMount: "transit", EncryptionKey: "app", Key: "PASSWORD"})
+	})
+	if out != "s3cret" {
+		t.Fatalf("get PASSWORD printed %q, want s3cret", out)
+	}
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "vlt.yaml")
+	config := "transit:\n  key: app\nsecrets:\n  - path: myapp/db\n  - env_var: APP_ENV\n    value: test\n"
+	if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, ".env")
+	if err := a.GenerateEnvFile(&SyncOptions{ConfigFile: configFile, OutputFile: output, KVMount: "kv", TransitMount: "transit"}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	for _, line := range []string{"PASSWORD=s3cret", "USER=app", "APP_ENV=test"} {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("output lacks %s:\n%s", line, content)
+		}
+	}
+}
+
+func TestConfigNamespacePrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		want string
+	}{
+		{name: "VAULT_NAMESPACE wins over the config", env: "env-ns", want: "env-ns"},
+		{name: "config applies without VAULT_NAMESPACE", env: "", want: "cfg-ns"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, s := newTestApp(t)
+			t.Setenv("VAULT_NAMESPACE", tt.env)
+			s.Put("kv", "myapp/db", map[string]interface{}{"USER": "app"})
+
+			// vaultfake ignores namespaces, so record the ones requests carry
+			var mu sync.Mutex
+			seen := map[string]bool{}
+			target, _ := url.Parse(s.URL)
+			proxy := httputil.NewSingleHostReverseProxy(target)
+			front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				seen[r.Header.Get("X-Vault-Namespace")] = true
+				mu.Unlock()
+				proxy.ServeHTTP(w, r)
+			}))
+			t.Cleanup(front.Close)
+
+			dir := t.TempDir()
+			configFile := filepath.Join(dir, "vlt.yaml")
+			config := "vault:\n  namespace: cfg-ns\nsecrets:\n  - path: myapp/db\n"
+			if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			a, err := New(&Options{VaultAddr: front.URL, VaultToken: s.Token, AuthMethod: "token", ConfigFile: configFile})
+			if err != nil {
+				t.Fatalf("new app: %v", err)
+			}
+			if err := a.GenerateEnvFile(&SyncOptions{ConfigFile: configFile, OutputFile: filepath.Join(dir, ".env"), KVMount: "kv", TransitMount: "transit"}); err != nil {
+				t.Fatalf("sync: %v", err)
+			}
+
+			if len(seen) != 1 || !seen[tt.want] {
+				t.Fatalf("requests used namespaces %v, want only %q", seen, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	// The test binary exits with status 2 on an unknown flag
+	exitErr := exec.Command(os.Args[0], "-test.no-such-flag").Run()
+	var execErr *exec.ExitError
+	if !errors.As(exitErr, &execErr) || execErr.ExitCode() != 2 {
+		t.Fatalf("running the test binary: got %v, want exit status 2", exitErr)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "coded", err: fmt.Errorf("wrapped: %w", WithExitCode(ExitDrift, errors.New("differs"))), want: ExitDrift},
+		{name: "child", err: &ChildExitError{Status: 42}, want: 42},
+		{name: "exec exit error", err: fmt.Errorf("git: %w", exitErr), want: ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Fatalf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelfUpdateKeepsBuiltInKey(t *testing.T) {
+	defer func(key string) { releasePublicKey = key }(releasePublicKey)
+	releasePublicKey = "built-in"
+
+	// Refused before the feed is fetched
+	err := SelfUpdate(&SelfUpdateOptions{FeedURL: "http://127.0.0.1:0/latest.json", PublicKey: "other"})
+	if ExitCode(err) != ExitUsage {
+		t.Fatalf("overriding the built-in key: got %v, want a usage error", err)
+	}
+}
+
+func TestPlanFileIsPrivate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vlt.plan")
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePlanFile(path, &PlanFile{}); err != nil {
+		t.Fatalf("write plan: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("plan file mode %o, want 600", mode)
+	}
+}
+
+func TestPutMergedRebasesOnConflict(t *testing.T) {
+	type secret = map[string]interface{}
+	base := secret{"A": "1", "B": "1"}
+	tests := []struct {
+		name    string
+		other   secret // what another writer stores after base was read
+		ours    secret // what this write derived from base
+		want    secret
+		wantErr string
+	}{
+		{
+			name:  "a key added by the other writer survives",
+			other: secret{"A": "1", "B": "1", "C": "1"},
+			ours:  secret{"A": "2", "B": "1"},
+			want:  secret{"A": "2", "B": "1", "C": "1"},
+		},
+		{
+			name:  "a key changed by the other writer survives",
+			other: secret{"A": "1", "B": "2"},
+			ours:  secret{"A": "2", "B": "1"},
+			want:  secret{"A": "2", "B": "2"},
+		},
+		{
+			name:  "a key this write deletes stays deleted",
+			other: secret{"A": "1", "B": "1", "C": "1"},
+			ours:  secret{"A": "1"},
+			want:  secret{"A": "1", "C": "1"},
+		},
+		{
+			name:  "a key deleted by the other writer is not brought back",
+			other: secret{"A": "1"},
+			ours:  secret{"A": "1", "B": "1", "C": "1"},
+			want:  secret{"A": "1", "C": "1"},
+		},
+		{
+			name:    "a concurrent delete of the edited key is reported",
+			other:   secret{"B": "1"},
+			ours:    secret{"A": "2", "B": "1"},
+			want:    secret{"B": "1"},
+			wantErr: "A deleted by another writer",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, s := newTestApp(t)
+			version := s.Put("kv", "myapp/db", base)
+			s.Put("kv", "myapp/db", tt.other)
+
+			_, err := a.putMerged("kv", "myapp/db", base, tt.ours, version)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("put: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("put: got %v, want an error containing %q", err, tt.wantErr)
+			}
+
+			stored, _ := s.Get("kv", "myapp/db")
+			if !reflect.DeepEqual(stored, tt.want) {
+				t.Fatalf("stored %v, want %v", stored, tt.want)
+			}
+		})
+	}
+}
+
+func TestRandomStoreIsRefusedReadOnly(t *testing.T) {
+	_, s := newTestApp(t)
+	global := &Options{VaultAddr: s.URL, VaultToken: s.Token, AuthMethod: "token", ReadOnly: true}
+	err := Random(global, &RandomOptions{Bytes: 16, Format: RandomBase64, Store: true, KVMount: "kv", KVPath: "myapp/key"})
+	if ExitCode(err) != ExitPermission {
+		t.Fatalf("random --store in read-only mode: got %v, want a permission error", err)
+	}
+	if _, ok := s.Get("kv", "myapp/key"); ok {
+		t.Fatal("random --store wrote in read-only mode")
+	}
+}
+
+func TestWriteTokenFileTightensMode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := os.WriteFile(tokenFilePath(), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTokenFile("hvs.new"); err != nil {
+		t.Fatalf("write token file: %v", err)
+	}
+	info, err := os.Stat(tokenFilePath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Fatalf("token file mode %o, want 600", mode)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	a, s := newTestApp(t)
+	s.Put("kv", "staging/app", map[string]interface{}{"USER": "app", "PASSWORD": "one", "DEBUG": "1"})
+	s.Put("kv", "prod/app", map[string]interface{}{"USER": "app", "PASSWORD": "two", "REPLICAS": "3"})
+	s.Put("kv", "copy/app", map[string]interface{}{"USER": "app", "PASSWORD": "one", "DEBUG": "1"})
+
+	out, err := captureOutput(t, func() error {
+		return a.Drift(&DriftOptions{LeftPath: "staging/app", RightPath: "prod/app", KVMount: "kv", TransitMount: "transit", OutputJSON: true})
+	})
+	if ExitCode(err) != ExitDrift {
+		t.Fatalf("drift between differing paths: got %v, want exit code %d", err, ExitDrift)
+	}
+	var report DriftReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("parse report %q: %v", out, err)
+	}
+	want := DriftReport{Left: "staging/app", Right: "prod/app", OnlyLeft: []string{"DEBUG"}, OnlyRight: []string{"REPLICAS"}, Changed: []string{"PASSWORD"}}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("report %+v, want %+v", report, want)
+	}
+	if strings.Contains(out, "one") || strings.Contains(out, "two") {
+		t.Fatalf("report contains a secret value:\n%s", out)
+	}
+
+	if _, err := captureOutput(t, func() error {
+		return a.Drift(&DriftOptions{LeftPath: "staging/app", RightPath: "copy/app", KVMount: "kv", TransitMount: "transit", OutputJSON: true})
+	}); err != nil {
+		t.Fatalf("drift between equal paths: %v", err)
+	}
+}
+
+func TestPlanApply(t *testing.T) {
+	a, s := newTestApp(t)
+	s.Put("kv", "myapp/db", map[string]interface{}{"USER": "app", "OLD": "gone"})
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "vlt.yaml")
+	if err := os.WriteFile(configFile, []byte("secrets:\n  - path: myapp/db\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	envFile := filepath.Join(dir, ".env")
+	writeEnv := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(envFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	planFile := filepath.Join(dir, "vlt.plan")
+	plan := func() {
+		t.Helper()
+		captureStdout(t, func() error {
+			return a.Plan(&PlanOptions{ConfigFile: configFile, EnvFile: envFile, OutFile: planFile, KVMount: "kv", TransitMount: "transit"})
+		})
+	}
+	apply := func() error {
+		_, err := captureOutput(t, func() error {
+			return a.Apply(&ApplyOptions{PlanFile: planFile})
+		})
+		return err
+	}
+
+	writeEnv("USER=admin\nNEW=added\n")
+	plan()
+	if content, _ := os.ReadFile(planFile); strings.Contains(string(content), "admin") {
+		t.Fatalf("plan file contains a new value:\n%s", content)
+	}
+	if err := apply(); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	want := map[string]interface{}{"USER": "admin", "NEW": "added"}
+	if stored, _ := s.Get("kv", "myapp/db"); !reflect.DeepEqual(stored, want) {
+		t.Fatalf("stored %v after apply, want %v", stored, want)
+	}
+
+	// An env file edited after the plan was reviewed is refused
+	writeEnv("USER=root\nNEW=added\n")
+	plan()
+	writeEnv("USER=other\nNEW=added\n")
+	if err := apply(); ExitCode(err) != ExitDrift {
+		t.Fatalf("apply with an edited env file: got %v, want exit code %d", err, ExitDrift)
+	}
+
+	// So is a secret changed since the plan, which is left alone
+	writeEnv("USER=root\nNEW=added\n")
+	plan()
+	s.Put("kv", "myapp/db", map[string]interface{}{"USER": "someone", "NEW": "added"})
+	if err := apply(); ExitCode(err) != ExitDrift {
+		t.Fatalf("apply over a changed secret: got %v, want exit code %d", err, ExitDrift)
+	}
+	if stored, _ := s.Get("kv", "myapp/db"); stored["USER"] != "someone" {
+		t.Fatalf("apply over a changed secret wrote USER=%v", stored["USER"])
+	}
+}
+
+func TestGuard(t *testing.T) {
+	_, s := newTestApp(t)
+	s.Put("kv", "myapp/db", map[string]interface{}{"PASSWORD": "correct-horse-battery", "PORT": "5432"})
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "vlt.yaml")
+	config := "secrets:\n  - path: myapp/db\noutputs:\n  api:\n    file: api/.env\n"
+	if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"clean.go":    "package main\n\nconst port = 5432\n",
+		"settings.go": "package main\n\nvar password = \"correct-horse-battery\"\n",
+		"deploy.sh":   "export VAULT_TOKEN=hvs.CAESIJlU9JMYEhOPYv4ZdGVzdGluZw\n",
+		"api/.env":    "PORT=5432\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	global := &Options{VaultAddr: s.URL, VaultToken: s.Token, AuthMethod: "token"}
+	out, err := captureOutput(t, func() error {
+		return Guard(&GuardOptions{Files: paths, ConfigFile: configFile, AppOptions: global, KVMount: "kv", TransitMount: "transit"})
+	})
+	if err == nil {
+		t.Fatal("guard found nothing")
+	}
+	for _, name := range []string{"settings.go:3", "deploy.sh:1", "api/.env"} {
+		if !strings.Contains(out, name) {
+			t.Errorf("guard did not report %s:\n%s", name, out)
+		}
+	}
+	if strings.Contains(out, "clean.go") || strings.Contains(out, "correct-horse-battery") {
+		t.Errorf("guard reported the clean file or printed the secret:\n%s", out)
+	}
+
+	clean := []string{filepath.Join(dir, "clean.go")}
+	if _, err := captureOutput(t, func() error {
+		return Guard(&GuardOptions{Files: clean, ConfigFile: configFile, AppOptions: global, KVMount: "kv", TransitMount: "transit"})
+	}); err != nil {
+		t.Fatalf("guard on a clean file: %v", err)
+	}
+}
+
+// fakeAge puts an age on PATH that "encrypts" by copying stdin, so snapshot
+// tests do not need the real binary
+func fakeAge(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake age is a shell script")
+	}
+	dir := t.TempDir()
+	script := "#!/bin/sh\ncase \"$1\" in\n--encrypt) cat ;;\n--decrypt) for last; do :; done; cat \"$last\" ;;\nesac\n"
+	if err := os.WriteFile(filepath.Join(dir, "age"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	fakeAge(t)
+	a, s := newTestApp(t)
+	if err := a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/db", TransitMount: "transit", EncryptionKey: "app", Key: "PASSWORD", value: []byte("s3cret")}); err != nil {
+		t.Fatalf("put PASSWORD: %v", err)
+	}
+	if err := a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/db", TransitMount: "transit", Key: "USER", value: []byte("app")}); err != nil {
+		t.Fatalf("put USER: %v", err)
+	}
+	if err := a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/cert", TransitMount: "transit", EncryptionKey: "app", value: []byte("-----BEGIN CERTIFICATE-----")}); err != nil {
+		t.Fatalf("put cert: %v", err)
+	}
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "vlt.yaml")
+	config := "transit:\n  key: app\nsecrets:\n  - path: myapp/db\n  - path: myapp/cert\n    env_var: CERT\n"
+	if err := os.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	snapshotFile := filepath.Join(dir, "snapshot.age")
+	if err := a.Snapshot(&SnapshotOptions{ConfigFile: configFile, KVMount: "kv", TransitMount: "transit", Recipients: []string{"age1test"}, OutputFile: snapshotFile}); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	if info, err := os.Stat(snapshotFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("snapshot file: %v, %v", info, err)
+	}
+
+	// Restoring into another mount merges with what is already there
+	s.Put("restored", "myapp/db", map[string]interface{}{"EXTRA": "kept", "USER": "old"})
+	if err := a.RestoreSnapshot(&RestoreOptions{SnapshotFile: snapshotFile, KVMount: "restored", TransitMount: "transit", EncryptionKey: "app"}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	for key, want := range map[string]string{"PASSWORD": "s3cret", "USER": "app", "EXTRA": "kept"} {
+		out := captureStdout(t, func() error {
+			return a.Get(&GetOptions{KVMount: "restored", KVPath: "myapp/db", TransitMount: "transit", EncryptionKey: "app", Key: key})
+		})
+		if out != want {
+			t.Errorf("restored %s is %q, want %q", key, out, want)
+		}
+	}
+
+	// The single-value secret is restored whole, in put's layout
+	cert, _ := s.Get("restored", "myapp/cert")
+	if _, ok := cert["ciphertext"]; !ok || len(utils.StripMeta(cert)) != 1 {
+		t.Fatalf("restored myapp/cert is %v, want a single ciphertext", cert)
+	}
+	out := captureStdout(t, func() error {
+		return a.Get(&GetOptions{KVMount: "restored", KVPath: "myapp/cert", TransitMount: "transit", EncryptionKey: "app"})
+	})
+	if out != "-----BEGIN CERTIFICATE-----" {
+		t.Fatalf("restored myapp/cert is %q", out)
+	}
+}
+
+func TestLockFilesSharesLockAcrossSpellings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs symlinks")
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "out"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "out"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := lockFiles(filepath.Join(dir, "out", ".env"))
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	acquired := make(chan error)
+	go func() {
+		unlock, err := lockFiles(filepath.Join(dir, "link", ".env"), filepath.Join(dir, "other.env"))
+		if err == nil {
+			unlock()
+		}
+		acquired <- err
+	}()
+
+	select {
+	case err := <-acquired:
+		t.Fatalf("second lock taken while the first was held: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Fatalf("second lock: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second lock not taken after the first was released")
+	}
+}
+
+func TestWriteFileAtomicFollowsSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs symlinks")
+	}
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.env")
+	link := filepath.Join(dir, ".env")
+	if err := os.WriteFile(target, []byte("OLD=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(link, []byte("NEW=1\n"), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s is no longer a symlink: %v", link, err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "NEW=1\n" {
+		t.Fatalf("target holds %q, want the new content", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 2 {
+		t.Fatalf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestFileChunks(t *testing.T) {
+	_, s := newTestApp(t)
+	// A low size limit splits a small file into several chunks and parts
+	defaults := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "vault-env", "config.yaml")
+	if err := os.MkdirAll(filepath.Dir(defaults), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(defaults, []byte("max_secret_size: 16384\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(&Options{VaultAddr: s.URL, VaultToken: s.Token, AuthMethod: "token"})
+	if err != nil {
+		t.Fatalf("new app: %v", err)
+	}
+
+	dir := t.TempDir()
+	content := make([]byte, 40000)
+	rng := rand.NewChaCha8([32]byte{})
+	rng.Read(content)
+	keystore := filepath.Join(dir, "keystore.p12")
+	if err := os.WriteFile(keystore, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := captureOutput(t, func() error {
+		return a.Put(&PutOptions{KVMount: "kv", KVPath: "myapp/keystore", TransitMount: "transit", EncryptionKey: "app", FromFile: keystore})
+	}); err != nil {
+		t.Fatalf("put --from-file: %v", err)
+	}
+	if _, ok := s.Get("kv", utils.ShardPath("myapp/keystore", 2)); !ok {
+		t.Fatal("the file was not split across parts")
+	}
+
+	out, err := captureOutput(t, func() error {
+		return a.Get(&GetOptions{KVMount: "kv", KVPath: "myapp/keystore", TransitMount: "transit", EncryptionKey: "app", Metadata: true, OutputJSON: true})
+	})
+	if err != nil {
+		t.Fatalf("get --metadata: %v", err)
+	}
+	var meta map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &meta); err != nil {
+		t.Fatalf("parse metadata %q: %v", out, err)
+	}
+	sum := sha256.Sum256(content)
+	if meta["name"] != "keystore.p12" || meta["size"] != float64(len(content)) || meta["sha256"] != hex.EncodeToString(sum[:]) || meta["content_type"] != "application/x-pkcs12" {
+		t.Fatalf("metadata %v does not describe the file", meta)
+	}
+
+	restored := filepath.Join(dir, "restored.p12")
+	if _, err := captureOutput(t, func() error {
+		return a.GetFile(&GetOptions{KVMount: "kv", KVPath: "myapp/keystore", TransitMount: "transit", EncryptionKey: "app", OutFile: restored})
+	}); err != nil {
+		t.Fatalf("get --out-file: %v", err)
+	}
+	if got, _ := os.ReadFile(restored); !bytes.Equal(got, content) {
+		t.Fatal("the file read back differs from the one stored")
+	}
+
+	// A chunk changed in Vault fails the checksum and writes nothing
+	part, _ := s.Get("kv", utils.ShardPath("myapp/keystore", 1))
+	for _, key := range utils.ChunkKeys(part) {
+		part[key] = s.Encrypt("transit", "app", []byte(base64.StdEncoding.EncodeToString(make([]byte, 10))))
+		break
+	}
+	s.Put("kv", utils.ShardPath("myapp/keystore", 1), part)
+	tampered := filepath.Join(dir, "tampered.p12")
+	if _, err := captureOutput(t, func() error {
+		return a.GetFile(&GetOptions{KVMount: "kv", KVPath: "myapp/keystore", TransitMount: "transit", EncryptionKey: "app", OutFile: tampered})
+	}); err == nil {
+		t.Fatal("get --out-file accepted a changed chunk")
+	}
+	if _, err := os.Stat(tampered); !os.IsNotExist(err) {
+		t.Fatalf("get --out-file wrote %s for a changed chunk", tampered)
+	}
+}
+
+func TestTransforms(t *testing.T) {
+	a, s := newTestApp(t)
+	wrapped := base64.StdEncoding.EncodeToString([]byte(`{"db": {"password": "  s3cret\n"}, "hosts": ["a", "b"]}`))
+	s.Put("kv", "legacy/db", map[string]interface{}{"credentials": wrapped})
+	s.Put("kv", "legacy/license", map[string]interface{}{"LICENSE": "abc"})
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "vlt.yaml")
+	yaml := `secrets:
+  - path: legacy/db
+    key: credentials
+    env_key: DB_PASSWORD
+    transform:
+      - base64-decode
+      - json-extract: .db.password
+      - trim
+  - path: legacy/db
+    key: credentials
+    env_key: DB_HOST
+    transform: [base64-decode, "json-extract: .hosts.1"]
+`
+	if runtime.GOOS != "windows" {
+		yaml += `  - path: legacy/license
+    transform: ["exec: tr a-z A-Z; printf %s \"-$VLT_VAR\""]
+`
+	}
+	if err := os.WriteFile(configFile, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, ".env")
+	if err := a.GenerateEnvFile(&SyncOptions{ConfigFile: configFile, OutputFile: output, KVMount: "kv", TransitMount: "transit"}); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{"DB_PASSWORD=s3cret", "DB_HOST=b"}
+	if runtime.GOOS != "windows" {
+		lines = append(lines, "LICENSE=ABC-LICENSE")
+	}
+	for _, line := range lines {
+		if !strings.Contains(string(content), line) {
+			t.Errorf("output lacks %s:\n%s", line, content)
+		}
+	}
+
+	// A step that fails fails the sync
+	bad := "secrets:\n  - path: legacy/license\n    transform:\n      - json-extract: .key\n"
+	if err := os.WriteFile(configFile, []byte(bad), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.GenerateEnvFile(&SyncOptions{ConfigFile: configFile, OutputFile: output, KVMount: "kv", TransitMount: "transit"}); err == nil {
+		t.Fatal("sync with a failing transform succeeded")
+	}
+
+	// exec is refused in a config that did not come from this machine
+	if _, err := parseTransforms(&config.SecretEntry{Transform: []string{"exec: cat"}, Source: "vault://kv/shared/vlt.yaml"}); err == nil {
+		t.Fatal("exec allowed in a remote config")
+	}
+}
@@ -0,0 +1,570 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// planFormatVersion is the version of the plan file format
+const planFormatVersion = 1
+
+// PlanOptions contains options for the Plan operation
+type PlanOptions struct {
+	ConfigFile    string
+	EnvFile       string // desired values, in the form sync writes them
+	OutFile       string // write the plan here (print only when empty)
+	EncryptionKey string
+	KVMount       string
+	TransitMount  string
+	Namespace     string   // overrides the client namespace for this command
+	Strict        bool     // treat skipped entries and config warnings as errors
+	OnlyTags      []string // keep only entries with one of these tags
+	SkipTags      []string // drop entries with any of these tags
+}
+
+// ApplyOptions contains options for the Apply operation
+type ApplyOptions struct {
+	PlanFile string
+	EnvFile  string // overrides the env file recorded in the plan
+}
+
+// PlanFile is a reviewable change set written by Plan and executed by Apply.
+// It records salted SHA-256 digests of the new values, never the values, and
+// the KV version of every secret so Apply can refuse to write over changes
+// made since the plan.
+type PlanFile struct {
+	Version       int        `json:"version"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+	Config        string     `json:"config"`
+	EnvFile       string     `json:"env_file"`
+	EncryptionKey string     `json:"encryption_key,omitempty"` // transit key new values are encrypted with
+	Salt          string     `json:"salt"`
+	Secrets       []PlanPath `json:"secrets"`
+	Unmapped      []string   `json:"unmapped,omitempty"` // env vars no config entry maps to a key
+}
+
+// PlanPath is the change set for one KV secret
+type PlanPath struct {
+	Namespace    string       `json:"namespace,omitempty"`
+	Mount        string       `json:"mount"`
+	Path         string       `json:"path"`
+	TransitMount string       `json:"transit_mount,omitempty"`
+	EncryptKeys  []string     `json:"encrypt_keys,omitempty"` // keys to encrypt; every key when empty
+	KVVersion    int          `json:"kv_version"`             // current version when planned; 0 if the secret does not exist
+	Changes      []PlanChange `json:"changes"`
+}
+
+// PlanChange is a planned write, deletion, or re-encryption of one key
+type PlanChange struct {
+	Key    string `json:"key"`
+	Action string `json:"action"`            // create, update, delete, or reencrypt
+	EnvVar string `json:"env_var,omitempty"` // variable of the env file holding the new value
+	Digest string `json:"sha256,omitempty"`  // salted SHA-256 of the new value
+}
+
+// planTarget collects the keys the config maps for one KV secret
+type planTarget struct {
+	app          *App
+	mount        string
+	path         string
+	transitMount string
+	keys         map[string]string     // KV key -> env var
+	allKeys      []*config.SecretEntry // entries loading every key; make the env file authoritative
+	encryptKeys  []string              // encrypt_keys of the entries; every key is encrypted when empty
+
+	// Set by load
+	version       int
+	stored        map[string]interface{} // data as stored in Vault
+	current       map[string]string      // decrypted values
+	authoritative map[string]bool        // keys deleted when missing from the env file
+}
+
+// Plan compares an env file with the secrets a config maps it from and
+// prints, and optionally saves, the writes, deletions, and re-encryptions
+// that would make Vault match the file. It works like sync in reverse:
+// entries with a key plan that key, and entries loading a whole path plan
+// every key of the path, deleting keys missing from the file.
+func (a *App) Plan(opts *PlanOptions) error {
+	cfg, err := a.LoadConfig(opts.ConfigFile, opts.Strict)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+
+	env, err := godotenv.Read(opts.EnvFile)
+	if err != nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("read env file: %w", err))
+	}
+
+	a = a.withNamespace(opts.Namespace)
+	encryptionKey := config.NonEmpty(a.effectiveEncryptionKey(opts.EncryptionKey), cfg.GetTransitKey())
+
+	targets, err := a.planTargets(cfg, opts)
+	if err != nil {
+		return err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+	plan := &PlanFile{
+		Version:       planFormatVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Config:        opts.ConfigFile,
+		EnvFile:       opts.EnvFile,
+		EncryptionKey: encryptionKey,
+		Salt:          hex.EncodeToString(salt),
+	}
+
+	for _, target := range targets {
+		if err := target.load(cfg, encryptionKey); err != nil {
+			return fmt.Errorf("plan %s/%s: %w", target.mount, target.path, err)
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, target := range targets {
+		for _, envVar := range target.keys {
+			used[envVar] = true
+		}
+	}
+	assignUnmapped(cfg, targets, env, used)
+	for name := range env {
+		if !used[name] {
+			plan.Unmapped = append(plan.Unmapped, name)
+		}
+	}
+	sort.Strings(plan.Unmapped)
+
+	keyVersions := make(map[string]int)
+	for _, target := range targets {
+		if secret := target.plan(env, encryptionKey, plan.Salt, keyVersions); len(secret.Changes) > 0 {
+			plan.Secrets = append(plan.Secrets, *secret)
+		}
+	}
+
+	for _, name := range plan.Unmapped {
+		warnf("%s is not mapped to a Vault key by the config and is not planned\n", name)
+	}
+
+	if len(plan.Secrets) == 0 {
+		infof("No changes: Vault matches %s\n", opts.EnvFile)
+		return nil
+	}
+
+	var all []keyChange
+	for _, secret := range plan.Secrets {
+		changes := make([]keyChange, len(secret.Changes))
+		for i, change := range secret.Changes {
+			changes[i] = keyChange{change.Key, change.Action}
+		}
+		all = append(all, changes...)
+		printPlanTable(fmt.Sprintf("%s/%s (version %d)", secret.Mount, secret.Path, secret.KVVersion), changes, nil)
+	}
+
+	if opts.OutFile == "" {
+		fmt.Printf("%s; run with --out to save the plan\n", planSummary(all))
+		return nil
+	}
+	if err := writePlanFile(opts.OutFile, plan); err != nil {
+		return err
+	}
+	fmt.Printf("%s; saved to %s (run 'vlt apply %s' after review)\n", planSummary(all), opts.OutFile, opts.OutFile)
+	return nil
+}
+
+// planTargets groups the config entries that map env vars to KV keys by
+// the secret they read
+func (a *App) planTargets(cfg *config.Config, opts *PlanOptions) ([]*planTarget, error) {
+	byID := make(map[string]*planTarget)
+	var targets []*planTarget
+
+	for i := range cfg.Secrets {
+		secret := &cfg.Secrets[i]
+		var path string
+		switch {
+		case secret.IsLiteral() || secret.IsTemplate():
+			continue
+		case len(secret.Vault) > 0, secret.Auth != "", secret.IsPathGlob(), !secret.IsPathBased(), len(secret.Transform) > 0:
+			if cfg.Strict {
+				return nil, WithExitCode(ExitUsage, fmt.Errorf("%s cannot be planned", secret.Describe()))
+			}
+			warnf("%s is not planned: only path entries on the default Vault server and login, without transform, are supported\n", secret.Describe())
+			continue
+		default:
+			path = strings.Trim(secret.Path, "/")
+		}
+
+		scoped := a.withNamespace(secret.Namespace)
+		mount := cfg.GetKVMountFor(secret, opts.KVMount)
+		id := scoped.vaultClient.Namespace() + "\x00" + mount + "\x00" + path
+		target, ok := byID[id]
+		if !ok {
+			target = &planTarget{
+				app:          scoped,
+				mount:        mount,
+				path:         path,
+				transitMount: cfg.GetTransitMountFor(secret, opts.TransitMount),
+				keys:         make(map[string]string),
+			}
+			byID[id] = target
+			targets = append(targets, target)
+		}
+
+		target.encryptKeys = append(target.encryptKeys, secret.EncryptKeys...)
+		if secret.IsPathAllKeys() {
+			target.allKeys = append(target.allKeys, secret)
+			continue
+		}
+		envName := secret.EnvKey
+		if envName == "" {
+			var err error
+			if envName, err = cfg.EnvNameFor(secret, secret.Key); err != nil {
+				return nil, WithExitCode(ExitUsage, err)
+			}
+		}
+		target.keys[secret.Key] = envName
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].mount != targets[j].mount {
+			return targets[i].mount < targets[j].mount
+		}
+		return targets[i].path < targets[j].path
+	})
+	return targets, nil
+}
+
+// assignUnmapped gives env vars no entry maps to the only secret loaded as a
+// whole, when the name is what that entry would derive from some key, so new
+// keys can be added to it. With several such secrets the target is ambiguous
+// and the variables stay unmapped.
+func assignUnmapped(cfg *config.Config, targets []*planTarget, env map[string]string, used map[string]bool) {
+	var whole []*planTarget
+	for _, target := range targets {
+		if len(target.allKeys) > 0 {
+			whole = append(whole, target)
+		}
+	}
+	if len(whole) != 1 {
+		return
+	}
+	target := whole[0]
+	entry := target.allKeys[0]
+	prefix := entry.NamingPolicy.Merge(cfg.NamingPolicy).Prefix
+
+	for name := range env {
+		if used[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(name, prefix)
+		if envName, err := cfg.EnvNameFor(entry, key); err != nil || envName != name {
+			continue
+		}
+		if _, taken := target.keys[key]; taken {
+			continue
+		}
+		target.keys[key] = name
+		used[name] = true
+	}
+}
+
+// load reads the secret and maps the keys of whole-path entries by the
+// env var names they would get
+func (t *planTarget) load(cfg *config.Config, encryptionKey string) error {
+	client := t.app.vaultClient
+
+	meta, err := client.KVGetMetadata(t.mount, t.path)
+	switch {
+	case err == nil:
+		t.version = meta.CurrentVersion
+	case !vault.IsNotFound(err):
+		return err
+	}
+
+	t.stored, err = client.KVGet(t.mount, t.path)
+	if err != nil && !vault.IsNotFound(err) {
+		return err
+	}
+	if utils.IsEncryptedSingleValue(t.stored) || utils.IsPlaintextSingleValue(t.stored) {
+		return WithExitCode(ExitUsage, errors.New("holds a single value; plan supports multi-key secrets only"))
+	}
+
+	t.current = make(map[string]string, len(t.stored))
+	for key, value := range utils.StripMeta(t.stored) {
+		t.current[key] = fmt.Sprint(value)
+	}
+	if utils.IsEncryptedMultiValue(t.stored) {
+		if encryptionKey == "" {
+			return WithExitCode(ExitUsage, errors.New("encryption key required for encrypted secrets"))
+		}
+		decrypted, err := utils.DecryptMultiValueData(t.stored, client, t.transitMount, encryptionKey)
+		if err != nil {
+			return err
+		}
+		for key, value := range decrypted {
+			t.current[key] = fmt.Sprint(value)
+		}
+	}
+
+	t.authoritative = make(map[string]bool)
+	for key := range utils.StripMeta(t.stored) {
+		if _, ok := t.keys[key]; ok {
+			continue
+		}
+		for _, entry := range t.allKeys {
+			envName, err := cfg.EnvNameFor(entry, key)
+			if err != nil {
+				return WithExitCode(ExitUsage, err)
+			}
+			t.keys[key] = envName
+			t.authoritative[key] = true
+		}
+	}
+	return nil
+}
+
+// plan compares the loaded secret with the env file. keyVersions caches the
+// latest version of transit keys across secrets.
+func (t *planTarget) plan(env map[string]string, encryptionKey, salt string, keyVersions map[string]int) *PlanPath {
+	client := t.app.vaultClient
+	secret := &PlanPath{
+		Namespace:    client.Namespace(),
+		Mount:        t.mount,
+		Path:         t.path,
+		TransitMount: t.transitMount,
+		EncryptKeys:  t.encryptKeys,
+		KVVersion:    t.version,
+	}
+
+	latest := 0
+	if encryptionKey != "" {
+		id := t.transitMount + "/" + encryptionKey
+		if v, ok := keyVersions[id]; ok {
+			latest = v
+		} else if v, err := client.TransitKeyVersion(t.transitMount, encryptionKey); err == nil {
+			latest = v
+		} else {
+			warnf("cannot read transit key %s (%v); rotated keys are not re-encrypted\n", id, err)
+		}
+		keyVersions[id] = latest
+	}
+
+	for key, envVar := range t.keys {
+		want, inFile := env[envVar]
+		have, exists := t.current[key]
+
+		change := PlanChange{Key: key, EnvVar: envVar}
+		switch {
+		case !inFile && exists && t.authoritative[key]:
+			change = PlanChange{Key: key, Action: planDelete}
+		case !inFile:
+			continue
+		case !exists:
+			change.Action = planCreate
+		case have != want:
+			change.Action = planUpdate
+		case encryptionKey != "" && utils.EncryptsKey(t.encryptKeys, key) && needsReencrypt(t.stored, key, latest):
+			change = PlanChange{Key: key, Action: planReencrypt}
+		case encryptionKey != "" && !utils.EncryptsKey(t.encryptKeys, key) && utils.IsEncryptedValue(t.stored, key):
+			// Outside encrypt_keys: rewritten as plaintext
+			change = PlanChange{Key: key, Action: planReencrypt}
+		default:
+			continue
+		}
+		if change.EnvVar != "" {
+			change.Digest = planDigest(salt, key, want)
+		}
+		secret.Changes = append(secret.Changes, change)
+	}
+	sort.Slice(secret.Changes, func(i, j int) bool {
+		return secret.Changes[i].Key < secret.Changes[j].Key
+	})
+	return secret
+}
+
+// needsReencrypt reports whether the stored value of key is plaintext, or
+// ciphertext from a transit key version older than latest
+func needsReencrypt(stored map[string]interface{}, key string, latest int) bool {
+	if !utils.IsEncryptedValue(stored, key) {
+		return true
+	}
+	version, _, _ := strings.Cut(strings.TrimPrefix(fmt.Sprint(stored[key]), "vault:v"), ":")
+	n, err := strconv.Atoi(version)
+	return err == nil && n < latest
+}
+
+// planDigest hashes a new value with the plan's salt and the key it is
+// written to
+func planDigest(salt, key, value string) string {
+	return sha256Hex([]byte(salt + "\x00" + key + "\x00" + value))
+}
+
+// writePlanFile writes plan to path, readable by the user only
+func writePlanFile(path string, plan *PlanFile) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal plan: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create plan directory: %w", err)
+		}
+	}
+	// The plan lists every key it touches with a salted digest of its value;
+	// keep it as private as a snapshot, replacing any looser existing file
+	if err := writeFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write plan: %w", err)
+	}
+	return nil
+}
+
+// readPlanFile loads a plan written by Plan
+func readPlanFile(path string) (*PlanFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read plan: %w", err)
+	}
+
+	var plan PlanFile
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("parse plan %s: %w", path, err))
+	}
+	if plan.Version != planFormatVersion {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("unsupported plan version %d", plan.Version))
+	}
+	return &plan, nil
+}
+
+// Apply executes a plan written by Plan. Every secret must still be at the
+// version it was planned against and every new value must match its digest,
+// so exactly the reviewed changes are made; nothing is written otherwise.
+// Writes use check-and-set, so a change racing the apply is not overwritten.
+func (a *App) Apply(opts *ApplyOptions) error {
+	if err := a.requireWritable("apply"); err != nil {
+		return err
+	}
+	plan, err := readPlanFile(opts.PlanFile)
+	if err != nil {
+		return err
+	}
+
+	envFile := config.NonEmpty(opts.EnvFile, plan.EnvFile)
+	env, err := godotenv.Read(envFile)
+	if err != nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("read env file: %w", err))
+	}
+
+	// Check the whole plan before writing anything
+	for _, secret := range plan.Secrets {
+		target := fmt.Sprintf("%s/%s", secret.Mount, secret.Path)
+		version := 0
+		meta, err := a.withNamespace(secret.Namespace).vaultClient.KVGetMetadata(secret.Mount, secret.Path)
+		switch {
+		case err == nil:
+			version = meta.CurrentVersion
+		case !vault.IsNotFound(err):
+			return fmt.Errorf("%s: %w", target, err)
+		}
+		if version != secret.KVVersion {
+			return WithExitCode(ExitDrift, fmt.Errorf("%s changed since the plan was made (version %d, planned against %d); run plan again", target, version, secret.KVVersion))
+		}
+
+		for _, change := range secret.Changes {
+			if change.EnvVar == "" {
+				continue
+			}
+			value, ok := env[change.EnvVar]
+			if !ok {
+				return WithExitCode(ExitDrift, fmt.Errorf("%s: %s is missing from %s", target, change.EnvVar, envFile))
+			}
+			if planDigest(plan.Salt, change.Key, value) != change.Digest {
+				return WithExitCode(ExitDrift, fmt.Errorf("%s: %s in %s differs from the planned value for %s", target, change.EnvVar, envFile, change.Key))
+			}
+		}
+	}
+
+	for i, secret := range plan.Secrets {
+		if err := a.applySecret(plan, &secret, env); err != nil {
+			err = fmt.Errorf("%s/%s: %w", secret.Mount, secret.Path, err)
+			if i > 0 {
+				err = fmt.Errorf("%w (%d of %d secret(s) were applied)", err, i, len(plan.Secrets))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// applySecret makes the planned changes to one secret
+func (a *App) applySecret(plan *PlanFile, secret *PlanPath, env map[string]string) error {
+	client := a.withNamespace(secret.Namespace).vaultClient
+
+	data, err := client.KVGet(secret.Mount, secret.Path)
+	switch {
+	case vault.IsNotFound(err):
+		data = make(map[string]interface{})
+	case err != nil:
+		return err
+	}
+
+	encrypted := utils.EncryptedKeys(data)
+	for _, change := range secret.Changes {
+		var plaintext string
+		switch change.Action {
+		case planDelete:
+			delete(data, change.Key)
+			continue
+		case planCreate, planUpdate:
+			plaintext = env[change.EnvVar]
+		case planReencrypt:
+			plaintext = fmt.Sprint(data[change.Key])
+			if utils.IsEncryptedValue(data, change.Key) {
+				decrypted, err := client.TransitDecrypt(secret.TransitMount, plan.EncryptionKey, plaintext)
+				if err != nil {
+					return fmt.Errorf("transit decrypt %s: %w", change.Key, err)
+				}
+				plaintext = string(decrypted)
+			}
+		default:
+			return WithExitCode(ExitUsage, fmt.Errorf("unknown action %q for %s", change.Action, change.Key))
+		}
+
+		if plan.EncryptionKey == "" || !utils.EncryptsKey(secret.EncryptKeys, change.Key) {
+			data[change.Key] = plaintext
+			delete(encrypted, change.Key)
+			continue
+		}
+		ciphertext, err := client.TransitEncrypt(secret.TransitMount, plan.EncryptionKey, []byte(plaintext))
+		if err != nil {
+			return fmt.Errorf("transit encrypt %s: %w", change.Key, err)
+		}
+		data[change.Key] = ciphertext
+		encrypted[change.Key] = utils.NewEncryptedValue(plan.EncryptionKey, ciphertext)
+	}
+	utils.SetMeta(data, encrypted)
+
+	if err := client.KVPutCAS(secret.Mount, secret.Path, data, secret.KVVersion); err != nil {
+		if errors.Is(err, vault.ErrCASMismatch) {
+			return WithExitCode(ExitDrift, fmt.Errorf("changed while applying; run plan again: %w", err))
+		}
+		return err
+	}
+	infof("Applied %d change(s) to %s/%s\n", len(secret.Changes), secret.Mount, secret.Path)
+	return nil
+}
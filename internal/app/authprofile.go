@@ -0,0 +1,71 @@
+package app
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// loginCache holds the clients logged in with the config's auth profiles, so
+// each profile logs in once however many entries use it. A failed login is
+// remembered too, and reported for every entry of the profile.
+type loginCache struct {
+	mu     sync.Mutex
+	logins map[string]*profileLogin
+}
+
+type profileLogin struct {
+	client *vault.Client
+	err    error
+}
+
+func newLoginCache() *loginCache {
+	return &loginCache{logins: make(map[string]*profileLogin)}
+}
+
+// withAuthProfile returns an app whose Vault client is logged in with the
+// named auth profile of cfg, in the namespace of the profile or else of the
+// current client
+func (a *App) withAuthProfile(cfg *config.Config, name string) (*App, error) {
+	profile, ok := cfg.Auth[name]
+	if !ok {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("auth profile %q is not defined in the config's auth section", name))
+	}
+	if err := profile.ApplyTo(&config.VaultConfig{}); err != nil {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("auth profile %s: %w", name, err))
+	}
+
+	login := func() (*vault.Client, error) {
+		client, err := a.vaultClient.WithAuth(&profile)
+		if err != nil {
+			return nil, fmt.Errorf("auth profile %s: %w", name, err)
+		}
+		return client, nil
+	}
+
+	var client *vault.Client
+	var err error
+	if a.logins == nil {
+		client, err = login()
+	} else {
+		key := name + "\x00" + a.vaultClient.Namespace()
+		a.logins.mu.Lock()
+		cached, ok := a.logins.logins[key]
+		if !ok {
+			cached = &profileLogin{}
+			cached.client, cached.err = login()
+			a.logins.logins[key] = cached
+		}
+		a.logins.mu.Unlock()
+		client, err = cached.client, cached.err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	scoped := *a
+	scoped.vaultClient = client
+	return &scoped, nil
+}
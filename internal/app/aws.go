@@ -0,0 +1,324 @@
+package app
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// AWS services supported by ImportAWS and ExportAWS
+const (
+	AWSSecretsManager = "aws-sm"
+	AWSParameterStore = "aws-ssm"
+)
+
+// AWSOptions contains options for the ImportAWS and ExportAWS operations
+type AWSOptions struct {
+	Service       string // AWSSecretsManager or AWSParameterStore
+	Prefix        string // secret name prefix (aws-sm) or parameter path (aws-ssm)
+	Region        string
+	KVMount       string
+	KVPath        string
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string // overrides the client namespace for this command
+	DryRun        bool   // import: print the keys that would be written without writing
+
+	// OverridePolicy imports values that violate the value policy anyway; the
+	// reason is recorded in the audit log
+	OverridePolicy string
+}
+
+// ImportAWS copies secrets under a prefix in AWS Secrets Manager or SSM
+// Parameter Store into one KV path. JSON-valued Secrets Manager secrets
+// contribute each of their fields as a key.
+func (a *App) ImportAWS(opts *AWSOptions) error {
+	if !opts.DryRun {
+		if err := a.requireWritable("import"); err != nil {
+			return err
+		}
+	}
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+
+	client, err := utils.NewAWSClient(opts.Region)
+	if err != nil {
+		return WithExitCode(ExitUsage, err)
+	}
+
+	var values map[string]string
+	switch opts.Service {
+	case AWSSecretsManager:
+		values, err = importSecretsManager(client, opts.Prefix)
+	case AWSParameterStore:
+		values, err = importParameterStore(client, opts.Prefix)
+	default:
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported AWS service %q (expected %s or %s)", opts.Service, AWSSecretsManager, AWSParameterStore))
+	}
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return WithExitCode(ExitNotFound, fmt.Errorf("no %s secrets found under %s", opts.Service, opts.Prefix))
+	}
+	if err := a.enforceValuePolicy(opts.KVPath, values, opts.OverridePolicy); err != nil {
+		return err
+	}
+
+	encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+	if opts.DryRun {
+		existing, finalData, _, err := a.mergedValues(opts.KVMount, opts.KVPath, opts.TransitMount, encryptionKey, values)
+		if err != nil {
+			return err
+		}
+		target := fmt.Sprintf("%s/%s (from %s %s)", opts.KVMount, opts.KVPath, opts.Service, opts.Prefix)
+		printKeyPlan(target, planKeys(existing, finalData), finalData)
+		return nil
+	}
+
+	count, err := a.mergeValues(opts.KVMount, opts.KVPath, opts.TransitMount, encryptionKey, values)
+	if err != nil {
+		return err
+	}
+
+	infof("Imported %d key(s) from %s %s into %s/%s\n", len(values), opts.Service, opts.Prefix, opts.KVMount, opts.KVPath)
+	if count != len(values) {
+		infof("%s/%s now holds %d key(s)\n", opts.KVMount, opts.KVPath, count)
+	}
+	return nil
+}
+
+// ExportAWS writes the keys of a KV path to AWS: as one JSON secret named
+// Prefix in Secrets Manager, or as SecureString parameters under Prefix in SSM
+func (a *App) ExportAWS(opts *AWSOptions) error {
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+
+	client, err := utils.NewAWSClient(opts.Region)
+	if err != nil {
+		return WithExitCode(ExitUsage, err)
+	}
+
+	values, err := a.readValues(opts.KVMount, opts.KVPath, opts.TransitMount, a.effectiveEncryptionKey(opts.EncryptionKey))
+	if err != nil {
+		return err
+	}
+
+	switch opts.Service {
+	case AWSSecretsManager:
+		err = exportSecretsManager(client, opts.Prefix, values)
+	case AWSParameterStore:
+		err = exportParameterStore(client, opts.Prefix, values)
+	default:
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported AWS service %q (expected %s or %s)", opts.Service, AWSSecretsManager, AWSParameterStore))
+	}
+	if err != nil {
+		return err
+	}
+
+	infof("Exported %d key(s) from %s/%s to %s %s\n", len(values), opts.KVMount, opts.KVPath, opts.Service, opts.Prefix)
+	return nil
+}
+
+// mergeValues encrypts values if a key is set and merges them into the
+// multi-value secret at a KV path, returning the resulting key count
+func (a *App) mergeValues(kvMount, kvPath, transitMount, encryptionKey string, values map[string]string) (int, error) {
+	existing, finalData, version, err := a.mergedValues(kvMount, kvPath, transitMount, encryptionKey, values)
+	if err != nil {
+		return 0, err
+	}
+	written, err := a.putMerged(kvMount, kvPath, existing, finalData, version)
+	if err != nil {
+		return 0, err
+	}
+	return len(utils.StripMeta(written)), nil
+}
+
+// mergedValues returns the data at a KV path, the data mergeValues would
+// replace it with, and the version it was read at. A single-value secret
+// counts as empty.
+func (a *App) mergedValues(kvMount, kvPath, transitMount, encryptionKey string, values map[string]string) (map[string]interface{}, map[string]interface{}, int, error) {
+	existing, version := a.readForWrite(kvMount, kvPath)
+	if isSingleValue(existing) {
+		existing = make(map[string]interface{})
+	}
+
+	newData, err := utils.EncodeValues(values, a.vaultClient, transitMount, encryptionKey, encryptionKey != "")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return existing, utils.MergeData(existing, newData), version, nil
+}
+
+// readValues returns the decrypted keys of a KV path. A single-value secret
+// is returned under the key "value".
+func (a *App) readValues(kvMount, kvPath, transitMount, encryptionKey string) (map[string]string, error) {
+	data, err := a.kvGet(kvMount, kvPath)
+	if err != nil {
+		return nil, fmt.Errorf("kv get: %w", err)
+	}
+
+	if ciphertext, ok := data["ciphertext"].(string); ok && utils.IsEncryptedValue(data, "ciphertext") {
+		if encryptionKey == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secret %s", kvPath))
+		}
+		plaintext, err := a.vaultClient.TransitDecrypt(transitMount, encryptionKey, ciphertext)
+		if err != nil {
+			return nil, fmt.Errorf("transit decrypt: %w", err)
+		}
+		return map[string]string{"value": string(plaintext)}, nil
+	}
+
+	if utils.IsEncryptedMultiValue(data) {
+		if encryptionKey == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("encryption key required for encrypted secrets at %s", kvPath))
+		}
+		if data, err = utils.DecryptMultiValueData(data, a.vaultClient, transitMount, encryptionKey); err != nil {
+			return nil, fmt.Errorf("decrypt secrets: %w", err)
+		}
+	}
+	data = utils.StripMeta(data)
+
+	values := make(map[string]string, len(data))
+	for k, v := range data {
+		values[k] = fmt.Sprintf("%v", v)
+	}
+	return values, nil
+}
+
+// awsKeyName derives a KV key from a secret or parameter name below prefix
+func awsKeyName(name, prefix string) string {
+	rel := strings.Trim(strings.TrimPrefix(name, prefix), "/")
+	if rel == "" {
+		rel = name[strings.LastIndex(name, "/")+1:]
+	}
+	return strings.ReplaceAll(rel, "/", "_")
+}
+
+func importSecretsManager(client *utils.AWSClient, prefix string) (map[string]string, error) {
+	type listInput struct {
+		Filters    []map[string]any `json:"Filters"`
+		MaxResults int              `json:"MaxResults"`
+		NextToken  string           `json:"NextToken,omitempty"`
+	}
+	var names []string
+	input := listInput{Filters: []map[string]any{{"Key": "name", "Values": []string{prefix}}}, MaxResults: 100}
+	for {
+		var out struct {
+			SecretList []struct{ Name string } `json:"SecretList"`
+			NextToken  string                  `json:"NextToken"`
+		}
+		if err := client.Call("secretsmanager", "secretsmanager.ListSecrets", input, &out); err != nil {
+			return nil, fmt.Errorf("list secrets: %w", err)
+		}
+		for _, s := range out.SecretList {
+			names = append(names, s.Name)
+		}
+		if out.NextToken == "" {
+			break
+		}
+		input.NextToken = out.NextToken
+	}
+	sort.Strings(names)
+
+	values := make(map[string]string)
+	for _, name := range names {
+		var out struct {
+			SecretString *string `json:"SecretString"`
+			SecretBinary []byte  `json:"SecretBinary"`
+		}
+		if err := client.Call("secretsmanager", "secretsmanager.GetSecretValue", map[string]string{"SecretId": name}, &out); err != nil {
+			return nil, fmt.Errorf("get secret %s: %w", name, err)
+		}
+
+		switch {
+		case out.SecretString != nil && strings.HasPrefix(strings.TrimSpace(*out.SecretString), "{"):
+			fields, err := utils.ParsePayload([]byte(*out.SecretString), "json")
+			if err != nil {
+				return nil, fmt.Errorf("secret %s: %w", name, err)
+			}
+			for k, v := range fields {
+				values[k] = v
+			}
+		case out.SecretString != nil:
+			values[awsKeyName(name, prefix)] = *out.SecretString
+		default:
+			values[awsKeyName(name, prefix)] = base64.StdEncoding.EncodeToString(out.SecretBinary)
+		}
+	}
+	return values, nil
+}
+
+func importParameterStore(client *utils.AWSClient, prefix string) (map[string]string, error) {
+	if !strings.HasPrefix(prefix, "/") {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("SSM parameter path must start with /: %s", prefix))
+	}
+
+	input := map[string]any{"Path": prefix, "Recursive": true, "WithDecryption": true}
+	values := make(map[string]string)
+	for {
+		var out struct {
+			Parameters []struct {
+				Name  string
+				Value string
+			} `json:"Parameters"`
+			NextToken string `json:"NextToken"`
+		}
+		if err := client.Call("ssm", "AmazonSSM.GetParametersByPath", input, &out); err != nil {
+			return nil, fmt.Errorf("get parameters: %w", err)
+		}
+		for _, p := range out.Parameters {
+			values[awsKeyName(p.Name, prefix)] = p.Value
+		}
+		if out.NextToken == "" {
+			break
+		}
+		input["NextToken"] = out.NextToken
+	}
+	return values, nil
+}
+
+func exportSecretsManager(client *utils.AWSClient, name string, values map[string]string) error {
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("marshal secret: %w", err)
+	}
+
+	err = client.Call("secretsmanager", "secretsmanager.PutSecretValue",
+		map[string]string{"SecretId": name, "SecretString": string(payload)}, nil)
+	var awsErr *utils.AWSError
+	if errors.As(err, &awsErr) && awsErr.Type == "ResourceNotFoundException" {
+		err = client.Call("secretsmanager", "secretsmanager.CreateSecret",
+			map[string]string{"Name": name, "SecretString": string(payload)}, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("write secret %s: %w", name, err)
+	}
+	return nil
+}
+
+func exportParameterStore(client *utils.AWSClient, prefix string, values map[string]string) error {
+	if !strings.HasPrefix(prefix, "/") {
+		return WithExitCode(ExitUsage, fmt.Errorf("SSM parameter path must start with /: %s", prefix))
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		name := strings.TrimSuffix(prefix, "/") + "/" + k
+		input := map[string]any{"Name": name, "Value": values[k], "Type": "SecureString", "Overwrite": true}
+		if err := client.Call("ssm", "AmazonSSM.PutParameter", input, nil); err != nil {
+			return fmt.Errorf("put parameter %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,584 @@
+package app
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/audit"
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// BrowseOptions contains options for the Browse operation
+type BrowseOptions struct {
+	KVMount       string // mount to open; empty starts at the list of KV v2 mounts
+	KVPath        string // directory or secret to open within KVMount
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string // overrides the client namespace for this command
+}
+
+// browser is the state of the interactive secret browser. It shows one of
+// three views: the KV mounts, a directory of a mount, or the keys of a secret.
+type browser struct {
+	app           *App
+	transitMount  string
+	encryptionKey string
+	restore       func() // leaves raw mode while a line is typed
+
+	mount  string // "" while choosing a mount
+	dir    string // directory being listed, without surrounding slashes
+	secret string // path of the secret being viewed; "" in a directory
+
+	items     []string          // mounts, directory entries, or secret keys
+	values    map[string]string // decrypted values of the viewed secret
+	encrypted bool              // the viewed secret is transit-encrypted
+	locked    bool              // encrypted, but no key to decrypt it with
+	single    bool              // the viewed secret stores a single value
+	reveal    bool              // show plaintext values instead of masking them
+
+	cursor int
+	offset int
+	status string
+}
+
+// Browse runs an interactive terminal browser for navigating KV mounts and
+// paths, viewing masked values, copying them, and editing or deleting keys
+func (a *App) Browse(opts *BrowseOptions) error {
+	if !utils.IsTerminal(os.Stdin) || !utils.IsTerminal(os.Stdout) {
+		return WithExitCode(ExitUsage, fmt.Errorf("browse needs an interactive terminal"))
+	}
+
+	// Without a mount from the flags or a config, browsing starts at the list of mounts
+	cfg := a.pathConfig()
+	b := &browser{
+		app:           a.withNamespace(opts.Namespace),
+		transitMount:  cfg.TransitMount(opts.TransitMount),
+		encryptionKey: a.effectiveEncryptionKey(opts.EncryptionKey),
+		mount:         strings.Trim(config.NonEmpty(opts.KVMount, cfg.KV.Mount), "/"),
+		dir:           strings.Trim(opts.KVPath, "/"),
+	}
+	if err := b.open(); err != nil {
+		return err
+	}
+
+	restore, err := utils.RawTerminal(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("browse: %w", err)
+	}
+	b.restore = restore
+	// Use the alternate screen so the shell's scrollback is left untouched
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		b.restore()
+	}()
+
+	for {
+		b.render()
+		key, err := readKeyPress(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("read key: %w", err)
+		}
+		if key == "q" || key == "ctrl-c" {
+			return nil
+		}
+		b.handle(key)
+	}
+}
+
+// open loads the view for the current mount and directory. A directory that
+// lists nothing is opened as a secret, so a secret path can be given directly.
+func (b *browser) open() error {
+	if b.mount == "" {
+		return b.loadMounts()
+	}
+	if err := b.loadDir(); err != nil {
+		return err
+	}
+	if len(b.items) == 0 && b.dir != "" {
+		name := b.dir
+		b.dir = parentDir(b.dir)
+		return b.loadSecret(name)
+	}
+	return nil
+}
+
+// loadMounts lists the KV v2 mounts the token can see
+func (b *browser) loadMounts() error {
+	mounts, err := b.app.vaultClient.Mounts()
+	if err != nil {
+		return fmt.Errorf("list mounts: %w (use --kv-mount to open a mount directly)", err)
+	}
+
+	b.items = nil
+	for _, m := range mounts {
+		if m.Type == "kv" && m.Version == "2" {
+			b.items = append(b.items, withTrailingSlash(strings.Trim(m.Path, "/")))
+		}
+	}
+	b.secret = ""
+	b.cursor, b.offset = 0, 0
+	return nil
+}
+
+// loadDir lists the current directory
+func (b *browser) loadDir() error {
+	keys, err := b.app.vaultClient.KVList(b.mount, b.dir)
+	if err != nil {
+		return fmt.Errorf("kv list %s/%s: %w", b.mount, b.dir, err)
+	}
+	sort.Strings(keys)
+
+	b.items = keys
+	b.secret = ""
+	b.cursor, b.offset = 0, 0
+	return nil
+}
+
+// loadSecret reads and decrypts a secret for viewing
+func (b *browser) loadSecret(secretPath string) error {
+	data, err := b.app.vaultClient.KVGet(b.mount, secretPath)
+	if err != nil {
+		return fmt.Errorf("kv get %s/%s: %w", b.mount, secretPath, err)
+	}
+
+	b.encrypted = utils.IsEncryptedSingleValue(data) || utils.IsEncryptedMultiValue(data)
+	b.single = utils.IsEncryptedSingleValue(data) || utils.IsPlaintextSingleValue(data)
+	b.locked = b.encrypted && b.encryptionKey == ""
+	b.values = make(map[string]string, len(data))
+
+	switch {
+	case b.locked:
+		for k := range utils.StripMeta(data) {
+			b.values[k] = "(encrypted)"
+		}
+	case utils.IsEncryptedSingleValue(data):
+		plaintext, err := b.app.vaultClient.TransitDecrypt(b.transitMount, b.encryptionKey, data["ciphertext"].(string))
+		if err != nil {
+			return fmt.Errorf("transit decrypt: %w", err)
+		}
+		b.values["value"] = string(plaintext)
+	case b.encrypted:
+		decrypted, err := utils.DecryptMultiValueData(data, b.app.vaultClient, b.transitMount, b.encryptionKey)
+		if err != nil {
+			return fmt.Errorf("decrypt multi-value data: %w", err)
+		}
+		for k, v := range decrypted {
+			b.values[k] = fmt.Sprintf("%v", v)
+		}
+	default:
+		for k, v := range utils.StripMeta(data) {
+			b.values[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	b.items = make([]string, 0, len(b.values))
+	for k := range b.values {
+		b.items = append(b.items, k)
+	}
+	sort.Strings(b.items)
+	b.secret = secretPath
+	b.cursor, b.offset = 0, 0
+	if b.locked {
+		b.status = "encrypted secret: set --encryption-key to view or edit values"
+	}
+	return nil
+}
+
+// handle applies one key press to the browser
+func (b *browser) handle(key string) {
+	b.status = ""
+	switch key {
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, max(len(b.items)-1, 0))
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = max(len(b.items)-1, 0)
+	case "enter", "right", "l":
+		b.enter()
+	case "left", "h", "backspace", "esc":
+		b.back()
+	case "r":
+		if b.secret != "" && !b.locked {
+			b.reveal = !b.reveal
+			if b.reveal {
+				audit.MarkDisplayed()
+			}
+		}
+	case "c":
+		b.copyValue()
+	case "e":
+		b.editValue(b.selected())
+	case "n":
+		b.addKey()
+	case "d":
+		b.delete()
+	}
+}
+
+// selected returns the item under the cursor, or "" in an empty view
+func (b *browser) selected() string {
+	if b.cursor < len(b.items) {
+		return b.items[b.cursor]
+	}
+	return ""
+}
+
+// enter opens the mount, directory, or secret under the cursor
+func (b *browser) enter() {
+	item := b.selected()
+	if item == "" || b.secret != "" {
+		return
+	}
+
+	mount, dir := b.mount, b.dir
+	var err error
+	switch {
+	case b.mount == "":
+		b.mount, b.dir = strings.TrimSuffix(item, "/"), ""
+		err = b.loadDir()
+	case strings.HasSuffix(item, "/"):
+		b.dir = strings.Trim(withTrailingSlash(b.dir)+item, "/")
+		err = b.loadDir()
+	default:
+		err = b.loadSecret(withTrailingSlash(b.dir) + item)
+	}
+	if err != nil {
+		// Stay in the view that was shown
+		b.mount, b.dir = mount, dir
+		b.status = err.Error()
+	}
+}
+
+// back returns to the directory or mount list above the current view, with
+// the cursor on the entry that was left
+func (b *browser) back() {
+	var from string
+	var err error
+	switch {
+	case b.secret != "":
+		from = path.Base(b.secret)
+		b.values, b.reveal = nil, false
+		err = b.loadDir()
+	case b.mount == "":
+		return
+	case b.dir == "":
+		from = b.mount + "/"
+		b.mount = ""
+		err = b.loadMounts()
+	default:
+		from = path.Base(b.dir) + "/"
+		b.dir = parentDir(b.dir)
+		err = b.loadDir()
+	}
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+	for i, item := range b.items {
+		if item == from {
+			b.cursor = i
+		}
+	}
+}
+
+// copyValue copies the value under the cursor to the clipboard
+func (b *browser) copyValue() {
+	key := b.selected()
+	if b.secret == "" || key == "" || b.locked {
+		return
+	}
+	audit.MarkCopied()
+	if err := utils.CopyToClipboard(b.values[key]); err != nil {
+		b.status = fmt.Sprintf("copy to clipboard: %v", err)
+		return
+	}
+	b.status = fmt.Sprintf("copied %s to the clipboard", key)
+}
+
+// addKey prompts for a new key and its value
+func (b *browser) addKey() {
+	if b.secret == "" || b.locked {
+		return
+	}
+	if b.single {
+		b.status = "single-value secret: edit its value instead"
+		return
+	}
+	key, err := b.prompt("New key: ", false)
+	if err != nil || key == "" {
+		return
+	}
+	b.editValue(key)
+}
+
+// editValue prompts for a new value of key and writes it to Vault, encrypted
+// like the rest of the secret
+func (b *browser) editValue(key string) {
+	if b.secret == "" || key == "" || b.locked {
+		return
+	}
+	if err := b.app.requireWritable("editing"); err != nil {
+		b.status = err.Error()
+		return
+	}
+	value, err := b.prompt(fmt.Sprintf("New value for %s (hidden): ", key), true)
+	if err != nil || value == "" {
+		b.status = "unchanged"
+		return
+	}
+
+	encryptionKey := ""
+	if b.encrypted {
+		encryptionKey = b.encryptionKey
+	}
+	if b.single {
+		err = b.app.putSingleValue(b.mount, b.secret, b.transitMount, encryptionKey, value)
+	} else {
+		_, err = b.app.mergeValues(b.mount, b.secret, b.transitMount, encryptionKey, map[string]string{key: value})
+	}
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+	b.reload(key)
+	b.status = fmt.Sprintf("saved %s", key)
+}
+
+// delete removes the key or secret under the cursor after confirmation
+func (b *browser) delete() {
+	item := b.selected()
+	if item == "" || b.mount == "" || strings.HasSuffix(item, "/") {
+		return
+	}
+	if err := b.app.requireWritable("deleting"); err != nil {
+		b.status = err.Error()
+		return
+	}
+
+	if b.secret == "" {
+		secretPath := withTrailingSlash(b.dir) + item
+		if !b.confirm(fmt.Sprintf("Delete %s/%s?", b.mount, secretPath)) {
+			return
+		}
+		if err := b.app.vaultClient.KVDelete(b.mount, secretPath); err != nil {
+			b.status = err.Error()
+			return
+		}
+		b.reload("")
+		b.status = fmt.Sprintf("deleted %s", secretPath)
+		return
+	}
+
+	if !b.confirm(fmt.Sprintf("Delete key %s of %s/%s?", item, b.mount, b.secret)) {
+		return
+	}
+	data, err := b.app.vaultClient.KVGet(b.mount, b.secret)
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+	delete(data, item)
+	utils.SetMeta(data, utils.EncryptedKeys(data))
+	if len(data) == 0 {
+		err = b.app.vaultClient.KVDelete(b.mount, b.secret)
+	} else {
+		err = b.app.vaultClient.KVPut(b.mount, b.secret, data)
+	}
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+
+	if len(data) == 0 {
+		b.back()
+	} else {
+		b.reload("")
+	}
+	b.status = fmt.Sprintf("deleted %s", item)
+}
+
+// reload reads the current view again, keeping the cursor on item if it
+// still exists and near its previous position otherwise
+func (b *browser) reload(item string) {
+	cursor, reveal := b.cursor, b.reveal
+	var err error
+	if b.secret != "" {
+		err = b.loadSecret(b.secret)
+	} else {
+		err = b.loadDir()
+	}
+	if err != nil {
+		b.status = err.Error()
+		return
+	}
+
+	b.reveal = reveal
+	b.cursor = min(cursor, max(len(b.items)-1, 0))
+	for i, it := range b.items {
+		if it == item {
+			b.cursor = i
+		}
+	}
+}
+
+// confirm asks a yes/no question on the status line
+func (b *browser) confirm(question string) bool {
+	b.status = question + " [y/N]"
+	b.render()
+	key, err := readKeyPress(os.Stdin)
+	b.status = ""
+	return err == nil && (key == "y" || key == "Y")
+}
+
+// prompt leaves raw mode and reads a line on the bottom row of the screen.
+// Hidden input is not echoed.
+func (b *browser) prompt(label string, hidden bool) (string, error) {
+	rows, _ := utils.TerminalSize(os.Stdin)
+	fmt.Printf("\x1b[%d;1H\x1b[K\x1b[?25h", rows)
+	b.restore()
+	defer func() {
+		fmt.Print("\x1b[?25l")
+		if restore, err := utils.RawTerminal(os.Stdin); err == nil {
+			b.restore = restore
+		}
+	}()
+
+	if hidden {
+		return utils.ReadSecret(label)
+	}
+	fmt.Fprint(os.Stderr, label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// render draws the current view
+func (b *browser) render() {
+	rows, cols := utils.TerminalSize(os.Stdin)
+	var out strings.Builder
+	out.WriteString("\x1b[H\x1b[2J")
+	line := func(text string) {
+		out.WriteString(truncateRunes(text, cols))
+		out.WriteString("\x1b[K\r\n")
+	}
+
+	title := "KV mounts"
+	if b.mount != "" {
+		title = b.mount + "/" + withTrailingSlash(b.dir)
+		if b.secret != "" {
+			title = b.mount + "/" + b.secret
+		}
+	}
+	if ns := b.app.vaultClient.Namespace(); ns != "" {
+		title = ns + " " + title
+	}
+	line(fmt.Sprintf("vlt browse  %s  %s", b.app.vaultClient.Address(), title))
+	line("")
+
+	// Keep the cursor inside the visible window
+	visible := max(rows-5, 1)
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	}
+	if b.cursor >= b.offset+visible {
+		b.offset = b.cursor - visible + 1
+	}
+
+	width := 0
+	for _, item := range b.items {
+		width = max(width, len(item))
+	}
+	if len(b.items) == 0 {
+		line("  (empty)")
+	}
+	for i := b.offset; i < min(len(b.items), b.offset+visible); i++ {
+		text := "  " + b.items[i]
+		if b.secret != "" {
+			value := b.values[b.items[i]]
+			if !b.reveal && !b.locked {
+				value = utils.MaskValue(value)
+			}
+			text = fmt.Sprintf("  %-*s  %s", width, b.items[i], strings.ReplaceAll(value, "\n", `\n`))
+		}
+		if i == b.cursor {
+			// Reverse video marks the selected row
+			out.WriteString("\x1b[7m" + truncateRunes(text, cols) + "\x1b[0m\x1b[K\r\n")
+			continue
+		}
+		line(text)
+	}
+
+	help := "↑/↓ move  →/enter open  ← back  d delete  q quit"
+	if b.secret != "" {
+		help = "↑/↓ move  ← back  r reveal  c copy  e edit  n new key  d delete  q quit"
+	}
+	fmt.Fprintf(&out, "\x1b[%d;1H%s\x1b[K\r\n%s\x1b[K", rows-1, truncateRunes(help, cols), truncateRunes(b.status, cols))
+	fmt.Print(out.String())
+}
+
+// readKeyPress reads one key press from a terminal in raw mode and names the
+// special keys: up, down, left, right, home, end, enter, backspace, esc, ctrl-c
+func readKeyPress(f *os.File) (string, error) {
+	buf := make([]byte, 16)
+	n, err := f.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", errors.New("no input")
+	}
+
+	switch seq := string(buf[:n]); seq {
+	case "\x1b[A", "\x1bOA":
+		return "up", nil
+	case "\x1b[B", "\x1bOB":
+		return "down", nil
+	case "\x1b[C", "\x1bOC":
+		return "right", nil
+	case "\x1b[D", "\x1bOD":
+		return "left", nil
+	case "\x1b[H", "\x1b[1~", "\x1bOH":
+		return "home", nil
+	case "\x1b[F", "\x1b[4~", "\x1bOF":
+		return "end", nil
+	case "\r", "\n":
+		return "enter", nil
+	case "\x7f", "\b":
+		return "backspace", nil
+	case "\x1b":
+		return "esc", nil
+	case "\x03":
+		return "ctrl-c", nil
+	default:
+		return seq, nil
+	}
+}
+
+// parentDir returns the directory above dir, or "" at the mount root
+func parentDir(dir string) string {
+	if i := strings.LastIndex(strings.Trim(dir, "/"), "/"); i >= 0 {
+		return dir[:i]
+	}
+	return ""
+}
+
+// truncateRunes shortens text to at most width runes
+func truncateRunes(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
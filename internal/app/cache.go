@@ -0,0 +1,76 @@
+package app
+
+import (
+	"maps"
+	"strings"
+	"sync"
+)
+
+// readCache remembers KV reads while secrets are being loaded, so config
+// entries and injections that share a path read it from Vault once.
+// Concurrent reads of the same path wait for the first instead of sending
+// their own request.
+type readCache struct {
+	mu    sync.Mutex
+	reads map[readKey]*cachedRead
+}
+
+// readKey identifies a KV secret on a particular server and namespace, as
+// read with a particular token
+type readKey struct {
+	address   string
+	namespace string
+	token     string // entries with another auth profile may not see the same data
+	mount     string
+	path      string
+}
+
+// cachedRead is the result of one KV read, available once done is closed
+type cachedRead struct {
+	done chan struct{}
+	data map[string]interface{}
+	err  error
+}
+
+// withReadCache returns an app that caches KV reads until it is discarded.
+// An app that already has a cache is returned as is, so nested loads share it.
+func (a *App) withReadCache() *App {
+	if a.reads != nil {
+		return a
+	}
+	scoped := *a
+	scoped.reads = &readCache{reads: make(map[readKey]*cachedRead)}
+	return &scoped
+}
+
+// kvGet reads a KV secret through the app's read cache, if it has one.
+// Each caller gets its own copy of the top-level map.
+func (a *App) kvGet(mount, path string) (map[string]interface{}, error) {
+	if a.reads == nil {
+		return a.readJoined(mount, path)
+	}
+
+	key := readKey{
+		address:   a.vaultClient.Address(),
+		namespace: a.vaultClient.Namespace(),
+		token:     a.vaultClient.Token(),
+		mount:     strings.Trim(mount, "/"),
+		path:      strings.Trim(path, "/"),
+	}
+
+	a.reads.mu.Lock()
+	read, ok := a.reads.reads[key]
+	if !ok {
+		read = &cachedRead{done: make(chan struct{})}
+		a.reads.reads[key] = read
+	}
+	a.reads.mu.Unlock()
+
+	if ok {
+		<-read.done
+	} else {
+		read.data, read.err = a.readJoined(mount, path)
+		close(read.done)
+	}
+	return maps.Clone(read.data), read.err
+}
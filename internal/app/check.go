@@ -0,0 +1,309 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// CheckOptions contains options for the Check operation
+type CheckOptions struct {
+	KVMount       string
+	TransitMount  string
+	EncryptionKey string
+	ConfigFile    string // also check the servers, namespaces, and mounts of every config entry
+
+	// ExpiryWindow reports credentials in the config's values expiring within
+	// it as warnings, or as failures when Strict (0 for the default)
+	ExpiryWindow time.Duration
+	Strict       bool
+}
+
+// shortTTL is the token TTL below which Check warns
+const shortTTL = 10 * time.Minute
+
+// checkTarget is a server and namespace with the mounts used on it
+type checkTarget struct {
+	app           *App
+	kvMounts      map[string]bool
+	transitMounts map[string]bool
+}
+
+// checker prints the result of each preflight check and counts failures
+type checker struct {
+	failures int
+	painter  utils.Painter
+}
+
+func (c *checker) report(status, format string, args ...any) {
+	if status == "failed" {
+		c.failures++
+	}
+	fmt.Printf("%s %s\n", c.painter.Paint(fmt.Sprintf("%-8s", status), statusStyle(status)), fmt.Sprintf(format, args...))
+}
+
+// statusStyle returns the color of a check or verify status
+func statusStyle(status string) string {
+	switch status {
+	case "ok":
+		return utils.Green
+	case "warning":
+		return utils.Yellow
+	default:
+		return utils.Red
+	}
+}
+
+// Check verifies that the Vault server is reachable, unsealed, and active,
+// that the token is valid, that the namespace exists, and that the KV and
+// transit mounts (and the transit key) commands will use exist with the
+// expected engine type, so a misconfiguration is reported up front instead
+// of as a 403 from the middle of a sync
+func (a *App) Check(opts *CheckOptions) error {
+	c := &checker{painter: utils.NewPainter(os.Stdout)}
+	encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+
+	targets := map[string]*checkTarget{}
+	var order []string
+	addTarget := func(app *App, kvMount, transitMount string) {
+		key := app.vaultClient.Address() + "\x00" + app.vaultClient.Namespace()
+		target, ok := targets[key]
+		if !ok {
+			target = &checkTarget{app: app, kvMounts: map[string]bool{}, transitMounts: map[string]bool{}}
+			targets[key] = target
+			order = append(order, key)
+		}
+		target.kvMounts[strings.Trim(kvMount, "/")] = true
+		if transitMount != "" {
+			target.transitMounts[strings.Trim(transitMount, "/")] = true
+		}
+	}
+
+	// The command's own mounts come from the config being checked, or else
+	// from the nearest vlt.yaml
+	var cfg *config.Config
+	if opts.ConfigFile != "" {
+		var err error
+		if cfg, err = a.LoadConfig(opts.ConfigFile, false); err != nil {
+			return err
+		}
+		opts.KVMount, opts.TransitMount = cfg.KVMount(opts.KVMount), cfg.TransitMount(opts.TransitMount)
+	} else {
+		opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	}
+
+	// Values encrypted client-side (encryption: local) do not use Transit
+	transitMount := ""
+	if encryptionKey != "" && !a.localEncrypt {
+		transitMount = opts.TransitMount
+	}
+	addTarget(a, opts.KVMount, transitMount)
+
+	if cfg != nil {
+		encryptionKey = config.NonEmpty(encryptionKey, cfg.GetTransitKey())
+		for _, secret := range cfg.Secrets {
+			if secret.IsLiteral() {
+				continue
+			}
+			entryApp, err := a.forEntry(cfg, &secret)
+			if err != nil {
+				c.report("failed", "%s: %v", secret.Describe(), err)
+				continue
+			}
+			transitMount := ""
+			if encryptionKey != "" && !a.localEncrypt {
+				transitMount = cfg.GetTransitMountFor(&secret, opts.TransitMount)
+			}
+			addTarget(entryApp, cfg.GetKVMountFor(&secret, opts.KVMount), transitMount)
+		}
+	}
+
+	for i, key := range order {
+		// Entries share the token, so it is checked on the first server only
+		c.checkTarget(targets[key], encryptionKey, i == 0)
+	}
+
+	// Values are only read once the servers and mounts they come from are usable
+	if cfg != nil && c.failures == 0 {
+		window := opts.ExpiryWindow
+		if window == 0 {
+			window = DefaultExpiryWindow
+		}
+		entryVars, err := a.loadConfigEntries(cfg, opts.KVMount, opts.TransitMount, encryptionKey)
+		if err != nil {
+			c.report("warning", "cannot check the expiry of credentials in the values: %v", err)
+		} else {
+			c.checkExpiries(configExpiries(cfg, entryVars), window, opts.Strict)
+		}
+	}
+
+	if c.failures > 0 {
+		return WithExitCode(ExitFailure, fmt.Errorf("%d preflight check(s) failed", c.failures))
+	}
+	return nil
+}
+
+// checkToken checks that the client token is valid and not about to expire
+func (c *checker) checkToken(client *vault.Client) {
+	info, err := client.LookupSelf()
+	switch {
+	case vault.IsPermissionDenied(err):
+		c.report("failed", "token is invalid or expired: run 'vlt login' or set VAULT_TOKEN")
+	case err != nil:
+		c.report("failed", "token: %v", err)
+	case info.TTL > 0 && info.TTL < shortTTL:
+		c.report("warning", "token %s expires in %s (policies: %s)", info.DisplayName, info.TTL, strings.Join(info.Policies, ", "))
+	default:
+		c.report("ok", "token %s (policies: %s, ttl: %s)", info.DisplayName, strings.Join(info.Policies, ", "), formatTTL(info.TTL))
+	}
+}
+
+// checkTarget checks one server, the token (when checkToken is set), the
+// namespace, and the mounts used on it
+func (c *checker) checkTarget(target *checkTarget, encryptionKey string, checkToken bool) {
+	client := target.app.vaultClient
+	addr := client.Address()
+
+	status, err := client.Health()
+	switch {
+	case err != nil:
+		c.report("failed", "server %s is unreachable: %v", addr, err)
+		return
+	case !status.Initialized:
+		c.report("failed", "server %s is not initialized: run 'vault operator init'", addr)
+		return
+	case status.Sealed:
+		c.report("failed", "server %s is sealed: unseal it with 'vault operator unseal'", addr)
+		return
+	case status.ReplicationDRMode == "secondary":
+		c.report("failed", "server %s is a DR secondary and cannot serve requests: point VAULT_ADDR at the primary", addr)
+		return
+	case status.Standby && !status.PerformanceStandby:
+		c.report("warning", "server %s (Vault %s) is a standby node: requests are forwarded to the active node", addr, status.Version)
+	default:
+		c.report("ok", "server %s (Vault %s%s)", addr, status.Version, clusterSuffix(status.ClusterName))
+	}
+
+	if checkToken {
+		c.checkToken(client)
+	}
+
+	available, err := client.Mounts()
+	if namespace := client.Namespace(); namespace != "" {
+		if err != nil && (vault.IsNotFound(err) || vault.IsPermissionDenied(err)) {
+			c.report("failed", "namespace %s does not exist or the token has no access to it: check VAULT_NAMESPACE / --namespace", namespace)
+			return
+		}
+		if err != nil {
+			c.report("failed", "namespace %s: %v", namespace, err)
+			return
+		}
+		c.report("ok", "namespace %s", namespace)
+	}
+
+	for _, mount := range sortedKeys(target.kvMounts) {
+		c.checkMount(client, mount, "kv", available)
+	}
+	for _, mount := range sortedKeys(target.transitMounts) {
+		if c.checkMount(client, mount, "transit", available) && encryptionKey != "" {
+			c.checkTransitKey(client, mount, encryptionKey)
+		}
+	}
+}
+
+// checkMount checks that mount is a secrets engine of engineType (KV v2 for
+// "kv"), listing the matching mounts the token can see when it is not
+func (c *checker) checkMount(client *vault.Client, mount, engineType string, available []vault.MountInfo) bool {
+	info, err := client.Mount(mount)
+	if err != nil {
+		if vault.IsPermissionDenied(err) || vault.IsNotFound(err) {
+			c.report("failed", "%s mount %s/ does not exist or the token has no access to it%s", engineType, mount, mountHint(engineType, available))
+		} else {
+			c.report("failed", "%s mount %s/: %v", engineType, mount, err)
+		}
+		return false
+	}
+
+	switch {
+	case info.Type != engineType:
+		c.report("failed", "mount %s/ is a %s engine, not %s%s", mount, info.Type, engineType, mountHint(engineType, available))
+		return false
+	case engineType == "kv" && info.Version != "2":
+		c.report("failed", "kv mount %s/ is KV version 1; vlt needs KV v2: run 'vault kv enable-versioning %s'", mount, mount)
+		return false
+	case engineType == "kv":
+		c.report("ok", "kv mount %s/ (KV v2)", mount)
+	default:
+		c.report("ok", "%s mount %s/", engineType, mount)
+	}
+	return true
+}
+
+// checkExpiries reports when each credential found in the values expires:
+// failed once expired, a warning (failed when strict) within window
+func (c *checker) checkExpiries(expiries []valueExpiry, window time.Duration, strict bool) {
+	for _, expiry := range expiries {
+		left := time.Until(expiry.NotAfter)
+		switch {
+		case left <= 0, left < window && strict:
+			c.report("failed", "%s", expiry)
+		case left < window:
+			c.report("warning", "%s", expiry)
+		default:
+			c.report("ok", "%s", expiry)
+		}
+	}
+}
+
+// checkTransitKey checks that the transit key exists
+func (c *checker) checkTransitKey(client *vault.Client, mount, keyName string) {
+	info, err := client.TransitKey(mount, keyName)
+	switch {
+	case vault.IsNotFound(err):
+		c.report("failed", "transit key %s does not exist on %s/: run 'vault write -f %s/keys/%s'", keyName, mount, mount, keyName)
+	case vault.IsPermissionDenied(err):
+		c.report("warning", "transit key %s on %s/: the token cannot read the key configuration (encrypt/decrypt may still be allowed)", keyName, mount)
+	case err != nil:
+		c.report("failed", "transit key %s on %s/: %v", keyName, mount, err)
+	default:
+		c.report("ok", "transit key %s on %s/ (%s, latest version %d)", keyName, mount, info.Type, info.LatestVersion)
+	}
+}
+
+// mountHint lists the mounts of engineType the token can see
+func mountHint(engineType string, available []vault.MountInfo) string {
+	var paths []string
+	for _, mount := range available {
+		if mount.Type == engineType && (engineType != "kv" || mount.Version == "2") {
+			paths = append(paths, mount.Path+"/")
+		}
+	}
+	if len(paths) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s mounts visible to this token: %s)", engineType, strings.Join(paths, ", "))
+}
+
+// clusterSuffix formats a cluster name for the server line
+func clusterSuffix(name string) string {
+	if name == "" {
+		return ""
+	}
+	return ", cluster " + name
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// completionCacheTTL is how long listed paths and keys are reused by the
+// shell completion before Vault is asked again
+const completionCacheTTL = 30 * time.Second
+
+// CompleteOptions contains options for the Complete operation
+type CompleteOptions struct {
+	Kind      string // "path" or "key"
+	Word      string // the partial word being completed
+	KVMount   string
+	KVPath    string // secret whose keys are completed for "key"
+	Namespace string
+}
+
+// Complete returns the shell completion candidates for a partial --path or
+// --key value. Paths complete one directory level at a time; directories keep
+// their trailing slash. Names are cached on disk briefly, values never are.
+func (a *App) Complete(opts *CompleteOptions) ([]string, error) {
+	a = a.withNamespace(opts.Namespace)
+	if opts.KVMount == "" {
+		opts.KVMount = a.pathConfig().KVMount("")
+	}
+
+	var dir, target string
+	switch opts.Kind {
+	case "path":
+		if i := strings.LastIndex(opts.Word, "/"); i >= 0 {
+			dir = opts.Word[:i+1]
+		}
+		target = dir
+	case "key":
+		if opts.KVPath == "" {
+			return nil, nil
+		}
+		target = opts.KVPath
+	default:
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("unknown completion kind %q (expected path or key)", opts.Kind))
+	}
+
+	cachePath := a.completionCachePath(opts.Kind, opts.KVMount, target)
+	names, ok := readCompletionCache(cachePath)
+	if !ok {
+		var err error
+		if names, err = a.completionNames(opts.Kind, opts.KVMount, target); err != nil {
+			return nil, err
+		}
+		writeCompletionCache(cachePath, names)
+	}
+
+	var candidates []string
+	for _, name := range names {
+		if candidate := dir + name; strings.HasPrefix(candidate, opts.Word) {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates, nil
+}
+
+// completionNames lists the children of a directory or the keys of a secret
+func (a *App) completionNames(kind, mount, target string) ([]string, error) {
+	if kind == "path" {
+		names, err := a.vaultClient.KVList(mount, target)
+		if err != nil {
+			return nil, fmt.Errorf("kv list: %w", err)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	data, err := a.vaultClient.KVGet(mount, target)
+	if err != nil {
+		return nil, fmt.Errorf("kv get: %w", err)
+	}
+	data = utils.StripMeta(data)
+	names := make([]string, 0, len(data))
+	for k := range data {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// completionCachePath returns where completion names are cached, or "" if
+// there is no cache dir
+func (a *App) completionCachePath(kind, mount, target string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	id := strings.Join([]string{a.vaultClient.Address(), a.vaultClient.Namespace(), kind, strings.Trim(mount, "/"), target}, "\x00")
+	return filepath.Join(dir, "vlt", "completion", sha256Hex([]byte(id))+".json")
+}
+
+// readCompletionCache returns the cached names if they are recent enough
+func readCompletionCache(path string) ([]string, bool) {
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > completionCacheTTL {
+		return nil, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, false
+	}
+	return names, true
+}
+
+// writeCompletionCache caches names, ignoring failures
+func writeCompletionCache(path string, names []string) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(names)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+		_ = os.WriteFile(path, data, 0600)
+	}
+}
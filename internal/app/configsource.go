@@ -0,0 +1,193 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// configFetchTimeout bounds how long fetching a remote config may take
+const configFetchTimeout = 30 * time.Second
+
+// isRemoteConfig reports whether source is a vault:// or https:// reference
+func isRemoteConfig(source string) bool {
+	return strings.HasPrefix(source, "vault://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// configSourceID returns a stable identity for a config source, used to detect include cycles
+func configSourceID(source string) (string, error) {
+	if isRemoteConfig(source) {
+		id, _, _ := strings.Cut(source, "#")
+		return id, nil
+	}
+	return filepath.Abs(source)
+}
+
+// resolveInclude resolves an include reference relative to the config that includes it
+func resolveInclude(base, include string) (string, error) {
+	if isRemoteConfig(include) || filepath.IsAbs(include) {
+		return include, nil
+	}
+
+	switch {
+	case strings.HasPrefix(base, "vault://"):
+		ref := strings.TrimPrefix(base, "vault://")
+		return "vault://" + path.Join(path.Dir(ref), include), nil
+	case isRemoteConfig(base):
+		baseURL, err := url.Parse(base)
+		if err != nil {
+			return "", fmt.Errorf("parse config url: %w", err)
+		}
+		ref, err := url.Parse(include)
+		if err != nil {
+			return "", fmt.Errorf("parse include url: %w", err)
+		}
+		baseURL.Fragment = ""
+		return baseURL.ResolveReference(ref).String(), nil
+	default:
+		return filepath.Join(filepath.Dir(base), include), nil
+	}
+}
+
+// readConfigSource returns the raw contents of a config, which may be a local
+// file, a vault://mount/path secret, or an https:// URL
+func (a *App) readConfigSource(source string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(source, "vault://"):
+		if a.vaultClient == nil {
+			// Loading the connection settings, before there is a client
+			return nil, nil
+		}
+		return a.readVaultConfig(source)
+	case strings.HasPrefix(source, "https://"):
+		return readHTTPSConfig(source)
+	case strings.HasPrefix(source, "http://"):
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("refusing to fetch config over plain http: %s", source))
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	return data, nil
+}
+
+// readVaultConfig reads a config stored in KV as vault://<mount>/<path>. The
+// YAML is taken from the "config" field, the "value" field, or the only field.
+func (a *App) readVaultConfig(source string) ([]byte, error) {
+	mount, secretPath, ok := strings.Cut(strings.TrimPrefix(source, "vault://"), "/")
+	if !ok || mount == "" || secretPath == "" {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("invalid vault config reference %q (expected vault://<mount>/<path>)", source))
+	}
+
+	data, err := a.vaultClient.KVGet(mount, secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("read config from %s: %w", source, err)
+	}
+	data = utils.StripMeta(data)
+
+	if value, ok := data["config"].(string); ok {
+		return []byte(value), nil
+	}
+	if value, ok := data["value"].(string); ok {
+		return []byte(value), nil
+	}
+	if len(data) == 1 {
+		for _, v := range data {
+			if value, ok := v.(string); ok {
+				return []byte(value), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("config secret %s must store the YAML in a 'config' or 'value' field", source)
+}
+
+// readHTTPSConfig fetches a config over HTTPS. A "#sha256=<hex>" fragment pins
+// the expected content. Fetched configs are cached and the cache is used when
+// it matches the pin, or when the server cannot be reached.
+func readHTTPSConfig(source string) ([]byte, error) {
+	rawURL, fragment, _ := strings.Cut(source, "#")
+	pin := ""
+	if fragment != "" {
+		var ok bool
+		if pin, ok = strings.CutPrefix(fragment, "sha256="); !ok {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("unsupported config url fragment %q (expected #sha256=<hex>)", fragment))
+		}
+		pin = strings.ToLower(pin)
+	}
+
+	cachePath := configCachePath(rawURL)
+	if pin != "" && cachePath != "" {
+		if cached, err := os.ReadFile(cachePath); err == nil && sha256Hex(cached) == pin {
+			return cached, nil
+		}
+	}
+
+	data, err := fetchConfig(rawURL)
+	if err != nil {
+		if cachePath != "" {
+			if cached, cacheErr := os.ReadFile(cachePath); cacheErr == nil && (pin == "" || sha256Hex(cached) == pin) {
+				warnf("%v; using cached copy\n", err)
+				return cached, nil
+			}
+		}
+		return nil, err
+	}
+
+	if pin != "" {
+		if sum := sha256Hex(data); sum != pin {
+			return nil, fmt.Errorf("config %s failed integrity check: sha256 is %s, expected %s", rawURL, sum, pin)
+		}
+	}
+
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err == nil {
+			_ = os.WriteFile(cachePath, data, 0600)
+		}
+	}
+	return data, nil
+}
+
+// fetchConfig downloads a config file
+func fetchConfig(rawURL string) ([]byte, error) {
+	client := &http.Client{Timeout: configFetchTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch config %s: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch config %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetch config %s: %w", rawURL, err)
+	}
+	return data, nil
+}
+
+// configCachePath returns where a fetched config is cached, or "" if there is no cache dir
+func configCachePath(rawURL string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "vlt", "config", sha256Hex([]byte(rawURL))+".yaml")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
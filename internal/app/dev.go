@@ -0,0 +1,392 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+	"github.com/razzkumar/vlt/pkg/vault/vaultfake"
+)
+
+// DevOptions contains options for the Dev operation
+type DevOptions struct {
+	ConfigFile    string   // config whose secrets are seeded; "" seeds nothing
+	Listen        string   // address the server listens on, e.g. 127.0.0.1:8200
+	Token         string   // root token of the server
+	VaultBin      string   // vault binary; "" uses vault from PATH, or the embedded server without one
+	Embedded      bool     // use the embedded server even when vault is installed
+	EncryptionKey string   // transit key to create and encrypt the placeholders with
+	Command       []string // command to run instead of an interactive shell
+}
+
+// devStartTimeout bounds how long Dev waits for the server to answer
+const devStartTimeout = 15 * time.Second
+
+// devServer is a running dev server
+type devServer struct {
+	addr   string
+	desc   string
+	exited <-chan struct{} // closed when a vault process exits; nil for the embedded server
+	log    string          // log file of a vault process
+	stop   func()
+}
+
+// devPath is a KV secret seeded by Dev, with the transit mount its values
+// are encrypted with ("" for plaintext)
+type devPath struct {
+	kvMount      string
+	transitMount string
+	path         string
+}
+
+// Dev starts a throwaway Vault server (vault server -dev, or the embedded
+// vaultfake server), mounts the engines the config uses, creates the
+// transit key, seeds a placeholder for every configured secret, and runs an
+// interactive shell (or opts.Command) with VAULT_ADDR and VAULT_TOKEN set.
+// The server and its data are gone once the shell exits.
+func Dev(opts *DevOptions) error {
+	server, err := startDevServer(opts)
+	if err != nil {
+		return err
+	}
+	defer server.stop()
+
+	defaults, err := config.LoadUserDefaults()
+	if err != nil {
+		return WithExitCode(ExitUsage, err)
+	}
+	client, err := vault.NewClient(&config.VaultConfig{
+		Addr:           server.addr,
+		Token:          opts.Token,
+		AuthMethod:     "token",
+		Timeout:        int(devStartTimeout / time.Second),
+		ConnectTimeout: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create vault client: %w", err)
+	}
+	a := &App{vaultClient: client, encryptionKey: opts.EncryptionKey, defaults: defaults, logins: newLoginCache()}
+
+	status, err := server.wait(client)
+	if err != nil {
+		return err
+	}
+	statusf("Started %s at %s (Vault %s)\n", server.desc, server.addr, status.Version)
+
+	if opts.ConfigFile != "" {
+		cfg, err := a.LoadConfig(opts.ConfigFile, false)
+		if err != nil {
+			return err
+		}
+		if err := a.seedDev(cfg); err != nil {
+			return err
+		}
+	}
+
+	statusf("\nThe server and its data are discarded when this shell exits. To use it from another terminal:\n\n")
+	statusf("  export VAULT_ADDR=%s\n  export VAULT_TOKEN=%s\n\n", server.addr, opts.Token)
+
+	command, args := interactiveShell(), []string(nil)
+	if len(opts.Command) > 0 {
+		command, args = opts.Command[0], opts.Command[1:]
+	}
+	cmd := exec.Command(command, args...)
+	cmd.Env = devEnv(server.addr, opts.Token)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return commandError(runForwardingSignals(cmd))
+}
+
+// startDevServer starts vault server -dev, or the embedded server when
+// asked to or when vault is not installed
+func startDevServer(opts *DevOptions) (*devServer, error) {
+	if !opts.Embedded {
+		bin, err := exec.LookPath(config.NonEmpty(opts.VaultBin, "vault"))
+		if err == nil {
+			return startVaultDev(bin, opts)
+		}
+		if opts.VaultBin != "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("vault binary %s not found: %w", opts.VaultBin, err))
+		}
+		statusf("vault is not installed; using the embedded server (KV v2 and transit only)\n")
+	}
+
+	fake, err := vaultfake.Listen(opts.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("start embedded server: %w", err)
+	}
+	fake.Token = opts.Token
+	return &devServer{addr: fake.URL, desc: "embedded Vault server", stop: fake.Close}, nil
+}
+
+// startVaultDev runs vault server -dev in the background, logging to a
+// temporary file
+func startVaultDev(bin string, opts *DevOptions) (*devServer, error) {
+	logFile, err := os.CreateTemp("", "vlt-dev-*.log")
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(bin, "server", "-dev",
+		"-dev-root-token-id="+opts.Token,
+		"-dev-listen-address="+opts.Listen)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	// Ctrl-C in the shell must not reach the server
+	setProcessGroup(cmd)
+	if err := startCommand(cmd); err != nil {
+		logFile.Close()
+		os.Remove(logFile.Name())
+		return nil, fmt.Errorf("start vault server -dev: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		_ = cmd.Wait()
+		close(exited)
+	}()
+
+	stop := func() {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			_ = cmd.Process.Kill()
+		}
+		select {
+		case <-exited:
+		case <-time.After(10 * time.Second):
+			_ = cmd.Process.Kill()
+			<-exited
+		}
+		logFile.Close()
+		os.Remove(logFile.Name())
+	}
+	return &devServer{
+		addr:   "http://" + opts.Listen,
+		desc:   "vault server -dev",
+		exited: exited,
+		log:    logFile.Name(),
+		stop:   stop,
+	}, nil
+}
+
+// wait polls the server until it answers health checks
+func (s *devServer) wait(client *vault.Client) (*vault.ServerStatus, error) {
+	deadline := time.Now().Add(devStartTimeout)
+	for {
+		status, err := client.Health()
+		if err == nil {
+			return status, nil
+		}
+		select {
+		case <-s.exited:
+			log, _ := os.ReadFile(s.log)
+			return nil, fmt.Errorf("%s exited:\n%s", s.desc, strings.TrimSpace(string(log)))
+		default:
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s did not start within %s: %w", s.desc, devStartTimeout, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// seedDev mounts the engines of cfg, creates its transit key, and writes a
+// placeholder for every secret it reads
+func (a *App) seedDev(cfg *config.Config) error {
+	encryptionKey := config.NonEmpty(a.effectiveEncryptionKey(""), cfg.GetTransitKey())
+	paths := map[devPath]map[string]string{}
+	singleValue := map[devPath]bool{}
+	encryptKeys := map[devPath][]string{}
+
+	add := func(secret *config.SecretEntry, path, key string, placeholder string) devPath {
+		p := devPath{kvMount: strings.Trim(cfg.GetKVMountFor(secret, os.Getenv(config.KVMountEnv)), "/"), path: strings.Trim(path, "/")}
+		if encryptionKey != "" {
+			p.transitMount = strings.Trim(cfg.GetTransitMountFor(secret, os.Getenv(config.TransitMountEnv)), "/")
+		}
+		if paths[p] == nil {
+			paths[p] = map[string]string{}
+		}
+		if secret.Default != nil {
+			placeholder = *secret.Default
+		}
+		paths[p][key] = placeholder
+		encryptKeys[p] = append(encryptKeys[p], secret.EncryptKeys...)
+		return p
+	}
+
+	for i := range cfg.Secrets {
+		secret := &cfg.Secrets[i]
+		switch {
+		case secret.IsLiteral():
+			continue
+		case secret.Namespace != "" || len(secret.Vault) > 0 || secret.Auth != "":
+			warnf("not seeding %s: it reads from another namespace, server, or login\n", secret.Describe())
+		case len(secret.Transform) > 0:
+			warnf("not seeding %s: its values are transformed, so placeholders cannot stand in for them\n", secret.Describe())
+		case secret.IsTemplate():
+			for _, field := range templateFields(secret.Template) {
+				add(secret, field[0], field[1], devPlaceholder(field[1]))
+			}
+		case secret.IsPathGlob():
+			base, _, _ := secret.PathGlob()
+			for _, key := range devKeys(secret, "example") {
+				add(secret, base+"example", key, devPlaceholder(key))
+			}
+		case secret.IsPathAllKeys():
+			for _, key := range devKeys(secret, secret.Path) {
+				add(secret, secret.Path, key, devPlaceholder(key))
+			}
+		case secret.IsPathSingleKey():
+			add(secret, secret.Path, secret.Key, devPlaceholder(secret.Key))
+		case secret.IsIndividual():
+			singleValue[add(secret, secret.KVPath, "value", devPlaceholder(secret.EnvVar))] = true
+		}
+	}
+
+	if err := a.mountDevEngines(paths, encryptionKey); err != nil {
+		return err
+	}
+
+	order := make([]devPath, 0, len(paths))
+	for p := range paths {
+		order = append(order, p)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if order[i].kvMount != order[j].kvMount {
+			return order[i].kvMount < order[j].kvMount
+		}
+		return order[i].path < order[j].path
+	})
+
+	for _, p := range order {
+		values := paths[p]
+		var data map[string]any
+		if singleValue[p] && len(values) == 1 && p.transitMount != "" {
+			// Individual entries read an encrypted value from 'ciphertext'
+			ciphertext, err := a.vaultClient.TransitEncrypt(p.transitMount, encryptionKey, []byte(values["value"]))
+			if err != nil {
+				return fmt.Errorf("seed %s/%s: %w", p.kvMount, p.path, err)
+			}
+			data = map[string]any{"ciphertext": ciphertext}
+			utils.SetMeta(data, map[string]utils.EncryptedValue{"ciphertext": utils.NewEncryptedValue(encryptionKey, ciphertext)})
+		} else {
+			keyName := ""
+			if p.transitMount != "" {
+				keyName = encryptionKey
+			}
+			var err error
+			if data, err = utils.EncodeSelectedValues(values, a.vaultClient, p.transitMount, keyName, encryptKeys[p]); err != nil {
+				return fmt.Errorf("seed %s/%s: %w", p.kvMount, p.path, err)
+			}
+		}
+		if err := a.vaultClient.KVPut(p.kvMount, p.path, data); err != nil {
+			return fmt.Errorf("seed %s/%s: %w", p.kvMount, p.path, err)
+		}
+
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		statusf("Seeded %s/%s (%s)\n", p.kvMount, p.path, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// mountDevEngines enables the KV v2 and transit mounts the seeded paths use
+// and creates the transit key on each transit mount
+func (a *App) mountDevEngines(paths map[devPath]map[string]string, encryptionKey string) error {
+	existing, err := a.vaultClient.Mounts()
+	if err != nil {
+		return err
+	}
+	mounted := map[string]bool{}
+	for _, m := range existing {
+		mounted[m.Path] = true
+	}
+
+	kvMounts, transitMounts := map[string]bool{}, map[string]bool{}
+	for p := range paths {
+		kvMounts[p.kvMount] = true
+		if p.transitMount != "" {
+			transitMounts[p.transitMount] = true
+		}
+	}
+	if encryptionKey != "" && len(transitMounts) == 0 {
+		transitMounts[strings.Trim(config.GetTransitMount(""), "/")] = true
+	}
+
+	for _, mount := range sortedKeys(kvMounts) {
+		if mounted[mount] {
+			continue
+		}
+		if err := a.vaultClient.EnableMount(mount, "kv", map[string]string{"version": "2"}); err != nil {
+			return err
+		}
+		statusf("Enabled KV v2 at %s/\n", mount)
+	}
+	for _, mount := range sortedKeys(transitMounts) {
+		if !mounted[mount] {
+			if err := a.vaultClient.EnableMount(mount, "transit", nil); err != nil {
+				return err
+			}
+			statusf("Enabled transit at %s/\n", mount)
+		}
+		if err := a.vaultClient.CreateTransitKey(mount, encryptionKey); err != nil {
+			return err
+		}
+		statusf("Created transit key %s on %s/\n", encryptionKey, mount)
+	}
+	return nil
+}
+
+// templateFields returns the path and key of every {{ secret "path" "KEY" }}
+// a template references
+func templateFields(text string) [][2]string {
+	var fields [][2]string
+	funcs := template.FuncMap{
+		"secret": func(path, key string) string {
+			fields = append(fields, [2]string{path, key})
+			return ""
+		},
+	}
+	tmpl, err := template.New("dev").Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil
+	}
+	_ = tmpl.Execute(io.Discard, nil)
+	return fields
+}
+
+// devKeys returns the keys to seed for an entry that loads every key of a
+// path: its require_keys, or one key named after the last path segment
+func devKeys(secret *config.SecretEntry, path string) []string {
+	if len(secret.RequireKeys) > 0 {
+		return secret.RequireKeys
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	return []string{strings.ToUpper(config.SanitizeEnvName(segments[len(segments)-1]))}
+}
+
+// devPlaceholder returns the dummy value seeded for a key
+func devPlaceholder(key string) string {
+	return "dev-" + strings.ToLower(key)
+}
+
+// devEnv returns the environment of the dev shell: the current one with
+// every Vault client variable replaced by the dev server's address and token
+func devEnv(addr, token string) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !isVaultEnvVar(name) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, "VAULT_ADDR="+addr, "VAULT_TOKEN="+token)
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// DriftOptions contains options for the Drift operation. Either both paths
+// or both config files are set.
+type DriftOptions struct {
+	LeftPath      string
+	RightPath     string
+	LeftConfig    string
+	RightConfig   string
+	KVMount       string
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string // overrides the client namespace for this command
+	OutputJSON    bool
+}
+
+// DriftReport lists the keys that differ between two sides. Values are never
+// included; differing values are detected by comparing SHA-256 hashes.
+type DriftReport struct {
+	Left      string   `json:"left"`
+	Right     string   `json:"right"`
+	OnlyLeft  []string `json:"only_left"`
+	OnlyRight []string `json:"only_right"`
+	Changed   []string `json:"changed"`
+}
+
+// HasDrift reports whether the two sides differ
+func (r *DriftReport) HasDrift() bool {
+	return len(r.OnlyLeft)+len(r.OnlyRight)+len(r.Changed) > 0
+}
+
+// Drift compares two KV paths, or the env vars produced by two configs, and
+// reports keys missing on either side and keys whose values differ
+func (a *App) Drift(opts *DriftOptions) error {
+	var left, right map[string]string
+	var leftName, rightName string
+	var err error
+
+	switch {
+	case opts.LeftPath != "" && opts.RightPath != "":
+		scoped := a.withNamespace(opts.Namespace)
+		encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+		opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+		if left, err = scoped.readValues(opts.KVMount, opts.LeftPath, opts.TransitMount, encryptionKey); err != nil {
+			return fmt.Errorf("left: %w", err)
+		}
+		if right, err = scoped.readValues(opts.KVMount, opts.RightPath, opts.TransitMount, encryptionKey); err != nil {
+			return fmt.Errorf("right: %w", err)
+		}
+		leftName, rightName = opts.LeftPath, opts.RightPath
+	case opts.LeftConfig != "" && opts.RightConfig != "":
+		if left, err = a.loadConfigVars(opts.LeftConfig, opts); err != nil {
+			return fmt.Errorf("left: %w", err)
+		}
+		if right, err = a.loadConfigVars(opts.RightConfig, opts); err != nil {
+			return fmt.Errorf("right: %w", err)
+		}
+		leftName, rightName = opts.LeftConfig, opts.RightConfig
+	default:
+		return WithExitCode(ExitUsage, fmt.Errorf("specify --left and --right, or --left-config and --right-config"))
+	}
+
+	report := compareValues(left, right)
+	report.Left, report.Right = leftName, rightName
+
+	if opts.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return fmt.Errorf("marshal json: %w", err)
+		}
+	} else {
+		printDriftReport(report)
+	}
+
+	if report.HasDrift() {
+		return WithExitCode(ExitDrift, fmt.Errorf("drift detected between %s and %s", leftName, rightName))
+	}
+	return nil
+}
+
+// loadConfigVars loads the env vars produced by a config file
+func (a *App) loadConfigVars(path string, opts *DriftOptions) (map[string]string, error) {
+	cfg, err := a.LoadConfig(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	scoped := a.withNamespace(opts.Namespace)
+	return scoped.loadSecretsFromConfig(cfg, opts.KVMount, opts.TransitMount, a.effectiveEncryptionKey(opts.EncryptionKey))
+}
+
+// compareValues diffs two key/value maps by key and value hash
+func compareValues(left, right map[string]string) *DriftReport {
+	report := &DriftReport{OnlyLeft: []string{}, OnlyRight: []string{}, Changed: []string{}}
+
+	for k, lv := range left {
+		rv, ok := right[k]
+		switch {
+		case !ok:
+			report.OnlyLeft = append(report.OnlyLeft, k)
+		case sha256.Sum256([]byte(lv)) != sha256.Sum256([]byte(rv)):
+			report.Changed = append(report.Changed, k)
+		}
+	}
+	for k := range right {
+		if _, ok := left[k]; !ok {
+			report.OnlyRight = append(report.OnlyRight, k)
+		}
+	}
+
+	sort.Strings(report.OnlyLeft)
+	sort.Strings(report.OnlyRight)
+	sort.Strings(report.Changed)
+	return report
+}
+
+func printDriftReport(r *DriftReport) {
+	painter := utils.NewPainter(os.Stdout)
+	if !r.HasDrift() {
+		fmt.Println(painter.Paint(fmt.Sprintf("No drift between %s and %s", r.Left, r.Right), utils.Green))
+		return
+	}
+
+	fmt.Printf("Drift between %s (left) and %s (right):\n", r.Left, r.Right)
+	for _, k := range r.OnlyLeft {
+		fmt.Printf("  %s %s\n", painter.Paint("- "+k, utils.Red), painter.Paint("(only in left)", utils.Dim))
+	}
+	for _, k := range r.OnlyRight {
+		fmt.Printf("  %s %s\n", painter.Paint("+ "+k, utils.Green), painter.Paint("(only in right)", utils.Dim))
+	}
+	for _, k := range r.Changed {
+		fmt.Printf("  %s %s\n", painter.Paint("~ "+k, utils.Yellow), painter.Paint("(values differ)", utils.Dim))
+	}
+	fmt.Printf("%d only in left, %d only in right, %d changed\n", len(r.OnlyLeft), len(r.OnlyRight), len(r.Changed))
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// Methods of sync --encrypt-output
+const (
+	encryptAge     = "age"
+	encryptTransit = "transit"
+)
+
+// transitEnvelopeFormat identifies env files encrypted with a Transit data key
+const transitEnvelopeFormat = "transit-v1"
+
+// ageHeaders start files encrypted by age, binary or armored
+var ageHeaders = [][]byte{
+	[]byte("age-encryption.org/v1\n"),
+	[]byte("-----BEGIN AGE ENCRYPTED FILE-----"),
+}
+
+// transitEnvelope is an env file encrypted locally with AES-256-GCM under a
+// data key generated by Transit. Only the wrapped data key is stored, so
+// reading the file takes a Transit decrypt call.
+type transitEnvelope struct {
+	Format       string `json:"vlt_encrypted_env"`
+	TransitMount string `json:"transit_mount"`
+	Key          string `json:"key"`
+	DataKey      string `json:"data_key"`   // the data key wrapped by the transit key
+	Ciphertext   string `json:"ciphertext"` // base64 of the nonce followed by the sealed content
+}
+
+// parseEncryptOutput splits an --encrypt-output value: age:<recipient>[,...]
+// or transit:<key>
+func parseEncryptOutput(spec string) (string, string, error) {
+	method, target, ok := strings.Cut(spec, ":")
+	if !ok || target == "" || (method != encryptAge && method != encryptTransit) {
+		return "", "", WithExitCode(ExitUsage, fmt.Errorf("invalid --encrypt-output %q: expected age:<recipient> or transit:<key>", spec))
+	}
+	return method, target, nil
+}
+
+// encryptContent encrypts a rendered file as spec (see parseEncryptOutput)
+// describes. The plaintext is never written to disk.
+func (a *App) encryptContent(content []byte, spec, transitMount string) ([]byte, error) {
+	method, target, err := parseEncryptOutput(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if method == encryptAge {
+		encrypted, err := utils.AgeEncrypt(content, strings.Split(target, ","), nil, false)
+		if err != nil {
+			return nil, fmt.Errorf("age encrypt: %w", err)
+		}
+		return encrypted, nil
+	}
+
+	dataKey, wrapped, err := a.vaultClient.TransitDataKey(transitMount, target)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(transitEnvelope{
+		Format:       transitEnvelopeFormat,
+		TransitMount: transitMount,
+		Key:          target,
+		DataKey:      wrapped,
+		Ciphertext:   base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, content, nil)),
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal encrypted file: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// readEnvFile parses a .env file, decrypting it first when it was written
+// by sync --encrypt-output. age files are decrypted with one of identities;
+// Transit files with the key recorded in them. A file past the expiry
+// written by sync --ttl is handled as the expired policy says.
+func (a *App) readEnvFile(path string, identities []string, expired string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env file: %w", err)
+	}
+	if content, err = a.decryptEnvFile(path, content, identities); err != nil {
+		return nil, err
+	}
+
+	resync, err := checkExpiry(path, content, expired)
+	if err != nil {
+		return nil, err
+	}
+	if resync {
+		if err := a.resync(path); err != nil {
+			return nil, fmt.Errorf("resync: %w", err)
+		}
+		return a.readEnvFile(path, identities, ExpiredRefuse)
+	}
+
+	envMap, err := godotenv.UnmarshalBytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .env file: %w", err)
+	}
+	return envMap, nil
+}
+
+// decryptEnvFile returns the plaintext of an encrypted env file, or content
+// unchanged when it is not encrypted
+func (a *App) decryptEnvFile(path string, content []byte, identities []string) ([]byte, error) {
+	if isAgeEncrypted(content) {
+		if len(identities) == 0 {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("%s is encrypted with age; pass --identity", path))
+		}
+		plaintext, err := utils.AgeDecrypt(path, identities)
+		if err != nil {
+			return nil, WithExitCode(ExitDecrypt, err)
+		}
+		return plaintext, nil
+	}
+
+	envelope, ok := parseTransitEnvelope(content)
+	if !ok {
+		return content, nil
+	}
+	if a == nil || a.vaultClient == nil {
+		return nil, fmt.Errorf("%s is encrypted with Transit key %s; a Vault connection is required to read it", path, envelope.Key)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%s: decode ciphertext: %w", path, err)
+	}
+	dataKey, err := a.vaultClient.TransitDecrypt(envelope.TransitMount, envelope.Key, envelope.DataKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: unwrap data key: %w", path, err)
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s: %w: ciphertext is truncated", path, vault.ErrDecryptFailed)
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %w", path, vault.ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// encryptedEnvFile reports whether content is an env file written by
+// sync --encrypt-output
+func encryptedEnvFile(content []byte) bool {
+	_, transit := parseTransitEnvelope(content)
+	return transit || isAgeEncrypted(content)
+}
+
+func isAgeEncrypted(content []byte) bool {
+	for _, header := range ageHeaders {
+		if bytes.HasPrefix(content, header) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTransitEnvelope(content []byte) (*transitEnvelope, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return nil, false
+	}
+	var envelope transitEnvelope
+	if err := json.Unmarshal(content, &envelope); err != nil || envelope.Format != transitEnvelopeFormat {
+		return nil, false
+	}
+	return &envelope, true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
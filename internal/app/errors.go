@@ -0,0 +1,188 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// Exit codes returned by the CLI. These are a stable contract for scripts and CI.
+const (
+	ExitOK         = 0
+	ExitFailure    = 1   // unclassified failure
+	ExitUsage      = 2   // invalid flags, arguments, or missing required input
+	ExitAuth       = 3   // authentication to Vault failed
+	ExitPermission = 4   // Vault denied access to a path
+	ExitNotFound   = 5   // secret path or key does not exist
+	ExitDecrypt    = 6   // transit decryption failed
+	ExitChild      = 7   // the command given to run could not be started
+	ExitDrift      = 8   // compared secrets, files, or versions differ
+	ExitTimeout    = 124 // the command given to run timed out (as timeout(1))
+)
+
+// exitCoder is implemented by vlt's errors that carry their own exit code.
+// The unexported marker keeps errors from elsewhere that happen to have an
+// ExitCode method, such as *exec.ExitError, from being taken for ours.
+type exitCoder interface {
+	ExitCode() int
+	vltExitCode()
+}
+
+// CodedError attaches an exit code to an error without changing its message
+type CodedError struct {
+	Code int
+	Err  error
+}
+
+// WithExitCode wraps err so that the CLI exits with code
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CodedError{Code: code, Err: err}
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code for the error
+func (e *CodedError) ExitCode() int {
+	return e.Code
+}
+
+func (e *CodedError) vltExitCode() {}
+
+// ChildExitError is returned by Run when the command exits with a non-zero
+// status. The CLI exits with the same status and prints nothing.
+type ChildExitError struct {
+	Status int
+}
+
+func (e *ChildExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Status)
+}
+
+// ExitCode returns the child's exit status
+func (e *ChildExitError) ExitCode() int {
+	return e.Status
+}
+
+func (e *ChildExitError) vltExitCode() {}
+
+// ExitCode maps an error to the CLI exit code contract
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var coder exitCoder
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+
+	switch {
+	case vault.IsUnauthorized(err), errors.Is(err, config.ErrMissingVaultToken):
+		return ExitAuth
+	case errors.Is(err, vault.ErrDecryptFailed):
+		return ExitDecrypt
+	case vault.IsPermissionDenied(err):
+		return ExitPermission
+	case vault.IsNotFound(err):
+		return ExitNotFound
+	case errors.Is(err, config.ErrMissingVaultAddr):
+		return ExitUsage
+	}
+
+	return ExitFailure
+}
+
+// EntryError describes a single secret entry that failed to load
+type EntryError struct {
+	Entry string // human readable description of the entry
+	Err   error
+}
+
+func (e *EntryError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Entry, e.Err)
+}
+
+func (e *EntryError) Unwrap() error {
+	return e.Err
+}
+
+// LoadError aggregates every entry that failed to load so they can be
+// reported together instead of stopping at the first failure
+type LoadError struct {
+	Entries []*EntryError
+}
+
+// Add records a failed entry
+func (e *LoadError) Add(entry string, err error) {
+	e.Entries = append(e.Entries, &EntryError{Entry: entry, Err: err})
+}
+
+// Merge appends the entries of another LoadError, or records err as-is
+func (e *LoadError) Merge(err error) {
+	if err == nil {
+		return
+	}
+	if other, ok := err.(*LoadError); ok {
+		e.Entries = append(e.Entries, other.Entries...)
+		return
+	}
+	e.Entries = append(e.Entries, &EntryError{Entry: "error", Err: err})
+}
+
+// ErrOrNil returns the LoadError if any entries failed, or nil otherwise
+func (e *LoadError) ErrOrNil() error {
+	if len(e.Entries) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *LoadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to load %d secret entr", len(e.Entries))
+	if len(e.Entries) == 1 {
+		b.WriteString("y:")
+	} else {
+		b.WriteString("ies:")
+	}
+	for _, entry := range e.Entries {
+		fmt.Fprintf(&b, "\n  - %s", entry.Error())
+	}
+	return b.String()
+}
+
+// ExitCode returns the shared exit code of all failed entries, or
+// ExitFailure if they failed for different reasons
+func (e *LoadError) ExitCode() int {
+	code := ExitFailure
+	for i, entry := range e.Entries {
+		entryCode := ExitCode(entry.Err)
+		if i > 0 && entryCode != code {
+			return ExitFailure
+		}
+		code = entryCode
+	}
+	return code
+}
+
+func (e *LoadError) vltExitCode() {}
+
+func (e *LoadError) Unwrap() []error {
+	errs := make([]error, len(e.Entries))
+	for i, entry := range e.Entries {
+		errs[i] = entry
+	}
+	return errs
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// DefaultExpiryWindow is how long before expiry credentials are reported
+// unless --expiry-window is given
+const DefaultExpiryWindow = 30 * 24 * time.Hour
+
+// credentialExpiry is the expiry of a credential found in a secret value
+type credentialExpiry struct {
+	Kind     string // certificate, JWT, AWS credentials, GCP access token, or Azure SAS token
+	Subject  string // common name, JWT subject, access key ID, or host; may be empty
+	NotAfter time.Time
+}
+
+// String names the credential, e.g. certificate api.example.com
+func (e credentialExpiry) String() string {
+	if e.Subject == "" {
+		return e.Kind
+	}
+	return e.Kind + " " + e.Subject
+}
+
+// expiryMessage describes when the credential expires, or expired
+func (e credentialExpiry) expiryMessage() string {
+	if time.Until(e.NotAfter) <= 0 {
+		return fmt.Sprintf("%s expired on %s", e, e.NotAfter.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s expires on %s", e, e.NotAfter.Format("2006-01-02"))
+}
+
+// findExpiries returns the expiries of the credentials in value: PEM
+// certificates (also base64-encoded, as put --from-file stores them), JWTs,
+// AWS credential_process or STS JSON, GCP access token JSON, and Azure SAS
+// tokens
+func findExpiries(value string) []credentialExpiry {
+	if pemData := pemContent(value); pemData != nil {
+		return certificateExpiries(pemData)
+	}
+	trimmed := strings.TrimSpace(value)
+	if expiry, ok := jwtExpiry(trimmed); ok {
+		return []credentialExpiry{expiry}
+	}
+	if expiry, ok := jsonCredentialExpiry(trimmed); ok {
+		return []credentialExpiry{expiry}
+	}
+	if expiry, ok := sasExpiry(trimmed); ok {
+		return []credentialExpiry{expiry}
+	}
+	return nil
+}
+
+// certificateExpiries returns the expiry of every certificate in PEM data
+func certificateExpiries(data []byte) []credentialExpiry {
+	var expiries []credentialExpiry
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return expiries
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		subject := cert.Subject.CommonName
+		if subject == "" {
+			subject = cert.Subject.String()
+		}
+		expiries = append(expiries, credentialExpiry{Kind: "certificate", Subject: subject, NotAfter: cert.NotAfter})
+	}
+}
+
+// jwtExpiry returns the exp claim of a JWT, optionally prefixed by "Bearer "
+func jwtExpiry(value string) (credentialExpiry, bool) {
+	parts := strings.Split(strings.TrimPrefix(value, "Bearer "), ".")
+	if len(parts) != 3 {
+		return credentialExpiry{}, false
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	var claims struct {
+		Exp json.Number `json:"exp"`
+		Sub string      `json:"sub"`
+		Iss string      `json:"iss"`
+	}
+	if decodeJWTPart(parts[0], &header) != nil || header.Alg == "" || decodeJWTPart(parts[1], &claims) != nil {
+		return credentialExpiry{}, false
+	}
+	exp, err := claims.Exp.Float64()
+	if err != nil || exp <= 0 {
+		return credentialExpiry{}, false
+	}
+	return credentialExpiry{Kind: "JWT", Subject: config.NonEmpty(claims.Sub, claims.Iss), NotAfter: time.Unix(int64(exp), 0).UTC()}, true
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a JWT
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// jsonCredentialExpiry returns the expiry of AWS credentials (as printed by
+// credential_process or aws sts) or a GCP access token held as JSON
+func jsonCredentialExpiry(value string) (credentialExpiry, bool) {
+	if !strings.HasPrefix(value, "{") {
+		return credentialExpiry{}, false
+	}
+	var creds struct {
+		AccessKeyID string `json:"AccessKeyId"`
+		Expiration  string `json:"Expiration"`
+		Credentials *struct {
+			AccessKeyID string `json:"AccessKeyId"`
+			Expiration  string `json:"Expiration"`
+		} `json:"Credentials"`
+		AccessToken string `json:"access_token"` // golang.org/x/oauth2 token
+		Expiry      string `json:"expiry"`
+		GCPToken    string `json:"accessToken"` // iamcredentials generateAccessToken
+		ExpireTime  string `json:"expireTime"`
+	}
+	if json.Unmarshal([]byte(value), &creds) != nil {
+		return credentialExpiry{}, false
+	}
+	if creds.Credentials != nil {
+		creds.AccessKeyID, creds.Expiration = creds.Credentials.AccessKeyID, creds.Credentials.Expiration
+	}
+
+	kind, subject, expiration := "", "", ""
+	switch {
+	case creds.AccessKeyID != "" && creds.Expiration != "":
+		kind, subject, expiration = "AWS credentials", creds.AccessKeyID, creds.Expiration
+	case creds.AccessToken != "" || creds.GCPToken != "":
+		kind, expiration = "GCP access token", config.NonEmpty(creds.Expiry, creds.ExpireTime)
+	}
+	notAfter, err := time.Parse(time.RFC3339, expiration)
+	if kind == "" || err != nil {
+		return credentialExpiry{}, false
+	}
+	return credentialExpiry{Kind: kind, Subject: subject, NotAfter: notAfter.UTC()}, true
+}
+
+// sasExpiry returns the signed expiry (se) of an Azure shared access
+// signature, given as a token or as a URL carrying one
+func sasExpiry(value string) (credentialExpiry, bool) {
+	if strings.ContainsAny(value, " \n") || !strings.Contains(value, "sig=") || !strings.Contains(value, "se=") {
+		return credentialExpiry{}, false
+	}
+	query, host := value, ""
+	if u, err := url.Parse(value); err == nil && u.Host != "" {
+		query, host = u.RawQuery, u.Host
+	}
+	params, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil || params.Get("sig") == "" {
+		return credentialExpiry{}, false
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z", "2006-01-02"} {
+		if notAfter, err := time.Parse(layout, params.Get("se")); err == nil {
+			return credentialExpiry{Kind: "Azure SAS token", Subject: host, NotAfter: notAfter.UTC()}, true
+		}
+	}
+	return credentialExpiry{}, false
+}
+
+// valueExpiry is a credential found in the value of a variable
+type valueExpiry struct {
+	EnvVar string
+	Entry  string // description of the config entry the variable comes from
+	credentialExpiry
+}
+
+// String describes the expiry, e.g. TLS_CERT: certificate api.example.com
+// expires on 2026-11-02 (path app/tls)
+func (v valueExpiry) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.EnvVar, v.expiryMessage(), v.Entry)
+}
+
+// configExpiries returns the credentials in the values of every config
+// entry, in entry and variable name order
+func configExpiries(cfg *config.Config, entryVars []map[string]string) []valueExpiry {
+	var found []valueExpiry
+	for i, vars := range entryVars {
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, expiry := range findExpiries(vars[name]) {
+				found = append(found, valueExpiry{EnvVar: name, Entry: cfg.Secrets[i].Describe(), credentialExpiry: expiry})
+			}
+		}
+	}
+	return found
+}
+
+// checkExpiring warns about credentials in the synced values that expired or
+// expire within window, and fails instead when strict
+func checkExpiring(cfg *config.Config, entryVars []map[string]string, window time.Duration, strict bool) error {
+	var expiring []string
+	for _, expiry := range configExpiries(cfg, entryVars) {
+		if time.Until(expiry.NotAfter) < window {
+			expiring = append(expiring, expiry.String())
+		}
+	}
+	if len(expiring) == 0 {
+		return nil
+	}
+	if strict {
+		return fmt.Errorf("%d credential(s) expired or expire within %s (strict mode):\n  %s", len(expiring), formatWindow(window), strings.Join(expiring, "\n  "))
+	}
+	for _, line := range expiring {
+		warnf("%s\n", line)
+	}
+	return nil
+}
+
+// formatWindow formats an expiry window, in days when it is a whole number of them
+func formatWindow(window time.Duration) string {
+	if day := 24 * time.Hour; window%day == 0 {
+		return fmt.Sprintf("%d days", window/day)
+	}
+	return window.String()
+}
@@ -0,0 +1,292 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/razzkumar/vlt/internal/audit"
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// putFileChunks stores a file larger than one chunk for put --from-file. The
+// file is read a chunk at a time, and the chunks are written to the parts of
+// a split secret (see putSharded) as each part fills, so neither the file
+// nor the secret is held in memory whole. A file that fits one secret is
+// written to the path itself. The manifest, with the size, SHA-256, and
+// content type of the file, is written last, with the index of the parts.
+func (a *App) putFileChunks(file *os.File, mount, kvPath, transitMount, encryptionKey string, oldParts, version int) error {
+	limit := a.maxSecretSize() * 4 / 5
+	overhead := utils.EncodedSize(utils.ShardIndex(0)) + len(utils.EncodeFileManifest(utils.FileManifest{})) + 256
+	source := mount + "/" + kvPath
+
+	hash := sha256.New()
+	buf := make([]byte, a.fileChunkSize())
+	part, size := make(map[string]interface{}), overhead
+	encrypted := make(map[string]utils.EncryptedValue)
+	manifest := utils.FileManifest{Name: filepath.Base(file.Name()), ChunkSize: len(buf)}
+	parts := 0
+
+	flush := func() error {
+		parts++
+		utils.SetMeta(part, encrypted)
+		if err := a.vaultClient.KVPut(mount, utils.ShardPath(kvPath, parts), part); err != nil {
+			return fmt.Errorf("write part %d of %s: %w", parts, source, err)
+		}
+		part, size = make(map[string]interface{}), overhead
+		encrypted = make(map[string]utils.EncryptedValue)
+		return nil
+	}
+
+	for {
+		n, err := io.ReadFull(file, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			if manifest.Chunks == 0 {
+				manifest.ContentType = utils.DetectContentType(manifest.Name, buf[:n])
+			}
+			manifest.Size += int64(n)
+			manifest.Chunks++
+
+			key := utils.ChunkKey(manifest.Chunks)
+			value := base64.StdEncoding.EncodeToString(buf[:n])
+			if encryptionKey != "" {
+				if value, err = a.vaultClient.TransitEncrypt(transitMount, encryptionKey, []byte(value)); err != nil {
+					return fmt.Errorf("encrypt chunk %d: %w", manifest.Chunks, err)
+				}
+			}
+
+			// A chunk and its record of encryption take about this much
+			cost := len(key) + len(value) + 64
+			if size+cost > limit && len(part) > 0 {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			part[key] = value
+			size += cost
+			if encryptionKey != "" {
+				encrypted[key] = utils.NewEncryptedValue(encryptionKey, value)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file.Name(), err)
+		}
+	}
+
+	manifest.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	data := part
+	if parts > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+		data = utils.ShardIndex(parts)
+	} else {
+		utils.SetMeta(data, encrypted)
+	}
+	data[utils.FileKey] = utils.EncodeFileManifest(manifest)
+	if err := a.finishParts(mount, kvPath, data, parts, oldParts, version); err != nil {
+		return err
+	}
+
+	status := "plaintext"
+	if encryptionKey != "" {
+		status = "encrypted"
+	}
+	infof("Stored %s (%s) in %d chunk(s) as %s: %s\n", manifest.Name, formatBytes(int(manifest.Size)), manifest.Chunks, status, source)
+	if parts > 0 {
+		infof("Split across %d part(s): %s/part1..part%d\n", parts, source, parts)
+	}
+	return nil
+}
+
+// fileChunkSize returns the number of file bytes put --from-file stores in
+// one chunk: utils.FileChunkSize, or less when the size limit of a secret is
+// too low for a chunk encoded in base64 and encrypted, both growing it by a
+// third
+func (a *App) fileChunkSize() int {
+	return max(min(utils.FileChunkSize, a.maxSecretSize()*2/5), 1024)
+}
+
+// GetFile writes the file stored at opts.KVPath by put --from-file to
+// opts.OutFile, or to stdout for "-". Chunked files are read one part at a
+// time, decoded as they are read, and checked against the size and SHA-256
+// of their manifest before the output file replaces any existing one.
+func (a *App) GetFile(opts *GetOptions) error {
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+
+	data, err := a.vaultClient.KVGet(opts.KVMount, opts.KVPath)
+	if err != nil {
+		return fmt.Errorf("kv get: %w", err)
+	}
+
+	write := func(w io.Writer) error {
+		return a.copyFileData(w, opts, data, encryptionKey)
+	}
+	if opts.OutFile == "-" {
+		return write(os.Stdout)
+	}
+	if err := writeFileAtomicFunc(opts.OutFile, 0600, write); err != nil {
+		return fmt.Errorf("write %s: %w", opts.OutFile, err)
+	}
+	infof("Wrote %s\n", opts.OutFile)
+	return nil
+}
+
+// catFile prints the file stored with put --from-file whose secret (or split
+// secret index) is data. On a terminal it prints the file's metadata unless
+// --reveal is given, and refuses binary content without --raw, so a keystore
+// is not dumped to the screen by accident.
+func (a *App) catFile(opts *GetOptions, data map[string]interface{}, manifest *utils.FileManifest) error {
+	source := opts.KVMount + "/" + opts.KVPath
+	if opts.Metadata || opts.OutputJSON {
+		return printFileManifest(source, manifest, opts.OutputJSON)
+	}
+
+	if utils.IsTerminal(os.Stdout) && !opts.Raw {
+		if !utils.IsTextContentType(contentTypeOf(manifest)) {
+			return WithExitCode(ExitUsage, fmt.Errorf("%s holds a binary file (%s, %s); write it out with --out-file FILE, or pass --raw to print it to the terminal anyway",
+				source, contentTypeOf(manifest), formatBytes(int(manifest.Size))))
+		}
+		if !opts.Reveal {
+			if err := printFileManifest(source, manifest, false); err != nil {
+				return err
+			}
+			infof("Pass --reveal to print the file, or --out-file FILE to write it out\n")
+			return nil
+		}
+	}
+
+	audit.MarkDisplayed()
+	return a.copyFileData(os.Stdout, opts, data, a.effectiveEncryptionKey(opts.EncryptionKey))
+}
+
+// printFileManifest prints what put --from-file recorded about a file
+func printFileManifest(source string, manifest *utils.FileManifest, asJSON bool) error {
+	if asJSON {
+		return utils.OutputJSON(map[string]any{
+			"path":         source,
+			"name":         manifest.Name,
+			"size":         manifest.Size,
+			"sha256":       manifest.SHA256,
+			"content_type": contentTypeOf(manifest),
+			"chunks":       manifest.Chunks,
+		})
+	}
+	fmt.Printf("Path:         %s\n", source)
+	fmt.Printf("File:         %s\n", manifest.Name)
+	if size := formatBytes(int(manifest.Size)); manifest.Size >= 1<<10 {
+		fmt.Printf("Size:         %s (%d bytes)\n", size, manifest.Size)
+	} else {
+		fmt.Printf("Size:         %s\n", size)
+	}
+	fmt.Printf("Content type: %s\n", contentTypeOf(manifest))
+	fmt.Printf("SHA-256:      %s\n", manifest.SHA256)
+	if manifest.Chunks > 0 {
+		fmt.Printf("Chunks:       %d of %s\n", manifest.Chunks, formatBytes(manifest.ChunkSize))
+	}
+	return nil
+}
+
+// contentTypeOf returns the content type of a stored file, guessing it from
+// the name for files stored before put --from-file recorded one
+func contentTypeOf(manifest *utils.FileManifest) string {
+	if manifest.ContentType == "" {
+		manifest.ContentType = utils.DetectContentType(manifest.Name, nil)
+	}
+	return manifest.ContentType
+}
+
+// copyFileData writes the content of the file whose secret (or split secret
+// index) is data to w
+func (a *App) copyFileData(w io.Writer, opts *GetOptions, data map[string]interface{}, encryptionKey string) error {
+	source := opts.KVMount + "/" + opts.KVPath
+	decode := func(part map[string]interface{}, key string) ([]byte, error) {
+		value, _ := part[key].(string)
+		if utils.IsEncryptedValue(part, key) {
+			if encryptionKey == "" {
+				return nil, WithExitCode(ExitUsage, fmt.Errorf("--encryption-key is required for encrypted secrets"))
+			}
+			plaintext, err := a.vaultClient.TransitDecrypt(opts.TransitMount, encryptionKey, value)
+			if err != nil {
+				return nil, fmt.Errorf("transit decrypt: %w", err)
+			}
+			value = string(plaintext)
+		}
+		content, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a file stored with put --from-file: %s is not base64", source, key)
+		}
+		return content, nil
+	}
+
+	// A file of a single chunk is stored as one value
+	manifest, _ := utils.ReadFileManifest(data)
+	if utils.IsEncryptedSingleValue(data) || utils.IsPlaintextSingleValue(data) {
+		key := "value"
+		if utils.IsEncryptedSingleValue(data) {
+			key = "ciphertext"
+		}
+		content, err := decode(data, key)
+		if err != nil {
+			return err
+		}
+		if manifest != nil {
+			sum := sha256.Sum256(content)
+			if int64(len(content)) != manifest.Size || hex.EncodeToString(sum[:]) != manifest.SHA256 {
+				return fmt.Errorf("%s: content does not match its manifest (%d of %d bytes)", source, len(content), manifest.Size)
+			}
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	// A split secret is read one part at a time
+	n := utils.ShardCount(data)
+	hash := sha256.New()
+	var size int64
+	chunks := 0
+	for i := 1; i <= max(n, 1); i++ {
+		part := data
+		if n > 0 {
+			var err error
+			if part, err = a.vaultClient.KVGet(opts.KVMount, utils.ShardPath(opts.KVPath, i)); err != nil {
+				return fmt.Errorf("read part %d of %d of %s: %w", i, n, source, err)
+			}
+		}
+		if m, ok := utils.ReadFileManifest(part); ok {
+			manifest = m
+		}
+		for _, key := range utils.ChunkKeys(part) {
+			if chunks++; key != utils.ChunkKey(chunks) {
+				return fmt.Errorf("%s: chunk %d is missing", source, chunks)
+			}
+			content, err := decode(part, key)
+			if err != nil {
+				return err
+			}
+			hash.Write(content)
+			size += int64(len(content))
+			if _, err := w.Write(content); err != nil {
+				return err
+			}
+		}
+	}
+
+	if manifest == nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s is not a file stored with put --from-file", source))
+	}
+	if chunks != manifest.Chunks || size != manifest.Size || hex.EncodeToString(hash.Sum(nil)) != manifest.SHA256 {
+		return fmt.Errorf("%s: content does not match its manifest (%d of %d chunks, %d of %d bytes); it may have been written partially", source, chunks, manifest.Chunks, size, manifest.Size)
+	}
+	return nil
+}
@@ -0,0 +1,363 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// Ways run and get handle an expired --env-file
+const (
+	ExpiredRefuse = "refuse"
+	ExpiredWarn   = "warn"
+	ExpiredResync = "resync"
+)
+
+// expiryHeaderPrefix starts the comment line sync --ttl writes at the top of
+// a file
+const expiryHeaderPrefix = "# vlt: expires "
+
+// generatedState lists the files sync has written, so clean can find
+// expired ones and run can regenerate them. Paths are absolute.
+type generatedState struct {
+	Files []generatedFile `json:"files"`
+}
+
+// generatedFile is one file written by sync
+type generatedFile struct {
+	Path      string      `json:"path"`
+	WrittenAt time.Time   `json:"written_at"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+	Sync      *syncRecord `json:"sync,omitempty"` // how to write it again
+}
+
+// syncRecord holds the sync options needed to regenerate a file. Relative
+// paths are resolved against Dir.
+type syncRecord struct {
+	Dir           string        `json:"dir"`
+	ConfigFile    string        `json:"config"`
+	OutputFile    string        `json:"output,omitempty"`
+	Format        string        `json:"format,omitempty"`
+	MapName       string        `json:"map,omitempty"`
+	EncryptionKey string        `json:"encryption_key,omitempty"`
+	KVMount       string        `json:"kv_mount,omitempty"`
+	TransitMount  string        `json:"transit_mount,omitempty"`
+	Namespace     string        `json:"namespace,omitempty"`
+	OnlyTags      []string      `json:"only,omitempty"`
+	SkipTags      []string      `json:"skip,omitempty"`
+	Mode          os.FileMode   `json:"mode,omitempty"`
+	EncryptOutput string        `json:"encrypt_output,omitempty"`
+	TTL           time.Duration `json:"ttl,omitempty"`
+}
+
+// CleanOptions contains options for the Clean operation
+type CleanOptions struct {
+	All    bool // remove every tracked file, not only expired ones
+	DryRun bool // list the files without removing them
+}
+
+// stateDir returns vlt's state directory, $XDG_STATE_HOME/vlt or
+// ~/.local/state/vlt, or "" when neither can be determined
+func stateDir() string {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(base, "vlt")
+}
+
+// generatedStatePath returns the state file listing generated files,
+// $XDG_STATE_HOME/vlt/generated.json or ~/.local/state/vlt/generated.json
+func generatedStatePath() string {
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "generated.json")
+}
+
+// readGeneratedState loads the state file; a missing file is an empty state
+func readGeneratedState() (*generatedState, error) {
+	state := &generatedState{}
+	path := generatedStatePath()
+	if path == "" {
+		return state, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return state, nil
+}
+
+func writeGeneratedState(state *generatedState) error {
+	path := generatedStatePath()
+	if path == "" {
+		return nil
+	}
+	sort.Slice(state.Files, func(i, j int) bool { return state.Files[i].Path < state.Files[j].Path })
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create state directory: %w", err)
+	}
+	if err := writeFileAtomic(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// find returns the entry of an absolute path, or nil
+func (s *generatedState) find(path string) *generatedFile {
+	for i := range s.Files {
+		if s.Files[i].Path == path {
+			return &s.Files[i]
+		}
+	}
+	return nil
+}
+
+// remove drops the entry of an absolute path
+func (s *generatedState) remove(path string) {
+	files := s.Files[:0]
+	for _, f := range s.Files {
+		if f.Path != path {
+			files = append(files, f)
+		}
+	}
+	s.Files = files
+}
+
+// recordGenerated adds the files of one sync to the state file. Failing to
+// record them does not fail the sync.
+func recordGenerated(outputs []renderedOutput, opts *SyncOptions, expiresAt *time.Time) {
+	dir := opts.WorkDir
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return
+		}
+	}
+	record := &syncRecord{
+		Dir:           dir,
+		ConfigFile:    opts.ConfigFile,
+		OutputFile:    opts.OutputFile,
+		Format:        opts.Format,
+		MapName:       opts.MapName,
+		EncryptionKey: opts.EncryptionKey,
+		KVMount:       opts.KVMount,
+		TransitMount:  opts.TransitMount,
+		Namespace:     opts.Namespace,
+		OnlyTags:      opts.OnlyTags,
+		SkipTags:      opts.SkipTags,
+		Mode:          opts.Mode,
+		EncryptOutput: opts.EncryptOutput,
+		TTL:           opts.TTL,
+	}
+
+	unlock, err := lockGeneratedState()
+	if err != nil {
+		warnf("cannot record generated files: %v\n", err)
+		return
+	}
+	defer unlock()
+	state, err := readGeneratedState()
+	if err != nil {
+		warnf("cannot record generated files: %v\n", err)
+		return
+	}
+	now := time.Now().UTC()
+	for _, output := range outputs {
+		path, err := filepath.Abs(output.Path)
+		if err != nil {
+			continue
+		}
+		state.remove(path)
+		entry := generatedFile{Path: path, WrittenAt: now}
+		if !output.DropIn {
+			entry.ExpiresAt = expiresAt
+			entry.Sync = record
+		}
+		state.Files = append(state.Files, entry)
+	}
+	if err := writeGeneratedState(state); err != nil {
+		warnf("cannot record generated files: %v\n", err)
+	}
+}
+
+// withExpiryHeader prepends the comment recording when a file expires, with
+// the line ending of content
+func withExpiryHeader(content []byte, expiresAt time.Time) []byte {
+	header := expiryHeaderPrefix + expiresAt.UTC().Format(time.RFC3339) + " (written by vlt sync --ttl)\n"
+	if utils.UsesCRLF(content) {
+		header = strings.TrimSuffix(header, "\n") + "\r\n"
+	}
+	return append([]byte(header), content...)
+}
+
+// stripExpiryHeader removes the header written by withExpiryHeader
+func stripExpiryHeader(content []byte) []byte {
+	if !bytes.HasPrefix(content, []byte(expiryHeaderPrefix)) {
+		return content
+	}
+	if i := bytes.IndexByte(content, '\n'); i >= 0 {
+		return content[i+1:]
+	}
+	return nil
+}
+
+// fileExpiry returns the expiry recorded in the header of a file's plaintext
+func fileExpiry(content []byte) (time.Time, bool) {
+	line, _, _ := bufio.NewReader(bytes.NewReader(content)).ReadLine()
+	rest, ok := strings.CutPrefix(strings.TrimSuffix(string(line), "\r"), expiryHeaderPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	stamp, _, _ := strings.Cut(rest, " ")
+	expiresAt, err := time.Parse(time.RFC3339, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// checkExpiry applies the --expired policy to a file's plaintext. It reports
+// whether the file has to be regenerated before it is used.
+func checkExpiry(path string, content []byte, policy string) (bool, error) {
+	expiresAt, ok := fileExpiry(content)
+	if !ok || time.Now().Before(expiresAt) {
+		return false, nil
+	}
+
+	age := time.Since(expiresAt).Round(time.Second)
+	switch policy {
+	case ExpiredWarn:
+		warnf("%s expired %s ago; its secrets may be out of date\n", path, age)
+		return false, nil
+	case ExpiredResync:
+		statusf("%s expired %s ago; syncing it again\n", path, age)
+		return true, nil
+	default:
+		return false, WithExitCode(ExitUsage, fmt.Errorf("%s expired %s ago; run vlt sync again (or pass --expired warn or --expired resync)", path, age))
+	}
+}
+
+// resync writes a generated file again with the options recorded when it
+// was last written
+func (a *App) resync(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	state, err := readGeneratedState()
+	if err != nil {
+		return err
+	}
+	entry := state.find(abs)
+	if entry == nil || entry.Sync == nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s has expired and there is no record of how it was generated; run vlt sync again", path))
+	}
+
+	record := entry.Sync
+	opts := &SyncOptions{
+		ConfigFile:    record.ConfigFile,
+		OutputFile:    record.OutputFile,
+		WorkDir:       record.Dir,
+		Format:        record.Format,
+		MapName:       record.MapName,
+		EncryptionKey: record.EncryptionKey,
+		KVMount:       record.KVMount,
+		TransitMount:  record.TransitMount,
+		Namespace:     record.Namespace,
+		OnlyTags:      record.OnlyTags,
+		SkipTags:      record.SkipTags,
+		Mode:          record.Mode,
+		EncryptOutput: record.EncryptOutput,
+		TTL:           record.TTL,
+	}
+	if !isRemoteConfig(opts.ConfigFile) && !filepath.IsAbs(opts.ConfigFile) {
+		opts.ConfigFile = filepath.Join(record.Dir, opts.ConfigFile)
+	}
+	return a.GenerateEnvFile(opts)
+}
+
+// Clean securely deletes the generated files whose TTL has passed (or all
+// tracked files) and forgets files that no longer exist
+func Clean(opts *CleanOptions) error {
+	unlock, err := lockGeneratedState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := readGeneratedState()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	removed, failed := 0, 0
+	kept := state.Files[:0]
+	for _, f := range state.Files {
+		if _, err := os.Lstat(f.Path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		expired := f.ExpiresAt != nil && now.After(*f.ExpiresAt)
+		if !expired && !opts.All {
+			kept = append(kept, f)
+			continue
+		}
+
+		reason := "tracked"
+		if expired {
+			reason = fmt.Sprintf("expired %s ago", now.Sub(*f.ExpiresAt).Round(time.Second))
+		}
+		if opts.DryRun {
+			infof("Would remove %s (%s)\n", f.Path, reason)
+			kept = append(kept, f)
+			continue
+		}
+		if err := utils.ShredFile(f.Path); err != nil {
+			warnf("cannot remove %s: %v\n", f.Path, err)
+			kept = append(kept, f)
+			failed++
+			continue
+		}
+		infof("Removed %s (%s)\n", f.Path, reason)
+		removed++
+	}
+	state.Files = kept
+
+	if !opts.DryRun {
+		if err := writeGeneratedState(state); err != nil {
+			return err
+		}
+		if removed == 0 && failed == 0 {
+			infof("Nothing to remove\n")
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be removed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// loadGlobEntry loads every secret matched by a wildcard path entry. Env var
+// names are prefixed with each secret's path relative to the wildcard, so
+// DB_URL of app/billing becomes BILLING_DB_URL for "app/*".
+func (a *App) loadGlobEntry(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (map[string]string, error) {
+	base, recursive, _ := secret.PathGlob()
+	paths, err := a.globPaths(cfg.GetKVMountFor(secret, kvMount), base, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	envVars := make(map[string]string)
+	for _, path := range paths {
+		namePrefix := config.SanitizeEnvName(strings.TrimPrefix(path, strings.Trim(base, "/")+"/")) + "_"
+		vars, err := a.loadKeysFromPath(cfg, secret, path, namePrefix, kvMount, transitMount, encryptionKey)
+		if errors.Is(err, vault.ErrSecretNotFound) {
+			// Deleted secrets are still listed until their metadata is destroyed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range vars {
+			envVars[k] = v
+		}
+	}
+
+	if len(envVars) == 0 {
+		return nil, WithExitCode(ExitNotFound, fmt.Errorf("no secrets found under %s", secret.Path))
+	}
+	return envVars, nil
+}
+
+// globPaths returns the secret paths directly under base, or every secret
+// below it when recursive, in sorted order
+func (a *App) globPaths(mount, base string, recursive bool) ([]string, error) {
+	base = strings.Trim(base, "/")
+	keys, err := a.vaultClient.KVList(mount, base)
+	if err != nil {
+		return nil, fmt.Errorf("kv list %s: %w", withTrailingSlash(base), err)
+	}
+	sort.Strings(keys)
+
+	var paths []string
+	for _, key := range keys {
+		fullPath := withTrailingSlash(base) + key
+		if !strings.HasSuffix(key, "/") {
+			paths = append(paths, fullPath)
+			continue
+		}
+		if !recursive {
+			continue
+		}
+		children, err := a.globPaths(mount, fullPath, true)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, children...)
+	}
+	return paths, nil
+}
@@ -0,0 +1,235 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// guardMinSecretLength is the shortest secret value guard looks for; shorter
+// values match too much unrelated text
+const guardMinSecretLength = 8
+
+// vaultTokenPattern matches Vault service, batch, and recovery tokens
+var vaultTokenPattern = regexp.MustCompile(`\bhv[sbr]\.[A-Za-z0-9_-]{20,}`)
+
+// GuardOptions contains options for the Guard operation
+type GuardOptions struct {
+	Files         []string // files to scan; the staged files when empty
+	ConfigFile    string   // config whose secrets and outputs are looked for (none when empty)
+	NoVault       bool     // skip resolving the config's secrets
+	AppOptions    *Options // Vault connection used to resolve the config
+	EncryptionKey string
+	KVMount       string
+	TransitMount  string
+	Namespace     string // overrides the client namespace for this command
+}
+
+// guardFinding is a problem found in a file
+type guardFinding struct {
+	File   string
+	Line   int // 0 for the file as a whole
+	Reason string
+}
+
+// guardScanner looks for secrets in file contents. Secret values are held
+// only as SHA-256 hashes.
+type guardScanner struct {
+	secrets map[[sha256.Size]byte]string // hash of a value -> env var name
+	outputs map[string]bool              // absolute paths of files sync writes
+}
+
+// Guard scans files about to be committed and fails when one contains a
+// value of a secret the config resolves to, a Vault token, or is a generated
+// .env file. It is meant to run as a git pre-commit hook.
+func Guard(opts *GuardOptions) error {
+	scanner := &guardScanner{
+		secrets: make(map[[sha256.Size]byte]string),
+		outputs: make(map[string]bool),
+	}
+	if opts.ConfigFile != "" {
+		scanner.loadConfig(opts)
+	}
+
+	files := opts.Files
+	staged := len(files) == 0
+	root := ""
+	if staged {
+		var err error
+		if root, err = utils.GitRoot(); err != nil {
+			return err
+		}
+		if files, err = utils.StagedFiles(); err != nil {
+			return err
+		}
+	}
+
+	var findings []guardFinding
+	for _, file := range files {
+		var content []byte
+		var err error
+		path := file
+		if staged {
+			content, err = utils.StagedContent(file)
+			path = filepath.Join(root, file)
+		} else {
+			content, err = os.ReadFile(file)
+		}
+		if err != nil {
+			warnf("cannot read %s: %v\n", file, err)
+			continue
+		}
+		findings = append(findings, scanner.scan(file, path, content)...)
+	}
+
+	if len(findings) == 0 {
+		infof("guard: %d file(s) checked, no secrets found\n", len(files))
+		return nil
+	}
+
+	painter := utils.NewPainter(os.Stdout)
+	for _, f := range findings {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		fmt.Printf("%s: %s\n", painter.Paint(location, utils.Bold), painter.Paint(f.Reason, utils.Red))
+	}
+	return fmt.Errorf("guard: %d problem(s) found; unstage the files or remove the secrets (git commit --no-verify skips the check)", len(findings))
+}
+
+// loadConfig records the outputs of the config and the hashes of the secret
+// values it resolves to. Without a usable config or Vault connection only the
+// other checks run.
+func (s *guardScanner) loadConfig(opts *GuardOptions) {
+	a, err := New(opts.AppOptions)
+	if err != nil {
+		warnf("cannot connect to Vault (%v); secret values are not checked\n", err)
+		return
+	}
+	cfg, err := a.LoadConfig(opts.ConfigFile, false)
+	if err != nil {
+		warnf("cannot load %s (%v); secret values are not checked\n", opts.ConfigFile, err)
+		return
+	}
+
+	if !isRemoteConfig(opts.ConfigFile) {
+		baseDir := filepath.Dir(opts.ConfigFile)
+		for _, output := range cfg.Outputs {
+			path := output.File
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(baseDir, path)
+			}
+			if abs, err := filepath.Abs(path); err == nil {
+				s.outputs[abs] = true
+			}
+		}
+	}
+
+	if opts.NoVault {
+		return
+	}
+	a = a.withNamespace(opts.Namespace)
+	entryVars, err := a.loadConfigEntries(cfg, opts.KVMount, opts.TransitMount, a.effectiveEncryptionKey(opts.EncryptionKey))
+	if err != nil {
+		warnf("cannot load secrets from %s (%v); secret values are not checked\n", opts.ConfigFile, err)
+		return
+	}
+	for i, vars := range entryVars {
+		if cfg.Secrets[i].IsLiteral() {
+			continue
+		}
+		for name, value := range vars {
+			if len(value) >= guardMinSecretLength {
+				s.secrets[sha256.Sum256([]byte(value))] = name
+			}
+		}
+	}
+}
+
+// scan checks the content of one file; path is its location on disk
+func (s *guardScanner) scan(name, path string, content []byte) []guardFinding {
+	var findings []guardFinding
+	if reason := s.generatedFile(path); reason != "" {
+		findings = append(findings, guardFinding{File: name, Reason: reason})
+	}
+
+	// Binary files cannot be scanned line by line
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		return findings
+	}
+
+	lines := bufio.NewScanner(bytes.NewReader(content))
+	lines.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+	for n := 1; lines.Scan(); n++ {
+		line := lines.Text()
+		if token := vaultTokenPattern.FindString(line); token != "" {
+			findings = append(findings, guardFinding{File: name, Line: n, Reason: fmt.Sprintf("Vault token %s****", token[:4])})
+		}
+		if len(s.secrets) == 0 {
+			continue
+		}
+		for _, candidate := range guardCandidates(line) {
+			if envVar, ok := s.secrets[sha256.Sum256([]byte(candidate))]; ok {
+				findings = append(findings, guardFinding{File: name, Line: n, Reason: fmt.Sprintf("value of secret %s", envVar)})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// generatedFile explains why path looks like a file written by sync, or
+// returns ""
+func (s *guardScanner) generatedFile(path string) string {
+	if abs, err := filepath.Abs(path); err == nil && s.outputs[abs] {
+		return "generated by vlt sync (listed in the config's outputs)"
+	}
+
+	base := filepath.Base(path)
+	if base != ".env" && !strings.HasPrefix(base, ".env.") {
+		return ""
+	}
+	for _, suffix := range []string{".example", ".sample", ".template", ".dist", ".defaults"} {
+		if strings.HasSuffix(base, suffix) {
+			return ""
+		}
+	}
+	return ".env files hold secrets and should not be committed (add it to .gitignore)"
+}
+
+// guardCandidates returns the strings of a line that may be a whole secret
+// value: the line, the value after the first "=" or ":", and its words
+func guardCandidates(line string) []string {
+	seen := make(map[string]bool)
+	var candidates []string
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		value = strings.TrimRight(value, ",;")
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if len(value) >= guardMinSecretLength && !seen[value] {
+			seen[value] = true
+			candidates = append(candidates, value)
+		}
+	}
+
+	add(line)
+	if i := strings.IndexAny(line, "=:"); i >= 0 {
+		add(line[i+1:])
+	}
+	for _, word := range strings.FieldsFunc(line, func(r rune) bool {
+		return strings.ContainsRune(" \t\"'`,;=()[]{}<>", r)
+	}) {
+		add(word)
+	}
+	return candidates
+}
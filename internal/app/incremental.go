@@ -0,0 +1,244 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// versionsSuffix is appended to the output path to name the state file of an
+// incremental sync
+const versionsSuffix = ".vlt-versions"
+
+// syncVersions is the state file sync --incremental keeps next to its
+// output: the KV version each entry was loaded from and the variables it set.
+// It holds no values, only a hash of them.
+type syncVersions struct {
+	Entries []entryVersion `json:"entries"`
+}
+
+// entryVersion records one config entry loaded from a versioned KV path
+type entryVersion struct {
+	Entry   string   `json:"entry"`   // hash of the entry, its naming policy, and where it is read from
+	Path    string   `json:"path"`    // mount/path, for people reading the file
+	Version int      `json:"version"` // current_version of the path when it was loaded
+	Vars    []string `json:"vars"`
+	Hash    string   `json:"hash"` // SHA-256 of the variables and their values
+}
+
+// versionTracker reuses the values of unchanged entries from the existing
+// output during an incremental sync and records the versions of all of them
+type versionTracker struct {
+	path     string                  // the state file
+	previous map[string]entryVersion // by entry hash
+	current  map[string]string       // variables of the existing output
+
+	mu      sync.Mutex
+	next    []entryVersion
+	reused  int
+	checked int
+}
+
+// versionsPath returns the state file of an incremental sync writing output
+func versionsPath(output string) string {
+	return output + versionsSuffix
+}
+
+// readVersions loads the state of the last incremental sync of output and
+// the variables in it. Without a state file, or an output to take values
+// from, every entry is loaded.
+func readVersions(output string) (*versionTracker, error) {
+	t := &versionTracker{path: versionsPath(output), previous: make(map[string]entryVersion)}
+
+	data, err := os.ReadFile(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", t.path, err)
+	}
+	var state syncVersions
+	if err := json.Unmarshal(data, &state); err != nil {
+		warnf("ignoring %s: %v\n", t.path, err)
+		return t, nil
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil || encryptedEnvFile(content) {
+		return t, nil
+	}
+	if t.current, err = godotenv.UnmarshalBytes(content); err != nil {
+		return t, nil
+	}
+	for _, entry := range state.Entries {
+		t.previous[entry.Entry] = entry
+	}
+	return t, nil
+}
+
+// withVersions returns an app that loads config entries through t
+func (a *App) withVersions(t *versionTracker) *App {
+	scoped := *a
+	scoped.versions = t
+	return &scoped
+}
+
+// versionedPath returns the single KV path an entry reads, or "" for entries
+// that read none or several
+func versionedPath(secret *config.SecretEntry) string {
+	switch {
+	case secret.IsLiteral(), secret.IsTemplate(), secret.IsPathGlob():
+		return ""
+	case secret.IsPathAllKeys(), secret.IsPathSingleKey():
+		return secret.Path
+	case secret.IsIndividual():
+		return secret.KVPath
+	}
+	return ""
+}
+
+// load returns the variables of an entry. When its KV path is at the
+// version recorded by the last sync and the output still holds the values it
+// set then, they are taken from the output without reading or decrypting the
+// secret; otherwise the entry is loaded. Paths whose metadata cannot be read
+// (KV v1 mounts, policies without metadata access) are always loaded.
+func (t *versionTracker) load(a *App, cfg *config.Config, secret *config.SecretEntry, kvMount string, loadEntry func() (map[string]string, error)) (map[string]string, error) {
+	kvPath := versionedPath(secret)
+	if kvPath == "" {
+		return loadEntry()
+	}
+	mount := cfg.GetKVMountFor(secret, kvMount)
+	meta, err := a.vaultClient.KVGetMetadata(mount, kvPath)
+	if err != nil || meta.CurrentVersion == 0 {
+		return loadEntry()
+	}
+
+	id := entryHash(a, cfg, secret, mount)
+	if vars, ok := t.unchanged(id, meta.CurrentVersion); ok {
+		return vars, nil
+	}
+	vars, err := loadEntry()
+	if err == nil && len(vars) > 0 {
+		t.record(id, strings.Trim(mount, "/")+"/"+strings.Trim(kvPath, "/"), meta.CurrentVersion, vars)
+	}
+	return vars, err
+}
+
+// unchanged returns the values an entry set at version from the existing
+// output, if they are all still there
+func (t *versionTracker) unchanged(id string, version int) (map[string]string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.checked++
+
+	prev, ok := t.previous[id]
+	if !ok || prev.Version != version {
+		return nil, false
+	}
+	vars := make(map[string]string, len(prev.Vars))
+	for _, name := range prev.Vars {
+		value, ok := t.current[name]
+		if !ok {
+			return nil, false
+		}
+		vars[name] = value
+	}
+	// A later entry setting the same variable, or an edit of the output,
+	// changes the value the output holds for this entry
+	if valuesHash(vars) != prev.Hash {
+		return nil, false
+	}
+	t.next = append(t.next, prev)
+	t.reused++
+	return vars, true
+}
+
+// record remembers the version an entry was loaded at
+func (t *versionTracker) record(id, path string, version int, vars map[string]string) {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.next = append(t.next, entryVersion{Entry: id, Path: path, Version: version, Vars: names, Hash: valuesHash(asWritten(vars))})
+}
+
+// save writes the versions recorded during this sync to the state file
+func (t *versionTracker) save() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sort.Slice(t.next, func(i, j int) bool { return t.next[i].Path+t.next[i].Entry < t.next[j].Path+t.next[j].Entry })
+	data, err := json.MarshalIndent(syncVersions{Entries: t.next}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", t.path, err)
+	}
+	if err := writeFileAtomic(t.path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write %s: %w", t.path, err)
+	}
+	return nil
+}
+
+// summary reports how many versioned paths were unchanged since the last sync
+func (t *versionTracker) summary() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.checked > 0 {
+		infof("%d of %d versioned path(s) unchanged since the last sync\n", t.reused, t.checked)
+	}
+}
+
+// entryHash identifies an entry as loaded by a particular server and
+// namespace, so editing the entry or where it is read from loads it again
+func entryHash(a *App, cfg *config.Config, secret *config.SecretEntry, mount string) string {
+	data, _ := json.Marshal(struct {
+		Address   string
+		Namespace string
+		Mount     string
+		Entry     *config.SecretEntry
+		Naming    config.NamingPolicy
+	}{a.vaultClient.Address(), a.vaultClient.Namespace(), strings.Trim(mount, "/"), secret, secret.NamingPolicy.Merge(cfg.NamingPolicy)})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// asWritten returns vars as they read back from an env file, which drops
+// what the format cannot hold, such as trailing spaces
+func asWritten(vars map[string]string) map[string]string {
+	content, err := utils.Render(vars, utils.RenderOptions{Format: utils.FormatEnv})
+	if err != nil {
+		return vars
+	}
+	parsed, err := godotenv.UnmarshalBytes(content)
+	if err != nil {
+		return vars
+	}
+	return parsed
+}
+
+// valuesHash hashes variables and their values in name order
+func valuesHash(vars map[string]string) string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s=%s\x00", name, vars[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
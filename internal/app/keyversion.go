@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// withKeyVersion returns an app whose Transit encryptions use the given key
+// version, or a itself when version is 0 (the latest version)
+func (a *App) withKeyVersion(version int) *App {
+	if version == 0 {
+		return a
+	}
+	scoped := *a
+	scoped.vaultClient = a.vaultClient.WithKeyVersion(version)
+	return &scoped
+}
+
+// checkKeyVersion enforces transit.min_key_version on the secret data read
+// from source, limited to keys when any are given. Values encrypted with an
+// older key version fail, or only warn with min_key_version_action: warn.
+func checkKeyVersion(cfg *config.Config, source string, data map[string]any, keys ...string) error {
+	minVersion, action := cfg.KeyVersionPolicy()
+	if minVersion <= 0 {
+		return nil
+	}
+	if action != config.KeyVersionFail && action != config.KeyVersionWarn {
+		return WithExitCode(ExitUsage, fmt.Errorf("transit.min_key_version_action must be %q or %q, got %q", config.KeyVersionFail, config.KeyVersionWarn, action))
+	}
+
+	var stale []string
+	for key, value := range utils.EncryptedKeys(data) {
+		if len(keys) > 0 && !slices.Contains(keys, key) {
+			continue
+		}
+		// Values encrypted client-side (encryption: local) have no key version
+		if value.Version > 0 && value.Version < minVersion {
+			stale = append(stale, fmt.Sprintf("%s (v%d)", key, value.Version))
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(stale)
+
+	msg := fmt.Sprintf("%s: %s encrypted with a key version below transit.min_key_version %d; re-encrypt them with vlt plan and vlt apply", source, strings.Join(stale, ", "), minVersion)
+	if action == config.KeyVersionWarn {
+		warnf("%s\n", msg)
+		return nil
+	}
+	return WithExitCode(ExitDecrypt, fmt.Errorf("%s", msg))
+}
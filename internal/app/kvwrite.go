@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// casAttempts is how many times a KV write is tried before giving up on a
+// secret that keeps changing underneath it
+const casAttempts = 5
+
+// unknownVersion marks a secret whose version could not be read, which is
+// then written without check-and-set
+const unknownVersion = -1
+
+// readForWrite returns the data at a KV path and the version it was read
+// at. A missing secret is empty at the version a first write must name; a
+// secret that cannot be read is empty at unknownVersion.
+func (a *App) readForWrite(kvMount, kvPath string) (map[string]interface{}, int) {
+	data, version, err := a.vaultClient.KVGetVersion(kvMount, kvPath)
+	switch {
+	case err == nil:
+		return data, version
+	case vault.IsNotFound(err):
+		return make(map[string]interface{}), version
+	default:
+		return make(map[string]interface{}), unknownVersion
+	}
+}
+
+// putMerged writes data to a KV path whose contents were base at version.
+// The write uses check-and-set, so a concurrent writer is never overwritten
+// blindly: when the secret has changed since it was read, the keys data
+// adds, changes, or removes relative to base are applied to the latest
+// contents and the write is tried again. It returns the data written.
+func (a *App) putMerged(kvMount, kvPath string, base, data map[string]interface{}, version int) (map[string]interface{}, error) {
+	if version == unknownVersion {
+		if err := a.vaultClient.KVPut(kvMount, kvPath, data); err != nil {
+			return nil, fmt.Errorf("kv put: %w", err)
+		}
+		return data, nil
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := a.vaultClient.KVPutCAS(kvMount, kvPath, data, version)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, vault.ErrCASMismatch) {
+			return nil, fmt.Errorf("kv put: %w", err)
+		}
+		if attempt == casAttempts {
+			return nil, fmt.Errorf("kv put: %s/%s kept changing during %d attempts: %w", kvMount, kvPath, casAttempts, err)
+		}
+
+		// Back off with jitter so writers that collided do not collide again
+		time.Sleep(time.Duration(attempt)*50*time.Millisecond + rand.N(50*time.Millisecond))
+
+		latest, latestVersion := a.readForWrite(kvMount, kvPath)
+		if latestVersion == unknownVersion {
+			return nil, fmt.Errorf("kv put: %s/%s changed while writing and cannot be read again: %w", kvMount, kvPath, err)
+		}
+		statusf("%s/%s changed while writing; merging with version %d\n", kvMount, kvPath, latestVersion)
+		if data, err = rebaseData(base, data, latest); err != nil {
+			return nil, fmt.Errorf("kv put: %s/%s: %w", kvMount, kvPath, err)
+		}
+		base, version = latest, latestVersion
+	}
+}
+
+// rebaseData applies the keys data adds, changes, or removes relative to
+// base onto latest. A single-value secret is replaced whole, and a
+// single-value base or latest counts as empty, as it does for a merge. A
+// key data changes that was deleted from latest is a conflict rather than
+// something to bring back.
+func rebaseData(base, data, latest map[string]interface{}) (map[string]interface{}, error) {
+	if isSingleValue(data) {
+		return data, nil
+	}
+	if isSingleValue(base) {
+		base = nil
+	}
+	if isSingleValue(latest) {
+		latest = nil
+	} else {
+		var deleted []string
+		for key, value := range utils.StripMeta(data) {
+			old, inBase := base[key]
+			_, inLatest := latest[key]
+			if inBase && !inLatest && !reflect.DeepEqual(old, value) {
+				deleted = append(deleted, key)
+			}
+		}
+		if len(deleted) > 0 {
+			sort.Strings(deleted)
+			return nil, fmt.Errorf("%s deleted by another writer while being changed; nothing was written", strings.Join(deleted, ", "))
+		}
+	}
+
+	result := utils.MergeData(latest, nil)
+	encrypted, ours := utils.EncryptedKeys(result), utils.EncryptedKeys(data)
+	for key, value := range utils.StripMeta(data) {
+		if old, ok := base[key]; !ok || !reflect.DeepEqual(old, value) {
+			result[key] = value
+			if e, ok := ours[key]; ok {
+				encrypted[key] = e
+			} else {
+				delete(encrypted, key)
+			}
+		}
+	}
+	for key := range base {
+		if _, ok := data[key]; !ok {
+			delete(result, key)
+		}
+	}
+	utils.SetMeta(result, encrypted)
+	return result, nil
+}
+
+func isSingleValue(data map[string]interface{}) bool {
+	return utils.IsEncryptedSingleValue(data) || utils.IsPlaintextSingleValue(data)
+}
@@ -0,0 +1,300 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// Severities of lint-values findings
+const (
+	lintError   = "error"
+	lintWarning = "warning"
+)
+
+// DefaultLintMinLength is the shortest credential value lint-values accepts
+// unless --min-length is given
+const DefaultLintMinLength = 12
+
+// placeholderValues are values left over from examples and templates
+var placeholderValues = map[string]bool{
+	"changeme": true, "change_me": true, "change-me": true, "password": true,
+	"secret": true, "todo": true, "placeholder": true, "example": true, "xxx": true,
+}
+
+// credentialNameParts mark variable names holding credentials, for which the
+// minimum length applies
+var credentialNameParts = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "KEY", "CREDENTIAL"}
+
+// LintOptions contains options for the LintValues operation
+type LintOptions struct {
+	ConfigFile     string
+	CompareConfigs []string      // configs of other environments whose values must differ
+	MinLength      int           // shortest accepted credential value (0 for the default)
+	ExpiryWindow   time.Duration // report credentials expiring within this window (0 for the default)
+	Strict         bool          // treat warnings as errors
+	EncryptionKey  string
+	KVMount        string
+	TransitMount   string
+	Namespace      string // overrides the client namespace for this command
+	OnlyTags       []string
+	SkipTags       []string
+	OutputJSON     bool
+}
+
+// LintFinding is a hygiene problem with one secret value. Values are never
+// included.
+type LintFinding struct {
+	Severity string `json:"severity"`
+	EnvVar   string `json:"env_var"`
+	Entry    string `json:"entry"`
+	Message  string `json:"message"`
+}
+
+// LintValues resolves the secrets of a config and reports values that are
+// empty, placeholders, too short, shared with another environment, expired or
+// expiring certificates, tokens, and cloud credentials, or private keys
+// without a passphrase. It fails when any finding is an error.
+func (a *App) LintValues(opts *LintOptions) error {
+	cfg, entryVars, err := a.lintLoad(opts.ConfigFile, opts)
+	if err != nil {
+		return err
+	}
+
+	// Values of the other environments, compared by hash
+	others := make([]map[string][sha256.Size]byte, len(opts.CompareConfigs))
+	for i, path := range opts.CompareConfigs {
+		otherCfg, otherVars, err := a.lintLoad(path, opts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		others[i] = make(map[string][sha256.Size]byte)
+		for j, vars := range otherVars {
+			if otherCfg.Secrets[j].IsLiteral() {
+				continue
+			}
+			for name, value := range vars {
+				others[i][name] = sha256.Sum256([]byte(value))
+			}
+		}
+	}
+
+	minLength := opts.MinLength
+	if minLength == 0 {
+		minLength = DefaultLintMinLength
+	}
+	window := opts.ExpiryWindow
+	if window == 0 {
+		window = DefaultExpiryWindow
+	}
+
+	var findings []LintFinding
+	for i, vars := range entryVars {
+		entry := &cfg.Secrets[i]
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			value := vars[name]
+			add := func(severity, format string, args ...any) {
+				findings = append(findings, LintFinding{severity, name, entry.Describe(), fmt.Sprintf(format, args...)})
+			}
+
+			for _, problem := range lintValue(name, value, minLength, window) {
+				add(problem.Severity, "%s", problem.Message)
+			}
+			if entry.IsLiteral() || value == "" {
+				continue
+			}
+			sum := sha256.Sum256([]byte(value))
+			for j, other := range others {
+				if otherSum, ok := other[name]; ok && otherSum == sum {
+					add(lintWarning, "same value as in %s", opts.CompareConfigs[j])
+				}
+			}
+		}
+	}
+
+	errors := 0
+	for i, f := range findings {
+		if opts.Strict && f.Severity == lintWarning {
+			findings[i].Severity = lintError
+		}
+		if findings[i].Severity == lintError {
+			errors++
+		}
+	}
+
+	if opts.OutputJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if findings == nil {
+			findings = []LintFinding{}
+		}
+		if err := enc.Encode(findings); err != nil {
+			return fmt.Errorf("marshal json: %w", err)
+		}
+	} else {
+		printLintReport(findings, errors)
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("%d secret value(s) failed lint", errors)
+	}
+	return nil
+}
+
+// lintLoad loads the values of every entry of a config
+func (a *App) lintLoad(path string, opts *LintOptions) (*config.Config, []map[string]string, error) {
+	cfg, err := a.LoadConfig(path, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+
+	scoped := a.withNamespace(opts.Namespace)
+	entryVars, err := scoped.loadConfigEntries(cfg, opts.KVMount, opts.TransitMount, a.effectiveEncryptionKey(opts.EncryptionKey))
+	if err != nil {
+		return nil, nil, fmt.Errorf("load secrets from config: %w", err)
+	}
+	return cfg, entryVars, nil
+}
+
+// lintValue checks one value on its own
+func lintValue(name, value string, minLength int, window time.Duration) []LintFinding {
+	trimmed := strings.TrimSpace(value)
+	switch {
+	case trimmed == "":
+		return []LintFinding{{Severity: lintError, Message: "value is empty"}}
+	case placeholderValues[strings.ToLower(trimmed)]:
+		return []LintFinding{{Severity: lintError, Message: fmt.Sprintf("value is the placeholder %q", strings.ToLower(trimmed))}}
+	}
+
+	var findings []LintFinding
+	for _, expiry := range findExpiries(value) {
+		switch left := time.Until(expiry.NotAfter); {
+		case left <= 0:
+			findings = append(findings, LintFinding{Severity: lintError, Message: expiry.expiryMessage()})
+		case left < window:
+			findings = append(findings, LintFinding{Severity: lintWarning, Message: expiry.expiryMessage()})
+		}
+	}
+	if pemData := pemContent(value); pemData != nil {
+		return append(findings, lintPEM(pemData)...)
+	}
+
+	if credentialName(name) && len(value) < minLength {
+		findings = append(findings, LintFinding{Severity: lintWarning, Message: fmt.Sprintf("value is %d characters, shorter than %d", len(value), minLength)})
+	}
+	return findings
+}
+
+// credentialName reports whether a variable name looks like it holds a
+// password, token, or key
+func credentialName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, part := range credentialNameParts {
+		if strings.Contains(upper, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// pemContent returns value, or its base64 decoding (as stored by
+// put --from-file), when it contains a PEM block
+func pemContent(value string) []byte {
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value)
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil && bytes.Contains(decoded, []byte("-----BEGIN ")) {
+		return decoded
+	}
+	return nil
+}
+
+// lintPEM reports certificates that cannot be parsed and private keys that
+// are not protected by a passphrase
+func lintPEM(data []byte) []LintFinding {
+	var findings []LintFinding
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return findings
+		}
+
+		switch {
+		case block.Type == "CERTIFICATE":
+			if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+				findings = append(findings, LintFinding{Severity: lintWarning, Message: fmt.Sprintf("cannot parse certificate: %v", err)})
+			}
+		case strings.HasSuffix(block.Type, "PRIVATE KEY") && !privateKeyEncrypted(block):
+			findings = append(findings, LintFinding{Severity: lintWarning, Message: fmt.Sprintf("%s is not protected by a passphrase", strings.ToLower(block.Type))})
+		}
+	}
+}
+
+// privateKeyEncrypted reports whether a PEM private key is encrypted: PKCS#8
+// "ENCRYPTED PRIVATE KEY", a legacy Proc-Type header, or an OpenSSH key with
+// a cipher other than "none"
+func privateKeyEncrypted(block *pem.Block) bool {
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		return true
+	case strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED"):
+		return true
+	case block.Type == "OPENSSH PRIVATE KEY":
+		// openssh-key-v1\0, then the cipher name as a length-prefixed string
+		rest, ok := bytes.CutPrefix(block.Bytes, []byte("openssh-key-v1\x00"))
+		if !ok || len(rest) < 4 {
+			return false
+		}
+		n := binary.BigEndian.Uint32(rest)
+		if uint32(len(rest)-4) < n {
+			return false
+		}
+		return string(rest[4:4+n]) != "none"
+	}
+	return false
+}
+
+func printLintReport(findings []LintFinding, errors int) {
+	painter := utils.NewPainter(os.Stdout)
+	if len(findings) == 0 {
+		fmt.Println(painter.Paint("No problems found", utils.Green))
+		return
+	}
+
+	rows := make([][]string, 0, len(findings))
+	for _, f := range findings {
+		rows = append(rows, []string{f.Severity, f.EnvVar, f.Message, "(" + f.Entry + ")"})
+	}
+	_ = utils.WriteTable(os.Stdout, rows, painter, func(row, col int) []string {
+		switch col {
+		case 0:
+			return []string{statusStyle(findings[row].Severity)}
+		case 1:
+			return []string{utils.Bold}
+		case 3:
+			return []string{utils.Dim}
+		}
+		return nil
+	})
+	fmt.Printf("%d error(s), %d warning(s)\n", errors, len(findings)-errors)
+}
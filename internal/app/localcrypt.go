@@ -0,0 +1,200 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// Schemes of values encrypted with encryption: local, recorded after the
+// format version: vlt:local:v1:<scheme>:<key reference>:<sealed value>
+const (
+	localSchemeAge    = "age"    // the key reference is the data key encrypted with age
+	localSchemeScrypt = "scrypt" // the key reference is the salt the key is derived with
+	localFormat       = vault.LocalCiphertextPrefix + "v1:"
+)
+
+// scrypt parameters of passphrase-derived keys
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// localCipher envelope-encrypts values client-side with AES-256-GCM. One
+// data key is used per invocation: generated and encrypted to the age
+// recipients, or derived from the passphrase with a fresh salt. Keys opened
+// for decryption are kept by reference, so age or scrypt runs once per key.
+type localCipher struct {
+	settings *config.LocalEncryption
+
+	mu     sync.Mutex
+	ref    string // key reference of new values; empty until the first encryption
+	scheme string
+	keys   map[string][]byte // data keys by scheme and key reference
+}
+
+// newLocalCipher returns the cipher for values encrypted client-side, or nil
+// when cfg neither encrypts locally nor configures local_encryption
+func newLocalCipher(cfg *config.Config) (*localCipher, error) {
+	if err := cfg.ValidateEncryption(); err != nil {
+		return nil, WithExitCode(ExitUsage, err)
+	}
+	if !cfg.UsesLocalEncryption() && cfg.LocalEncryption == nil {
+		return nil, nil
+	}
+	settings := cfg.LocalEncryption
+	if settings == nil {
+		settings = &config.LocalEncryption{}
+	}
+	return &localCipher{settings: settings, keys: make(map[string][]byte)}, nil
+}
+
+// Encrypt seals plaintext under the invocation's data key
+func (c *localCipher) Encrypt(plaintext []byte) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ref == "" {
+		if err := c.newDataKey(); err != nil {
+			return "", err
+		}
+	}
+	gcm, err := newGCM(c.keys[c.scheme+":"+c.ref])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, plaintext, nil))
+	return localFormat + c.scheme + ":" + c.ref + ":" + sealed, nil
+}
+
+// newDataKey creates the data key of new values
+func (c *localCipher) newDataKey() error {
+	if len(c.settings.AgeRecipients) > 0 {
+		key := make([]byte, scryptKeyLen)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("generate data key: %w", err)
+		}
+		wrapped, err := utils.AgeEncrypt(key, c.settings.AgeRecipients, nil, false)
+		if err != nil {
+			return fmt.Errorf("age encrypt data key: %w", err)
+		}
+		c.scheme, c.ref = localSchemeAge, base64.StdEncoding.EncodeToString(wrapped)
+		c.keys[c.scheme+":"+c.ref] = key
+		return nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("generate salt: %w", err)
+	}
+	key, err := c.deriveKey(salt)
+	if err != nil {
+		return err
+	}
+	c.scheme, c.ref = localSchemeScrypt, base64.StdEncoding.EncodeToString(salt)
+	c.keys[c.scheme+":"+c.ref] = key
+	return nil
+}
+
+// Decrypt opens a value written by Encrypt
+func (c *localCipher) Decrypt(ciphertext string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(ciphertext, localFormat), ":")
+	if !strings.HasPrefix(ciphertext, localFormat) || len(parts) != 3 {
+		return nil, fmt.Errorf("%w: unsupported client-side encryption format", vault.ErrDecryptFailed)
+	}
+	scheme, ref, sealed64 := parts[0], parts[1], parts[2]
+
+	key, err := c.dataKey(scheme, ref)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(sealed64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode ciphertext: %w", vault.ErrDecryptFailed, err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: ciphertext is truncated", vault.ErrDecryptFailed)
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", vault.ErrDecryptFailed, err)
+	}
+	return plaintext, nil
+}
+
+// dataKey returns the data key a value was sealed with, opening it with the
+// age identity or deriving it from the passphrase the first time
+func (c *localCipher) dataKey(scheme, ref string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if key, ok := c.keys[scheme+":"+ref]; ok {
+		return key, nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: decode key reference: %w", vault.ErrDecryptFailed, err)
+	}
+
+	var key []byte
+	switch scheme {
+	case localSchemeAge:
+		if c.settings.AgeIdentity == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("value is encrypted with age; set local_encryption.age_identity"))
+		}
+		if key, err = utils.AgeDecryptData(raw, []string{expandHome(c.settings.AgeIdentity)}); err != nil {
+			return nil, fmt.Errorf("%w: %w", vault.ErrDecryptFailed, err)
+		}
+	case localSchemeScrypt:
+		if key, err = c.deriveKey(raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported client-side encryption scheme %q", vault.ErrDecryptFailed, scheme)
+	}
+	c.keys[scheme+":"+ref] = key
+	return key, nil
+}
+
+// deriveKey derives a data key from the configured passphrase
+func (c *localCipher) deriveKey(salt []byte) ([]byte, error) {
+	passphrase, name := c.settings.Passphrase()
+	if passphrase == "" {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("local encryption needs a passphrase in %s, or local_encryption.age_recipients", name))
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("derive key: %w", err)
+	}
+	return key, nil
+}
+
+// expandHome resolves a leading ~/ against the home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// lockTimeout is how long vlt waits for another vlt process to finish
+// writing a file before giving up
+const lockTimeout = 2 * time.Minute
+
+// lockDir returns the directory holding vlt's lock files
+func lockDir() string {
+	if dir := stateDir(); dir != "" {
+		return filepath.Join(dir, "locks")
+	}
+	return filepath.Join(os.TempDir(), "vlt-locks")
+}
+
+// lockPath returns the lock file guarding writes to target. Lock files live
+// in the state directory rather than next to target, so they never show up
+// in a project tree; they are named after the hash of the resolved path, so
+// every spelling of a path shares one lock.
+func lockPath(target string) (string, error) {
+	abs, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	} else if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		// target does not exist yet; resolve its directory so the lock is
+		// the same once it does
+		abs = filepath.Join(dir, filepath.Base(abs))
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(lockDir(), hex.EncodeToString(sum[:16])+".lock"), nil
+}
+
+// lockFiles takes the locks of paths, in a fixed order so two processes
+// locking overlapping sets cannot deadlock. The returned function releases
+// them.
+func lockFiles(paths ...string) (unlock func(), err error) {
+	locks := map[string]string{}
+	for _, path := range paths {
+		lock, err := lockPath(path)
+		if err != nil {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		locks[lock] = path
+	}
+	order := make([]string, 0, len(locks))
+	for lock := range locks {
+		order = append(order, lock)
+	}
+	sort.Strings(order)
+
+	var held []*utils.FileLock
+	unlock = func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].Unlock()
+		}
+	}
+	for _, lock := range order {
+		path := locks[lock]
+		l, err := utils.LockFile(lock, lockTimeout, func() {
+			statusf("Waiting for another vlt process writing %s\n", path)
+		})
+		if err != nil {
+			unlock()
+			return nil, fmt.Errorf("%s is being written by another vlt process: %w", path, err)
+		}
+		held = append(held, l)
+	}
+	return unlock, nil
+}
+
+// lockGeneratedState takes the lock of the generated files state, which
+// must be held from reading the state until writing it back
+func lockGeneratedState() (unlock func(), err error) {
+	path := generatedStatePath()
+	if path == "" {
+		return func() {}, nil
+	}
+	return lockFiles(path)
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file in the
+// same directory and renaming it over path, so readers see either the old
+// or the new content and never a partial write. A symlink at path is
+// followed, and the file it points to is replaced.
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	return writeFileAtomicFunc(path, mode, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomicFunc replaces path like writeFileAtomic with what write
+// writes, so large content can be streamed. Nothing replaces path when write
+// fails.
+func writeFileAtomicFunc(path string, mode os.FileMode, write func(io.Writer) error) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
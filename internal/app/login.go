@@ -0,0 +1,252 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/audit"
+	"github.com/razzkumar/vlt/internal/keychain"
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// Token stores for Login
+const (
+	TokenStoreKeychain = "keychain" // the OS keychain
+	TokenStoreFile     = "file"     // ~/.vault-token, as the Vault CLI's default token helper
+	TokenStoreNone     = "none"     // print the token instead of storing it
+)
+
+// LoginOptions contains options for the Login operation
+type LoginOptions struct {
+	Method   string // auth method; defaults to the configured credentials, or a token read from stdin
+	AuthPath string // mount path of the auth method when not the default
+	Username string // ldap and userpass: defaults to the local user name
+	Role     string // oidc and kubernetes: role to log in with
+	Store    string // where to keep the token (see TokenStore*)
+}
+
+// Login authenticates to Vault, stores the resulting token (in the OS
+// keychain by default) where later commands find it when no other
+// credentials are set, and prints the token's policies and TTL
+func Login(opts *Options, loginOpts *LoginOptions) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	store := config.NonEmpty(loginOpts.Store, TokenStoreKeychain)
+	switch store {
+	case TokenStoreKeychain, TokenStoreFile, TokenStoreNone:
+	default:
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported token store %q (expected keychain, file, or none)", store))
+	}
+
+	_, _, vaultConfig, err := loadVaultConfig(opts)
+	if err != nil {
+		return err
+	}
+	account := keychainAccount(vaultConfig)
+	if account == "" {
+		return config.ErrMissingVaultAddr
+	}
+
+	if err := applyLoginMethod(vaultConfig, loginOpts); err != nil {
+		return err
+	}
+
+	client, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create vault client: %w", err)
+	}
+
+	// Only store a token Vault accepts
+	info, err := client.LookupSelf()
+	if err != nil {
+		if vault.IsPermissionDenied(err) {
+			return WithExitCode(ExitAuth, fmt.Errorf("verify token: %w", err))
+		}
+		return fmt.Errorf("verify token: %w", err)
+	}
+
+	switch store {
+	case TokenStoreKeychain:
+		if err := keychain.Set(account, client.Token()); err != nil {
+			if errors.Is(err, keychain.ErrUnsupported) {
+				return fmt.Errorf("%w (use --store file to write ~/.vault-token instead)", err)
+			}
+			return err
+		}
+	case TokenStoreFile:
+		if err := writeTokenFile(client.Token()); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Authenticated to %s via %s\n", account, vaultConfig.AuthMethod)
+	printTokenInfo(info)
+	switch store {
+	case TokenStoreKeychain:
+		fmt.Println("  stored in:    OS keychain")
+	case TokenStoreFile:
+		fmt.Printf("  stored in:    %s\n", tokenFilePath())
+	case TokenStoreNone:
+		audit.MarkDisplayed()
+		fmt.Printf("  token:        %s\n", client.Token())
+	}
+	return nil
+}
+
+// applyLoginMethod fills the credentials of the requested auth method,
+// prompting for the ones that are secret
+func applyLoginMethod(cfg *config.VaultConfig, opts *LoginOptions) error {
+	if opts.Method != "" {
+		cfg.AuthMethod = strings.ToLower(opts.Method)
+		if cfg.AuthMethod != "token" {
+			cfg.Token = ""
+		}
+	}
+	cfg.AuthPath = opts.AuthPath
+
+	switch cfg.AuthMethod {
+	case "", "token":
+		if cfg.AuthMethod == "token" || !hasCredentials(cfg) {
+			if cfg.Token == "" {
+				token, err := utils.ReadSecret("Vault token: ")
+				if err != nil {
+					return WithExitCode(ExitUsage, fmt.Errorf("read token: %w", err))
+				}
+				cfg.Token = strings.TrimSpace(token)
+			}
+			cfg.AuthMethod = "token"
+		}
+	case "ldap", "userpass":
+		cfg.Username = config.NonEmpty(opts.Username, os.Getenv("USER"), os.Getenv("USERNAME"))
+		if cfg.Username == "" {
+			return WithExitCode(ExitUsage, fmt.Errorf("--username is required for %s login", cfg.AuthMethod))
+		}
+		password, err := utils.ReadSecret(fmt.Sprintf("Password for %s: ", cfg.Username))
+		if err != nil {
+			return WithExitCode(ExitUsage, fmt.Errorf("read password: %w", err))
+		}
+		cfg.Password = password
+	case "oidc":
+		cfg.OIDCRole = opts.Role
+	case "kubernetes":
+		cfg.K8sRole = config.NonEmpty(opts.Role, cfg.K8sRole)
+	}
+
+	if cfg.AuthMethod == "" {
+		cfg.AuthMethod = cfg.DetectAuthMethod()
+	}
+	return nil
+}
+
+// Logout revokes the stored token of the configured Vault server (unless
+// revoke is false) and removes it from the keychain and ~/.vault-token
+func Logout(opts *Options, revoke bool) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	_, _, vaultConfig, err := loadVaultConfig(opts)
+	if err != nil {
+		return err
+	}
+	account := keychainAccount(vaultConfig)
+	if account == "" {
+		return config.ErrMissingVaultAddr
+	}
+
+	token, source := storedToken(account)
+	if token == "" {
+		return WithExitCode(ExitNotFound, fmt.Errorf("no stored token for %s", account))
+	}
+
+	if revoke {
+		vaultConfig.AuthMethod, vaultConfig.Token = "token", token
+		client, err := vault.NewClient(vaultConfig)
+		if err != nil {
+			return fmt.Errorf("failed to create vault client: %w", err)
+		}
+		if err := client.RevokeSelf(); err != nil {
+			// A token Vault no longer accepts only needs to be forgotten
+			if !vault.IsPermissionDenied(err) && !vault.IsUnauthorized(err) {
+				return fmt.Errorf("%w (use --no-revoke to only remove the stored token)", err)
+			}
+			warnf("token was already invalid: %v\n", err)
+		} else {
+			infof("Revoked token for %s\n", account)
+		}
+	}
+
+	switch source {
+	case TokenStoreKeychain:
+		if err := keychain.Delete(account); err != nil {
+			return err
+		}
+		infof("Removed token from the OS keychain\n")
+	case TokenStoreFile:
+		if err := os.Remove(tokenFilePath()); err != nil {
+			return fmt.Errorf("remove token file: %w", err)
+		}
+		infof("Removed %s\n", tokenFilePath())
+	}
+	return nil
+}
+
+// storedToken returns the token saved by 'vlt login' for account and where it
+// was found: the OS keychain, then the Vault CLI's ~/.vault-token
+func storedToken(account string) (token, source string) {
+	if token, err := keychain.Get(account); err == nil && token != "" {
+		return token, TokenStoreKeychain
+	}
+	if data, err := os.ReadFile(tokenFilePath()); err == nil {
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, TokenStoreFile
+		}
+	}
+	return "", ""
+}
+
+// tokenFilePath returns the token file of the Vault CLI's default token helper
+func tokenFilePath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".vault-token"
+	}
+	return filepath.Join(home, ".vault-token")
+}
+
+// writeTokenFile writes token to ~/.vault-token, readable only by the owner
+func writeTokenFile(token string) error {
+	// Replaced rather than rewritten, so a token file left readable by others
+	// ends up private too
+	if err := writeFileAtomic(tokenFilePath(), []byte(token), 0600); err != nil {
+		return fmt.Errorf("write token file: %w", err)
+	}
+	return nil
+}
+
+// printTokenInfo prints the details of a token
+func printTokenInfo(info *vault.TokenInfo) {
+	fmt.Printf("  display name: %s\n", info.DisplayName)
+	fmt.Printf("  policies:     %s\n", strings.Join(info.Policies, ", "))
+	ttl := formatTTL(info.TTL)
+	if info.Renewable {
+		ttl += " (renewable)"
+	}
+	fmt.Printf("  ttl:          %s\n", ttl)
+}
+
+// formatTTL formats a token TTL, where 0 means the token never expires
+func formatTTL(ttl time.Duration) string {
+	if ttl == 0 {
+		return "never expires"
+	}
+	return ttl.String()
+}
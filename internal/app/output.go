@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"os"
+)
+
+// quiet suppresses informational messages and warnings, so stdout carries
+// only the requested data. Errors are still reported.
+var quiet bool
+
+// SetQuiet turns quiet mode on or off for the whole process
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// infof prints an informational message such as "Stored 3 secret(s)" to stdout
+func infof(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Printf(format, args...)
+	}
+}
+
+// statusf prints a progress message to stderr
+func statusf(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+// warnf prints a warning to stderr. Warnings that strict mode turns into
+// errors are returned as errors instead, so they are never lost.
+func warnf(format string, args ...interface{}) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "warning: "+format, args...)
+	}
+}
@@ -0,0 +1,120 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// defaultOutputMode is the permission of files written by sync unless --mode
+// is given
+const defaultOutputMode os.FileMode = 0600
+
+// checkOutputs runs the safety checks on every secrets file sync is about to
+// write, before any of them is written
+func checkOutputs(outputs []renderedOutput, opts *SyncOptions) error {
+	for _, output := range outputs {
+		if output.DropIn {
+			continue
+		}
+		if !opts.InsecureOutput {
+			if err := checkOutputDir(output.Path, outputMode(opts)); err != nil {
+				return err
+			}
+		}
+		checkGitignore(output.Path, opts.FixGitignore)
+		if output.versions != nil {
+			checkGitignore(output.versions.path, opts.FixGitignore)
+		}
+	}
+	return nil
+}
+
+// checkOutputDir refuses to write a secrets file where other users could
+// read it or replace it: into a world-writable directory such as /tmp, or
+// with a world-readable mode into a directory others can list. A directory
+// that does not exist yet is judged by its nearest existing parent.
+func checkOutputDir(path string, mode os.FileMode) error {
+	if runtime.GOOS == "windows" {
+		return nil // permission bits do not describe access on Windows
+	}
+
+	dir, _ := nearestExistingDir(filepath.Dir(path))
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil
+	}
+	perm := info.Mode().Perm()
+
+	switch {
+	case perm&0002 != 0:
+		return WithExitCode(ExitUsage, fmt.Errorf("refusing to write %s: %s is world-writable, so other users can replace or read the file (use --insecure-output to write it anyway)", path, dir))
+	case perm&0004 != 0 && mode&0004 != 0:
+		return WithExitCode(ExitUsage, fmt.Errorf("refusing to write %s with mode %04o: %s is world-readable, so every user could read the secrets (use a stricter --mode or --insecure-output)", path, mode, dir))
+	}
+	return nil
+}
+
+// checkGitignore warns when path is inside a git work tree and not ignored,
+// or with fix appends it to the work tree's .gitignore. Paths outside a work
+// tree, or without git installed, are not checked.
+func checkGitignore(path string, fix bool) {
+	dir, rel := nearestExistingDir(filepath.Dir(path))
+	rel = filepath.Join(rel, filepath.Base(path))
+
+	prefix, ok := utils.GitPrefix(dir)
+	if !ok {
+		return
+	}
+	ignored, err := utils.GitIgnored(dir, rel)
+	if err != nil {
+		warnf("cannot check whether %s is ignored by git: %v\n", path, err)
+		return
+	}
+	if ignored {
+		return
+	}
+
+	if !fix {
+		warnf("%s is inside a git work tree but not covered by .gitignore (use --fix-gitignore to add it)\n", path)
+		return
+	}
+	pattern := "/" + prefix + filepath.ToSlash(rel)
+	gitignore, err := utils.AppendGitignore(dir, pattern)
+	if err != nil {
+		warnf("cannot add %s to .gitignore: %v\n", pattern, err)
+		return
+	}
+	statusf("Added %s to %s\n", pattern, gitignore)
+}
+
+// nearestExistingDir returns dir, or its nearest existing parent and the
+// path of dir relative to that parent
+func nearestExistingDir(dir string) (string, string) {
+	rel := ""
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, rel
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir, rel
+		}
+		rel = filepath.Join(filepath.Base(dir), rel)
+		dir = parent
+	}
+}
+
+// outputMode returns the permissions for files written with opts
+func outputMode(opts *SyncOptions) os.FileMode {
+	if opts.Mode == 0 {
+		return defaultOutputMode
+	}
+	return opts.Mode
+}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// partialSync is the subset of config entries a sync --vars/--paths
+// refreshes
+type partialSync struct {
+	vars    map[string]bool // requested variable or entry names
+	entries map[string]bool // requested names that matched an entry name
+
+	// wide marks entries that export variables not known before loading
+	// (all keys of a path) and were selected only to find a requested
+	// variable; only the requested variables are taken from them
+	wide []bool
+}
+
+// selectEntries keeps the entries of cfg selected by name, variable, or
+// path and returns the selection. An entry is selected when its name or the
+// variable it sets is in vars, or its path is in paths (or below one of them,
+// or matched by one as a glob). Whole-path entries are searched for the
+// variables no other entry sets.
+func selectEntries(cfg *config.Config, vars, paths []string) (*partialSync, error) {
+	selection := &partialSync{vars: make(map[string]bool), entries: make(map[string]bool)}
+	for _, name := range vars {
+		selection.vars[name] = true
+	}
+
+	var kept []config.SecretEntry
+	var candidates []config.SecretEntry
+	found := make(map[string]bool)
+	for _, secret := range cfg.Secrets {
+		name := entryVarName(cfg, &secret)
+		switch {
+		case selection.vars[secret.Name] && secret.Name != "":
+			found[secret.Name] = true
+			selection.entries[secret.Name] = true
+		case name != "" && selection.vars[name]:
+			found[name] = true
+		case entryMatchesPaths(&secret, paths):
+		case name == "" && len(vars) > 0:
+			candidates = append(candidates, secret)
+			continue
+		default:
+			continue
+		}
+		kept = append(kept, secret)
+		selection.wide = append(selection.wide, false)
+	}
+
+	var missing []string
+	for _, name := range vars {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		kept = append(kept, candidates...)
+		for range candidates {
+			selection.wide = append(selection.wide, true)
+		}
+	}
+
+	if len(kept) == 0 {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("no config entry matches --vars %s --paths %s", strings.Join(vars, ","), strings.Join(paths, ",")))
+	}
+	cfg.Secrets = kept
+	return selection, nil
+}
+
+// entryVarName returns the variable an entry sets when it is known without
+// reading Vault, or "" for entries exporting every key of a path
+func entryVarName(cfg *config.Config, secret *config.SecretEntry) string {
+	switch {
+	case secret.IsLiteral(), secret.IsTemplate(), secret.IsIndividual():
+		return secret.EnvVar
+	case secret.IsPathSingleKey():
+		if secret.EnvKey != "" {
+			return secret.EnvKey
+		}
+		name, _ := cfg.EnvNameFor(secret, secret.Key)
+		return name
+	}
+	return ""
+}
+
+// entryMatchesPaths reports whether the Vault path of an entry is one of
+// paths, below one of them, or matched by one used as a glob
+func entryMatchesPaths(secret *config.SecretEntry, paths []string) bool {
+	entryPath := strings.Trim(config.NonEmpty(secret.Path, secret.KVPath), "/")
+	if entryPath == "" {
+		return false
+	}
+	for _, p := range paths {
+		p = strings.Trim(p, "/")
+		if matched, _ := path.Match(p, entryPath); matched || entryPath == p || strings.HasPrefix(entryPath, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// filter drops the variables of wide entries that were not requested
+func (p *partialSync) filter(entryVars []map[string]string) {
+	for i, vars := range entryVars {
+		if !p.wide[i] {
+			continue
+		}
+		for name := range vars {
+			if !p.vars[name] {
+				delete(vars, name)
+			}
+		}
+	}
+}
+
+// merge returns the variables of the existing env file at outputPath with
+// the refreshed ones replaced or added. Variables no longer exported by the
+// selected entries are kept as they are.
+func (p *partialSync) merge(outputPath string, refreshed map[string]string) (map[string]string, error) {
+	for name := range p.vars {
+		if _, ok := refreshed[name]; !ok && !p.entries[name] {
+			return nil, WithExitCode(ExitNotFound, fmt.Errorf("no config entry sets %s", name))
+		}
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("%s does not exist yet; run a full sync before refreshing part of it", outputPath))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", outputPath, err)
+	}
+	if encryptedEnvFile(content) {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("%s is encrypted; run a full sync to refresh it", outputPath))
+	}
+	current, err := godotenv.UnmarshalBytes(content)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", outputPath, err)
+	}
+
+	names := make([]string, 0, len(refreshed))
+	for name, value := range refreshed {
+		if current[name] != value {
+			names = append(names, name)
+		}
+		current[name] = value
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		infof("Refreshed %d variable(s) of %s: no changes\n", len(refreshed), outputPath)
+	} else {
+		infof("Refreshed %d variable(s) of %s, changed: %s\n", len(refreshed), outputPath, strings.Join(names, ", "))
+	}
+	return current, nil
+}
+
+// partialFormat reports whether a sync of format can refresh part of its
+// output, which is read back as a dotenv file
+func partialFormat(format string) bool {
+	return format == "" || format == utils.FormatEnv
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// capabilityOrder is the order capabilities are listed in policy stanzas
+var capabilityOrder = []string{"create", "read", "update", "patch", "delete", "list", "sudo"}
+
+// PermissionError is a 403 from Vault explained with the capabilities the
+// token has on the denied path
+type PermissionError struct {
+	Err      error
+	Request  *vault.DeniedRequest
+	Has      []string // capabilities of the token on Request.Path
+	Policies []string // policies attached to the token
+}
+
+func (e *PermissionError) Error() string {
+	var b strings.Builder
+	// Vault's error bodies end in blank lines
+	b.WriteString(strings.TrimRight(e.Err.Error(), "\n"))
+	b.WriteString("\n\n")
+
+	path := e.Request.Path
+	if e.Request.Namespace != "" {
+		path = e.Request.Namespace + "/" + path
+	}
+	missing := make([]string, 0, len(e.Request.Capabilities))
+	for _, c := range e.Request.Capabilities {
+		if !slices.Contains(e.Has, c) {
+			missing = append(missing, c)
+		}
+	}
+	if len(missing) == 0 {
+		fmt.Fprintf(&b, "The token has %s on %s, so the request was denied by something other than its ACL policies: a Sentinel policy, a control group, or a different namespace.\n", quoteList(e.Has), path)
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	fmt.Fprintf(&b, "Token lacks %s on %s; %s.\n", quoteList(missing), path, e.grants())
+	b.WriteString("A policy stanza that allows it (ask your Vault administrator to add it to one of the token's policies):\n\n")
+
+	granted := append(slices.Clone(e.Has), missing...)
+	caps := make([]string, 0, len(granted))
+	for _, c := range capabilityOrder {
+		if slices.Contains(granted, c) {
+			caps = append(caps, `"`+c+`"`)
+		}
+	}
+	fmt.Fprintf(&b, "  path %q {\n    capabilities = [%s]\n  }", e.Request.Path, strings.Join(caps, ", "))
+	return b.String()
+}
+
+func (e *PermissionError) Unwrap() error {
+	return e.Err
+}
+
+// grants describes what the token's policies allow on the path
+func (e *PermissionError) grants() string {
+	subject := "its policies grant"
+	switch len(e.Policies) {
+	case 0:
+	case 1:
+		subject = fmt.Sprintf("policy %q grants", e.Policies[0])
+	default:
+		quoted := make([]string, len(e.Policies))
+		for i, p := range e.Policies {
+			quoted[i] = fmt.Sprintf("%q", p)
+		}
+		subject = "policies " + strings.Join(quoted, ", ") + " grant"
+	}
+	if len(e.Has) == 0 {
+		return subject + " nothing there"
+	}
+	return subject + " only " + strings.Join(e.Has, ", ")
+}
+
+// quoteList formats capabilities as 'read' or 'create' and 'update'
+func quoteList(capabilities []string) string {
+	quoted := make([]string, len(capabilities))
+	for i, c := range capabilities {
+		quoted[i] = "'" + c + "'"
+	}
+	if len(quoted) <= 1 {
+		return strings.Join(quoted, "")
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + " and " + quoted[len(quoted)-1]
+}
+
+// ExplainPermissionDenied looks up the token's capabilities on the path of a
+// 403 from Vault and returns a PermissionError naming what is missing and the
+// policy stanza that would allow the request. Other errors, and denials that
+// cannot be looked up, are returned unchanged.
+func ExplainPermissionDenied(opts *Options, err error) error {
+	denied, ok := vault.PermissionDenied(err)
+	if !ok || len(denied.Capabilities) == 0 || strings.HasPrefix(denied.Path, "auth/") {
+		// A denied login or token lookup means bad credentials, not a policy gap
+		return err
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	_, _, vaultConfig, cfgErr := loadVaultConfig(opts)
+	if cfgErr != nil {
+		return err
+	}
+	applyStoredToken(vaultConfig)
+	client, clientErr := vault.NewClient(vaultConfig)
+	if clientErr != nil {
+		return err
+	}
+	client = client.WithNamespace(denied.Namespace)
+
+	has, capErr := client.CapabilitiesSelf(denied.Path)
+	if capErr != nil {
+		return err
+	}
+	if slices.Equal(has, []string{"deny"}) {
+		// Reported both for no grant at all and for an explicit deny
+		has = nil
+	}
+	explained := &PermissionError{Err: err, Request: denied, Has: has}
+	if info, lookupErr := client.LookupSelf(); lookupErr == nil {
+		explained.Policies = info.Policies
+	}
+	return explained
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// allKeysChoice is the key picker entry that selects the whole secret
+const allKeysChoice = "(all keys)"
+
+// CanPrompt reports whether stdin and stdout are both attached to a terminal,
+// so an interactive picker can be shown
+func CanPrompt() bool {
+	return utils.IsTerminal(os.Stdin) && utils.IsTerminal(os.Stdout)
+}
+
+// PickSecret lets the user choose opts.KVPath with a fuzzy finder over the
+// secrets of the mount, then one of its keys when it has several
+func (a *App) PickSecret(opts *GetOptions) error {
+	if !CanPrompt() {
+		return WithExitCode(ExitUsage, fmt.Errorf("either --path, --config, or vlt.yaml file must be specified"))
+	}
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+
+	paths, err := a.globPaths(opts.KVMount, "", true)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return WithExitCode(ExitNotFound, fmt.Errorf("no secrets found in mount %s", opts.KVMount))
+	}
+	path, err := pick(fmt.Sprintf("Secret in %s/", strings.Trim(opts.KVMount, "/")), paths)
+	if err != nil {
+		return err
+	}
+	opts.KVPath = path
+
+	if opts.Key != "" {
+		return nil
+	}
+	data, err := a.vaultClient.KVGet(opts.KVMount, path)
+	if err != nil {
+		return fmt.Errorf("kv get: %w", err)
+	}
+	data = utils.StripMeta(data)
+	if len(data) < 2 {
+		return nil
+	}
+	keys := make([]string, 0, len(data)+1)
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	key, err := pick(fmt.Sprintf("Key of %s", path), append([]string{allKeysChoice}, keys...))
+	if err != nil {
+		return err
+	}
+	if key != allKeysChoice {
+		opts.Key = key
+	}
+	return nil
+}
+
+// pick shows a fuzzy finder over items and returns the chosen one. Typing
+// filters the list, the arrow keys move, enter chooses, and esc cancels.
+func pick(title string, items []string) (string, error) {
+	restore, err := utils.RawTerminal(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("picker: %w", err)
+	}
+	fmt.Print("\x1b[?1049h")
+	defer func() {
+		fmt.Print("\x1b[?1049l")
+		restore()
+	}()
+
+	query := ""
+	cursor := 0
+	for {
+		matches := fuzzyFilter(query, items)
+		cursor = min(cursor, max(len(matches)-1, 0))
+		renderPicker(title, query, matches, cursor, len(items))
+
+		key, err := readKeyPress(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("read key: %w", err)
+		}
+		switch key {
+		case "enter":
+			if len(matches) > 0 {
+				return matches[cursor], nil
+			}
+		case "esc", "ctrl-c":
+			return "", WithExitCode(ExitUsage, fmt.Errorf("no secret selected"))
+		case "up":
+			cursor = max(cursor-1, 0)
+		case "down":
+			cursor++
+		case "left", "right", "home", "end":
+		case "backspace":
+			if runes := []rune(query); len(runes) > 0 {
+				query = string(runes[:len(runes)-1])
+				cursor = 0
+			}
+		default:
+			if typed := printable(key); typed != "" {
+				query += typed
+				cursor = 0
+			}
+		}
+	}
+}
+
+// renderPicker draws the picker with the query on top and the best matches below
+func renderPicker(title, query string, matches []string, cursor, total int) {
+	rows, cols := utils.TerminalSize(os.Stdin)
+	visible := max(rows-3, 1)
+	offset := max(cursor-visible+1, 0)
+
+	var out strings.Builder
+	out.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&out, "%s\x1b[K\r\n", truncateRunes(fmt.Sprintf("%s  (%d/%d, enter to choose, esc to cancel)", title, len(matches), total), cols))
+	for i := offset; i < min(len(matches), offset+visible); i++ {
+		line := truncateRunes("  "+matches[i], cols)
+		if i == cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		out.WriteString(line + "\x1b[K\r\n")
+	}
+	fmt.Fprintf(&out, "\x1b[%d;1H> %s\x1b[K", rows, query)
+	fmt.Print(out.String())
+}
+
+// fuzzyFilter returns the items matching query, best match first
+func fuzzyFilter(query string, items []string) []string {
+	if query == "" {
+		return items
+	}
+
+	type match struct {
+		item  string
+		score int
+	}
+	var matches []match
+	for _, item := range items {
+		if score, ok := fuzzyScore(query, item); ok {
+			matches = append(matches, match{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]string, len(matches))
+	for i, m := range matches {
+		result[i] = m.item
+	}
+	return result
+}
+
+// fuzzyScore reports whether the characters of query appear in text in
+// order, ignoring case, and scores the match: consecutive characters and
+// characters at the start of a path segment or word count extra, and
+// shorter texts win ties
+func fuzzyScore(query, text string) (int, bool) {
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		score++
+		if ti == prev+1 {
+			score += 5
+		}
+		if ti == 0 || strings.ContainsRune("/_-. ", t[ti-1]) {
+			score += 3
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score*100 - len(t), true
+}
+
+// printable returns the printable characters of a key press, dropping
+// escape sequences and control characters
+func printable(key string) string {
+	if strings.HasPrefix(key, "\x1b") {
+		return ""
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPrint(r) {
+			return r
+		}
+		return -1
+	}, key)
+}
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// Actions in a dry-run or plan file
+const (
+	planCreate    = "create"
+	planUpdate    = "update"
+	planUnchanged = "unchanged"
+	planDelete    = "delete"
+	planReencrypt = "reencrypt"
+)
+
+// keyChange is what a write would do to one key of a secret
+type keyChange struct {
+	Key    string
+	Action string
+}
+
+// planKeys compares the keys a write would store with the existing ones.
+// Values are compared as stored, so freshly encrypted values always count
+// as updated.
+func planKeys(existing, final map[string]interface{}) []keyChange {
+	var changes []keyChange
+	existing, final = utils.StripMeta(existing), utils.StripMeta(final)
+	for k, v := range final {
+		old, ok := existing[k]
+		switch {
+		case !ok:
+			changes = append(changes, keyChange{k, planCreate})
+		case fmt.Sprint(old) == fmt.Sprint(v):
+			changes = append(changes, keyChange{k, planUnchanged})
+		default:
+			changes = append(changes, keyChange{k, planUpdate})
+		}
+	}
+	for k := range existing {
+		if _, ok := final[k]; !ok {
+			changes = append(changes, keyChange{k, planDelete})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// printKeyPlan prints what a write to target would change. New values are
+// shown masked, or as "(encrypted)" when they are Transit ciphertext.
+func printKeyPlan(target string, changes []keyChange, final map[string]interface{}) {
+	printPlanTable("Dry run: "+target, changes, final)
+	fmt.Printf("%s; nothing was written\n", planSummary(changes))
+}
+
+// printPlanTable prints a heading and the changes to one secret. Values come
+// from final; with a nil final the value column is left out.
+func printPlanTable(heading string, changes []keyChange, final map[string]interface{}) {
+	painter := utils.NewPainter(os.Stdout)
+	fmt.Println(heading)
+
+	rows := make([][]string, 0, len(changes))
+	for _, change := range changes {
+		row := []string{"  " + planMarker(change.Action) + " " + change.Key, change.Action}
+		switch {
+		case final == nil:
+		case change.Action == planDelete:
+			row = append(row, "")
+		case utils.IsEncryptedValue(final, change.Key):
+			row = append(row, "(encrypted)")
+		default:
+			row = append(row, utils.MaskValue(final[change.Key]))
+		}
+		rows = append(rows, row)
+	}
+	_ = utils.WriteTable(os.Stdout, rows, painter, func(row, col int) []string {
+		if style := planStyle(changes[row].Action); col == 0 && style != "" {
+			return []string{style}
+		}
+		return []string{utils.Dim}
+	})
+}
+
+// planSummary counts the changes by action, e.g. "1 to create, 0 to update,
+// 0 to delete, 2 unchanged"; re-encryptions and unchanged keys are only
+// mentioned when there are any
+func planSummary(changes []keyChange) string {
+	counts := make(map[string]int)
+	for _, change := range changes {
+		counts[change.Action]++
+	}
+	summary := fmt.Sprintf("%d to create, %d to update, %d to delete", counts[planCreate], counts[planUpdate], counts[planDelete])
+	if counts[planReencrypt] > 0 {
+		summary += fmt.Sprintf(", %d to re-encrypt", counts[planReencrypt])
+	}
+	if counts[planUnchanged] > 0 {
+		summary += fmt.Sprintf(", %d unchanged", counts[planUnchanged])
+	}
+	return summary
+}
+
+func planMarker(action string) string {
+	switch action {
+	case planCreate:
+		return "+"
+	case planUpdate:
+		return "~"
+	case planDelete:
+		return "-"
+	case planReencrypt:
+		return "*"
+	default:
+		return " "
+	}
+}
+
+func planStyle(action string) string {
+	switch action {
+	case planCreate:
+		return utils.Green
+	case planUpdate:
+		return utils.Yellow
+	case planDelete:
+		return utils.Red
+	case planReencrypt:
+		return utils.Cyan
+	default:
+		return ""
+	}
+}
+
+// printFileDiff prints the lines sync would change in a file. Secret values
+// are masked: each changed line keeps only its text up to the first "=" or
+// ":", so the diff shows which variables change but never their values.
+func printFileDiff(output renderedOutput) error {
+	painter := utils.NewPainter(os.Stdout)
+
+	current, err := os.ReadFile(output.Path)
+	if os.IsNotExist(err) {
+		fmt.Printf("Dry run: %s would be created with %d secret(s)\n", output.Path, output.Secrets)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", output.Path, err)
+	}
+
+	if encryptedEnvFile(current) {
+		fmt.Printf("Dry run: %s is encrypted; it would be rewritten with %d secret(s)\n", output.Path, output.Secrets)
+		return nil
+	}
+
+	lines := diffLines(splitLines(string(stripExpiryHeader(current))), splitLines(string(output.Content)))
+	if len(lines) == 0 {
+		fmt.Printf("Dry run: %s is up to date\n", output.Path)
+		return nil
+	}
+
+	added, removed := 0, 0
+	for _, line := range lines {
+		if line.op == '+' {
+			added++
+		} else {
+			removed++
+		}
+	}
+	fmt.Printf("Dry run: %s would change (%d line(s) added, %d removed)\n", output.Path, added, removed)
+	fmt.Println(painter.Paint("--- "+output.Path, utils.Bold))
+	fmt.Println(painter.Paint("+++ "+output.Path+" (would be written)", utils.Bold))
+	for _, line := range lines {
+		style := utils.Green
+		if line.op == '-' {
+			style = utils.Red
+		}
+		text := line.text
+		if !output.DropIn {
+			text = maskLine(text)
+		}
+		fmt.Println(painter.Paint(string(line.op)+text, style))
+	}
+	return nil
+}
+
+// diffLine is a line only in the old ('-') or the new ('+') file
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// diffLines returns the lines removed from and added to old to get new,
+// based on their longest common subsequence
+func diffLines(old, new []string) []diffLine {
+	// lcs[i][j] is the LCS length of old[i:] and new[j:]
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diffLine{'-', old[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', new[j]})
+			j++
+		}
+	}
+	return lines
+}
+
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+}
+
+// maskLine hides the value of a rendered variable line
+func maskLine(line string) string {
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return line
+	}
+	if strings.TrimSpace(line[i+1:]) == "" {
+		return line
+	}
+	return line[:i+1] + "****"
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+
+package app
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// setProcessGroup starts cmd in its own process group so that signals reach
+// everything it spawns, such as the commands of a shell pipeline
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signalProcessGroup sends sig to the process group led by p
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return p.Signal(sig)
+	}
+	return syscall.Kill(-p.Pid, s)
+}
+
+// startCommand starts cmd. Process groups already reach everything it spawns.
+func startCommand(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+// signalStatus returns 128 plus the signal number when the process was
+// killed by a signal
+func signalStatus(state *os.ProcessState) (int, bool) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return 0, false
+	}
+	return 128 + int(status.Signal()), true
+}
+
+// shellCommand returns the command line that runs script through the user's shell
+func shellCommand(script string) (string, []string) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	return shell, []string{"-c", script}
+}
+
+// interactiveShell returns the user's login shell
+func interactiveShell() string {
+	return config.NonEmpty(os.Getenv("SHELL"), "/bin/sh")
+}
@@ -0,0 +1,141 @@
+//go:build windows
+
+package app
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject       = kernel32.NewProc("TerminateJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformationClass = 9
+	jobObjectLimitKillOnJobClose           = 0x2000
+	processSetQuota                        = 0x0100
+	processTerminate                       = 0x0001
+
+	// statusControlCExit is the exit status of a process ended by Ctrl-C
+	statusControlCExit = 0xC000013A
+)
+
+// jobObjectBasicLimitInformation mirrors JOBOBJECT_BASIC_LIMIT_INFORMATION
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+// jobObjectExtendedLimitInformation mirrors JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                [6]uint64 // IO_COUNTERS
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// jobs holds the Job Object of every command started with startCommand, by pid
+var jobs sync.Map
+
+// setProcessGroup is a no-op on Windows; startCommand groups the process
+// tree in a Job Object instead
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// startCommand starts cmd and assigns it to a Job Object that is closed, and
+// its remaining processes killed, when vlt exits, so nothing the command
+// spawned outlives vlt. Without Job Object support only cmd itself is tracked.
+func startCommand(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if job, err := newKillOnCloseJob(cmd.Process.Pid); err == nil {
+		jobs.Store(cmd.Process.Pid, job)
+	}
+	return nil
+}
+
+// newKillOnCloseJob creates a Job Object that kills its processes when its
+// last handle is closed, and assigns the process pid to it
+func newKillOnCloseJob(pid int) (syscall.Handle, error) {
+	r, _, err := procCreateJobObjectW.Call(0, 0)
+	if r == 0 {
+		return 0, err
+	}
+	job := syscall.Handle(r)
+
+	info := jobObjectExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	r, _, err = procSetInformationJobObject.Call(uintptr(job), jobObjectExtendedLimitInformationClass, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info))
+	if r == 0 {
+		syscall.CloseHandle(job)
+		return 0, err
+	}
+
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(pid))
+	if err != nil {
+		syscall.CloseHandle(job)
+		return 0, err
+	}
+	defer syscall.CloseHandle(process)
+	if r, _, err = procAssignProcessToJobObject.Call(uintptr(job), uintptr(process)); r == 0 {
+		syscall.CloseHandle(job)
+		return 0, err
+	}
+	return job, nil
+}
+
+// signalProcessGroup signals p. Windows only supports killing a process, so
+// any other signal returns an error and callers fall back to os.Kill, which
+// ends every process of p's Job Object.
+func signalProcessGroup(p *os.Process, sig os.Signal) error {
+	if sig != os.Kill {
+		return p.Signal(sig)
+	}
+	if job, ok := jobs.LoadAndDelete(p.Pid); ok {
+		procTerminateJobObject.Call(uintptr(job.(syscall.Handle)), 1)
+		syscall.CloseHandle(job.(syscall.Handle))
+	}
+	return p.Kill()
+}
+
+// signalStatus reports the status of a process ended by Ctrl-C as 130, the
+// status shells give a process killed by SIGINT
+func signalStatus(state *os.ProcessState) (int, bool) {
+	if uint32(state.ExitCode()) == statusControlCExit {
+		return 128 + int(syscall.SIGINT), true
+	}
+	return 0, false
+}
+
+// shellCommand returns the command line that runs script through cmd.exe
+func shellCommand(script string) (string, []string) {
+	shell := os.Getenv("ComSpec")
+	if shell == "" {
+		shell = "cmd.exe"
+	}
+	return shell, []string{"/C", script}
+}
+
+// interactiveShell returns the command interpreter
+func interactiveShell() string {
+	return config.NonEmpty(os.Getenv("ComSpec"), "cmd.exe")
+}
@@ -0,0 +1,265 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+var procNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// procEntry is one process of a Procfile
+type procEntry struct {
+	Name    string
+	Command string
+}
+
+// parseProcfile reads "name: command" lines, skipping blank lines and comments
+func parseProcfile(path string) ([]procEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open procfile: %w", err)
+	}
+	defer f.Close()
+
+	var entries []procEntry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, command, ok := strings.Cut(line, ":")
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if !ok || !procNameRe.MatchString(name) || command == "" {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("%s:%d: expected \"name: command\"", path, lineNo))
+		}
+		if seen[name] {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("%s:%d: duplicate process %q", path, lineNo, name))
+		}
+		seen[name] = true
+		entries = append(entries, procEntry{Name: name, Command: command})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read procfile: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("procfile %s defines no processes", path))
+	}
+	return entries, nil
+}
+
+// procExit reports that the process at index exited
+type procExit struct {
+	index int
+	err   error
+}
+
+// runProcfile starts every process of the Procfile with the same environment
+// and prefixes their output with the process name. Signals are forwarded to
+// all processes. When one exits, the others are stopped (unless opts.WaitAll)
+// and its exit status becomes the result.
+func (a *App) runProcfile(opts *RunOptions, env []string, maskValues []string) error {
+	entries, err := parseProcfile(opts.Procfile)
+	if err != nil {
+		return err
+	}
+
+	width := 0
+	for _, e := range entries {
+		width = max(width, len(e.Name))
+	}
+
+	var outMu sync.Mutex
+	var flushers []func() error
+	cmds := make([]*exec.Cmd, len(entries))
+	exits := make(chan procExit, len(entries))
+
+	for i, e := range entries {
+		command, args := shellCommand(e.Command)
+		cmd := exec.Command(command, args...)
+		cmd.Env = env
+		setProcessGroup(cmd)
+
+		prefix := fmt.Sprintf("%-*s | ", width, e.Name)
+		stdout := newPrefixWriter(os.Stdout, prefix, &outMu)
+		stderr := newPrefixWriter(os.Stderr, prefix, &outMu)
+		flushers = append(flushers, stdout.Flush, stderr.Flush)
+		cmd.Stdout, cmd.Stderr = io.Writer(stdout), io.Writer(stderr)
+		if len(maskValues) > 0 {
+			// Redact before prefixing so multi-line secrets are still matched
+			redactOut := utils.NewRedactWriter(stdout, maskValues)
+			redactErr := utils.NewRedactWriter(stderr, maskValues)
+			flushers = append([]func() error{redactOut.Flush, redactErr.Flush}, flushers...)
+			cmd.Stdout, cmd.Stderr = redactOut, redactErr
+		}
+
+		if err := startCommand(cmd); err != nil {
+			stopProcesses(cmds[:i], opts.KillAfter, exits, i)
+			return WithExitCode(ExitChild, fmt.Errorf("start %s: %w", e.Name, err))
+		}
+		cmds[i] = cmd
+		statusf("%sstarted with pid %d\n", prefix, cmd.Process.Pid)
+
+		go func(index int) { exits <- procExit{index: index, err: cmds[index].Wait()} }(i)
+	}
+	defer func() {
+		for _, flush := range flushers {
+			_ = flush()
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	var timeout <-chan time.Time
+	if opts.Timeout > 0 {
+		timer := time.NewTimer(opts.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	running := len(cmds)
+	status := 0
+	first := true
+	for running > 0 {
+		select {
+		case exit := <-exits:
+			running--
+			code := exitStatus(exit.err)
+			fmt.Fprintf(os.Stderr, "%-*s | exited with status %d\n", width, entries[exit.index].Name, code)
+			if first || (opts.WaitAll && status == 0) {
+				status = code
+			}
+			if first && !opts.WaitAll && running > 0 {
+				fmt.Fprintf(os.Stderr, "vlt: %s exited, stopping remaining processes\n", entries[exit.index].Name)
+				return stopAndWait(cmds, opts.KillAfter, exits, running, status)
+			}
+			first = false
+		case sig := <-sigCh:
+			for _, cmd := range cmds {
+				forwardSignal(cmd.Process, sig)
+			}
+		case <-timeout:
+			fmt.Fprintf(os.Stderr, "vlt: processes timed out after %s, terminating\n", opts.Timeout)
+			stopProcesses(cmds, opts.KillAfter, exits, running)
+			return WithExitCode(ExitTimeout, fmt.Errorf("procfile timed out after %s", opts.Timeout))
+		}
+	}
+
+	if status != 0 {
+		return &ChildExitError{Status: status}
+	}
+	return nil
+}
+
+// stopAndWait stops the remaining processes and returns status as the result
+func stopAndWait(cmds []*exec.Cmd, killAfter time.Duration, exits <-chan procExit, running, status int) error {
+	stopProcesses(cmds, killAfter, exits, running)
+	if status != 0 {
+		return &ChildExitError{Status: status}
+	}
+	return nil
+}
+
+// stopProcesses sends SIGTERM to every process group, SIGKILL after
+// killAfter, and waits for the running processes to exit
+func stopProcesses(cmds []*exec.Cmd, killAfter time.Duration, exits <-chan procExit, running int) {
+	for _, cmd := range cmds {
+		if err := signalProcessGroup(cmd.Process, syscall.SIGTERM); err != nil {
+			_ = signalProcessGroup(cmd.Process, os.Kill)
+		}
+	}
+
+	deadline := time.After(killAfter)
+	for running > 0 {
+		select {
+		case <-exits:
+			running--
+		case <-deadline:
+			for _, cmd := range cmds {
+				_ = signalProcessGroup(cmd.Process, os.Kill)
+			}
+			deadline = nil
+		}
+	}
+}
+
+// exitStatus returns the exit status of a finished command, or 128 plus the
+// signal number when it was killed by a signal
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitError, ok := err.(*exec.ExitError); ok {
+		if status, ok := signalStatus(exitError.ProcessState); ok {
+			return status
+		}
+		return exitError.ExitCode()
+	}
+	return ExitFailure
+}
+
+// prefixWriter writes complete lines prefixed with a process name. Lines of
+// all processes share a mutex so they are never interleaved mid-line.
+type prefixWriter struct {
+	w       io.Writer
+	prefix  string
+	mu      *sync.Mutex
+	pending []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *prefixWriter {
+	return &prefixWriter{w: w, prefix: prefix, mu: mu}
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.pending = append(p.pending, b...)
+
+	var out bytes.Buffer
+	for {
+		i := bytes.IndexByte(p.pending, '\n')
+		if i < 0 {
+			break
+		}
+		out.WriteString(p.prefix)
+		out.Write(p.pending[:i+1])
+		p.pending = p.pending[i+1:]
+	}
+
+	if out.Len() > 0 {
+		p.mu.Lock()
+		_, err := p.w.Write(out.Bytes())
+		p.mu.Unlock()
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush writes a final line that did not end in a newline
+func (p *prefixWriter) Flush() error {
+	if len(p.pending) == 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.pending)
+	p.pending = nil
+	return err
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Output formats of the random command
+const (
+	RandomBase64 = "base64"
+	RandomHex    = "hex"
+)
+
+// RandomOptions contains options for the Random operation
+type RandomOptions struct {
+	Bytes  int    // number of random bytes
+	Format string // RandomBase64 or RandomHex
+
+	// Store writes the value to KVPath (under Key) like put instead of
+	// printing it
+	Store         bool
+	KVMount       string
+	KVPath        string
+	Key           string
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string
+}
+
+// Random prints random bytes from Vault's sys/tools/random, or stores them
+// in a secret without printing them. When Vault refuses the request, or
+// cannot be reached and nothing is stored, the bytes come from crypto/rand.
+func Random(global *Options, opts *RandomOptions) error {
+	if opts.Bytes <= 0 {
+		return WithExitCode(ExitUsage, fmt.Errorf("--bytes must be positive"))
+	}
+	if opts.Format != RandomBase64 && opts.Format != RandomHex {
+		return WithExitCode(ExitUsage, fmt.Errorf("unknown --format %q (expected base64 or hex)", opts.Format))
+	}
+	var random []byte
+	a, err := New(global)
+	switch {
+	case err != nil && opts.Store:
+		return fmt.Errorf("failed to create app: %w", err)
+	case err != nil:
+		warnf("%v; using local randomness\n", err)
+	default:
+		if opts.Store {
+			if err := a.requireWritable("random --store"); err != nil {
+				return err
+			}
+		}
+		if random, err = a.withNamespace(opts.Namespace).vaultClient.RandomBytes(opts.Bytes); err != nil {
+			warnf("%v; using local randomness\n", err)
+		}
+	}
+	if random == nil {
+		random = make([]byte, opts.Bytes)
+		if _, err := rand.Read(random); err != nil {
+			return fmt.Errorf("generate random bytes: %w", err)
+		}
+	}
+
+	value := base64.StdEncoding.EncodeToString(random)
+	if opts.Format == RandomHex {
+		value = hex.EncodeToString(random)
+	}
+	if !opts.Store {
+		fmt.Println(value)
+		return nil
+	}
+
+	return a.Put(&PutOptions{
+		KVMount:       opts.KVMount,
+		KVPath:        opts.KVPath,
+		TransitMount:  opts.TransitMount,
+		EncryptionKey: opts.EncryptionKey,
+		Key:           opts.Key,
+		Namespace:     opts.Namespace,
+		value:         []byte(value),
+	})
+}
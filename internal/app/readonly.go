@@ -0,0 +1,12 @@
+package app
+
+import "fmt"
+
+// requireWritable fails operations that write to Vault when the app runs in
+// read-only mode, before they read or change anything
+func (a *App) requireWritable(operation string) error {
+	if !a.readOnly {
+		return nil
+	}
+	return WithExitCode(ExitPermission, fmt.Errorf("%s is disabled in read-only mode (--read-only or VAULT_ENV_READ_ONLY)", operation))
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// SearchOptions contains options for the Search operation
+type SearchOptions struct {
+	KVMount       string
+	KVPath        string
+	TransitMount  string
+	EncryptionKey string
+	KeyPattern    string
+	ValuePattern  string // Requires AllowValues since it reads every secret value
+	AllowValues   bool
+}
+
+// Search walks the KV subtree under a path and reports secrets whose keys
+// or values match the given patterns. Matched values are never printed.
+func (a *App) Search(opts *SearchOptions) error {
+	if opts.KeyPattern == "" && opts.ValuePattern == "" {
+		return WithExitCode(ExitUsage, fmt.Errorf("at least one of --key-pattern or --value-pattern is required"))
+	}
+	if opts.ValuePattern != "" && !opts.AllowValues {
+		return WithExitCode(ExitUsage, fmt.Errorf("--value-pattern reads and decrypts every secret value; pass --allow-value-search to confirm"))
+	}
+
+	var keyRe, valueRe *regexp.Regexp
+	var err error
+	if opts.KeyPattern != "" {
+		if keyRe, err = regexp.Compile(opts.KeyPattern); err != nil {
+			return WithExitCode(ExitUsage, fmt.Errorf("invalid --key-pattern: %w", err))
+		}
+	}
+	if opts.ValuePattern != "" {
+		if valueRe, err = regexp.Compile(opts.ValuePattern); err != nil {
+			return WithExitCode(ExitUsage, fmt.Errorf("invalid --value-pattern: %w", err))
+		}
+	}
+
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+	effectiveEncryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+	painter := utils.NewPainter(os.Stdout)
+	matches := 0
+
+	err = a.walkSecrets(opts.KVMount, opts.KVPath, func(path string) error {
+		data, err := a.vaultClient.KVGet(opts.KVMount, path)
+		if errors.Is(err, vault.ErrRequestBudget) {
+			return err
+		}
+		if err != nil {
+			warnf("skipping %s: %v\n", path, err)
+			return nil
+		}
+
+		if valueRe != nil && (utils.IsEncryptedSingleValue(data) || utils.IsEncryptedMultiValue(data)) {
+			if effectiveEncryptionKey == "" {
+				warnf("skipping values of encrypted secret %s (no encryption key)\n", path)
+			} else if decrypted, err := utils.DecryptMultiValueData(data, a.vaultClient, opts.TransitMount, effectiveEncryptionKey); err != nil {
+				warnf("skipping values of %s: %v\n", path, err)
+			} else {
+				data = decrypted
+			}
+		}
+		data = utils.StripMeta(data)
+
+		keys := make([]string, 0, len(data))
+		for k := range data {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if keyRe != nil && !keyRe.MatchString(k) {
+				continue
+			}
+			if valueRe != nil && !valueRe.MatchString(fmt.Sprintf("%v", data[k])) {
+				continue
+			}
+			fmt.Printf("%s %s\n", painter.Paint(path+":", utils.Blue), painter.Paint(k, utils.Bold))
+			matches++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if matches == 0 {
+		return WithExitCode(ExitNotFound, fmt.Errorf("no matches found under %s", withTrailingSlash(strings.Trim(opts.KVPath, "/"))))
+	}
+	return nil
+}
+
+// walkSecrets calls fn for every secret path under root, depth-first in sorted order
+func (a *App) walkSecrets(mount, root string, fn func(path string) error) error {
+	root = strings.Trim(root, "/")
+
+	keys, err := a.vaultClient.KVList(mount, root)
+	if err != nil {
+		return fmt.Errorf("kv list %s: %w", root, err)
+	}
+	if len(keys) == 0 && root != "" {
+		// The root is a single secret rather than a directory
+		return fn(root)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fullPath := withTrailingSlash(root) + key
+		if strings.HasSuffix(key, "/") {
+			if err := a.walkSecrets(mount, fullPath, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(fullPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// DefaultMaxSecretSize is the largest secret, in bytes as sent to Vault,
+// put writes to a single KV path unless max_secret_size says otherwise. It
+// is the default max_entry_size of Vault's integrated storage; Consul
+// storage allows 512 KiB.
+const DefaultMaxSecretSize = 1 << 20
+
+// manySecretKeys is the number of keys in one secret above which put
+// suggests splitting it
+const manySecretKeys = 500
+
+// maxSecretSize returns the size limit of a single KV secret
+func (a *App) maxSecretSize() int {
+	if size := a.pathConfig().MaxSecretSize; size > 0 {
+		return size
+	}
+	return DefaultMaxSecretSize
+}
+
+// readJoined reads a secret, reassembling one that put --shard split into
+// parts
+func (a *App) readJoined(mount, path string) (map[string]interface{}, error) {
+	data, err := a.vaultClient.KVGet(mount, path)
+	if err != nil {
+		return nil, err
+	}
+	return a.joinShards(mount, path, data)
+}
+
+// joinShards returns data itself, or when it is the index of a split secret
+// read from path, the parts it lists merged into one secret
+func (a *App) joinShards(mount, path string, data map[string]interface{}) (map[string]interface{}, error) {
+	n := utils.ShardCount(data)
+	if n == 0 {
+		return data, nil
+	}
+	parts := make([]map[string]interface{}, 0, n)
+	for i := 1; i <= n; i++ {
+		part, err := a.vaultClient.KVGet(mount, utils.ShardPath(path, i))
+		if err != nil {
+			return nil, fmt.Errorf("read part %d of %d of %s: %w", i, n, path, err)
+		}
+		parts = append(parts, part)
+	}
+	joined := utils.JoinShards(parts)
+	if manifest, ok := data[utils.FileKey]; ok {
+		joined[utils.FileKey] = manifest
+	}
+	return joined, nil
+}
+
+// checkSecretSize fails before writing a secret Vault would refuse as too
+// large, and warns about one close to the limit or with very many keys, so
+// it can be split with --shard before it is
+func (a *App) checkSecretSize(source string, data map[string]interface{}) error {
+	limit := a.maxSecretSize()
+	size := utils.EncodedSize(data)
+	keys := len(utils.StripMeta(data))
+	switch {
+	case size > limit && isSingleValue(data):
+		return WithExitCode(ExitUsage, fmt.Errorf("%s: the value is %s, above the %s limit of a single secret", source, formatBytes(size), formatBytes(limit)))
+	case size > limit:
+		return WithExitCode(ExitUsage, fmt.Errorf("%s: %d key(s) take %s, above the %s limit of a single secret; pass --shard to split them across %s/part1..N", source, keys, formatBytes(size), formatBytes(limit), source))
+	case size > limit*4/5:
+		warnf("%s: %d key(s) take %s, close to the %s limit of a single secret; consider --shard\n", source, keys, formatBytes(size), formatBytes(limit))
+	case keys > manySecretKeys:
+		warnf("%s: %d keys in a single secret; consider --shard\n", source, keys)
+	}
+	return nil
+}
+
+// putSharded writes data split into parts, each below the size warning
+// threshold, at path/part1..N, then the index listing them at path. The
+// index is written with check-and-set against version, and parts left over
+// from an earlier split into more of them are deleted. It returns the number
+// of parts.
+func (a *App) putSharded(mount, path string, data map[string]interface{}, oldParts, version int) (int, error) {
+	parts, err := utils.SplitShards(data, a.maxSecretSize()*4/5)
+	if err != nil {
+		return 0, WithExitCode(ExitUsage, fmt.Errorf("shard %s/%s: %w", mount, path, err))
+	}
+	for i, part := range parts {
+		if err := a.vaultClient.KVPut(mount, utils.ShardPath(path, i+1), part); err != nil {
+			return 0, fmt.Errorf("write part %d of %d: %w", i+1, len(parts), err)
+		}
+	}
+
+	if err := a.finishParts(mount, path, utils.ShardIndex(len(parts)), len(parts), oldParts, version); err != nil {
+		return 0, err
+	}
+	return len(parts), nil
+}
+
+// finishParts writes data at path once its first parts parts are written,
+// with check-and-set against version, and deletes the parts beyond them left
+// from an earlier write into oldParts parts
+func (a *App) finishParts(mount, path string, data map[string]interface{}, parts, oldParts, version int) error {
+	var err error
+	if version == unknownVersion {
+		err = a.vaultClient.KVPut(mount, path, data)
+	} else {
+		err = a.vaultClient.KVPutCAS(mount, path, data, version)
+	}
+	if errors.Is(err, vault.ErrCASMismatch) && parts > 0 {
+		return fmt.Errorf("kv put: %s/%s changed while its parts were written; run the command again: %w", mount, path, err)
+	}
+	if err != nil {
+		return fmt.Errorf("kv put: %w", err)
+	}
+
+	a.deleteParts(mount, path, parts+1, oldParts)
+	return nil
+}
+
+// deleteParts deletes parts from to last of the split secret at path,
+// warning about those it cannot delete
+func (a *App) deleteParts(mount, path string, from, last int) {
+	for i := from; i <= last; i++ {
+		if err := a.vaultClient.KVDelete(mount, utils.ShardPath(path, i)); err != nil {
+			warnf("could not delete unused part %s: %v\n", utils.ShardPath(path, i), err)
+		}
+	}
+}
+
+// formatBytes formats a size in bytes, KiB, or MiB
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// ShredOptions contains options for the Shred operation
+type ShredOptions struct {
+	Files []string
+	Force bool // also shred files sync has no record of writing
+}
+
+// Shred securely deletes files written by sync and forgets them. Files sync
+// did not write are refused unless Force is set, so a mistyped path cannot
+// destroy an unrelated file.
+func Shred(opts *ShredOptions) error {
+	unlock, err := lockGeneratedState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := readGeneratedState()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, file := range opts.Files {
+		if err := shredGenerated(state, file, opts.Force); err != nil {
+			warnf("%v\n", err)
+			failed++
+			continue
+		}
+		infof("Shredded %s\n", file)
+	}
+
+	if err := writeGeneratedState(state); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be shredded", failed)
+	}
+	return nil
+}
+
+// shredGenerated overwrites and removes one file and drops it from state
+func shredGenerated(state *generatedState, path string, force bool) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !force && state.find(abs) == nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("%s was not written by vlt sync; pass --force to shred it anyway", path))
+	}
+	if err := utils.ShredFile(abs); err != nil {
+		return fmt.Errorf("cannot shred %s: %w", path, err)
+	}
+	state.remove(abs)
+	return nil
+}
+
+// cleanupEnvFile shreds the env file of run --cleanup once the command has
+// exited. Failures are reported but do not change run's exit status.
+func cleanupEnvFile(path string) {
+	unlock, err := lockGeneratedState()
+	if err != nil {
+		warnf("cleanup: %v\n", err)
+		return
+	}
+	defer unlock()
+	state, err := readGeneratedState()
+	if err != nil {
+		warnf("cleanup: %v\n", err)
+		return
+	}
+	if err := shredGenerated(state, path, false); err != nil {
+		warnf("cleanup: %v\n", err)
+		return
+	}
+	if err := writeGeneratedState(state); err != nil {
+		warnf("cleanup: %v\n", err)
+		return
+	}
+	statusf("Shredded %s\n", path)
+}
@@ -0,0 +1,255 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// snapshotVersion is the version of the snapshot document format
+const snapshotVersion = 1
+
+// Snapshot is the plaintext document stored inside an age-encrypted snapshot
+type Snapshot struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"created_at"`
+	Secrets   []SnapshotSecret `json:"secrets"`
+}
+
+// SnapshotSecret holds the decrypted keys of one KV path
+type SnapshotSecret struct {
+	Namespace string            `json:"namespace,omitempty"`
+	Mount     string            `json:"mount"`
+	Path      string            `json:"path"`
+	Data      map[string]string `json:"data"`
+
+	// SingleValue is set when the path held a single-value secret, which
+	// restore replaces whole; keys of other secrets are merged
+	SingleValue bool `json:"single_value,omitempty"`
+}
+
+// SnapshotOptions contains options for the Snapshot operation
+type SnapshotOptions struct {
+	ConfigFile     string
+	KVMount        string
+	TransitMount   string
+	EncryptionKey  string
+	Namespace      string   // overrides the client namespace for this command
+	OnlyTags       []string // keep only entries with one of these tags
+	SkipTags       []string // drop entries with any of these tags
+	Recipients     []string // age recipients (age1...)
+	RecipientFiles []string // files of age recipients, one per line
+	Armor          bool     // write PEM-armored output
+	OutputFile     string
+}
+
+// Snapshot resolves the Vault paths referenced by a config and writes their
+// decrypted keys to an age-encrypted file for transfer to another Vault
+func (a *App) Snapshot(opts *SnapshotOptions) error {
+	if len(opts.Recipients) == 0 && len(opts.RecipientFiles) == 0 {
+		return WithExitCode(ExitUsage, fmt.Errorf("at least one age recipient is required"))
+	}
+
+	cfg, err := a.LoadConfig(opts.ConfigFile, false)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	cfg.FilterByTags(opts.OnlyTags, opts.SkipTags)
+
+	// Entries reading other keys of the same path share one read
+	a = a.withNamespace(opts.Namespace).withReadCache()
+	encryptionKey := config.NonEmpty(a.effectiveEncryptionKey(opts.EncryptionKey), cfg.GetTransitKey())
+
+	snapshot := &Snapshot{Version: snapshotVersion, CreatedAt: time.Now().UTC()}
+	index := make(map[string]*SnapshotSecret)
+	loadErr := &LoadError{}
+
+	for _, secret := range cfg.Secrets {
+		path, key := secret.Path, secret.Key
+		if secret.IsLiteral() || secret.IsTemplate() {
+			continue
+		}
+		if path == "" {
+			path, key = secret.KVPath, ""
+		}
+		if path == "" {
+			continue
+		}
+
+		entryApp, err := a.forEntry(cfg, &secret)
+		if err != nil {
+			loadErr.Add(secret.Describe(), fmt.Errorf("failed to connect: %w", err))
+			continue
+		}
+
+		mount := cfg.GetKVMountFor(&secret, opts.KVMount)
+		paths := []string{path}
+		base, recursive, isGlob := secret.PathGlob()
+		if isGlob {
+			if paths, err = entryApp.globPaths(mount, base, recursive); err != nil {
+				loadErr.Add(secret.Describe(), err)
+				continue
+			}
+		}
+
+		for _, path := range paths {
+			values, err := entryApp.readValues(mount, path, cfg.GetTransitMountFor(&secret, opts.TransitMount), encryptionKey)
+			if isGlob && errors.Is(err, vault.ErrSecretNotFound) {
+				continue
+			}
+			if err != nil {
+				loadErr.Add(secret.Describe(), err)
+				continue
+			}
+			_, hasValue := values["value"]
+			single := hasValue && len(values) == 1
+			if key != "" {
+				value, ok := values[key]
+				if !ok {
+					loadErr.Add(secret.Describe(), vault.NewError(vault.ErrSecretNotFound, fmt.Errorf("key %q not found at path %s", key, path)))
+					continue
+				}
+				values = map[string]string{key: value}
+			}
+
+			id := secret.Namespace + "\x00" + mount + "\x00" + path
+			record, ok := index[id]
+			if !ok {
+				snapshot.Secrets = append(snapshot.Secrets, SnapshotSecret{Namespace: secret.Namespace, Mount: mount, Path: path, Data: map[string]string{}, SingleValue: single})
+				record = &snapshot.Secrets[len(snapshot.Secrets)-1]
+				index[id] = record
+			}
+			for k, v := range values {
+				record.Data[k] = v
+			}
+		}
+	}
+
+	if err := loadErr.ErrOrNil(); err != nil {
+		return fmt.Errorf("load secrets from config: %w", err)
+	}
+	if len(snapshot.Secrets) == 0 {
+		return WithExitCode(ExitNotFound, fmt.Errorf("config references no Vault paths"))
+	}
+
+	plaintext, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	ciphertext, err := utils.AgeEncrypt(plaintext, opts.Recipients, opts.RecipientFiles, opts.Armor)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(opts.OutputFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(opts.OutputFile, ciphertext, 0600); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	infof("Wrote snapshot of %d path(s) to %s\n", len(snapshot.Secrets), opts.OutputFile)
+	return nil
+}
+
+// RestoreOptions contains options for the RestoreSnapshot operation
+type RestoreOptions struct {
+	SnapshotFile  string
+	Identities    []string // age identity files
+	KVMount       string   // overrides the mount recorded in the snapshot
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string // overrides the client namespace for this command
+	DryRun        bool   // list the paths and keys that would be written
+}
+
+// RestoreSnapshot decrypts an age snapshot and merges its keys back into
+// Vault, encrypting them with transit when an encryption key is set
+func (a *App) RestoreSnapshot(opts *RestoreOptions) error {
+	if !opts.DryRun {
+		if err := a.requireWritable("restore-snapshot"); err != nil {
+			return err
+		}
+	}
+	if opts.TransitMount == "" {
+		opts.TransitMount = a.pathConfig().TransitMount("")
+	}
+	plaintext, err := utils.AgeDecrypt(opts.SnapshotFile, opts.Identities)
+	if err != nil {
+		return err
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(plaintext, &snapshot); err != nil {
+		return WithExitCode(ExitUsage, fmt.Errorf("parse snapshot %s: %w", opts.SnapshotFile, err))
+	}
+	if snapshot.Version != snapshotVersion {
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported snapshot version %d", snapshot.Version))
+	}
+
+	a = a.withNamespace(opts.Namespace)
+	encryptionKey := a.effectiveEncryptionKey(opts.EncryptionKey)
+
+	for _, secret := range snapshot.Secrets {
+		mount := config.NonEmpty(opts.KVMount, secret.Mount)
+		if opts.DryRun {
+			keys := make([]string, 0, len(secret.Data))
+			for k := range secret.Data {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			fmt.Printf("%s/%s: %v\n", mount, secret.Path, keys)
+			continue
+		}
+
+		target := a.withNamespace(secret.Namespace)
+		if value, ok := secret.Data["value"]; ok && secret.SingleValue {
+			// Single-value secrets are replaced, in the layout put uses for them
+			if err := target.putSingleValue(mount, secret.Path, opts.TransitMount, encryptionKey, value); err != nil {
+				return fmt.Errorf("restore %s/%s: %w", mount, secret.Path, err)
+			}
+			infof("Restored single value to %s/%s\n", mount, secret.Path)
+			continue
+		}
+
+		count, err := target.mergeValues(mount, secret.Path, opts.TransitMount, encryptionKey, secret.Data)
+		if err != nil {
+			return fmt.Errorf("restore %s/%s: %w", mount, secret.Path, err)
+		}
+		infof("Restored %d key(s) to %s/%s\n", count, mount, secret.Path)
+	}
+
+	infof("Snapshot from %s: %d path(s)\n", snapshot.CreatedAt.Format(time.RFC3339), len(snapshot.Secrets))
+	return nil
+}
+
+// putSingleValue stores value as a single-value secret, as ciphertext when an
+// encryption key is set
+func (a *App) putSingleValue(kvMount, kvPath, transitMount, encryptionKey, value string) error {
+	data := map[string]interface{}{"value": value}
+	encrypted := map[string]utils.EncryptedValue{}
+	if encryptionKey != "" {
+		ciphertext, err := a.vaultClient.TransitEncrypt(transitMount, encryptionKey, []byte(value))
+		if err != nil {
+			return fmt.Errorf("transit encrypt: %w", err)
+		}
+		data = map[string]interface{}{"ciphertext": ciphertext}
+		encrypted["ciphertext"] = utils.NewEncryptedValue(encryptionKey, ciphertext)
+	}
+	utils.SetMeta(data, encrypted)
+
+	if err := a.vaultClient.KVPut(kvMount, kvPath, data); err != nil {
+		return fmt.Errorf("kv put: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// ExportFormatSOPS is the file format written by ExportFile
+const ExportFormatSOPS = "sops"
+
+// ExportFileOptions contains options for the ExportFile operation
+type ExportFileOptions struct {
+	Format        string // only ExportFormatSOPS is supported
+	KVMount       string
+	KVPath        string
+	TransitMount  string
+	EncryptionKey string
+	Namespace     string // overrides the client namespace for this command
+	OutputFile    string // .json, .env, or YAML otherwise
+	Recipients    utils.SOPSRecipients
+}
+
+// ExportFile writes the decrypted keys of a KV path to a SOPS-encrypted file
+func (a *App) ExportFile(opts *ExportFileOptions) error {
+	if opts.Format != ExportFormatSOPS {
+		return WithExitCode(ExitUsage, fmt.Errorf("unsupported export format %q (expected %s)", opts.Format, ExportFormatSOPS))
+	}
+	a = a.withNamespace(opts.Namespace)
+	opts.KVMount, opts.TransitMount = a.pathMounts(opts.KVMount, opts.TransitMount)
+
+	values, err := a.readValues(opts.KVMount, opts.KVPath, opts.TransitMount, a.effectiveEncryptionKey(opts.EncryptionKey))
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := utils.EncryptSOPS(values, opts.OutputFile, opts.Recipients)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(opts.OutputFile); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(opts.OutputFile, encrypted, 0600); err != nil {
+		return fmt.Errorf("write %s: %w", opts.OutputFile, err)
+	}
+
+	infof("Exported %d key(s) from %s/%s to %s (sops)\n", len(values), opts.KVMount, opts.KVPath, opts.OutputFile)
+	return nil
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// loadTemplateEntry renders a templated config entry. Templates may reference
+// any number of secret fields with {{ secret "path" "KEY" }}.
+func (a *App) loadTemplateEntry(cfg *config.Config, secret *config.SecretEntry, kvMount, transitMount, encryptionKey string) (string, error) {
+	values := make(map[string]string)
+
+	funcs := template.FuncMap{
+		"secret": func(path, key string) (string, error) {
+			cacheKey := path + "\x00" + key
+			if value, ok := values[cacheKey]; ok {
+				return value, nil
+			}
+
+			// Resolve the field with the entry's mount and namespace settings
+			field := *secret
+			field.Path = path
+			field.Key = key
+			value, err := a.loadSingleKeyFromPath(cfg, &field, kvMount, transitMount, encryptionKey)
+			if err != nil {
+				return "", err
+			}
+			values[cacheKey] = value
+			return value, nil
+		},
+	}
+
+	tmpl, err := template.New(secret.EnvVar).Funcs(funcs).Option("missingkey=error").Parse(secret.Template)
+	if err != nil {
+		return "", WithExitCode(ExitUsage, fmt.Errorf("invalid template for %s: %w", secret.EnvVar, err))
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, nil); err != nil {
+		return "", fmt.Errorf("failed to render template for %s: %w", secret.EnvVar, err)
+	}
+	return out.String(), nil
+}
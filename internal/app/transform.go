@@ -0,0 +1,167 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// transformTimeout bounds how long an exec transformer may run for one value
+const transformTimeout = 30 * time.Second
+
+// transformer is one step of an entry's transform list: a name, and the
+// argument after "name:" if any
+type transformer struct {
+	name string
+	arg  string
+}
+
+// transformers are the built-in transformers, by name; exec is handled by
+// runTransformer since it needs the entry
+var transformers = map[string]func(value, arg string) (string, error){
+	"base64-decode": base64Decode,
+	"base64-encode": func(value, _ string) (string, error) {
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	},
+	"trim": func(value, _ string) (string, error) {
+		return strings.TrimSpace(value), nil
+	},
+	"json-extract": jsonExtract,
+}
+
+// parseTransforms parses the transform list of an entry
+func parseTransforms(secret *config.SecretEntry) ([]transformer, error) {
+	steps := make([]transformer, 0, len(secret.Transform))
+	for _, spec := range secret.Transform {
+		name, arg, _ := strings.Cut(spec, ":")
+		step := transformer{name: strings.ToLower(strings.TrimSpace(name)), arg: strings.TrimSpace(arg)}
+		switch _, builtin := transformers[step.name]; {
+		case step.name == "exec":
+			if step.arg == "" {
+				return nil, fmt.Errorf("transform %q: exec needs a command, e.g. exec: ./decode.sh", spec)
+			}
+			if isRemoteConfig(secret.Source) {
+				return nil, fmt.Errorf("transform %q: exec is not allowed in a config read from %s", spec, secret.Source)
+			}
+		case step.name == "json-extract" && step.arg == "":
+			return nil, fmt.Errorf("transform %q: json-extract needs a field, e.g. json-extract: .password", spec)
+		case !builtin:
+			return nil, fmt.Errorf("unknown transform %q (expected base64-decode, base64-encode, trim, json-extract, or exec)", spec)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// transformVars applies the transform list of an entry to each of the
+// values it resolved, in order
+func transformVars(secret *config.SecretEntry, vars map[string]string) (map[string]string, error) {
+	steps, err := parseTransforms(secret)
+	if err != nil {
+		return nil, WithExitCode(ExitUsage, err)
+	}
+	transformed := make(map[string]string, len(vars))
+	for name, value := range vars {
+		for _, step := range steps {
+			if value, err = runTransformer(secret, step, name, value); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		transformed[name] = value
+	}
+	return transformed, nil
+}
+
+// runTransformer applies one transformer to the value of the variable name
+func runTransformer(secret *config.SecretEntry, step transformer, name, value string) (string, error) {
+	if step.name != "exec" {
+		return transformers[step.name](value, step.arg)
+	}
+	return execTransform(secret, step.arg, name, value)
+}
+
+// execTransform runs command through the shell in the directory of the
+// config the entry came from, with the value on stdin and never in its
+// arguments or environment, and returns its output without the trailing
+// newline. VLT_VAR names the variable being transformed.
+func execTransform(secret *config.SecretEntry, command, name, value string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), transformTimeout)
+	defer cancel()
+
+	shell, args := shellCommand(command)
+	cmd := exec.CommandContext(ctx, shell, args...)
+	if secret.Source != "" {
+		cmd.Dir = filepath.Dir(secret.Source)
+	}
+	cmd.Env = append(os.Environ(), "VLT_VAR="+name)
+	cmd.Stdin = strings.NewReader(value)
+	cmd.Stderr = os.Stderr
+	var out bytes.Buffer
+	cmd.Stdout = &out
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("transform exec %q did not finish within %s", command, transformTimeout)
+		}
+		return "", fmt.Errorf("transform exec %q: %w", command, err)
+	}
+	return string(trimNewline(out.Bytes())), nil
+}
+
+// base64Decode decodes standard or URL-safe base64, padded or not
+func base64Decode(value, _ string) (string, error) {
+	value = strings.TrimSpace(value)
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := encoding.DecodeString(value); err == nil {
+			return string(decoded), nil
+		}
+	}
+	return "", fmt.Errorf("base64-decode: the value is not base64")
+}
+
+// jsonExtract returns the field of a JSON document at a dotted path such as
+// .db.password or .hosts.0; strings are returned as-is and other values as
+// JSON
+func jsonExtract(value, field string) (string, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(value), &doc); err != nil {
+		return "", fmt.Errorf("json-extract: the value is not JSON")
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(field, "."), ".") {
+		switch node := doc.(type) {
+		case map[string]any:
+			child, ok := node[part]
+			if !ok {
+				return "", fmt.Errorf("json-extract: %s: no field %q", field, part)
+			}
+			doc = child
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("json-extract: %s: no element %q in a list of %d", field, part, len(node))
+			}
+			doc = node[i]
+		default:
+			return "", fmt.Errorf("json-extract: %s: %q is not inside an object or list", field, part)
+		}
+	}
+	if s, ok := doc.(string); ok {
+		return s, nil
+	}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("json-extract: %w", err)
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/razzkumar/vlt/internal/utils"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// TreeOptions contains options for the Tree operation
+type TreeOptions struct {
+	KVMount string
+	KVPath  string
+}
+
+// Tree prints the KV hierarchy under a path, annotating each secret with
+// its key count, encryption status, and last-modified time
+func (a *App) Tree(opts *TreeOptions) error {
+	if opts.KVMount == "" {
+		opts.KVMount = a.pathConfig().KVMount("")
+	}
+	root := strings.Trim(opts.KVPath, "/")
+	painter := utils.NewPainter(os.Stdout)
+
+	keys, err := a.vaultClient.KVList(opts.KVMount, root)
+	if err != nil {
+		return fmt.Errorf("kv list: %w", err)
+	}
+
+	if len(keys) == 0 {
+		// The path may be a secret rather than a directory
+		annotation, err := a.describeSecret(opts.KVMount, root)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  %s\n", root, painter.Paint(annotation, utils.Dim))
+		return nil
+	}
+
+	fmt.Println(painter.Paint(fmt.Sprintf("%s/%s", strings.TrimSuffix(opts.KVMount, "/"), withTrailingSlash(root)), utils.Bold, utils.Blue))
+	return a.printTree(painter, opts.KVMount, root, "")
+}
+
+// printTree recursively renders the entries under path
+func (a *App) printTree(painter utils.Painter, mount, path, prefix string) error {
+	keys, err := a.vaultClient.KVList(mount, path)
+	if err != nil {
+		return fmt.Errorf("kv list %s: %w", path, err)
+	}
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		connector, childPrefix := "├── ", prefix+"│   "
+		if i == len(keys)-1 {
+			connector, childPrefix = "└── ", prefix+"    "
+		}
+
+		fullPath := withTrailingSlash(path) + key
+		if strings.HasSuffix(key, "/") {
+			fmt.Printf("%s%s%s\n", prefix, connector, painter.Paint(key, utils.Bold, utils.Blue))
+			if err := a.printTree(painter, mount, fullPath, childPrefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		annotation, err := a.describeSecret(mount, fullPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%s%s  %s\n", prefix, connector, key, painter.Paint(annotation, utils.Dim))
+	}
+
+	return nil
+}
+
+// describeSecret returns a short annotation for a secret leaf. Read errors
+// are shown in the annotation; only an exhausted request budget is returned.
+func (a *App) describeSecret(mount, path string) (string, error) {
+	data, err := a.vaultClient.KVGet(mount, path)
+	if errors.Is(err, vault.ErrRequestBudget) {
+		return "", err
+	}
+	if err != nil {
+		return fmt.Sprintf("(unreadable: %v)", err), nil
+	}
+
+	status := "plaintext"
+	if utils.IsEncryptedSingleValue(data) || utils.IsEncryptedMultiValue(data) {
+		status = "encrypted"
+	}
+
+	data = utils.StripMeta(data)
+	noun := "keys"
+	if len(data) == 1 {
+		noun = "key"
+	}
+
+	annotation := fmt.Sprintf("(%d %s, %s", len(data), noun, status)
+	if meta, err := a.vaultClient.KVGetMetadata(mount, path); err == nil && !meta.UpdatedTime.IsZero() {
+		annotation += fmt.Sprintf(", modified %s", meta.UpdatedTime.Local().Format("2006-01-02 15:04"))
+	}
+	return annotation + ")", nil
+}
+
+// withTrailingSlash appends "/" to a non-empty path that lacks one
+func withTrailingSlash(path string) string {
+	if path == "" || strings.HasSuffix(path, "/") {
+		return path
+	}
+	return path + "/"
+}
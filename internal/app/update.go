@@ -0,0 +1,274 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/buildinfo"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// DefaultReleaseFeed is the GitHub API endpoint of the latest release
+const DefaultReleaseFeed = "https://api.github.com/repos/razzkumar/vlt/releases/latest"
+
+// Release assets next to the platform binaries. checksums.txt lists the
+// SHA-256 of every binary (sha256sum format) and checksums.txt.sig is the
+// base64 Ed25519 signature of checksums.txt.
+const (
+	checksumsAsset = "checksums.txt"
+	signatureAsset = "checksums.txt.sig"
+)
+
+const releaseFetchTimeout = 5 * time.Minute
+
+// releasePublicKey is the base64 Ed25519 key release checksums are signed
+// with. It is set at build time:
+//
+//	-ldflags "-X github.com/razzkumar/vlt/internal/app.releasePublicKey=<base64>"
+var releasePublicKey = ""
+
+// SelfUpdateOptions contains options for the SelfUpdate operation
+type SelfUpdateOptions struct {
+	CurrentVersion string
+	FeedURL        string
+	PublicKey      string // base64 Ed25519 key, for builds without one built in
+	Check          bool   // only report whether an update is available
+}
+
+// release is the part of a GitHub release the updater uses
+type release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []releaseAsset `json:"assets"`
+}
+
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// SelfUpdate replaces the running binary with the latest release for this
+// platform after checking its checksum against the signed checksum list.
+// With Check set it only reports, failing with ExitDrift when outdated.
+func SelfUpdate(opts *SelfUpdateOptions) error {
+	// A build that carries the release key never trusts another one
+	if releasePublicKey != "" && opts.PublicKey != "" && opts.PublicKey != releasePublicKey {
+		return WithExitCode(ExitUsage, fmt.Errorf("--public-key is only for builds without a built-in release key, and this build has one"))
+	}
+
+	client := &http.Client{Timeout: releaseFetchTimeout}
+	userAgent := buildinfo.UserAgent()
+
+	body, err := download(client, opts.FeedURL, userAgent)
+	if err != nil {
+		return fmt.Errorf("release feed: %w", err)
+	}
+	var latest release
+	if err := json.Unmarshal(body, &latest); err != nil {
+		return fmt.Errorf("release feed %s: %w", opts.FeedURL, err)
+	}
+	if latest.TagName == "" {
+		return fmt.Errorf("release feed %s: no release tag", opts.FeedURL)
+	}
+
+	current := strings.TrimPrefix(opts.CurrentVersion, "v")
+	available := strings.TrimPrefix(latest.TagName, "v")
+	if compareVersions(available, current) <= 0 {
+		infof("vlt %s is up to date\n", current)
+		return nil
+	}
+	if opts.Check {
+		return WithExitCode(ExitDrift, fmt.Errorf("vlt %s is outdated: %s is available (run vlt self-update)", current, available))
+	}
+
+	publicKey, err := parsePublicKey(config.NonEmpty(opts.PublicKey, releasePublicKey))
+	if err != nil {
+		return err
+	}
+
+	binaryName := fmt.Sprintf("vlt-%s-%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		binaryName += ".exe"
+	}
+	assets := make(map[string]string, len(latest.Assets))
+	for _, asset := range latest.Assets {
+		assets[asset.Name] = asset.URL
+	}
+	for _, name := range []string{binaryName, checksumsAsset, signatureAsset} {
+		if assets[name] == "" {
+			return WithExitCode(ExitNotFound, fmt.Errorf("release %s has no %s asset", latest.TagName, name))
+		}
+	}
+
+	checksums, err := download(client, assets[checksumsAsset], userAgent)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", checksumsAsset, err)
+	}
+	signature, err := download(client, assets[signatureAsset], userAgent)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", signatureAsset, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(signature)), ""))
+	if err != nil || !ed25519.Verify(publicKey, checksums, sig) {
+		return fmt.Errorf("release %s: %s signature is invalid", latest.TagName, checksumsAsset)
+	}
+	want, err := lookupChecksum(checksums, binaryName)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", latest.TagName, err)
+	}
+
+	binary, err := download(client, assets[binaryName], userAgent)
+	if err != nil {
+		return fmt.Errorf("download %s: %w", binaryName, err)
+	}
+	if sum := sha256.Sum256(binary); hex.EncodeToString(sum[:]) != want {
+		return fmt.Errorf("release %s: %s does not match its signed checksum", latest.TagName, binaryName)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate running binary: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locate running binary: %w", err)
+	}
+	if err := replaceBinary(exe, binary); err != nil {
+		return err
+	}
+	infof("Updated vlt %s to %s (%s)\n", current, available, exe)
+	return nil
+}
+
+// parsePublicKey decodes a base64 Ed25519 public key
+func parsePublicKey(encoded string) (ed25519.PublicKey, error) {
+	if encoded == "" {
+		return nil, WithExitCode(ExitUsage, errors.New("this build has no release signing key; pass --public-key to verify the update"))
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, WithExitCode(ExitUsage, errors.New("release public key must be a base64 Ed25519 public key"))
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// lookupChecksum returns the SHA-256 of name from a sha256sum listing
+func lookupChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not listed in %s", name, checksumsAsset)
+}
+
+// download fetches url and returns its body
+func download(client *http.Client, url, userAgent string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// replaceBinary atomically replaces the file at exe with data, keeping its
+// permissions. Windows cannot overwrite a running binary, so the old one is
+// moved aside first and removed on the next update.
+func replaceBinary(exe string, data []byte) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", exe, err)
+	}
+
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".vlt-update-*")
+	if err != nil {
+		return fmt.Errorf("write update next to %s: %w", exe, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write update: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write update: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write update: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return fmt.Errorf("move %s aside: %w", exe, err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("replace %s: %w", exe, err)
+	}
+	return nil
+}
+
+// compareVersions compares dotted numeric versions like 2.1.0, ignoring any
+// pre-release or build suffix, and returns -1, 0, or 1
+func compareVersions(a, b string) int {
+	partsA := versionParts(a)
+	partsB := versionParts(b)
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		var x, y int
+		if i < len(partsA) {
+			x = partsA[i]
+		}
+		if i < len(partsB) {
+			y = partsB[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version, _, _ = strings.Cut(version, "-")
+	version, _, _ = strings.Cut(version, "+")
+	var parts []int
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
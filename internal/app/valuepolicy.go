@@ -0,0 +1,246 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/razzkumar/vlt/internal/audit"
+)
+
+// ValuePolicyEnv names the value policy file put and import enforce. It
+// takes precedence over the config's value_policy.
+const ValuePolicyEnv = "VLT_VALUE_POLICY"
+
+// valuePolicy is a file of rules that values must satisfy before put or
+// import write them
+type valuePolicy struct {
+	path  string
+	Rules []valueRule `yaml:"rules"`
+}
+
+// valueRule constrains the values of the keys and KV paths it matches. A
+// rule without paths or keys applies to every value.
+type valueRule struct {
+	Name            string   `yaml:"name"`
+	Paths           []string `yaml:"paths,omitempty"`            // KV path globs; "prod/**" matches everything below prod
+	Keys            []string `yaml:"keys,omitempty"`             // regular expressions on key names
+	MinLength       int      `yaml:"min_length,omitempty"`       // shortest accepted value, in characters
+	MinEntropy      float64  `yaml:"min_entropy,omitempty"`      // lowest accepted estimated entropy, in bits
+	Deny            []string `yaml:"deny,omitempty"`             // rejected values, compared case-insensitively
+	DenyPatterns    []string `yaml:"deny_patterns,omitempty"`    // regular expressions values must not match
+	RequirePatterns []string `yaml:"require_patterns,omitempty"` // regular expressions values must all match
+
+	keys, deny, require []*regexp.Regexp
+}
+
+// loadValuePolicy reads the value policy named by VLT_VALUE_POLICY or the
+// config's value_policy, or returns nil when neither is set
+func (a *App) loadValuePolicy() (*valuePolicy, error) {
+	file := os.Getenv(ValuePolicyEnv)
+	if file == "" {
+		file = a.pathConfig().ValuePolicy
+	}
+	if file == "" {
+		return nil, nil
+	}
+	file = expandHome(file)
+
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		// A policy that vanished must not silently stop being enforced
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("value policy %s does not exist", file))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read value policy: %w", err)
+	}
+
+	policy := &valuePolicy{path: file}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("parse value policy %s: %w", file, err))
+	}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		for _, glob := range rule.Paths {
+			if _, err := path.Match(strings.TrimSuffix(glob, "/**"), ""); err != nil {
+				return nil, WithExitCode(ExitUsage, fmt.Errorf("value policy %s: %s: invalid path glob %q", file, rule.Name, glob))
+			}
+		}
+		var err error
+		if rule.keys, err = compilePatterns(rule.Keys); err == nil {
+			if rule.deny, err = compilePatterns(rule.DenyPatterns); err == nil {
+				rule.require, err = compilePatterns(rule.RequirePatterns)
+			}
+		}
+		if err != nil {
+			return nil, WithExitCode(ExitUsage, fmt.Errorf("value policy %s: %s: %w", file, rule.Name, err))
+		}
+	}
+	return policy, nil
+}
+
+// compilePatterns compiles the regular expressions of a rule
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// violations returns what is wrong with the values written to kvPath, one
+// "KEY: problem (rule)" line per failed check, in key order. Values are
+// never included.
+func (p *valuePolicy) violations(kvPath string, values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var found []string
+	for _, key := range keys {
+		for i := range p.Rules {
+			rule := &p.Rules[i]
+			if !rule.applies(kvPath, key) {
+				continue
+			}
+			for _, problem := range rule.check(values[key]) {
+				found = append(found, fmt.Sprintf("%s: %s (%s)", key, problem, rule.Name))
+			}
+		}
+	}
+	return found
+}
+
+// applies reports whether the rule covers key of the secret at kvPath
+func (r *valueRule) applies(kvPath, key string) bool {
+	if len(r.Paths) > 0 && !matchesAnyGlob(r.Paths, strings.Trim(kvPath, "/")) {
+		return false
+	}
+	if len(r.keys) == 0 {
+		return true
+	}
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// check returns the rule's complaints about one value
+func (r *valueRule) check(value string) []string {
+	var problems []string
+	for _, denied := range r.Deny {
+		if strings.EqualFold(strings.TrimSpace(value), denied) {
+			problems = append(problems, "value is on the deny-list")
+			break
+		}
+	}
+	for _, re := range r.deny {
+		if re.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("value matches denied pattern %q", re.String()))
+		}
+	}
+	for _, re := range r.require {
+		if !re.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("value does not match required pattern %q", re.String()))
+		}
+	}
+	if length := utf8.RuneCountInString(value); r.MinLength > 0 && length < r.MinLength {
+		problems = append(problems, fmt.Sprintf("value is %d characters, shorter than %d", length, r.MinLength))
+	}
+	if bits := entropyBits(value); r.MinEntropy > 0 && bits < r.MinEntropy {
+		problems = append(problems, fmt.Sprintf("value has about %.0f bits of entropy, less than %g", bits, r.MinEntropy))
+	}
+	return problems
+}
+
+// matchesAnyGlob reports whether kvPath matches one of the globs. A glob
+// ending in /** matches every path below its prefix.
+func matchesAnyGlob(globs []string, kvPath string) bool {
+	for _, glob := range globs {
+		glob = strings.Trim(glob, "/")
+		if prefix, ok := strings.CutSuffix(glob, "/**"); ok {
+			parts := strings.Split(kvPath, "/")
+			for i := 1; i <= len(parts); i++ {
+				if matched, _ := path.Match(prefix, strings.Join(parts[:i], "/")); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(glob, kvPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// entropyBits estimates the entropy of value as its length times the Shannon
+// entropy of its character distribution
+func entropyBits(value string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+	var perChar float64
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		perChar -= p * math.Log2(p)
+	}
+	return perChar * float64(total)
+}
+
+// enforceValuePolicy checks the plaintext values about to be written to
+// kvPath against the value policy. Violations fail the write unless
+// overrideReason is given, in which case they are reported as warnings and
+// the override, with its reason, is recorded in the audit log.
+func (a *App) enforceValuePolicy(kvPath string, values map[string]string, overrideReason string) error {
+	policy, err := a.loadValuePolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+	violations := policy.violations(kvPath, values)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	overrideReason = strings.TrimSpace(overrideReason)
+	if overrideReason == "" {
+		return WithExitCode(ExitPermission, fmt.Errorf("value policy %s rejects %s:\n  %s\npass --override-policy REASON to write anyway",
+			policy.path, kvPath, strings.Join(violations, "\n  ")))
+	}
+	if !audit.Enabled() {
+		return WithExitCode(ExitUsage, fmt.Errorf("--override-policy needs an audit log to record the override (--audit-log or VLT_AUDIT_LOG)"))
+	}
+	for _, violation := range violations {
+		warnf("value policy: %s\n", violation)
+	}
+	warnf("overriding value policy %s: %s\n", policy.path, overrideReason)
+	audit.RecordPolicyOverride(audit.PolicyOverride{
+		Policy:     policy.path,
+		Path:       kvPath,
+		Reason:     overrideReason,
+		Violations: violations,
+	})
+	return nil
+}
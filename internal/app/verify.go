@@ -0,0 +1,128 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/utils"
+)
+
+// manifestVersion is the version of the checksum manifest format
+const manifestVersion = 1
+
+// Manifest records the SHA-256 hash of every file written by sync. Hashes
+// cover the rendered file content, never individual secrets.
+type Manifest struct {
+	Version     int               `json:"version"`
+	GeneratedAt time.Time         `json:"generated_at"`
+	Files       map[string]string `json:"files"` // path -> hex SHA-256
+}
+
+// writeManifest writes the hashes of the rendered outputs to path
+func writeManifest(path string, outputs []renderedOutput) error {
+	manifest := Manifest{Version: manifestVersion, GeneratedAt: time.Now().UTC(), Files: make(map[string]string, len(outputs))}
+	for _, output := range outputs {
+		manifest.Files[output.Path] = sha256Hex(output.Content)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal manifest: %w", err)
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create manifest directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+
+	infof("Generated manifest %s for %d file(s)\n", path, len(outputs))
+	return nil
+}
+
+// readManifest loads a manifest written by sync
+func readManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("parse manifest %s: %w", path, err))
+	}
+	if manifest.Version != manifestVersion {
+		return nil, WithExitCode(ExitUsage, fmt.Errorf("unsupported manifest version %d", manifest.Version))
+	}
+	return &manifest, nil
+}
+
+// Verify renders the files sync would write from the current Vault state and
+// checks that the files on disk still match them. With a manifest, files
+// changed since the last sync are told apart from secrets changed in Vault.
+func (a *App) Verify(opts *SyncOptions) error {
+	outputs, err := a.renderSyncOutputs(opts)
+	if err != nil {
+		return err
+	}
+
+	var manifest *Manifest
+	if opts.Manifest != "" {
+		if manifest, err = readManifest(opts.Manifest); err != nil {
+			return err
+		}
+	}
+
+	painter := utils.NewPainter(os.Stdout)
+	mismatches := 0
+	for _, output := range outputs {
+		status := a.verifyOutput(output, manifest, opts.Identities)
+		if status != "ok" {
+			mismatches++
+		}
+		fmt.Printf("%s %s\n", painter.Paint(fmt.Sprintf("%-8s", status), statusStyle(status)), output.Path)
+	}
+
+	if mismatches > 0 {
+		return WithExitCode(ExitDrift, fmt.Errorf("%d of %d file(s) do not match the current Vault state", mismatches, len(outputs)))
+	}
+	return nil
+}
+
+// verifyOutput returns the status of one file: ok, missing, modified (the
+// file was changed since sync), stale (the secrets changed in Vault since
+// sync), or differs (no manifest to tell the two apart). Files written with
+// --encrypt-output are compared by their plaintext.
+func (a *App) verifyOutput(output renderedOutput, manifest *Manifest, identities []string) string {
+	data, err := os.ReadFile(output.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "missing"
+	}
+	if err == nil && !output.DropIn {
+		data, err = a.decryptEnvFile(output.Path, data, identities)
+		data = stripExpiryHeader(data)
+	}
+	if err != nil {
+		warnf("%s: %v\n", output.Path, err)
+		return "error"
+	}
+
+	actual := sha256Hex(data)
+	if actual == sha256Hex(output.Content) {
+		return "ok"
+	}
+	if manifest == nil {
+		return "differs"
+	}
+	if recorded, ok := manifest.Files[output.Path]; ok && recorded == actual {
+		return "stale"
+	}
+	return "modified"
+}
@@ -0,0 +1,29 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/razzkumar/vlt/internal/buildinfo"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// Version prints the build metadata of the running binary
+func Version(asJSON bool) error {
+	info := buildinfo.Get()
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Printf("Version:        %s\n", info.Version)
+	fmt.Printf("Commit:         %s\n", config.NonEmpty(info.Commit, "unknown"))
+	fmt.Printf("Built:          %s\n", config.NonEmpty(info.Date, "unknown"))
+	fmt.Printf("Go:             %s\n", info.GoVersion)
+	fmt.Printf("Vault API:      %s\n", config.NonEmpty(info.VaultAPIVersion, "unknown"))
+	fmt.Printf("Platform:       %s\n", info.Platform)
+	return nil
+}
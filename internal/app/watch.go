@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/razzkumar/vlt/internal/metrics"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// DefaultWatchInterval is how often run --watch re-reads secrets when Vault
+// events are unavailable
+const DefaultWatchInterval = 30 * time.Second
+
+// runWatch runs the command and restarts it whenever its environment changes
+// in Vault. Changes are pushed by Vault's event API (Vault 1.13+); when the
+// subscription fails the secrets are re-read every WatchInterval instead.
+// The command exiting on its own ends the watch with its exit status.
+func (a *App) runWatch(opts *RunOptions, envVars map[string]string, maskValues []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interval := opts.WatchInterval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	if opts.MetricsAddr != "" {
+		if err := metrics.Serve(ctx, opts.MetricsAddr); err != nil {
+			return WithExitCode(ExitUsage, fmt.Errorf("metrics: %w", err))
+		}
+	}
+	metrics.RecordRender(nil)
+	changes := a.watchChanges(ctx, interval)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigCh)
+
+	current := envHash(envVars)
+	cmd, flush, done, err := startWatched(opts, envVars, maskValues)
+	if err != nil {
+		return commandError(err)
+	}
+
+	for {
+		select {
+		case err := <-done:
+			flush()
+			return commandError(err)
+		case sig := <-sigCh:
+			_ = signalProcessGroup(cmd.Process, sig)
+		case <-changes:
+			newEnv, newSecrets, err := a.runEnvironment(opts)
+			metrics.RecordRender(err)
+			if err != nil {
+				statusf("vlt: reload secrets: %v (command keeps running)\n", err)
+				continue
+			}
+			if hash := envHash(newEnv); hash != current {
+				current = hash
+			} else {
+				continue
+			}
+
+			statusf("vlt: secrets changed, restarting command\n")
+			metrics.CommandRestarts.Inc()
+			stopWatched(cmd, done, opts.KillAfter)
+			flush()
+
+			if opts.MaskOutput {
+				maskValues = newSecrets
+			}
+			cmd, flush, done, err = startWatched(opts, newEnv, maskValues)
+			if err != nil {
+				return commandError(err)
+			}
+		}
+	}
+}
+
+// startWatched starts the command of opts in its own process group. done
+// receives the result of Wait.
+func startWatched(opts *RunOptions, envVars map[string]string, maskValues []string) (*exec.Cmd, func(), <-chan error, error) {
+	cmd, flush := newRunCommand(opts, envVars, maskValues)
+	setProcessGroup(cmd)
+	if err := startCommand(cmd); err != nil {
+		return nil, nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	return cmd, flush, done, nil
+}
+
+// stopWatched sends SIGTERM to the command's process group, and SIGKILL if it
+// is still running killAfter later
+func stopWatched(cmd *exec.Cmd, done <-chan error, killAfter time.Duration) {
+	if err := signalProcessGroup(cmd.Process, syscall.SIGTERM); err != nil {
+		_ = signalProcessGroup(cmd.Process, os.Kill)
+	}
+	select {
+	case <-done:
+	case <-time.After(killAfter):
+		fmt.Fprintf(os.Stderr, "vlt: command still running after %s, killing\n", killAfter)
+		_ = signalProcessGroup(cmd.Process, os.Kill)
+		<-done
+	}
+}
+
+// watchChanges signals when secrets may have changed: on every KV write event
+// from Vault, or every interval once the event subscription is unavailable.
+// Bursts of events are coalesced into one signal.
+func (a *App) watchChanges(ctx context.Context, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	notify := func() {
+		select {
+		case changes <- struct{}{}:
+		default:
+		}
+	}
+
+	go func() {
+		events := make(chan vault.Event)
+		go func() {
+			for range events {
+				notify()
+			}
+		}()
+
+		err := a.vaultClient.SubscribeEvents(ctx, vault.EventKVWrite, events)
+		close(events)
+		if ctx.Err() != nil {
+			return
+		}
+		statusf("vlt: Vault events unavailable (%v), polling every %s\n", err, interval)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				notify()
+			}
+		}
+	}()
+
+	return changes
+}
+
+// envHash fingerprints an environment so reloads can tell whether it changed
+func envHash(envVars map[string]string) string {
+	keys := make([]string, 0, len(envVars))
+	for k := range envVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\x00%s\x00", k, envVars[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// whoamiReport is the JSON form of Whoami's output
+type whoamiReport struct {
+	Address     string    `json:"address"`
+	AuthMethod  string    `json:"auth_method"`
+	Source      string    `json:"source"`
+	DisplayName string    `json:"display_name"`
+	TokenType   string    `json:"token_type,omitempty"`
+	Policies    []string  `json:"policies"`
+	TTL         int64     `json:"ttl"` // seconds; 0 when the token never expires
+	Renewable   bool      `json:"renewable"`
+	ExpireTime  time.Time `json:"expire_time,omitzero"`
+	EntityID    string    `json:"entity_id,omitempty"`
+	Namespace   string    `json:"namespace"`
+	AuthPath    string    `json:"auth_path,omitempty"`
+	Accessor    string    `json:"accessor,omitempty"`
+}
+
+// Whoami prints the identity behind the configured credentials: the token's
+// display name, policies, TTL, entity, and namespace from
+// auth/token/lookup-self, and which auth method and credential source
+// produced it
+func Whoami(opts *Options, asJSON bool) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	_, _, vaultConfig, err := loadVaultConfig(opts)
+	if err != nil {
+		return err
+	}
+	source := credentialSource(vaultConfig)
+	if stored := applyStoredToken(vaultConfig); stored != "" {
+		source = storeDescription(stored)
+	}
+
+	client, err := vault.NewClient(vaultConfig)
+	if err != nil {
+		if vault.IsPermissionDenied(err) || vault.IsUnauthorized(err) {
+			return WithExitCode(ExitAuth, fmt.Errorf("failed to create vault client: %w", err))
+		}
+		return fmt.Errorf("failed to create vault client: %w", err)
+	}
+
+	info, err := client.LookupSelf()
+	if err != nil {
+		if vault.IsPermissionDenied(err) {
+			return WithExitCode(ExitAuth, fmt.Errorf("%w (the token from %s is invalid or expired)", err, source))
+		}
+		return err
+	}
+
+	report := whoamiReport{
+		Address:     client.Address(),
+		AuthMethod:  config.NonEmpty(vaultConfig.AuthMethod, vaultConfig.DetectAuthMethod()),
+		Source:      source,
+		DisplayName: info.DisplayName,
+		TokenType:   info.Type,
+		Policies:    info.Policies,
+		TTL:         int64(info.TTL / time.Second),
+		Renewable:   info.Renewable,
+		ExpireTime:  info.ExpireTime,
+		EntityID:    info.EntityID,
+		Namespace:   config.NonEmpty(strings.TrimSuffix(info.Namespace, "/"), "root"),
+		AuthPath:    info.Path,
+		Accessor:    info.Accessor,
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("Vault:          %s\n", report.Address)
+	fmt.Printf("Auth method:    %s\n", report.AuthMethod)
+	fmt.Printf("Credentials:    %s\n", report.Source)
+	fmt.Printf("Display name:   %s\n", report.DisplayName)
+	fmt.Printf("Policies:       %s\n", strings.Join(report.Policies, ", "))
+	if report.TokenType != "" {
+		fmt.Printf("Token type:     %s\n", report.TokenType)
+	}
+	ttl := formatTTL(info.TTL)
+	if info.Renewable {
+		ttl += " (renewable)"
+	}
+	fmt.Printf("TTL:            %s\n", ttl)
+	if !info.ExpireTime.IsZero() {
+		fmt.Printf("Expires:        %s\n", info.ExpireTime.Local().Format(time.RFC3339))
+	}
+	fmt.Printf("Entity ID:      %s\n", config.NonEmpty(report.EntityID, "(none)"))
+	fmt.Printf("Namespace:      %s\n", report.Namespace)
+	if report.AuthPath != "" {
+		fmt.Printf("Created by:     %s\n", report.AuthPath)
+	}
+	return nil
+}
+
+// credentialSource describes where the configured credentials come from,
+// before any fallback to a stored token
+func credentialSource(cfg *config.VaultConfig) string {
+	switch method := config.NonEmpty(cfg.AuthMethod, cfg.DetectAuthMethod()); method {
+	case "approle":
+		return "AppRole login (" + flagOrEnv(cfg.RoleID, "VAULT_ROLE_ID", "--vault-role-id") + ")"
+	case "github":
+		return "GitHub login (" + flagOrEnv(cfg.GitHubToken, "VAULT_GITHUB_TOKEN", "--vault-github-token") + ")"
+	case "kubernetes":
+		return fmt.Sprintf("Kubernetes login (role %s)", cfg.K8sRole)
+	case "token":
+		switch {
+		case cfg.Token != "":
+		case cfg.AgentTokenFile != "":
+			return "Vault Agent sink " + cfg.AgentTokenFile + " (VAULT_AGENT_TOKEN_FILE)"
+		case cfg.AgentAddr != "":
+			return "Vault Agent auto-auth (VAULT_AGENT_ADDR)"
+		default:
+			return "none"
+		}
+		return flagOrEnv(cfg.Token, "VAULT_TOKEN", "--vault-token")
+	default:
+		return method + " login"
+	}
+}
+
+// flagOrEnv names the environment variable when it holds value, and the
+// global flag that takes precedence over it otherwise
+func flagOrEnv(value, env, flag string) string {
+	if os.Getenv(env) == value {
+		return env
+	}
+	return flag + " flag"
+}
+
+// storeDescription describes a token store returned by storedToken
+func storeDescription(store string) string {
+	if store == TokenStoreFile {
+		return tokenFilePath() + " (vlt login --store file or the Vault CLI)"
+	}
+	return "OS keychain (vlt login)"
+}
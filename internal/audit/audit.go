@@ -0,0 +1,244 @@
+// Package audit writes an opt-in, append-only JSONL record of each vlt
+// invocation: who ran which command, which Vault paths it touched, and
+// whether secret values were displayed. Values themselves are never recorded.
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Access is one Vault API path touched by the command
+type Access struct {
+	Op        string `json:"op"` // read, list, write, delete, encrypt, decrypt, login
+	Path      string `json:"path"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Record is one line of the audit log
+type Record struct {
+	Time          time.Time `json:"time"`
+	User          string    `json:"user"`
+	Host          string    `json:"host,omitempty"`
+	PID           int       `json:"pid"`
+	Command       string    `json:"command"`
+	Flags         []string  `json:"flags,omitempty"`          // flag names only; their values may be secrets
+	CorrelationID string    `json:"correlation_id,omitempty"` // X-Correlation-ID sent with the Vault requests
+	Vault         []Access  `json:"vault"`
+	Displayed     bool      `json:"displayed"` // plaintext values were printed
+	Copied        bool      `json:"copied"`    // a plaintext value was copied to the clipboard
+	ExitCode      int       `json:"exit_code"`
+
+	// PolicyOverrides lists the value policy violations written anyway with --override-policy
+	PolicyOverrides []PolicyOverride `json:"policy_overrides,omitempty"`
+}
+
+// PolicyOverride is a write that violated the value policy, allowed with a reason
+type PolicyOverride struct {
+	Policy     string   `json:"policy"` // policy file
+	Path       string   `json:"path"`   // KV path written
+	Reason     string   `json:"reason"`
+	Violations []string `json:"violations"` // key, problem, and rule of each violation; never values
+}
+
+var (
+	mu     sync.Mutex
+	file   *os.File
+	record *Record
+	seen   map[Access]bool
+)
+
+// Start opens the audit log at path and begins a record for the command.
+// The file is opened before any secret is read so an unwritable log stops
+// the command instead of leaving it unrecorded.
+func Start(path, command string, flags []string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("open audit log: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	mu.Lock()
+	defer mu.Unlock()
+	file = f
+	record = &Record{
+		Time:    time.Now().UTC(),
+		User:    currentUser(),
+		Host:    host,
+		PID:     os.Getpid(),
+		Command: command,
+		Flags:   flags,
+		Vault:   []Access{},
+	}
+	seen = make(map[Access]bool)
+	return nil
+}
+
+// Enabled reports whether an audit record is being collected
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return record != nil
+}
+
+// MarkDisplayed records that plaintext secret values were printed
+func MarkDisplayed() {
+	mu.Lock()
+	defer mu.Unlock()
+	if record != nil {
+		record.Displayed = true
+	}
+}
+
+// MarkCopied records that a plaintext secret value was copied to the clipboard
+func MarkCopied() {
+	mu.Lock()
+	defer mu.Unlock()
+	if record != nil {
+		record.Copied = true
+	}
+}
+
+// SetCorrelationID records the correlation ID sent with the command's Vault
+// requests, so the record can be matched with gateway and Vault audit logs
+func SetCorrelationID(id string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if record != nil {
+		record.CorrelationID = id
+	}
+}
+
+// RecordPolicyOverride records a value policy override and its reason
+func RecordPolicyOverride(override PolicyOverride) {
+	mu.Lock()
+	defer mu.Unlock()
+	if record != nil {
+		record.PolicyOverrides = append(record.PolicyOverrides, override)
+	}
+}
+
+// RecordAccess adds a Vault API path to the record, once per operation
+func RecordAccess(access Access) {
+	mu.Lock()
+	defer mu.Unlock()
+	if record == nil || seen[access] {
+		return
+	}
+	seen[access] = true
+	record.Vault = append(record.Vault, access)
+}
+
+// Finish appends the record with the command's exit code and closes the log
+func Finish(exitCode int) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if record == nil {
+		return nil
+	}
+	defer func() {
+		file.Close()
+		file, record, seen = nil, nil, nil
+	}()
+
+	record.ExitCode = exitCode
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode audit record: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// currentUser returns the local user name, falling back to $USER
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
+
+// FlagNames returns the names of the flags in args, up to a "--" separator.
+// Flag values are dropped since they can hold secrets (put --value).
+func FlagNames(args []string) []string {
+	var names []string
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		names = append(names, name)
+	}
+	return names
+}
+
+// Transport records the Vault paths requested through an http.RoundTripper
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// InstrumentTransport returns base wrapped so its Vault requests are added
+// to the current audit record
+func InstrumentTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if path, ok := strings.CutPrefix(req.URL.Path, "/v1/"); ok && Enabled() && !strings.HasPrefix(path, "sys/health") {
+		RecordAccess(Access{
+			Op:        operation(req, path),
+			Path:      path,
+			Namespace: req.Header.Get("X-Vault-Namespace"),
+		})
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// Unwrap returns the wrapped transport
+func (t *Transport) Unwrap() http.RoundTripper {
+	return t.Base
+}
+
+// operation classifies a Vault API request
+func operation(req *http.Request, path string) string {
+	segments := strings.Split(path, "/")
+	switch {
+	case segments[0] == "auth" && (segments[len(segments)-1] == "login" ||
+		len(segments) > 2 && segments[len(segments)-2] == "login"):
+		return "login"
+	case len(segments) > 1 && (segments[1] == "encrypt" || segments[1] == "decrypt"):
+		return segments[1]
+	}
+
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("list") == "true" {
+			return "list"
+		}
+		return "read"
+	case "LIST":
+		return "list"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "write"
+	}
+}
@@ -0,0 +1,83 @@
+// Package buildinfo describes the running binary: its version, the commit and
+// date it was built from, and the Go and Vault API versions it was built with.
+// Release builds set the variables with -ldflags; other builds fall back to
+// the VCS information Go embeds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with
+//
+//	-ldflags "-X github.com/razzkumar/vlt/internal/buildinfo.Version=2.1.0 ..."
+var (
+	Version = "2.0.0"
+	Commit  = ""
+	Date    = ""
+)
+
+const vaultAPIModule = "github.com/hashicorp/vault/api"
+
+// Info is the build metadata printed by "vlt version"
+type Info struct {
+	Version         string `json:"version"`
+	Commit          string `json:"commit,omitempty"`
+	Date            string `json:"date,omitempty"`
+	GoVersion       string `json:"go_version"`
+	VaultAPIVersion string `json:"vault_api_version,omitempty"`
+	Platform        string `json:"platform"`
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, dep := range build.Deps {
+		if dep.Path == vaultAPIModule {
+			info.VaultAPIVersion = dep.Version
+			if dep.Replace != nil {
+				info.VaultAPIVersion = dep.Replace.Version
+			}
+		}
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// UserAgent identifies vlt in HTTP requests, e.g.
+// "vlt/2.1.0 (commit 1a2b3c4; linux/amd64; go1.24.1)"
+func UserAgent() string {
+	info := Get()
+	commit := info.Commit
+	if len(commit) > 7 {
+		commit = commit[:7]
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	return fmt.Sprintf("vlt/%s (commit %s; %s; %s)", info.Version, commit, info.Platform, info.GoVersion)
+}
@@ -0,0 +1,45 @@
+// Package keychain stores secrets in the operating system's credential store:
+// the macOS Keychain, the Secret Service (GNOME Keyring, KWallet) through
+// secret-tool on Linux and the BSDs, and the Windows Credential Manager.
+package keychain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Service is the service name vlt credentials are stored under
+const Service = "vlt"
+
+var (
+	// ErrNotFound is returned when no credential is stored for an account
+	ErrNotFound = errors.New("no credential found in the keychain")
+
+	// ErrUnsupported is returned when no credential store is available
+	ErrUnsupported = errors.New("no keychain available on this system")
+)
+
+// Get returns the secret stored for account
+func Get(account string) (string, error) {
+	secret, err := get(account)
+	if err != nil {
+		return "", fmt.Errorf("keychain read: %w", err)
+	}
+	return secret, nil
+}
+
+// Set stores secret for account, replacing any existing value
+func Set(account, secret string) error {
+	if err := set(account, secret); err != nil {
+		return fmt.Errorf("keychain write: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the secret stored for account
+func Delete(account string) error {
+	if err := del(account); err != nil {
+		return fmt.Errorf("keychain delete: %w", err)
+	}
+	return nil
+}
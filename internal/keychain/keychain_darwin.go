@@ -0,0 +1,62 @@
+//go:build darwin
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errItemNotFound is the exit status of security(1) for a missing item
+const errItemNotFound = 44
+
+func get(account string) (string, error) {
+	out, err := security(nil, "find-generic-password", "-s", Service, "-a", account, "-w")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func set(account, secret string) error {
+	// Commands read from stdin keep the secret out of the process list
+	script := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quote(Service), quote(account), quote(secret))
+	_, err := security([]byte(script), "-i")
+	return err
+}
+
+func del(account string) error {
+	_, err := security(nil, "delete-generic-password", "-s", Service, "-a", account)
+	return err
+}
+
+// security runs the macOS security tool
+func security(stdin []byte, args ...string) (string, error) {
+	path, err := exec.LookPath("security")
+	if err != nil {
+		return "", ErrUnsupported
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == errItemNotFound {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("security %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// quote quotes s for the command parser of security -i
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
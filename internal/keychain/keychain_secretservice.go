@@ -0,0 +1,60 @@
+//go:build !darwin && !windows
+
+package keychain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+func get(account string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", Service, "account", account)
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return out, nil
+}
+
+func set(account, secret string) error {
+	// secret-tool reads the secret from stdin, keeping it out of the process list
+	_, err := secretTool([]byte(secret), "store", "--label", "vlt Vault token for "+account, "service", Service, "account", account)
+	return err
+}
+
+func del(account string) error {
+	if _, err := get(account); err != nil {
+		return err
+	}
+	_, err := secretTool(nil, "clear", "service", Service, "account", account)
+	return err
+}
+
+// secretTool runs secret-tool from libsecret
+func secretTool(stdin []byte, args ...string) (string, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return "", ErrUnsupported
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		// lookup exits non-zero without output when nothing matches
+		var exitErr *exec.ExitError
+		if args[0] == "lookup" && errors.As(err, &exitErr) && strings.TrimSpace(stderr.String()) == "" {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secret-tool %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,109 @@
+//go:build windows
+
+package keychain
+
+import (
+	"errors"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+var (
+	advapi32     = syscall.NewLazyDLL("advapi32.dll")
+	procRead     = advapi32.NewProc("CredReadW")
+	procWrite    = advapi32.NewProc("CredWriteW")
+	procDelete   = advapi32.NewProc("CredDeleteW")
+	procCredFree = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// target returns the Credential Manager target name for account
+func target(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(Service + ":" + account)
+}
+
+func get(account string) (string, error) {
+	name, err := target(account)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	ok, _, err := procRead.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		return "", credError(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func set(account, secret string) error {
+	name, err := target(account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	ok, _, err := procWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ok == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+func del(account string) error {
+	name, err := target(account)
+	if err != nil {
+		return err
+	}
+	ok, _, err := procDelete.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0)
+	if ok == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+// credError maps the last error of a Cred* call
+func credError(err error) error {
+	if errors.Is(err, errorNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
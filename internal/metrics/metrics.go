@@ -0,0 +1,189 @@
+// Package metrics keeps process-wide counters and gauges and exposes them in
+// the Prometheus text format for long-running modes such as run --watch.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metric is a value exposed on /metrics
+type Metric struct {
+	name  string
+	help  string
+	kind  string // "counter" or "gauge"
+	value atomic.Uint64
+}
+
+// Inc adds one to a counter
+func (m *Metric) Inc() {
+	m.Add(1)
+}
+
+// Add adds delta to the metric
+func (m *Metric) Add(delta float64) {
+	for {
+		old := m.value.Load()
+		if m.value.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// Set sets a gauge to v
+func (m *Metric) Set(v float64) {
+	m.value.Store(math.Float64bits(v))
+}
+
+// SetToCurrentTime sets a gauge to the current Unix time in seconds
+func (m *Metric) SetToCurrentTime() {
+	m.Set(float64(time.Now().UnixNano()) / 1e9)
+}
+
+// Value returns the current value of the metric
+func (m *Metric) Value() float64 {
+	return math.Float64frombits(m.value.Load())
+}
+
+var (
+	mu       sync.Mutex
+	registry []*Metric
+)
+
+func register(name, help, kind string) *Metric {
+	m := &Metric{name: name, help: help, kind: kind}
+	mu.Lock()
+	registry = append(registry, m)
+	mu.Unlock()
+	return m
+}
+
+// NewCounter registers a counter, a value that only increases
+func NewCounter(name, help string) *Metric {
+	return register(name, help, "counter")
+}
+
+// NewGauge registers a gauge, a value that can go up and down
+func NewGauge(name, help string) *Metric {
+	return register(name, help, "gauge")
+}
+
+// Metrics recorded by vlt
+var (
+	VaultRequests      = NewCounter("vlt_vault_requests_total", "HTTP requests sent to Vault.")
+	VaultErrors        = NewCounter("vlt_vault_request_errors_total", "Vault requests that failed or returned a 5xx, 401, 403, or 429 status.")
+	TokenRenewals      = NewCounter("vlt_token_renewals_total", "Vault token renewals.")
+	TokenRenewalErrors = NewCounter("vlt_token_renewal_errors_total", "Failed Vault token renewals.")
+	CacheHits          = NewCounter("vlt_cache_hits_total", "Secret reads served from the local cache.")
+	CacheMisses        = NewCounter("vlt_cache_misses_total", "Secret reads that missed the local cache.")
+	RenderCycles       = NewCounter("vlt_render_cycles_total", "Times the secrets were resolved into an environment or output.")
+	RenderErrors       = NewCounter("vlt_render_errors_total", "Render cycles that failed.")
+	CommandRestarts    = NewCounter("vlt_command_restarts_total", "Times run --watch restarted the command after a secret change.")
+	LastRender         = NewGauge("vlt_last_render_timestamp_seconds", "Unix time of the last render cycle.")
+	LastRenderSuccess  = NewGauge("vlt_last_render_success_timestamp_seconds", "Unix time of the last successful render cycle.")
+)
+
+// RecordRender counts a render cycle and updates the timestamp gauges
+func RecordRender(err error) {
+	RenderCycles.Inc()
+	LastRender.SetToCurrentTime()
+	if err != nil {
+		RenderErrors.Inc()
+		return
+	}
+	LastRenderSuccess.SetToCurrentTime()
+}
+
+// WriteText writes every registered metric in the Prometheus text format
+func WriteText(w io.Writer) error {
+	mu.Lock()
+	metrics := append([]*Metric(nil), registry...)
+	mu.Unlock()
+
+	for _, m := range metrics {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %s\n",
+			m.name, m.help, m.name, m.kind, m.name, strconv.FormatFloat(m.Value(), 'g', -1, 64))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WriteText(w)
+	})
+}
+
+// Serve listens on addr and serves /metrics until ctx is cancelled. It
+// returns once the listener is open, so address errors are reported to the
+// caller.
+func Serve(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	go func() {
+		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "vlt: metrics server: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Transport counts the requests sent through an http.RoundTripper
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// InstrumentTransport returns base wrapped so its requests are counted in
+// VaultRequests and VaultErrors
+func InstrumentTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	VaultRequests.Inc()
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		VaultErrors.Inc()
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode >= 500, resp.StatusCode == http.StatusUnauthorized,
+		resp.StatusCode == http.StatusForbidden, resp.StatusCode == http.StatusTooManyRequests:
+		VaultErrors.Inc()
+	}
+	return resp, nil
+}
+
+// Unwrap returns the wrapped transport
+func (t *Transport) Unwrap() http.RoundTripper {
+	return t.Base
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrNoAge is returned when the age binary is not installed
+var ErrNoAge = errors.New("age not found in PATH (install it from https://age-encryption.org)")
+
+// AgeEncrypt encrypts plaintext for the given age recipients and recipient
+// files. Plaintext is passed on stdin and never written to disk.
+func AgeEncrypt(plaintext []byte, recipients, recipientFiles []string, armor bool) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, ErrNoAge
+	}
+
+	args := []string{"--encrypt"}
+	if armor {
+		args = append(args, "--armor")
+	}
+	for _, r := range recipients {
+		args = append(args, "--recipient", r)
+	}
+	for _, f := range recipientFiles {
+		args = append(args, "--recipients-file", f)
+	}
+
+	return runAge(args, plaintext)
+}
+
+// AgeDecrypt decrypts an age file with the given identity files and returns
+// the plaintext without writing it to disk
+func AgeDecrypt(path string, identities []string) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, ErrNoAge
+	}
+
+	args := []string{"--decrypt"}
+	for _, id := range identities {
+		args = append(args, "--identity", id)
+	}
+	args = append(args, path)
+
+	return runAge(args, nil)
+}
+
+// AgeDecryptData decrypts age ciphertext held in memory with the given
+// identity files
+func AgeDecryptData(ciphertext []byte, identities []string) ([]byte, error) {
+	if _, err := exec.LookPath("age"); err != nil {
+		return nil, ErrNoAge
+	}
+
+	args := []string{"--decrypt"}
+	for _, id := range identities {
+		args = append(args, "--identity", id)
+	}
+
+	return runAge(args, ciphertext)
+}
+
+func runAge(args []string, stdin []byte) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("age", args...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("age %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
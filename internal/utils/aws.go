@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// AWSClient calls the JSON APIs of AWS Secrets Manager and SSM Parameter
+// Store, signing requests with SigV4
+type AWSClient struct {
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	endpoint     string // optional override (AWS_ENDPOINT_URL), e.g. for LocalStack
+	http         *http.Client
+}
+
+// AWSError is an error response from an AWS API
+type AWSError struct {
+	Type    string
+	Message string
+	Status  int
+}
+
+func (e *AWSError) Error() string {
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.Type, e.Message, e.Status)
+}
+
+// NewAWSClient resolves credentials and region from the environment
+// (AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION)
+// or the shared ~/.aws/credentials and ~/.aws/config files for AWS_PROFILE
+func NewAWSClient(region string) (*AWSClient, error) {
+	profile := config.NonEmpty(os.Getenv("AWS_PROFILE"), "default")
+	home, _ := os.UserHomeDir()
+	credentials := readAWSINI(config.NonEmpty(os.Getenv("AWS_SHARED_CREDENTIALS_FILE"), filepath.Join(home, ".aws", "credentials")), profile)
+	configSection := profile
+	if profile != "default" {
+		configSection = "profile " + profile
+	}
+	awsConfig := readAWSINI(config.NonEmpty(os.Getenv("AWS_CONFIG_FILE"), filepath.Join(home, ".aws", "config")), configSection)
+
+	c := &AWSClient{
+		region:    config.NonEmpty(region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), awsConfig["region"]),
+		accessKey: config.NonEmpty(os.Getenv("AWS_ACCESS_KEY_ID"), credentials["aws_access_key_id"]),
+		secretKey: config.NonEmpty(os.Getenv("AWS_SECRET_ACCESS_KEY"), credentials["aws_secret_access_key"]),
+		endpoint:  os.Getenv("AWS_ENDPOINT_URL"),
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+	if os.Getenv("AWS_ACCESS_KEY_ID") != "" {
+		c.sessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	} else {
+		c.sessionToken = credentials["aws_session_token"]
+	}
+
+	if c.region == "" {
+		return nil, fmt.Errorf("AWS region is required (--region, AWS_REGION, or ~/.aws/config)")
+	}
+	if c.accessKey == "" || c.secretKey == "" {
+		return nil, fmt.Errorf("AWS credentials not found (AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY or ~/.aws/credentials profile %q)", profile)
+	}
+	return c, nil
+}
+
+// readAWSINI returns the keys of one section of an AWS shared config file
+func readAWSINI(path, section string) map[string]string {
+	values := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer f.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			current = strings.TrimSpace(line[1 : len(line)-1])
+		case current == section:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return values
+}
+
+// Call invokes an AWS JSON API action, e.g. ("secretsmanager", "secretsmanager.ListSecrets")
+func (c *AWSClient) Call(service, target string, input, output any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("marshal %s request: %w", target, err)
+	}
+
+	endpoint := c.endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", service, c.region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("parse AWS endpoint: %w", err)
+	}
+	u.Path = "/"
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", target, err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	c.sign(req, service, body, time.Now().UTC())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", target, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s: read response: %w", target, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type     string `json:"__type"`
+			Message  string `json:"message"`
+			Message2 string `json:"Message"`
+		}
+		_ = json.Unmarshal(respBody, &apiErr)
+		errType := apiErr.Type
+		if i := strings.LastIndex(errType, "#"); i >= 0 {
+			errType = errType[i+1:]
+		}
+		return &AWSError{Type: config.NonEmpty(errType, "AWSError"), Message: config.NonEmpty(apiErr.Message, apiErr.Message2, resp.Status), Status: resp.StatusCode}
+	}
+
+	if output != nil {
+		if err := json.Unmarshal(respBody, output); err != nil {
+			return fmt.Errorf("%s: decode response: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// sign adds SigV4 authentication headers to req
+func (c *AWSClient) sign(req *http.Request, service string, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, c.region, service)
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
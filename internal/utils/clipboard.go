@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoClipboard is returned when no supported clipboard tool is available
+var ErrNoClipboard = errors.New("no clipboard tool found (install pbcopy, wl-copy, xclip, or xsel)")
+
+// clipboardCommands returns the copy and paste commands for the current platform
+func clipboardCommands() (copyCmd, pasteCmd []string, err error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}, []string{"pbpaste"}, nil
+	case "windows":
+		return []string{"clip"}, []string{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}, nil
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			return []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}, nil
+		}
+	}
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return []string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}, nil
+	}
+	if _, err := exec.LookPath("xsel"); err == nil {
+		return []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}, nil
+	}
+	return nil, nil, ErrNoClipboard
+}
+
+// CopyToClipboard writes value to the system clipboard
+func CopyToClipboard(value string) error {
+	copyCmd, _, err := clipboardCommands()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(copyCmd[0], copyCmd[1:]...)
+	cmd.Stdin = strings.NewReader(value)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", copyCmd[0], err)
+	}
+	return nil
+}
+
+// ReadClipboard returns the current contents of the system clipboard
+func ReadClipboard() (string, error) {
+	_, pasteCmd, err := clipboardCommands()
+	if err != nil {
+		return "", err
+	}
+
+	out, err := exec.Command(pasteCmd[0], pasteCmd[1:]...).Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", pasteCmd[0], err)
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
+
+// ClearClipboard empties the system clipboard
+func ClearClipboard() error {
+	return CopyToClipboard("")
+}
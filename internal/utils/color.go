@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI styles for terminal output; several can be combined in Paint
+const (
+	Bold   = "1"
+	Dim    = "2"
+	Red    = "31"
+	Green  = "32"
+	Yellow = "33"
+	Blue   = "34"
+	Cyan   = "36"
+)
+
+// ColorEnabled reports whether output written to f should be colored: f is a
+// terminal, TERM is not "dumb", and NO_COLOR is unset or empty (no-color.org)
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// Painter styles text when color is enabled and leaves it as is otherwise
+type Painter struct {
+	enabled bool
+}
+
+// NewPainter returns a painter for output written to f
+func NewPainter(f *os.File) Painter {
+	return Painter{enabled: ColorEnabled(f)}
+}
+
+// Enabled reports whether the painter adds color
+func (p Painter) Enabled() bool {
+	return p.enabled
+}
+
+// Paint wraps text in the given styles
+func (p Painter) Paint(text string, styles ...string) string {
+	if !p.enabled || len(styles) == 0 || text == "" {
+		return text
+	}
+	return "\x1b[" + strings.Join(styles, ";") + "m" + text + "\x1b[0m"
+}
+
+// WriteTable writes rows as left-aligned columns separated by two spaces.
+// style returns the styles of a cell; padding is added outside the styles
+// so colors do not throw off the alignment.
+func WriteTable(w io.Writer, rows [][]string, p Painter, style func(row, col int) []string) error {
+	var widths []int
+	for _, row := range rows {
+		for col, cell := range row {
+			if col >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[col] = max(widths[col], utf8.RuneCountInString(cell))
+		}
+	}
+
+	var b strings.Builder
+	for i, row := range rows {
+		for col, cell := range row {
+			if col > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(p.Paint(cell, style(i, col)...))
+			if col < len(row)-1 {
+				b.WriteString(strings.Repeat(" ", widths[col]-utf8.RuneCountInString(cell)))
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -1,20 +1,42 @@
 package utils
 
 import (
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/razzkumar/vlt/pkg/vault"
 )
 
+// ReadEnvFile parses a .env file, or standard input when path is "-"
+func ReadEnvFile(path string) (map[string]string, error) {
+	if path == "-" {
+		return godotenv.Parse(os.Stdin)
+	}
+	return godotenv.Read(path)
+}
+
+// ReadInput reads a file, or standard input when path is "-"
+func ReadInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
 // LoadEnvFileAsPlaintext loads a .env file and returns plaintext data map (no vault client needed)
 func LoadEnvFileAsPlaintext(path string) (map[string]any, error) {
 	// Use godotenv to parse the .env file
-	envMap, err := godotenv.Read(path)
+	envMap, err := ReadEnvFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read .env file: %w", err)
 	}
@@ -30,29 +52,96 @@ func LoadEnvFileAsPlaintext(path string) (map[string]any, error) {
 // LoadEnvFile loads a .env file and returns encrypted/plaintext data map
 func LoadEnvFile(path string, client *vault.Client, transitMount, keyName string, useEncryption bool) (map[string]any, error) {
 	// Use godotenv to parse the .env file
-	envMap, err := godotenv.Read(path)
+	envMap, err := ReadEnvFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read .env file: %w", err)
 	}
 
+	return EncodeValues(envMap, client, transitMount, keyName, useEncryption)
+}
+
+// EncodeValues returns values as a KV data map, encrypting each value with
+// transit when useEncryption is set
+func EncodeValues(values map[string]string, client *vault.Client, transitMount, keyName string, useEncryption bool) (map[string]any, error) {
+	if !useEncryption {
+		keyName = ""
+	}
+	return EncodeSelectedValues(values, client, transitMount, keyName, nil)
+}
+
+// EncodeSelectedValues returns values as a KV data map, encrypting with the
+// transit key keyName the keys listed in encryptKeys, or every key when the
+// list is empty. Without keyName nothing is encrypted.
+func EncodeSelectedValues(values map[string]string, client *vault.Client, transitMount, keyName string, encryptKeys []string) (map[string]any, error) {
 	data := make(map[string]any)
+	encrypted := make(map[string]EncryptedValue)
 
-	for key, value := range envMap {
-		if useEncryption {
+	for key, value := range values {
+		if key == MetaKey {
+			return nil, fmt.Errorf("%s is reserved for vlt's record of encrypted values", MetaKey)
+		}
+		if keyName != "" && EncryptsKey(encryptKeys, key) {
 			ciphertext, err := client.TransitEncrypt(transitMount, keyName, []byte(value))
 			if err != nil {
 				return nil, fmt.Errorf("encrypt %s: %w", key, err)
 			}
 			data[key] = ciphertext
+			encrypted[key] = NewEncryptedValue(keyName, ciphertext)
 		} else {
 			data[key] = value
 		}
 	}
 
+	SetMeta(data, encrypted)
 	return data, nil
 }
 
-// LoadFileAsBase64 reads a file and encodes it as base64
+// EncryptsKey reports whether an encrypt_keys list selects key for
+// encryption; an empty list selects every key
+func EncryptsKey(encryptKeys []string, key string) bool {
+	return len(encryptKeys) == 0 || slices.Contains(encryptKeys, key)
+}
+
+// ParsePayload parses a multi-key payload in "json" (an object) or "env"
+// (dotenv) format. With "auto" or "", JSON is assumed when the payload starts
+// with "{". Non-string JSON values are stored in their JSON encoding.
+func ParsePayload(payload []byte, format string) (map[string]string, error) {
+	if format == "" || format == "auto" {
+		format = "env"
+		if strings.HasPrefix(strings.TrimSpace(string(payload)), "{") {
+			format = "json"
+		}
+	}
+
+	switch format {
+	case "env":
+		values, err := godotenv.UnmarshalBytes(payload)
+		if err != nil {
+			return nil, fmt.Errorf("parse dotenv: %w", err)
+		}
+		return values, nil
+	case "json":
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(payload, &raw); err != nil {
+			return nil, fmt.Errorf("parse json object: %w", err)
+		}
+		values := make(map[string]string, len(raw))
+		for key, msg := range raw {
+			var str string
+			if err := json.Unmarshal(msg, &str); err == nil {
+				values[key] = str
+			} else {
+				values[key] = string(msg)
+			}
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported payload format %q (expected auto, json, or env)", format)
+	}
+}
+
+// LoadFileAsBase64 reads a file and encodes it as base64, with a FileKey
+// manifest recording its name, size, SHA-256, and content type
 func LoadFileAsBase64(path string, client *vault.Client, transitMount, keyName string, useEncryption bool) (map[string]any, error) {
 	fileContent, err := os.ReadFile(path)
 	if err != nil {
@@ -60,30 +149,39 @@ func LoadFileAsBase64(path string, client *vault.Client, transitMount, keyName s
 	}
 
 	base64Content := base64.StdEncoding.EncodeToString(fileContent)
+	sum := sha256.Sum256(fileContent)
+	manifest := EncodeFileManifest(FileManifest{
+		Name:        filepath.Base(path),
+		Size:        int64(len(fileContent)),
+		SHA256:      hex.EncodeToString(sum[:]),
+		ContentType: DetectContentType(path, fileContent),
+	})
 
 	if useEncryption {
 		ciphertext, err := client.TransitEncrypt(transitMount, keyName, []byte(base64Content))
 		if err != nil {
 			return nil, fmt.Errorf("encrypt file content: %w", err)
 		}
-		return map[string]any{"ciphertext": ciphertext}, nil
+		data := map[string]any{"ciphertext": ciphertext}
+		markEncrypted(data, keyName)
+		data[FileKey] = manifest
+		return data, nil
 	}
 
-	return map[string]any{"value": base64Content}, nil
+	return map[string]any{"value": base64Content, FileKey: manifest}, nil
 }
 
 // IsEncryptedSingleValue checks if data contains a single encrypted value
 func IsEncryptedSingleValue(data map[string]any) bool {
-	if len(data) != 1 {
+	if len(StripMeta(data)) != 1 {
 		return false
 	}
-	ciphertext, ok := data["ciphertext"].(string)
-	return ok && strings.HasPrefix(ciphertext, "vault:v")
+	return IsEncryptedValue(data, "ciphertext")
 }
 
 // IsPlaintextSingleValue checks if data contains a single plaintext value
 func IsPlaintextSingleValue(data map[string]any) bool {
-	if len(data) != 1 {
+	if len(StripMeta(data)) != 1 {
 		return false
 	}
 	_, hasValue := data["value"]
@@ -92,24 +190,18 @@ func IsPlaintextSingleValue(data map[string]any) bool {
 
 // IsEncryptedMultiValue checks if data contains multiple encrypted values
 func IsEncryptedMultiValue(data map[string]any) bool {
-	if len(data) == 0 {
-		return false
-	}
-
-	for _, v := range data {
-		if str, ok := v.(string); ok && strings.HasPrefix(str, "vault:v") {
-			return true
-		}
-	}
-	return false
+	return len(EncryptedKeys(data)) > 0
 }
 
-// DecryptMultiValueData decrypts all encrypted values in a data map
+// DecryptMultiValueData decrypts all encrypted values in a data map. The
+// result leaves out the MetaKey field.
 func DecryptMultiValueData(data map[string]any, client *vault.Client, transitMount, keyName string) (map[string]any, error) {
 	decryptedData := make(map[string]any)
+	encrypted := EncryptedKeys(data)
 
-	for k, v := range data {
-		if ciphertext, ok := v.(string); ok && strings.HasPrefix(ciphertext, "vault:v") {
+	for k, v := range StripMeta(data) {
+		if _, ok := encrypted[k]; ok {
+			ciphertext := v.(string)
 			plaintext, err := client.TransitDecrypt(transitMount, keyName, ciphertext)
 			if err != nil {
 				return nil, fmt.Errorf("decrypt %s: %w", k, err)
@@ -133,16 +225,56 @@ func OutputJSON(data map[string]any) error {
 	return nil
 }
 
-// OutputEnvFormat outputs data in .env format
-func OutputEnvFormat(data map[string]any) {
-	for k, v := range data {
-		fmt.Printf("%s=%v\n", k, v)
+// OutputEnvFormat outputs data in .env format, sorted by key. On a color
+// terminal the keys and values are shown as aligned columns instead, with
+// masked values dimmed.
+func OutputEnvFormat(data map[string]any, masked bool) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	painter := NewPainter(os.Stdout)
+	if !painter.Enabled() {
+		for _, k := range keys {
+			fmt.Printf("%s=%v\n", k, data[k])
+		}
+		return
+	}
+
+	rows := make([][]string, len(keys))
+	for i, k := range keys {
+		rows[i] = []string{k, fmt.Sprintf("%v", data[k])}
+	}
+	_ = WriteTable(os.Stdout, rows, painter, func(row, col int) []string {
+		if col == 0 {
+			return []string{Bold, Cyan}
+		}
+		if masked {
+			return []string{Dim}
+		}
+		return nil
+	})
+}
+
+// OutputPowerShell outputs data as PowerShell statements setting environment
+// variables, sorted by key, for piping to Invoke-Expression
+func OutputPowerShell(data map[string]any) {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Print(PowerShellAssignment(k, fmt.Sprintf("%v", data[k])))
 	}
 }
 
 // MergeData merges new data into existing data, preserving existing values and adding/updating new ones
 func MergeData(existing, new map[string]any) map[string]any {
 	result := make(map[string]any)
+	encrypted := EncryptedKeys(existing)
 
 	// Copy existing data
 	for k, v := range existing {
@@ -152,7 +284,12 @@ func MergeData(existing, new map[string]any) map[string]any {
 	// Add/update with new data
 	for k, v := range new {
 		result[k] = v
+		delete(encrypted, k)
 	}
 
+	for k, v := range EncryptedKeys(new) {
+		encrypted[k] = v
+	}
+	SetMeta(result, encrypted)
 	return result
 }
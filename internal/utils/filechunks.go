@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileKey is the KV field describing a file that put --from-file stored in
+// chunks. The file's content is held in chunk000001, chunk000002, ... fields,
+// each the base64 encoding (Transit-encrypted with an encryption key) of a
+// piece of the file of the manifest's chunk size.
+const FileKey = "__vault_env_file"
+
+// FileChunkSize is the largest number of file bytes in one chunk. Files up
+// to the chunk size are stored as a single base64 value instead.
+const FileChunkSize = 192 << 10
+
+// chunkPrefix starts the names of the fields holding file chunks
+const chunkPrefix = "chunk"
+
+// FileManifest is the JSON document stored under FileKey
+type FileManifest struct {
+	Name      string `json:"name"`                 // base name of the stored file
+	Size      int64  `json:"size"`                 // bytes
+	SHA256    string `json:"sha256"`               // hex digest of the content
+	Chunks    int    `json:"chunks,omitempty"`     // number of chunk fields; 0 for a file stored as one value
+	ChunkSize int    `json:"chunk_size,omitempty"` // bytes per chunk, except the last
+
+	ContentType string `json:"content_type,omitempty"` // MIME type guessed from the name and content
+}
+
+// ChunkKey returns the field holding chunk i, counting from 1
+func ChunkKey(i int) string {
+	return fmt.Sprintf("%s%06d", chunkPrefix, i)
+}
+
+// ChunkKeys returns the chunk fields of data in order
+func ChunkKeys(data map[string]any) []string {
+	var keys []string
+	for key := range data {
+		if strings.HasPrefix(key, chunkPrefix) && len(key) == len(chunkPrefix)+6 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ReadFileManifest returns the manifest of a chunked file in data, if it has one
+func ReadFileManifest(data map[string]any) (*FileManifest, bool) {
+	raw, ok := data[FileKey].(string)
+	if !ok {
+		return nil, false
+	}
+	var manifest FileManifest
+	if err := json.Unmarshal([]byte(raw), &manifest); err != nil {
+		return nil, false
+	}
+	return &manifest, true
+}
+
+// secretFileTypes are the MIME types of secret file formats that the
+// standard library does not know by extension
+var secretFileTypes = map[string]string{
+	".jks":      "application/x-java-keystore",
+	".keystore": "application/x-java-keystore",
+	".jceks":    "application/x-java-jce-keystore",
+	".p12":      "application/x-pkcs12",
+	".pfx":      "application/x-pkcs12",
+	".der":      "application/x-x509-ca-cert",
+	".pem":      "application/x-pem-file",
+	".crt":      "application/x-pem-file",
+	".key":      "application/x-pem-file",
+	".kdbx":     "application/x-keepass2",
+	".gpg":      "application/pgp-encrypted",
+	".yaml":     "application/yaml",
+	".yml":      "application/yaml",
+}
+
+// DetectContentType guesses the MIME type of a file from its name, or from
+// the first bytes of its content when the name does not tell
+func DetectContentType(name string, head []byte) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := secretFileTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	if len(head) == 0 {
+		return "application/octet-stream"
+	}
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	if bytes.HasPrefix(head, []byte("-----BEGIN ")) {
+		return "application/x-pem-file"
+	}
+	return http.DetectContentType(head)
+}
+
+// IsTextContentType reports whether content of a MIME type can be shown on a
+// terminal: text/*, PEM, JSON, YAML, XML, and shell scripts
+func IsTextContentType(contentType string) bool {
+	base, _, _ := strings.Cut(contentType, ";")
+	base = strings.TrimSpace(base)
+	switch {
+	case strings.HasPrefix(base, "text/"):
+		return true
+	case base == "application/x-pem-file", base == "application/yaml", base == "application/xml",
+		base == "application/x-sh", base == "application/toml":
+		return true
+	}
+	return strings.HasSuffix(base, "json") || strings.HasSuffix(base, "+xml")
+}
+
+// EncodeFileManifest returns the FileKey field value of a manifest
+func EncodeFileManifest(manifest FileManifest) string {
+	raw, _ := json.Marshal(manifest)
+	return string(raw)
+}
@@ -0,0 +1,325 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Output formats supported by Render
+const (
+	FormatEnv        = "env"
+	FormatTFVars     = "tfvars"
+	FormatTFVarsJSON = "tfvars-json"
+	FormatProperties = "properties"
+	FormatTOML       = "toml"
+	FormatSystemd    = "systemd"
+	FormatDockerArgs = "docker-args"
+	FormatCompose    = "compose"
+	FormatPowerShell = "powershell"
+)
+
+// OutputFormats lists the formats accepted by Render
+var OutputFormats = []string{FormatEnv, FormatTFVars, FormatTFVarsJSON, FormatProperties, FormatTOML, FormatSystemd, FormatDockerArgs, FormatCompose, FormatPowerShell}
+
+// RenderOptions controls how environment variables are rendered
+type RenderOptions struct {
+	Format  string // one of OutputFormats (default env)
+	MapName string // tfvars formats: nest values in a map variable; toml: in a table
+}
+
+// Render renders environment variables in the requested output format.
+// Variables are written in sorted order so the output is stable.
+func Render(vars map[string]string, opts RenderOptions) ([]byte, error) {
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	switch opts.Format {
+	case "", FormatEnv:
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", k, vars[k])
+		}
+	case FormatTFVars:
+		if opts.MapName != "" {
+			if !isHCLIdentifier(opts.MapName) {
+				return nil, fmt.Errorf("invalid terraform variable name %q", opts.MapName)
+			}
+			fmt.Fprintf(&b, "%s = {\n", opts.MapName)
+			for _, k := range keys {
+				fmt.Fprintf(&b, "  %s = %s\n", hclString(k), hclString(vars[k]))
+			}
+			b.WriteString("}\n")
+			break
+		}
+		for _, k := range keys {
+			if !isHCLIdentifier(k) {
+				return nil, fmt.Errorf("%q is not a valid terraform variable name (use a map to nest it)", k)
+			}
+			fmt.Fprintf(&b, "%s = %s\n", k, hclString(vars[k]))
+		}
+	case FormatTFVarsJSON:
+		var doc any = vars
+		if opts.MapName != "" {
+			doc = map[string]any{opts.MapName: vars}
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("marshal json: %w", err)
+		}
+		return buf.Bytes(), nil
+	case FormatProperties:
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", propertiesEscape(k, true), propertiesEscape(vars[k], false))
+		}
+	case FormatTOML:
+		if opts.MapName != "" {
+			fmt.Fprintf(&b, "[%s]\n", tomlKey(opts.MapName))
+		}
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", tomlKey(k), tomlString(vars[k]))
+		}
+	case FormatSystemd:
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s=%s\n", k, systemdQuote(vars[k]))
+		}
+	case FormatDockerArgs:
+		// docker run --env-file takes everything after "=" literally, without
+		// quote handling, and cannot represent multi-line values
+		for _, k := range keys {
+			if strings.ContainsAny(vars[k], "\r\n") {
+				return nil, fmt.Errorf("%s contains a newline, which docker --env-file cannot represent", k)
+			}
+			fmt.Fprintf(&b, "%s=%s\n", k, vars[k])
+		}
+	case FormatCompose:
+		b.WriteString("environment:\n")
+		for _, k := range keys {
+			// Compose interpolates $VAR, so a literal $ is written as $$
+			value := strings.ReplaceAll(vars[k], "$", "$$")
+			key := k
+			if !isHCLIdentifier(key) {
+				key = yamlString(key)
+			}
+			fmt.Fprintf(&b, "  %s: %s\n", key, yamlString(value))
+		}
+	case FormatPowerShell:
+		for _, k := range keys {
+			b.WriteString(PowerShellAssignment(k, vars[k]))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format %q (supported: %s)", opts.Format, strings.Join(OutputFormats, ", "))
+	}
+
+	return []byte(b.String()), nil
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences
+// so values are never interpolated by Terraform
+func hclString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			b.WriteString(`\\`)
+		case c == '"':
+			b.WriteString(`\"`)
+		case c == '\n':
+			b.WriteString(`\n`)
+		case c == '\r':
+			b.WriteString(`\r`)
+		case c == '\t':
+			b.WriteString(`\t`)
+		case (c == '$' || c == '%') && i+1 < len(s) && s[i+1] == '{':
+			b.WriteByte(c)
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\u%04x`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// isHCLIdentifier reports whether name can be used as a bare HCL identifier
+func isHCLIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case i > 0 && (r == '-' || (r >= '0' && r <= '9')):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// PowerShellAssignment returns the PowerShell statement setting the
+// environment variable key to value, as a single-quoted string so nothing in
+// it is expanded. Names PowerShell cannot parse after $env: use ${env:...}.
+func PowerShellAssignment(key, value string) string {
+	name := "$env:" + key
+	if !isPowerShellName(key) {
+		name = "${env:" + powerShellBraced.Replace(key) + "}"
+	}
+	return name + " = '" + powerShellQuote.Replace(value) + "'\n"
+}
+
+// powerShellQuote doubles the quote characters of a single-quoted string;
+// PowerShell also accepts typographic single quotes as delimiters
+var powerShellQuote = strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019", "\u201a", "\u201a\u201a", "\u201b", "\u201b\u201b")
+
+// powerShellBraced escapes a ${...} variable name
+var powerShellBraced = strings.NewReplacer("`", "``", "{", "`{", "}", "`}")
+
+// isPowerShellName reports whether name can follow $env: unbraced
+func isPowerShellName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesCRLF reports whether the first line of content ends with CRLF, as in
+// files saved by Windows editors
+func UsesCRLF(content []byte) bool {
+	i := bytes.IndexByte(content, '\n')
+	return i > 0 && content[i-1] == '\r'
+}
+
+// ToCRLF converts the line endings of content to CRLF
+func ToCRLF(content []byte) []byte {
+	content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+}
+
+// propertiesEscape escapes s for a Java .properties file. Non-ASCII characters
+// are written as \uXXXX escapes since Properties.load reads ISO-8859-1.
+func propertiesEscape(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\f':
+			b.WriteString(`\f`)
+		case r == ' ' && (isKey || i == 0):
+			b.WriteString(`\ `)
+		case isKey && (r == '=' || r == ':'):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case (r == '#' || r == '!') && (isKey || i == 0):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&b, `\u%04X`, unit)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// tomlKey returns k as a bare TOML key when possible, quoted otherwise
+func tomlKey(k string) string {
+	if k == "" {
+		return `""`
+	}
+	for _, r := range k {
+		if !(r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return tomlString(k)
+		}
+	}
+	return k
+}
+
+// tomlString quotes s as a TOML basic string
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// systemdQuote double-quotes s for a systemd EnvironmentFile, escaping the
+// characters that are special inside double quotes
+func systemdQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '`', '$':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// SystemdDropIn returns a unit drop-in that loads envFile as an EnvironmentFile
+func SystemdDropIn(envFile string) []byte {
+	return []byte(fmt.Sprintf("[Service]\nEnvironmentFile=%s\n", envFile))
+}
+
+// yamlString quotes s as a YAML double-quoted scalar (JSON strings are valid YAML)
+func yamlString(s string) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoGit is returned when the git binary is not installed
+var ErrNoGit = errors.New("git not found in PATH")
+
+// GitRoot returns the top-level directory of the work tree containing the
+// current directory
+func GitRoot() (string, error) {
+	out, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// StagedFiles returns the files added, copied, modified, or renamed in the
+// index, relative to the top of the work tree
+func StagedFiles() ([]string, error) {
+	out, err := runGit("diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// StagedContent returns the staged content of a file given relative to the
+// top of the work tree, which is what a commit would record
+func StagedContent(path string) ([]byte, error) {
+	return runGit("show", ":"+path)
+}
+
+// GitPrefix returns the location of dir relative to the top of its work
+// tree ("" at the top, "sub/dir/" below it), or false when dir is not in a
+// work tree or git is not installed
+func GitPrefix(dir string) (string, bool) {
+	out, err := runGitIn(dir, "rev-parse", "--is-inside-work-tree", "--show-prefix")
+	if err != nil {
+		return "", false
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if lines[0] != "true" {
+		return "", false
+	}
+	if len(lines) < 2 {
+		return "", true
+	}
+	return lines[1], true
+}
+
+// GitIgnored reports whether a path relative to dir is matched by the
+// ignore rules of the work tree containing dir. Tracked files are never
+// ignored.
+func GitIgnored(dir, path string) (bool, error) {
+	_, err := runGitIn(dir, "check-ignore", "-q", "--", path)
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// AppendGitignore adds a pattern to the .gitignore at the top of the work
+// tree containing dir
+func AppendGitignore(dir, pattern string) (string, error) {
+	out, err := runGitIn(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(strings.TrimSpace(string(out)), ".gitignore")
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	line := pattern + "\n"
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		line = "\n" + line
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(line); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+func runGit(args ...string) ([]byte, error) {
+	return runGitIn("", args...)
+}
+
+// runGitIn runs git in dir, or in the current directory when dir is empty
+func runGitIn(dir string, args ...string) ([]byte, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNoGit
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
@@ -0,0 +1,278 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeTarget is an API server endpoint with the credentials to call it
+type kubeTarget struct {
+	server    string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// kubeConfig is the subset of a kubeconfig file needed to reach the API server
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string    `yaml:"token"`
+			TokenFile             string    `yaml:"tokenFile"`
+			ClientCertificate     string    `yaml:"client-certificate"`
+			ClientCertificateData string    `yaml:"client-certificate-data"`
+			ClientKey             string    `yaml:"client-key"`
+			ClientKeyData         string    `yaml:"client-key-data"`
+			Exec                  *struct{} `yaml:"exec"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// ReadK8sSecret reads a Kubernetes Secret given as "namespace/name" (or
+// "name" for the context's namespace) and returns its base64-decoded data.
+// It uses the in-cluster service account when running in a pod, and the
+// kubeconfig (KUBECONFIG or ~/.kube/config) otherwise.
+func ReadK8sSecret(ref, kubeContext string) (map[string]string, error) {
+	target, err := kubeTargetFor(kubeContext)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		namespace, name = target.namespace, ref
+	}
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid secret reference %q (expected namespace/name)", ref)
+	}
+
+	reqURL := fmt.Sprintf("%s/api/v1/namespaces/%s/secrets/%s",
+		strings.TrimSuffix(target.server, "/"), url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if target.token != "" {
+		req.Header.Set("Authorization", "Bearer "+target.token)
+	}
+
+	resp, err := target.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get secret %s/%s: %w", namespace, name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get secret %s/%s: kubernetes API returned %s", namespace, name, resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return nil, fmt.Errorf("decode secret %s/%s: %w", namespace, name, err)
+	}
+
+	values := make(map[string]string, len(secret.Data))
+	for key, encoded := range secret.Data {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %s: %w", key, err)
+		}
+		values[key] = string(decoded)
+	}
+	return values, nil
+}
+
+// kubeTargetFor returns the in-cluster API server when no context is
+// requested and a service account is mounted, or the kubeconfig target
+func kubeTargetFor(kubeContext string) (*kubeTarget, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if kubeContext == "" && host != "" && port != "" {
+		if token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token")); err == nil {
+			return inClusterTarget(host, port, strings.TrimSpace(string(token)))
+		}
+	}
+	return kubeconfigTarget(kubeContext)
+}
+
+func inClusterTarget(host, port, token string) (*kubeTarget, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt")); err == nil {
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		tlsConfig.RootCAs = pool
+	}
+
+	namespace := "default"
+	if ns, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+		namespace = strings.TrimSpace(string(ns))
+	}
+
+	return &kubeTarget{
+		server:    "https://" + strings.Trim(host, "[]") + ":" + port,
+		token:     token,
+		namespace: namespace,
+		client:    kubeHTTPClient(tlsConfig),
+	}, nil
+}
+
+func kubeconfigTarget(kubeContext string) (*kubeTarget, error) {
+	path := kubeconfigPath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read kubeconfig: %w", err)
+	}
+
+	var kc kubeConfig
+	if err := yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("parse kubeconfig %s: %w", path, err)
+	}
+	baseDir := filepath.Dir(path)
+
+	contextName := config.NonEmpty(kubeContext, kc.CurrentContext)
+	target := &kubeTarget{namespace: "default"}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName = c.Context.Cluster, c.Context.User
+			if c.Context.Namespace != "" {
+				target.namespace = c.Context.Namespace
+			}
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig context %q not found in %s", contextName, path)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		target.server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := kubeData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read cluster CA: %w", err)
+		}
+		if len(ca) > 0 {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(ca)
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if target.server == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %q not found in %s", clusterName, path)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name != userName {
+			continue
+		}
+		if u.User.Exec != nil && u.User.Token == "" && u.User.TokenFile == "" {
+			return nil, errors.New("kubeconfig exec credential plugins are not supported; pipe `kubectl get secret -o json` into put --from-stdin-json instead")
+		}
+		target.token = u.User.Token
+		if u.User.TokenFile != "" {
+			token, err := os.ReadFile(kubePath(u.User.TokenFile, baseDir))
+			if err != nil {
+				return nil, fmt.Errorf("read token file: %w", err)
+			}
+			target.token = strings.TrimSpace(string(token))
+		}
+
+		cert, err := kubeData(u.User.ClientCertificateData, u.User.ClientCertificate, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read client certificate: %w", err)
+		}
+		key, err := kubeData(u.User.ClientKeyData, u.User.ClientKey, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("read client key: %w", err)
+		}
+		if len(cert) > 0 && len(key) > 0 {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	target.client = kubeHTTPClient(tlsConfig)
+	return target, nil
+}
+
+// kubeconfigPath returns the first file in KUBECONFIG, or ~/.kube/config
+func kubeconfigPath() string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		return filepath.SplitList(env)[0]
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kube", "config")
+}
+
+// kubeData returns base64-encoded inline data, or the contents of file
+func kubeData(inline, file, baseDir string) ([]byte, error) {
+	if inline != "" {
+		return base64.StdEncoding.DecodeString(inline)
+	}
+	if file != "" {
+		return os.ReadFile(kubePath(file, baseDir))
+	}
+	return nil, nil
+}
+
+// kubePath resolves a kubeconfig file reference relative to the kubeconfig
+func kubePath(path, baseDir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(baseDir, path)
+}
+
+func kubeHTTPClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// lockPollInterval is how often LockFile retries a lock held by another
+// process
+const lockPollInterval = 100 * time.Millisecond
+
+// FileLock is an exclusive advisory lock held on a lock file
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile takes an exclusive advisory lock on the file at path, creating it
+// and its directory if needed. While another process holds the lock, onWait
+// is called once and the lock is retried until timeout has passed. The lock
+// only excludes other callers of LockFile; it does not stop plain writes.
+func LockFile(path string, timeout time.Duration, onWait func()) (*FileLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("create lock directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	waited := false
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if locked {
+			return &FileLock{f: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: still held by another process after %s", path, timeout)
+		}
+		if !waited && onWait != nil {
+			onWait()
+		}
+		waited = true
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Unlock releases the lock. The lock file is left in place, since removing
+// it would race with a process that has just opened it.
+func (l *FileLock) Unlock() error {
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
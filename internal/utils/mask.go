@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// IsTerminal reports whether the given file is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// ShouldMask returns true if values printed to stdout should be masked.
+// Values are masked when stdout is a terminal, unless reveal is requested.
+func ShouldMask(reveal bool) bool {
+	return !reveal && IsTerminal(os.Stdout)
+}
+
+// MaskValue masks a secret value, keeping only the last 4 characters visible
+// for values long enough that doing so does not leak most of the secret
+func MaskValue(value any) string {
+	str := fmt.Sprintf("%v", value)
+	if len(str) < 12 {
+		return "****"
+	}
+	return "****" + str[len(str)-4:]
+}
+
+// MaskData returns a copy of data with every value masked
+func MaskData(data map[string]any) map[string]any {
+	masked := make(map[string]any, len(data))
+	for k, v := range data {
+		masked[k] = MaskValue(v)
+	}
+	return masked
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// MetaKey is the KV field in which vlt records which values of a secret are
+// Transit ciphertext, so that a plaintext value starting with "vault:v" (a
+// URL, a docs string) is not mistaken for one. Secrets without it, written by
+// older versions or other tools, are classified by the prefix alone.
+const MetaKey = "__vault_env_meta"
+
+// EncryptedValue records the Transit key and key version a value was
+// encrypted with. Key is empty when it was inferred from the prefix.
+type EncryptedValue struct {
+	Key     string `json:"key,omitempty"`
+	Version int    `json:"version"`
+}
+
+// secretMeta is the JSON document stored under MetaKey
+type secretMeta struct {
+	Encrypted map[string]EncryptedValue `json:"encrypted"`
+}
+
+// looksEncrypted reports whether v has the form of Transit ciphertext, or of
+// a value encrypted client-side with encryption: local
+func looksEncrypted(v any) bool {
+	s, ok := v.(string)
+	return ok && (strings.HasPrefix(s, "vault:v") || vault.IsLocalCiphertext(s))
+}
+
+// ciphertextVersion returns the key version of a "vault:vN:..." ciphertext,
+// or 0 for other values
+func ciphertextVersion(ciphertext string) int {
+	version, _, _ := strings.Cut(strings.TrimPrefix(ciphertext, "vault:v"), ":")
+	n, _ := strconv.Atoi(version)
+	return n
+}
+
+// NewEncryptedValue records ciphertext as encrypted with the Transit key keyName
+func NewEncryptedValue(keyName, ciphertext string) EncryptedValue {
+	return EncryptedValue{Key: keyName, Version: ciphertextVersion(ciphertext)}
+}
+
+// readMeta parses the MetaKey field of data. It reports false when there is
+// none, or it cannot be parsed and the prefix has to be relied on.
+func readMeta(data map[string]any) (map[string]EncryptedValue, bool) {
+	raw, ok := data[MetaKey].(string)
+	if !ok {
+		return nil, false
+	}
+	var meta secretMeta
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil, false
+	}
+	return meta.Encrypted, true
+}
+
+// EncryptedKeys returns the keys of data that hold Transit ciphertext: those
+// recorded under MetaKey, or without it, those whose value starts with "vault:v"
+func EncryptedKeys(data map[string]any) map[string]EncryptedValue {
+	keys := make(map[string]EncryptedValue)
+	if recorded, ok := readMeta(data); ok {
+		for key, value := range recorded {
+			if key != MetaKey && looksEncrypted(data[key]) {
+				keys[key] = value
+			}
+		}
+		return keys
+	}
+	for key, value := range data {
+		if key != MetaKey && looksEncrypted(value) {
+			keys[key] = EncryptedValue{Version: ciphertextVersion(value.(string))}
+		}
+	}
+	return keys
+}
+
+// IsEncryptedValue reports whether the value of key in data is Transit ciphertext
+func IsEncryptedValue(data map[string]any, key string) bool {
+	if recorded, ok := readMeta(data); ok {
+		_, encrypted := recorded[key]
+		return encrypted && looksEncrypted(data[key])
+	}
+	return looksEncrypted(data[key])
+}
+
+// SetMeta records keys as the encrypted values of data. The MetaKey field is
+// written when a value is encrypted or a plaintext value looks like
+// ciphertext, and removed when neither is the case.
+func SetMeta(data map[string]any, keys map[string]EncryptedValue) {
+	delete(data, MetaKey)
+	encrypted := make(map[string]EncryptedValue)
+	needed := false
+	for key, value := range data {
+		if recorded, ok := keys[key]; ok && looksEncrypted(value) {
+			encrypted[key] = recorded
+			needed = true
+		} else if looksEncrypted(value) {
+			needed = true
+		}
+	}
+	if !needed {
+		return
+	}
+	raw, _ := json.Marshal(secretMeta{Encrypted: encrypted})
+	data[MetaKey] = string(raw)
+}
+
+// StripMeta returns data without vlt's own fields: MetaKey, and the FileKey
+// manifest of a stored file
+func StripMeta(data map[string]any) map[string]any {
+	_, hasMeta := data[MetaKey]
+	_, hasFile := data[FileKey]
+	if !hasMeta && !hasFile {
+		return data
+	}
+	values := make(map[string]any, len(data))
+	for key, value := range data {
+		if key != MetaKey && key != FileKey {
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// markEncrypted records every value of data as encrypted with keyName, or
+// none when keyName is empty
+func markEncrypted(data map[string]any, keyName string) {
+	keys := make(map[string]EncryptedValue)
+	if keyName != "" {
+		for key, value := range data {
+			if s, ok := value.(string); ok {
+				keys[key] = NewEncryptedValue(keyName, s)
+			}
+		}
+	}
+	SetMeta(data, keys)
+}
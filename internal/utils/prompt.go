@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ReadSecret reads one line from standard input. On a terminal it prints
+// prompt to stderr and turns off echo (through stty) while the line is typed.
+func ReadSecret(prompt string) (string, error) {
+	if IsTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, prompt)
+		if stty(os.Stdin, "-echo") == nil {
+			defer func() {
+				_ = stty(os.Stdin, "echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("read input: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// stty changes the settings of the terminal attached to f
+func stty(f *os.File, args ...string) error {
+	path, err := exec.LookPath("stty")
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = f
+	return cmd.Run()
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactMask replaces secret values in redacted output
+const RedactMask = "***"
+
+// MinRedactLength is the shortest value RedactWriter masks; shorter values
+// (flags like "1" or "on") would mangle unrelated output
+const MinRedactLength = 4
+
+// RedactWriter masks every occurrence of a set of secret values in a byte
+// stream before passing it on. A trailing partial match is held back until
+// the next write (or Flush) shows whether it completes a secret, so values
+// split across writes are still masked.
+type RedactWriter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte // longest first, so the longest match wins
+	pending []byte
+}
+
+// NewRedactWriter returns a writer that masks values before writing to w.
+// Multi-line values are also masked line by line.
+func NewRedactWriter(w io.Writer, values []string) *RedactWriter {
+	seen := make(map[string]bool)
+	var secrets [][]byte
+	add := func(v string) {
+		if len(v) >= MinRedactLength && !seen[v] {
+			seen[v] = true
+			secrets = append(secrets, []byte(v))
+		}
+	}
+	for _, v := range values {
+		add(v)
+		if strings.Contains(v, "\n") {
+			for _, line := range strings.Split(v, "\n") {
+				add(strings.TrimSpace(line))
+			}
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	return &RedactWriter{w: w, secrets: secrets}
+}
+
+// Write masks p and writes everything that cannot be the start of a secret
+func (r *RedactWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buf := append(r.pending, p...)
+	out, lastMatch := r.mask(buf)
+
+	hold := r.partialSuffix(buf[lastMatch:])
+	r.pending = append([]byte(nil), buf[len(buf)-hold:]...)
+	out = out[:len(out)-hold]
+
+	if _, err := r.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes any held-back bytes
+func (r *RedactWriter) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.pending) == 0 {
+		return nil
+	}
+	_, err := r.w.Write(r.pending)
+	r.pending = nil
+	return err
+}
+
+// mask replaces every secret in buf and returns the result along with the
+// offset in buf just past the last match
+func (r *RedactWriter) mask(buf []byte) ([]byte, int) {
+	out := make([]byte, 0, len(buf))
+	lastMatch := 0
+
+	for i := 0; i < len(buf); {
+		matched := false
+		for _, s := range r.secrets {
+			if bytes.HasPrefix(buf[i:], s) {
+				out = append(out, RedactMask...)
+				i += len(s)
+				lastMatch = i
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			out = append(out, buf[i])
+			i++
+		}
+	}
+	return out, lastMatch
+}
+
+// partialSuffix returns the length of the longest suffix of buf that is a
+// proper prefix of a secret
+func (r *RedactWriter) partialSuffix(buf []byte) int {
+	longest := 0
+	for _, s := range r.secrets {
+		n := len(s) - 1
+		if n > len(buf) {
+			n = len(buf)
+		}
+		for ; n > longest; n-- {
+			if bytes.HasPrefix(s, buf[len(buf)-n:]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
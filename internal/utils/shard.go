@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// shardMeta is the MetaKey document of a secret split into parts
+type shardMeta struct {
+	Encrypted map[string]EncryptedValue `json:"encrypted"`
+	Shards    int                       `json:"shards"`
+}
+
+// EncodedSize returns the size in bytes of data as written to Vault
+func EncodedSize(data map[string]any) int {
+	raw, err := json.Marshal(map[string]any{"data": data})
+	if err != nil {
+		return 0
+	}
+	return len(raw)
+}
+
+// ShardCount returns the number of parts the secret whose data is stored at
+// a path was split into, or 0 for a secret stored whole
+func ShardCount(data map[string]any) int {
+	raw, ok := data[MetaKey].(string)
+	if !ok || len(StripMeta(data)) != 0 {
+		return 0
+	}
+	var meta shardMeta
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return 0
+	}
+	return meta.Shards
+}
+
+// ShardPath returns the path of part i, counting from 1, of the secret at path
+func ShardPath(path string, i int) string {
+	return strings.TrimSuffix(path, "/") + "/part" + strconv.Itoa(i)
+}
+
+// ShardIndex returns the data stored at the path of a secret split into n
+// parts: only a MetaKey field recording the count
+func ShardIndex(n int) map[string]any {
+	raw, _ := json.Marshal(shardMeta{Encrypted: map[string]EncryptedValue{}, Shards: n})
+	return map[string]any{MetaKey: string(raw)}
+}
+
+// SplitShards splits the keys of data into parts of at most maxSize bytes
+// each, as EncodedSize counts them, keeping the record of encrypted values
+// with the keys it describes. Keys are assigned in name order, so the parts
+// of an unchanged secret stay the same.
+func SplitShards(data map[string]any, maxSize int) ([]map[string]any, error) {
+	encrypted := EncryptedKeys(data)
+	values := StripMeta(data)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Sizes are added up per key rather than encoding every candidate part
+	overhead := EncodedSize(ShardIndex(0))
+	var parts []map[string]any
+	part, size := make(map[string]any), overhead
+	for _, key := range keys {
+		cost := entrySize(key, values[key], encrypted)
+		if overhead+cost > maxSize {
+			return nil, fmt.Errorf("key %s alone is about %d bytes, above the %d byte limit of a part", key, overhead+cost, maxSize)
+		}
+		if size+cost > maxSize && len(part) > 0 {
+			parts = append(parts, part)
+			part, size = make(map[string]any), overhead
+		}
+		part[key] = values[key]
+		size += cost
+	}
+	if len(part) > 0 {
+		parts = append(parts, part)
+	}
+	for _, part := range parts {
+		SetMeta(part, encrypted)
+	}
+	return parts, nil
+}
+
+// entrySize returns the bytes key and its value add to an encoded secret,
+// including its entry in the record of encrypted values
+func entrySize(key string, value any, encrypted map[string]EncryptedValue) int {
+	name, _ := json.Marshal(key)
+	raw, _ := json.Marshal(value)
+	size := len(name) + len(raw) + 2
+	if recorded, ok := encrypted[key]; ok {
+		// The record is a JSON document held in a string, so its quotes are escaped
+		entry, _ := json.Marshal(map[string]EncryptedValue{key: recorded})
+		quoted, _ := json.Marshal(string(entry))
+		size += len(quoted)
+	}
+	return size
+}
+
+// JoinShards merges the parts of a split secret into one, with a single
+// record of the encrypted values and the manifest of a stored file, if any
+func JoinShards(parts []map[string]any) map[string]any {
+	joined := make(map[string]any)
+	encrypted := make(map[string]EncryptedValue)
+	for _, part := range parts {
+		if manifest, ok := part[FileKey]; ok {
+			joined[FileKey] = manifest
+		}
+		for key, value := range EncryptedKeys(part) {
+			encrypted[key] = value
+		}
+		for key, value := range StripMeta(part) {
+			joined[key] = value
+		}
+	}
+	SetMeta(joined, encrypted)
+	return joined
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ShredFile overwrites a regular file with random data, flushes it to disk,
+// and removes it, so its former content is not left in the freed blocks.
+// Copy-on-write and journaling file systems, and SSD wear leveling, may still
+// keep old copies; the overwrite is a best effort on top of the removal.
+func ShredFile(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		// Never follow a symlink to overwrite the file it points to
+		return os.Remove(path)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, info.Size()); err != nil {
+		f.Close()
+		return fmt.Errorf("overwrite %s: %w", path, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", path, err)
+	}
+	if err := f.Truncate(0); err != nil {
+		f.Close()
+		return fmt.Errorf("truncate %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
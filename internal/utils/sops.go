@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoSOPS is returned when the sops binary is not installed
+var ErrNoSOPS = errors.New("sops not found in PATH (install it from https://github.com/getsops/sops)")
+
+// SOPSRecipients selects the keys a new SOPS file is encrypted for. When all
+// fields are empty, sops uses the creation rules in the nearest .sops.yaml.
+type SOPSRecipients struct {
+	Age []string // age public keys
+	KMS []string // AWS KMS key ARNs
+	PGP []string // PGP fingerprints
+}
+
+// ReadSOPSFile decrypts a SOPS-encrypted YAML, JSON, or dotenv file with
+// the keys available locally and returns its top-level values. Nested values
+// are returned in their JSON encoding.
+func ReadSOPSFile(path string) (map[string]string, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, ErrNoSOPS
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", "--output-type", "json", path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sops --decrypt %s: %w: %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return ParsePayload(out, "json")
+}
+
+// EncryptSOPS encrypts values with sops into the format implied by the
+// output file name (.json, .env, or YAML otherwise). Plaintext is passed on
+// stdin and never written to disk.
+func EncryptSOPS(values map[string]string, outputPath string, recipients SOPSRecipients) ([]byte, error) {
+	if _, err := exec.LookPath("sops"); err != nil {
+		return nil, ErrNoSOPS
+	}
+
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("marshal values: %w", err)
+	}
+
+	args := []string{"--encrypt", "--input-type", "json", "--output-type", sopsFileType(outputPath),
+		"--filename-override", outputPath}
+	if len(recipients.Age) > 0 {
+		args = append(args, "--age", strings.Join(recipients.Age, ","))
+	}
+	if len(recipients.KMS) > 0 {
+		args = append(args, "--kms", strings.Join(recipients.KMS, ","))
+	}
+	if len(recipients.PGP) > 0 {
+		args = append(args, "--pgp", strings.Join(recipients.PGP, ","))
+	}
+	args = append(args, "/dev/stdin")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("sops", args...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sops --encrypt: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// sopsFileType returns the sops store type for a file name
+func sopsFileType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".env":
+		return "dotenv"
+	default:
+		return "yaml"
+	}
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RawTerminal puts the terminal attached to f into raw mode, so single key
+// presses can be read without echo. The returned function restores the
+// previous settings.
+func RawTerminal(f *os.File) (restore func(), err error) {
+	saved, err := sttyOutput(f, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("read terminal settings: %w", err)
+	}
+	if err := stty(f, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("set raw mode: %w", err)
+	}
+	return func() { _ = stty(f, strings.TrimSpace(saved)) }, nil
+}
+
+// TerminalSize returns the rows and columns of the terminal attached to f,
+// or 24x80 when they cannot be determined
+func TerminalSize(f *os.File) (rows, cols int) {
+	out, err := sttyOutput(f, "size")
+	if err == nil {
+		if _, err := fmt.Sscan(out, &rows, &cols); err == nil && rows > 0 && cols > 0 {
+			return rows, cols
+		}
+	}
+	return 24, 80
+}
+
+// sttyOutput runs stty on the terminal attached to f and returns its output
+func sttyOutput(f *os.File, args ...string) (string, error) {
+	path, err := exec.LookPath("stty")
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = f
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
@@ -11,16 +15,216 @@ import (
 	"github.com/razzkumar/vlt/pkg/config"
 )
 
+// globalOptions collects the global flags from the parent contexts.
+// Subcommands may define flags with the same name, so the command's own context is skipped.
+func globalOptions(ctx *cli.Context) *app.Options {
+	return &app.Options{
+		VaultAddr:        globalString(ctx, "vault-addr"),
+		VaultToken:       globalString(ctx, "vault-token"),
+		VaultNamespace:   globalString(ctx, "vault-namespace"),
+		EncryptionKey:    globalString(ctx, "encryption-key"),
+		Proxy:            globalString(ctx, "proxy"),
+		TLSMinVersion:    globalString(ctx, "tls-min-version"),
+		Timeout:          globalString(ctx, "vault-timeout"),
+		ConnectTimeout:   globalString(ctx, "vault-connect-timeout"),
+		Headers:          globalHeaders(ctx),
+		ConfigFile:       findConfigFile(ctx),
+		Profile:          globalString(ctx, "profile"),
+		AuthMethod:       globalString(ctx, "vault-auth-method"),
+		RoleID:           globalString(ctx, "vault-role-id"),
+		SecretID:         globalString(ctx, "vault-secret-id"),
+		GitHubToken:      globalString(ctx, "vault-github-token"),
+		K8sRole:          globalString(ctx, "vault-k8s-role"),
+		TokenRole:        globalString(ctx, "vault-token-role"),
+		BatchToken:       globalBool(ctx, "batch-token"),
+		ReadOnly:         globalBool(ctx, "read-only"),
+		MFA:              globalStringSlice(ctx, "mfa"),
+		ControlGroupWait: globalString(ctx, "control-group-wait"),
+		RecordFile:       globalString(ctx, "record"),
+		ReplayFile:       globalString(ctx, "replay"),
+	}
+}
+
+// bulkFlags returns the request limit flags of commands that walk many paths
+func bulkFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.Float64Flag{
+			Name:  "rps",
+			Usage: "Maximum Vault requests per second (0 for no limit)",
+			Value: app.DefaultBulkRateLimit,
+		},
+		&cli.IntFlag{
+			Name:  "burst",
+			Usage: "Vault requests allowed at once above --rps",
+			Value: app.DefaultBulkBurst,
+		},
+		&cli.IntFlag{
+			Name:  "max-requests",
+			Usage: "Fail after this many Vault requests (0 for no limit)",
+			Value: app.DefaultBulkMaxRequests,
+		},
+	}
+}
+
+// bulkOptions returns the global options with the request limits of a bulk command
+func bulkOptions(ctx *cli.Context) (*app.Options, error) {
+	if ctx.Float64("rps") < 0 || ctx.Int("burst") < 0 || ctx.Int("max-requests") < 0 {
+		return nil, usageError("--rps, --burst, and --max-requests cannot be negative")
+	}
+	opts := globalOptions(ctx)
+	opts.RateLimit = ctx.Float64("rps")
+	opts.Burst = ctx.Int("burst")
+	opts.MaxRequests = ctx.Int("max-requests")
+	return opts, nil
+}
+
+// globalString returns the first non-empty value of a global flag above the current command
+func globalString(ctx *cli.Context, name string) string {
+	for _, c := range ctx.Lineage()[1:] {
+		if v := c.String(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// globalStringSlice returns the values of a global slice flag above the current command
+func globalStringSlice(ctx *cli.Context, name string) []string {
+	for _, c := range ctx.Lineage()[1:] {
+		if v := c.StringSlice(name); len(v) > 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+// globalBool reports whether a global boolean flag is set above the current command
+func globalBool(ctx *cli.Context, name string) bool {
+	for _, c := range ctx.Lineage()[1:] {
+		if c.Bool(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderList collects repeated --header values. Unlike a string slice flag
+// it does not split on commas, which are common in header values.
+type HeaderList []string
+
+// Set appends one "Name: value" header
+func (h *HeaderList) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
+// String returns the headers for usage output
+func (h *HeaderList) String() string {
+	return strings.Join(*h, "; ")
+}
+
+// globalHeaders returns the --header values given above the current command
+func globalHeaders(ctx *cli.Context) []string {
+	for _, c := range ctx.Lineage()[1:] {
+		if headers, ok := c.Generic("header").(*HeaderList); ok && len(*headers) > 0 {
+			return *headers
+		}
+	}
+	return nil
+}
+
+// mountFlag returns a mount given on the command line, or else by KV_MOUNT or
+// TRANSIT_MOUNT, or "" so the app falls back to the config file and the
+// built-in default
+func mountFlag(ctx *cli.Context, name string) string {
+	if ctx.IsSet(name) {
+		return ctx.String(name)
+	}
+	switch name {
+	case "kv-mount":
+		return os.Getenv(config.KVMountEnv)
+	case "transit-mount":
+		return os.Getenv(config.TransitMountEnv)
+	}
+	return ""
+}
+
+// findConfigFile returns the --config value, or the nearest vlt.yaml in the
+// current directory or its parents, or "" if there is none
+func findConfigFile(ctx *cli.Context) string {
+	if ctx.IsSet("config") {
+		return ctx.String("config")
+	}
+	if path, ok := config.FindConfigFile(config.DefaultConfigFile); ok {
+		return path
+	}
+	return ""
+}
+
+// usageError returns an error that makes the CLI exit with app.ExitUsage
+func usageError(format string, args ...interface{}) error {
+	return app.WithExitCode(app.ExitUsage, fmt.Errorf(format, args...))
+}
+
+// onUsageError classifies flag parsing errors as usage errors
+func onUsageError(ctx *cli.Context, err error, isSubcommand bool) error {
+	return app.WithExitCode(app.ExitUsage, err)
+}
+
+// ExitCode maps an error returned by the CLI to its documented exit code
+func ExitCode(err error) int {
+	// urfave/cli reports missing required flags with an unexported error type
+	if err != nil && strings.HasPrefix(err.Error(), "Required flag") {
+		return app.ExitUsage
+	}
+	return app.ExitCode(err)
+}
+
 // GetCommands returns all CLI commands
 func GetCommands() []*cli.Command {
-	return []*cli.Command{
+	commands := []*cli.Command{
 		getPutCommand(),
 		getGetCommand(),
 		getSyncCommand(),
+		getVerifyCommand(),
+		getPlanCommand(),
+		getApplyCommand(),
 		getRunCommand(),
 		getJSONCommand(),
+		getTreeCommand(),
+		getSearchCommand(),
+		getBrowseCommand(),
+		getImportCommand(),
+		getExportCommand(),
+		getSnapshotCommand(),
+		getRestoreSnapshotCommand(),
+		getDriftCommand(),
+		getLintValuesCommand(),
+		getLoginCommand(),
+		getLogoutCommand(),
+		getWhoamiCommand(),
+		getCheckCommand(),
+		getDevCommand(),
+		getGuardCommand(),
+		getCleanCommand(),
+		getShredCommand(),
+		getRandomCommand(),
 		getCompletionCommand(),
+		getVersionCommand(),
+		getDocsCommand(),
+		getSelfUpdateCommand(),
+		getCompleteCommand(),
+	}
+
+	for _, cmd := range commands {
+		cmd.OnUsageError = onUsageError
+		if action := cmd.Action; action != nil {
+			cmd.Action = func(ctx *cli.Context) error {
+				return app.ExplainPermissionDenied(globalOptions(ctx), action(ctx))
+			}
+		}
 	}
+	return commands
 }
 
 func getPutCommand() *cli.Command {
@@ -39,12 +243,17 @@ func getPutCommand() *cli.Command {
 				Usage: "Transit encryption key name (optional)",
 			},
 			&cli.StringFlag{
-				Name:  "key",
-				Usage: "Specific key to update in multi-value secret",
+				Name:    "key",
+				Aliases: []string{"subkey"},
+				Usage:   "Specific key to update in multi-value secret",
 			},
 			&cli.StringFlag{
 				Name:  "value",
-				Usage: "Secret value (or use stdin)",
+				Usage: "Secret value, or - to read it from stdin (visible in shell history and the process list)",
+			},
+			&cli.StringFlag{
+				Name:  "value-file",
+				Usage: "Read the secret value from this file (stored as is, unlike --from-file)",
 			},
 			&cli.StringFlag{
 				Name:  "from-env",
@@ -54,15 +263,66 @@ func getPutCommand() *cli.Command {
 				Name:  "from-file",
 				Usage: "Load file content as base64 encoded value",
 			},
+			&cli.BoolFlag{
+				Name:  "from-stdin",
+				Usage: "Load multiple key-value pairs from stdin (format set by --format)",
+			},
+			&cli.BoolFlag{
+				Name:  "from-stdin-json",
+				Usage: "Load multiple key-value pairs from a JSON object on stdin",
+			},
+			&cli.BoolFlag{
+				Name:  "from-stdin-env",
+				Usage: "Load multiple key-value pairs from dotenv content on stdin",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Payload format for --from-stdin: auto, json, or env",
+				Value: "auto",
+			},
+			&cli.StringFlag{
+				Name:  "from-k8s-secret",
+				Usage: "Import all keys of a Kubernetes Secret (namespace/name)",
+			},
+			&cli.StringFlag{
+				Name:  "k8s-context",
+				Usage: "kubeconfig context for --from-k8s-secret (default: in-cluster or current context)",
+			},
+			&cli.StringFlag{
+				Name:  "from-sops",
+				Usage: "Import the top-level values of a SOPS-encrypted YAML, JSON, or dotenv file",
+			},
+			&cli.StringSliceFlag{
+				Name:  "encrypt-keys",
+				Usage: "Encrypt only these keys and store the others as plaintext (repeatable or comma-separated)",
+			},
+			&cli.IntFlag{
+				Name:  "key-version",
+				Usage: "Encrypt with this Transit key version instead of the latest",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Show the keys that would be created or updated (masked) without writing them",
+			},
+			&cli.StringFlag{
+				Name:  "override-policy",
+				Usage: "Write values that violate the value policy anyway, recording this reason in the audit log",
+			},
+			&cli.BoolFlag{
+				Name:  "shard",
+				Usage: "Split the keys across PATH/part1..N, each below the size limit of one secret; get, sync, and run reassemble them",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
 			&cli.StringFlag{
 				Name:  "kv-mount",
-				Usage: "KV v2 mount path",
-				Value: "kv",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
 			},
 			&cli.StringFlag{
 				Name:  "transit-mount",
-				Usage: "Transit mount path",
-				Value: "transit",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
 			},
 		},
 		Action: func(ctx *cli.Context) error {
@@ -71,36 +331,71 @@ func getPutCommand() *cli.Command {
 			if ctx.String("value") != "" {
 				inputCount++
 			}
+			if ctx.String("value-file") != "" {
+				inputCount++
+			}
 			if ctx.String("from-env") != "" {
 				inputCount++
 			}
 			if ctx.String("from-file") != "" {
 				inputCount++
 			}
+			if ctx.String("from-k8s-secret") != "" {
+				inputCount++
+			}
+			if ctx.String("from-sops") != "" {
+				inputCount++
+			}
+
+			fromStdin := ""
+			for _, flag := range []struct{ name, format string }{
+				{"from-stdin", ctx.String("format")},
+				{"from-stdin-json", "json"},
+				{"from-stdin-env", "env"},
+			} {
+				if ctx.Bool(flag.name) {
+					inputCount++
+					fromStdin = flag.format
+				}
+			}
 
 			if inputCount > 1 {
-				return fmt.Errorf("only one of --value, --from-env, or --from-file can be specified")
+				return usageError("only one of --value, --value-file, --from-env, --from-file, --from-stdin[-json|-env], --from-k8s-secret, or --from-sops can be specified")
 			}
 
 			// Validate key update operation
-			if ctx.String("key") != "" && (ctx.String("from-env") != "" || ctx.String("from-file") != "") {
-				return fmt.Errorf("--key cannot be used with --from-env or --from-file")
+			if ctx.String("key") != "" && (ctx.String("from-env") != "" || ctx.String("from-file") != "" || fromStdin != "" || ctx.String("from-k8s-secret") != "" || ctx.String("from-sops") != "") {
+				return usageError("--key cannot be used with --from-env, --from-file, --from-stdin, --from-k8s-secret, or --from-sops")
+			}
+			if len(ctx.StringSlice("encrypt-keys")) > 0 && ctx.String("key") == "" && (inputCount == 0 || ctx.String("value") != "" || ctx.String("value-file") != "" || ctx.String("from-file") != "") {
+				return usageError("--encrypt-keys applies to multi-key secrets: use it with --key or a multi-key input")
 			}
 
-			appInstance, err := app.New()
+			appInstance, err := app.New(globalOptions(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create app: %w", err)
 			}
 
 			opts := &app.PutOptions{
-				KVMount:       ctx.String("kv-mount"),
-				KVPath:        ctx.String("path"),
-				TransitMount:  ctx.String("transit-mount"),
-				EncryptionKey: ctx.String("encryption-key"),
-				Key:           ctx.String("key"),
-				Value:         ctx.String("value"),
-				FromEnv:       ctx.String("from-env"),
-				FromFile:      ctx.String("from-file"),
+				KVMount:        mountFlag(ctx, "kv-mount"),
+				KVPath:         ctx.String("path"),
+				TransitMount:   mountFlag(ctx, "transit-mount"),
+				EncryptionKey:  ctx.String("encryption-key"),
+				Key:            ctx.String("key"),
+				Value:          ctx.String("value"),
+				ValueFile:      ctx.String("value-file"),
+				FromEnv:        ctx.String("from-env"),
+				FromFile:       ctx.String("from-file"),
+				FromStdin:      fromStdin,
+				FromK8sSecret:  ctx.String("from-k8s-secret"),
+				K8sContext:     ctx.String("k8s-context"),
+				FromSOPS:       ctx.String("from-sops"),
+				EncryptKeys:    ctx.StringSlice("encrypt-keys"),
+				KeyVersion:     ctx.Int("key-version"),
+				Namespace:      ctx.String("namespace"),
+				DryRun:         ctx.Bool("dry-run"),
+				OverridePolicy: ctx.String("override-policy"),
+				Shard:          ctx.Bool("shard"),
 			}
 
 			return appInstance.Put(opts)
@@ -118,6 +413,9 @@ func getGetCommand() *cli.Command {
 Examples:
   # Get secrets from specific path
   vlt get --path secrets/prod
+
+  # Get several paths at once (keys are prefixed with the path)
+  vlt get --path myapp/db --path myapp/api
   
   # Get all secrets from config file
   vlt get --config secrets.yaml
@@ -126,76 +424,267 @@ Examples:
   vlt get
   
   # Output as JSON
-  vlt get --config secrets.yaml --json`,
+  vlt get --config secrets.yaml --json
+
+  # Set the variables in the current PowerShell session
+  vlt get --config secrets.yaml --format powershell | Out-String | Invoke-Expression
+
+  # Show plaintext values in an interactive terminal
+  vlt get --path secrets/prod --reveal
+
+  # Copy a single value to the clipboard (cleared after 45s)
+  vlt get --path secrets/db_password --copy
+
+  # Pick the path (and key) with a fuzzy finder
+  vlt get
+
+  # Read a file written by sync --encrypt-output
+  vlt get --env-file .env --identity ~/.config/age/key.txt --key DB_PASSWORD
+
+  # Hand a value to someone else as a single-use wrapping token
+  vlt get --path secrets/db --key password --wrap-ttl 15m
+
+  # Write a file stored with put --from-file back to disk
+  vlt get --path certs/keystore --out-file keystore.p12
+
+  # Show the name, size, SHA-256, and content type of a stored file
+  vlt get --path certs/keystore --metadata
+
+  # The vault CLI's -field and -format work too
+  vlt get -field=password -format=json --path secrets/db
+
+Without --path, --config, or a vlt.yaml on a terminal, a fuzzy finder over the
+secrets of the mount picks the path, then the key of a multi-value secret.
+
+Values are masked (e.g. API_KEY=****abcd) when stdout is a terminal.
+Use --reveal, or redirect/pipe the output, to print plaintext values.`,
 		Flags: []cli.Flag{
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:  "path",
-				Usage: "KV path to retrieve secret",
+				Usage: "KV path to retrieve secret (repeatable or comma-separated)",
 			},
 			&cli.StringFlag{
 				Name:  "config",
-				Usage: "YAML config file with secret definitions (defaults to vlt.yaml if exists)",
+				Usage: "YAML config file, vault://mount/path, or https:// URL with secret definitions (defaults to the nearest vlt.yaml)",
+			},
+			&cli.StringFlag{
+				Name:  "env-file",
+				Usage: "Read the variables of a .env file instead, decrypting it if sync --encrypt-output wrote it",
+			},
+			&cli.StringSliceFlag{
+				Name:  "identity",
+				Usage: "age identity file for an encrypted --env-file (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "expired",
+				Usage: "When --env-file was written by sync --ttl and has expired: refuse, warn, or resync",
+				Value: app.ExpiredRefuse,
 			},
 			&cli.StringFlag{
 				Name:  "encryption-key",
 				Usage: "Transit encryption key name (required for encrypted secrets)",
 			},
 			&cli.StringFlag{
-				Name:  "key",
-				Usage: "Specific key to retrieve (for multi-value secrets)",
+				Name:    "key",
+				Aliases: []string{"subkey", "field"},
+				Usage:   "Specific key to retrieve (for multi-value secrets)",
 			},
 			&cli.BoolFlag{
 				Name:  "json",
 				Usage: "Output as JSON format",
 			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: env (default, also accepted as table), json (same as --json), or powershell",
+			},
+			&cli.BoolFlag{
+				Name:  "reveal",
+				Usage: "Print plaintext values even when stdout is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "copy",
+				Usage: "Copy a single value to the clipboard instead of printing it",
+			},
+			&cli.DurationFlag{
+				Name:  "clipboard-timeout",
+				Usage: "Clear the clipboard after this duration when using --copy (0 to keep)",
+				Value: 45 * time.Second,
+			},
+			&cli.DurationFlag{
+				Name:  "wrap-ttl",
+				Usage: "Print a single-use Vault wrapping token that expires after this duration (e.g. 15m) instead of the values",
+			},
+			&cli.StringFlag{
+				Name:  "out-file",
+				Usage: "Write the file stored at --path with put --from-file to this file (- for stdout), reassembling chunks",
+			},
+			&cli.BoolFlag{
+				Name:  "metadata",
+				Usage: "Print the name, size, SHA-256, and content type of the file stored at --path instead of its content",
+			},
+			&cli.BoolFlag{
+				Name:  "raw",
+				Usage: "Print a binary file stored with put --from-file even when stdout is a terminal",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail on skipped entries, unknown config fields, and ambiguous values instead of warning",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Only load config entries tagged with one of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "skip",
+				Usage: "Skip config entries tagged with any of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
 			&cli.StringFlag{
 				Name:  "kv-mount",
-				Usage: "KV v2 mount path",
-				Value: "kv",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
 			},
 			&cli.StringFlag{
 				Name:  "transit-mount",
-				Usage: "Transit mount path",
-				Value: "transit",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
 			},
 		},
 		Action: func(ctx *cli.Context) error {
 			// Check for default config file if neither path nor config specified
 			configFile := ctx.String("config")
-			kvPath := ctx.String("path")
+			kvPaths := ctx.StringSlice("path")
+			kvPath := ""
+			if len(kvPaths) > 0 {
+				kvPath = kvPaths[0]
+			}
+
+			envFile := ctx.String("env-file")
+			if envFile != "" && (configFile != "" || kvPath != "") {
+				return usageError("--env-file cannot be combined with --path or --config")
+			}
+			expired, err := expiredFlag(ctx)
+			if err != nil {
+				return err
+			}
+			outputJSON := ctx.Bool("json")
+			powerShell := false
+			switch format := ctx.String("format"); format {
+			case "", "env", "table":
+			case "json":
+				outputJSON = true
+			case utils.FormatPowerShell:
+				powerShell = true
+			default:
+				return usageError("unknown --format %q (expected env, json, or powershell)", format)
+			}
+			wrapTTL := ctx.Duration("wrap-ttl")
+			if ctx.IsSet("wrap-ttl") {
+				switch {
+				case wrapTTL < time.Second:
+					return usageError("--wrap-ttl must be at least 1s")
+				case envFile != "":
+					return usageError("--wrap-ttl cannot be combined with --env-file")
+				case ctx.Bool("copy"):
+					return usageError("--wrap-ttl cannot be combined with --copy")
+				case len(kvPaths) > 1:
+					return usageError("--wrap-ttl requires a single --path")
+				}
+			}
 
-			if configFile == "" && kvPath == "" {
-				// Check if vlt.yaml exists in current directory
-				if _, err := os.Stat("vlt.yaml"); err == nil {
-					configFile = "vlt.yaml"
+			outFile := ctx.String("out-file")
+			if outFile != "" {
+				switch {
+				case len(kvPaths) != 1:
+					return usageError("--out-file requires a single --path")
+				case envFile != "" || configFile != "":
+					return usageError("--out-file cannot be combined with --config or --env-file")
+				case ctx.Bool("copy") || ctx.IsSet("wrap-ttl") || ctx.IsSet("key"):
+					return usageError("--out-file cannot be combined with --key, --copy, or --wrap-ttl")
+				}
+			}
+			if ctx.Bool("metadata") || ctx.Bool("raw") {
+				switch {
+				case len(kvPaths) != 1:
+					return usageError("--metadata and --raw require a single --path")
+				case envFile != "" || configFile != "":
+					return usageError("--metadata and --raw cannot be combined with --config or --env-file")
+				case ctx.Bool("copy") || ctx.IsSet("wrap-ttl") || ctx.IsSet("key") || outFile != "":
+					return usageError("--metadata and --raw cannot be combined with --key, --copy, --wrap-ttl, or --out-file")
 				}
 			}
 
-			// Validate that we have either path or config
-			if kvPath == "" && configFile == "" {
-				return fmt.Errorf("either --path, --config, or vlt.yaml file must be specified")
+			if configFile == "" && kvPath == "" && envFile == "" {
+				// Look for vlt.yaml in the current directory and its parents
+				configFile = findConfigFile(ctx)
+			}
+
+			// Without a path or config, a terminal user picks the secret interactively
+			pickPath := kvPath == "" && configFile == "" && envFile == ""
+			if pickPath && !app.CanPrompt() {
+				return usageError("either --path, --config, or vlt.yaml file must be specified")
 			}
 
-			appInstance, err := app.New()
+			appInstance, err := app.New(globalOptions(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create app: %w", err)
 			}
 
+			opts := &app.GetOptions{
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				KVPath:        kvPath,
+				ConfigFile:    configFile,
+				EnvFile:       envFile,
+				Identities:    ctx.StringSlice("identity"),
+				Expired:       expired,
+				Strict:        ctx.Bool("strict"),
+				OnlyTags:      ctx.StringSlice("only"),
+				SkipTags:      ctx.StringSlice("skip"),
+				Namespace:     ctx.String("namespace"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Key:           ctx.String("key"),
+				OutputJSON:    outputJSON,
+				PowerShell:    powerShell,
+				Reveal:        ctx.Bool("reveal"),
+				Copy:          ctx.Bool("copy"),
+				ClipTimeout:   ctx.Duration("clipboard-timeout"),
+				WrapTTL:       wrapTTL,
+				OutFile:       outFile,
+				Raw:           ctx.Bool("raw"),
+				Metadata:      ctx.Bool("metadata"),
+			}
+
+			if pickPath {
+				if err := appInstance.PickSecret(opts); err != nil {
+					return err
+				}
+			}
+
+			if envFile != "" {
+				return appInstance.GetFromEnvFile(opts)
+			}
+
 			if configFile != "" {
-				// Use config file to get all secrets
-				return appInstance.GetFromConfig(configFile, ctx.String("encryption-key"), ctx.Bool("json"))
-			} else {
-				// Use direct path
-				opts := &app.GetOptions{
-					KVMount:       ctx.String("kv-mount"),
-					KVPath:        kvPath,
-					TransitMount:  ctx.String("transit-mount"),
-					EncryptionKey: ctx.String("encryption-key"),
-					Key:           ctx.String("key"),
-					OutputJSON:    ctx.Bool("json"),
+				if opts.Copy {
+					return usageError("--copy requires --path")
 				}
-				return appInstance.Get(opts)
+				// Use config file to get all secrets
+				return appInstance.GetFromConfig(opts)
+			}
+
+			if len(kvPaths) > 1 {
+				opts.KVPaths = kvPaths
+				return appInstance.GetPaths(opts)
+			}
+
+			if outFile != "" {
+				return appInstance.GetFile(opts)
 			}
+
+			// Use direct path
+			return appInstance.Get(opts)
 		},
 	}
 }
@@ -204,91 +693,563 @@ func getSyncCommand() *cli.Command {
 	return &cli.Command{
 		Name:    "sync",
 		Usage:   "Sync secrets from YAML config to .env file",
-		Aliases: []string{"s"},
-		Flags: []cli.Flag{
+		Aliases: []string{"s", "env"},
+		Description: `Generate a .env file from the secrets listed in a YAML config file.
+
+"env" is kept as an alias for the legacy command of the same name, and --key is
+accepted as an alias for --encryption-key.
+
+Examples:
+  # Sync using the default config (vlt.yaml) into .env
+  vlt sync
+
+  # Legacy form
+  vlt env --key app-secrets --config secrets.yaml --output .env
+
+  # Terraform variables file (values nested in a "secrets" map variable)
+  vlt sync --format tfvars --tfvars-map secrets --output secrets.auto.tfvars
+
+  # systemd EnvironmentFile plus a drop-in that loads it
+  vlt sync --format systemd --output /etc/myapp/secrets.env \
+    --systemd-dropin /etc/systemd/system/myapp.service.d/override.conf
+
+  # File for docker run --env-file (values are not quoted)
+  vlt sync --format docker-args --output docker.env
+
+  # Record hashes of the generated files for "vlt verify"
+  vlt sync --manifest .vlt-manifest.json
+
+  # Review what would change in .env before writing it
+  vlt sync --dry-run
+
+  # Group-readable output, added to .gitignore if it is not ignored yet
+  vlt sync --mode 0640 --fix-gitignore
+
+  # Never leave plaintext on disk; "vlt run --env-file .env" decrypts it
+  vlt sync --encrypt-output transit:app-secrets
+  vlt sync --encrypt-output age:age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+
+  # Short-lived file: run refuses it after two hours, "vlt clean" deletes it
+  vlt sync --ttl 2h
+
+  # Fail instead of warning when a certificate or token expires within a week
+  vlt sync --strict --expiry-window 168h
+
+  # Refresh one rotated credential, and everything read from secrets/db, in .env
+  vlt sync --vars STRIPE_KEY --paths secrets/db
+
+  # Decrypt only the paths whose KV version changed since the last sync
+  vlt sync --incremental
+
+If the config defines an outputs section and --output and --format are not
+given, every output is written from a single load of the secrets.
+
+--vars and --paths load only the matching entries and merge their variables
+into the existing env file, leaving the other variables as they are. --vars
+matches the variable an entry sets, or its name; entries exporting every key of
+a path are searched for variables no other entry sets.
+
+--incremental records the KV version of each path in <output>.vlt-versions.
+The next incremental sync reads the metadata of each path first and takes the
+values of unchanged paths from the existing env file instead of reading and
+decrypting them.
+
+Files are written with mode 0600. A file inside a git work tree that is not
+covered by .gitignore is reported. Writing into a world-writable directory such
+as /tmp, or a world-readable file into a directory others can list, is refused
+unless --insecure-output is given.`,
+		Flags: append(syncFlags(),
 			&cli.StringFlag{
-				Name:  "config",
-				Usage: "YAML config file",
-				Value: "vlt.yaml",
+				Name:  "manifest",
+				Usage: "Also write SHA-256 hashes of the generated files to this manifest (see verify)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Show a masked diff of each file that would be written without writing it",
 			},
 			&cli.StringFlag{
-				Name:  "output",
-				Usage: "Output .env file",
-				Value: ".env",
+				Name:  "mode",
+				Usage: "Permissions of the written files, in octal",
+				Value: "0600",
 			},
-		},
+			&cli.BoolFlag{
+				Name:  "fix-gitignore",
+				Usage: "Add files written inside a git work tree to its .gitignore when they are not ignored",
+			},
+			&cli.BoolFlag{
+				Name:  "insecure-output",
+				Usage: "Write files even into world-writable directories or world-readable with --mode",
+			},
+			&cli.StringFlag{
+				Name:  "encrypt-output",
+				Usage: "Encrypt the written files: age:<recipient>[,<recipient>...] or transit:<key> (a local data key from Transit)",
+			},
+			&cli.DurationFlag{
+				Name:  "ttl",
+				Usage: "Record an expiry this far ahead (e.g. 2h) in each file; run and get --env-file refuse expired files and clean removes them",
+			},
+			&cli.DurationFlag{
+				Name:  "expiry-window",
+				Usage: "Warn about certificates, tokens, and cloud credentials in the values expiring within this window (fails with --strict; 0 to skip)",
+				Value: app.DefaultExpiryWindow,
+			},
+			&cli.StringSliceFlag{
+				Name:  "vars",
+				Usage: "Refresh only the entries setting these variables, or with these names, in the existing output (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "paths",
+				Usage: "Refresh only the entries reading these Vault paths (or below them) in the existing output (repeatable or comma-separated)",
+			},
+			&cli.BoolFlag{
+				Name:  "incremental",
+				Usage: "Skip reading and decrypting paths whose KV version is unchanged since the last incremental sync, keeping their values from the existing output",
+			},
+		),
 		Action: func(ctx *cli.Context) error {
-			appInstance, err := app.New()
+			opts, err := syncOptions(ctx)
+			if err != nil {
+				return err
+			}
+			mode, err := strconv.ParseUint(ctx.String("mode"), 8, 32)
+			if err != nil || mode > 0777 {
+				return usageError("invalid --mode %q: expected octal permissions such as 0600 or 0640", ctx.String("mode"))
+			}
+			opts.DryRun = ctx.Bool("dry-run")
+			opts.Mode = os.FileMode(mode)
+			opts.FixGitignore = ctx.Bool("fix-gitignore")
+			opts.InsecureOutput = ctx.Bool("insecure-output")
+			opts.EncryptOutput = ctx.String("encrypt-output")
+			if opts.TTL = ctx.Duration("ttl"); opts.TTL < 0 {
+				return usageError("--ttl must be positive")
+			}
+			if opts.ExpiryWindow = ctx.Duration("expiry-window"); opts.ExpiryWindow < 0 {
+				return usageError("--expiry-window must not be negative")
+			}
+			opts.Vars = ctx.StringSlice("vars")
+			opts.Paths = ctx.StringSlice("paths")
+			opts.Incremental = ctx.Bool("incremental")
+
+			appInstance, err := app.New(globalOptions(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create app: %w", err)
 			}
 
-			return appInstance.GenerateEnvFile(
-				ctx.String("config"),
-				ctx.String("output"),
-				"", // encryption key will be taken from config or environment
-			)
+			return appInstance.GenerateEnvFile(opts)
 		},
 	}
 }
 
-func getRunCommand() *cli.Command {
+func getVerifyCommand() *cli.Command {
 	return &cli.Command{
-		Name:    "run",
-		Usage:   "Run command with secrets injected as environment variables",
-		Aliases: []string{"r"},
-		Description: `Run a command with secrets from Vault injected as environment variables.
-
-This command fetches secrets from Vault (using a config file or individual paths),
-decrypts them if needed, and injects them into the environment of the specified command.
+		Name:  "verify",
+		Usage: "Check that generated files still match what sync would produce",
+		Description: `Renders the files sync would write from the current Vault state, without
+writing anything, and compares them with the files on disk. Takes the same
+flags as sync, so pass whatever sync was run with.
 
-The command inherits your current environment and adds/overrides with Vault secrets.
+Each file is reported as ok, missing, or differs. With --manifest (written by
+sync --manifest), differing files are reported as modified when they changed
+on disk since the sync, or stale when the secrets changed in Vault instead.
+Files written with --encrypt-output are decrypted first (age files need
+--identity). Exits with code 8 when any file does not match.
 
 Examples:
-  # Run with config file (most common)
-  vlt run --config secrets.yaml -- go run main.go
-  
-  # Run with default config file (vlt.yaml)
-  vlt run -- go run main.go
-  
-  # Run with inline secret injection
-  vlt run --inject DB_PASSWORD=secrets/db_password -- ./myapp
-  
-  # Run with multiple secret injections
-  vlt run --inject DB_PASSWORD=secrets/db_password --inject API_KEY=secrets/api_key -- npm start
-  
-  # Run with existing .env file plus Vault secrets
-  vlt run --config secrets.yaml --env-file .env.local -- python app.py
-
-Note: Use -- to separate vlt flags from the command to run.
-If vlt.yaml exists in the current directory, it will be used automatically if no --config is specified.`,
-		ArgsUsage: "[-- command args...]",
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:  "config",
-				Usage: "YAML config file with secret definitions (defaults to vlt.yaml if exists)",
-			},
+  # Sync with a manifest, then later check the host for tampering
+  vlt sync --manifest .vlt-manifest.json
+  vlt verify --manifest .vlt-manifest.json`,
+		Flags: append(syncFlags(),
 			&cli.StringFlag{
-				Name:  "encryption-key",
-				Usage: "Transit encryption key name",
+				Name:  "manifest",
+				Usage: "Manifest written by sync --manifest, to tell tampered files from stale ones",
 			},
 			&cli.StringSliceFlag{
-				Name:  "inject",
-				Usage: "Inject specific secret as ENV_VAR=vault_path (can be used multiple times)",
+				Name:  "identity",
+				Usage: "age identity file for files written with --encrypt-output age:... (repeatable)",
 			},
-			&cli.StringFlag{
-				Name:  "env-file",
-				Usage: "Load additional environment variables from .env file",
-			},
-			&cli.StringFlag{
+		),
+		Action: func(ctx *cli.Context) error {
+			opts, err := syncOptions(ctx)
+			if err != nil {
+				return err
+			}
+			opts.Identities = ctx.StringSlice("identity")
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Verify(opts)
+		},
+	}
+}
+
+func getPlanCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "plan",
+		Usage: "Plan the Vault changes that would make secrets match a local .env file",
+		Description: `Compares a .env file with the secrets the config maps it from (sync in
+reverse) and lists the keys that would be created, updated, deleted, or
+re-encrypted. With --out the change set is saved for review and "vlt apply".
+
+Entries with a key plan that key. Entries loading a whole path plan every key
+of the path: keys missing from the file are deleted, and when only one such
+path is configured, new variables are added to it. Keys stored in plaintext, or
+encrypted with an older transit key version, are re-encrypted when an
+encryption key is set.
+
+The plan file holds salted SHA-256 digests of the new values, never the values,
+and the version of every secret, so apply makes exactly the reviewed changes.
+
+Examples:
+  # Show what pushing .env to Vault would change
+  vlt plan --env-file .env
+
+  # Save the plan for review, then apply it
+  vlt plan --config vlt.yaml --env-file .env.prod --out plan.json
+  vlt apply plan.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config file, vault://mount/path, or https:// URL",
+				Value: "vlt.yaml",
+			},
+			&cli.StringFlag{
+				Name:  "env-file",
+				Usage: "Env file holding the desired values",
+				Value: ".env",
+			},
+			&cli.StringFlag{
+				Name:  "out",
+				Usage: "Save the plan to this file for vlt apply",
+			},
+			&cli.StringFlag{
+				Name:    "encryption-key",
+				Aliases: []string{"key"},
+				Usage:   "Transit encryption key name (defaults to config or ENCRYPTION_KEY)",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail on entries that cannot be planned and on config warnings",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Only plan entries with this tag (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "skip",
+				Usage: "Skip entries with this tag (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			configFile := findConfigFile(ctx)
+			if configFile == "" {
+				configFile = ctx.String("config")
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Plan(&app.PlanOptions{
+				ConfigFile:    configFile,
+				EnvFile:       ctx.String("env-file"),
+				OutFile:       ctx.String("out"),
+				EncryptionKey: ctx.String("encryption-key"),
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				Namespace:     ctx.String("namespace"),
+				Strict:        ctx.Bool("strict"),
+				OnlyTags:      ctx.StringSlice("only"),
+				SkipTags:      ctx.StringSlice("skip"),
+			})
+		},
+	}
+}
+
+func getApplyCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "apply",
+		Usage:     "Apply a plan file written by vlt plan",
+		ArgsUsage: "<plan.json>",
+		Description: `Makes exactly the changes recorded in a plan file. Before writing anything,
+every secret must still be at the version it was planned against and every new
+value in the env file must match its digest in the plan; otherwise apply exits
+with code 8 and the plan has to be made again. Writes use check-and-set, so a
+change made while applying is not overwritten.
+
+Examples:
+  vlt apply plan.json
+
+  # Read the values from another copy of the env file
+  vlt apply --env-file /secure/.env.prod plan.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "env-file",
+				Usage: "Env file holding the planned values (default: the one recorded in the plan)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 1 {
+				return usageError("exactly one plan file is required")
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Apply(&app.ApplyOptions{
+				PlanFile: ctx.Args().First(),
+				EnvFile:  ctx.String("env-file"),
+			})
+		},
+	}
+}
+
+// expiredFlag validates --expired of run and get
+func expiredFlag(ctx *cli.Context) (string, error) {
+	switch expired := ctx.String("expired"); expired {
+	case app.ExpiredRefuse, app.ExpiredWarn, app.ExpiredResync:
+		return expired, nil
+	default:
+		return "", usageError("invalid --expired %q: expected refuse, warn, or resync", expired)
+	}
+}
+
+// syncFlags returns the flags shared by sync and verify
+func syncFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "YAML config file, vault://mount/path, or https:// URL",
+			Value: "vlt.yaml",
+		},
+		&cli.StringFlag{
+			Name:  "output",
+			Usage: "Output .env file (when omitted, the config's outputs are written, or .env)",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format for --output (default env): " + strings.Join(utils.OutputFormats, ", "),
+		},
+		&cli.StringFlag{
+			Name:  "tfvars-map",
+			Usage: "With tfvars formats, nest all values in a map variable with this name (toml: a table)",
+		},
+		&cli.StringFlag{
+			Name:  "systemd-dropin",
+			Usage: "With --format systemd, also write a unit drop-in (e.g. /etc/systemd/system/app.service.d/override.conf) loading the output file",
+		},
+		&cli.StringFlag{
+			Name:    "encryption-key",
+			Aliases: []string{"key"},
+			Usage:   "Transit encryption key name (defaults to config or ENCRYPTION_KEY)",
+		},
+		&cli.BoolFlag{
+			Name:  "strict",
+			Usage: "Fail on skipped entries, unknown config fields, and ambiguous values instead of warning",
+		},
+		&cli.StringSliceFlag{
+			Name:  "only",
+			Usage: "Only load config entries tagged with one of these tags (repeatable or comma-separated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "skip",
+			Usage: "Skip config entries tagged with any of these tags (repeatable or comma-separated)",
+		},
+		&cli.StringFlag{
+			Name:  "namespace",
+			Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+		},
+		&cli.StringFlag{
+			Name:  "kv-mount",
+			Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+		},
+		&cli.StringFlag{
+			Name:  "transit-mount",
+			Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+		},
+	}
+}
+
+// syncOptions builds SyncOptions from the flags of sync or verify
+func syncOptions(ctx *cli.Context) (*app.SyncOptions, error) {
+	if ctx.String("systemd-dropin") != "" && ctx.String("format") != utils.FormatSystemd {
+		return nil, usageError("--systemd-dropin requires --format systemd")
+	}
+
+	configFile := findConfigFile(ctx)
+	if configFile == "" {
+		configFile = ctx.String("config")
+	}
+
+	return &app.SyncOptions{
+		ConfigFile:    configFile,
+		OutputFile:    ctx.String("output"),
+		Format:        ctx.String("format"),
+		MapName:       ctx.String("tfvars-map"),
+		SystemdDropIn: ctx.String("systemd-dropin"),
+		Manifest:      ctx.String("manifest"),
+		EncryptionKey: ctx.String("encryption-key"),
+		KVMount:       mountFlag(ctx, "kv-mount"),
+		TransitMount:  mountFlag(ctx, "transit-mount"),
+		Namespace:     ctx.String("namespace"),
+		Strict:        ctx.Bool("strict"),
+		OnlyTags:      ctx.StringSlice("only"),
+		SkipTags:      ctx.StringSlice("skip"),
+	}, nil
+}
+
+func getRunCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "run",
+		Usage:   "Run command with secrets injected as environment variables",
+		Aliases: []string{"r"},
+		Description: `Run a command with secrets from Vault injected as environment variables.
+
+This command fetches secrets from Vault (using a config file or individual paths),
+decrypts them if needed, and injects them into the environment of the specified command.
+
+The command inherits your current environment and adds/overrides with Vault secrets.
+
+Examples:
+  # Run with config file (most common)
+  vlt run --config secrets.yaml -- go run main.go
+  
+  # Run with default config file (vlt.yaml)
+  vlt run -- go run main.go
+  
+  # Run with inline secret injection
+  vlt run --inject DB_PASSWORD=secrets/db_password -- ./myapp
+  
+  # Run with multiple secret injections
+  vlt run --inject DB_PASSWORD=secrets/db_password --inject API_KEY=secrets/api_key -- npm start
+
+  # Read the injections from a file, one ENV_VAR=vault_path per line
+  vlt run --inject-file secrets.inject -- npm start
+  
+  # Run with existing .env file plus Vault secrets
+  vlt run --config secrets.yaml --env-file .env.local -- python app.py
+
+  # Run with a hermetic environment keeping only a few host variables
+  vlt run --isolate --allow-env PATH,HOME,LANG -- ./batch-job
+
+  # Run a pipeline through the shell, giving up after 10 minutes
+  vlt run --shell --timeout 10m -- './migrate.sh | tee migrate.log'
+
+  # Restart the server whenever its secrets change
+  vlt run --watch -- ./server
+
+  # Start every process of a Procfile with the same secrets
+  vlt run --procfile Procfile
+
+VAULT_* variables (including VAULT_TOKEN, VAULT_ROLE_ID, and VAULT_SECRET_ID)
+are removed from the inherited environment so the command and its dependencies
+cannot read the Vault credentials. Use --redact-vault-env=false to keep them.
+
+With --isolate, the command starts from an empty environment: only the
+injected secrets, --env-file values, and the host variables named by
+--allow-env (e.g. PATH,HOME,LANG; a trailing * matches a prefix) are set, for
+hermetic and reproducible runs.
+
+With --mask-output, every injected secret value (4 characters or longer) in the
+command's stdout and stderr is replaced with ***. The exit status is unchanged,
+but the command then writes to pipes rather than a terminal.
+
+With --shell, the command and its arguments are joined and run through
+$SHELL -c (cmd /C on Windows), so pipes, globs, and redirects work. With
+--timeout, the command is sent SIGTERM when the duration elapses, SIGKILL after
+--kill-after more, and vlt exits with code 124.
+
+With --procfile, each "name: command" line of the file is run through the
+shell with the same environment, and output lines are prefixed with the process
+name. Signals are forwarded to every process. When one process exits the others
+are stopped and vlt exits with its status; --wait-all keeps the others running
+until they exit too. --timeout applies to the whole set.
+
+With --watch, the command is restarted (SIGTERM, then SIGKILL after
+--kill-after) whenever its environment changes. vlt subscribes to Vault's KV
+write events (Vault 1.13+, needs the subscribe capability on
+sys/events/subscribe/kv-v2/data-write) and falls back to re-reading the
+secrets every --watch-interval when events are unavailable. --metrics-addr
+serves Prometheus metrics (Vault requests and errors, render cycles, restarts,
+and last-render timestamps) at /metrics while watching.
+
+Note: Use -- to separate vlt flags from the command to run.
+If no --config is specified, the nearest vlt.yaml in the current directory or its parents is used.`,
+		ArgsUsage: "[-- command args...]",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config file, vault://mount/path, or https:// URL with secret definitions (defaults to the nearest vlt.yaml)",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name",
+			},
+			&cli.StringSliceFlag{
+				Name:  "inject",
+				Usage: "Inject specific secret as ENV_VAR=vault_path (can be used multiple times)",
+			},
+			&cli.StringFlag{
+				Name:  "inject-file",
+				Usage: "Read --inject specs from this file, one ENV_VAR=vault_path per line (# for comments)",
+			},
+			&cli.StringFlag{
+				Name:  "env-file",
+				Usage: "Load additional environment variables from .env file (decrypted if sync --encrypt-output wrote it)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "identity",
+				Usage: "age identity file for an encrypted --env-file (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "expired",
+				Usage: "When --env-file was written by sync --ttl and has expired: refuse, warn, or resync",
+				Value: app.ExpiredRefuse,
+			},
+			&cli.BoolFlag{
+				Name:  "cleanup",
+				Usage: "Shred --env-file once the command exits (only files written by vlt sync)",
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail on skipped entries, unknown config fields, and ambiguous values instead of warning",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Only load config entries tagged with one of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "skip",
+				Usage: "Skip config entries tagged with any of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
 				Name:  "kv-mount",
-				Usage: "KV v2 mount path",
-				Value: "kv",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
 			},
 			&cli.StringFlag{
 				Name:  "transit-mount",
-				Usage: "Transit mount path",
-				Value: "transit",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
 			},
 			&cli.BoolFlag{
 				Name:  "dry-run",
@@ -299,46 +1260,161 @@ If vlt.yaml exists in the current directory, it will be used automatically if no
 				Usage: "Preserve all current environment variables (default: true)",
 				Value: true,
 			},
+			&cli.BoolFlag{
+				Name:  "isolate",
+				Usage: "Start the command with only the injected secrets and the --allow-env host variables",
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow-env",
+				Usage: "With --isolate, host variables to keep, e.g. PATH,HOME,LC_* (repeatable or comma-separated)",
+			},
+			&cli.BoolFlag{
+				Name:  "redact-vault-env",
+				Usage: "Remove VAULT_* variables (including VAULT_TOKEN) from the command's environment (default: true)",
+				Value: true,
+			},
+			&cli.BoolFlag{
+				Name:  "pass-vault-env",
+				Usage: "Same as --redact-vault-env=false (deprecated)",
+			},
+			&cli.BoolFlag{
+				Name:  "mask-output",
+				Usage: "Replace injected secret values in the command's stdout and stderr with ***",
+			},
+			&cli.BoolFlag{
+				Name:  "shell",
+				Usage: "Run the command string through $SHELL -c (for pipes, globs, and redirects)",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Terminate the command after this duration (e.g. 10m) and exit with code 124",
+			},
+			&cli.DurationFlag{
+				Name:  "kill-after",
+				Usage: "With --timeout, send SIGKILL if the command is still running this long after SIGTERM",
+				Value: 10 * time.Second,
+			},
+			&cli.StringFlag{
+				Name:  "procfile",
+				Usage: "Run the processes of a Procfile (name: command per line) instead of a single command",
+			},
+			&cli.BoolFlag{
+				Name:  "wait-all",
+				Usage: "With --procfile, keep running until every process exits instead of stopping all when one exits",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Restart the command when its secrets change in Vault (uses Vault events, falling back to polling)",
+			},
+			&cli.DurationFlag{
+				Name:  "watch-interval",
+				Usage: "With --watch, how often to re-read secrets when Vault events are unavailable",
+				Value: app.DefaultWatchInterval,
+			},
+			&cli.StringFlag{
+				Name:  "metrics-addr",
+				Usage: "With --watch, serve Prometheus metrics on this address (e.g. :9102) at /metrics",
+			},
 		},
 		Action: func(ctx *cli.Context) error {
 			// Check for default config file if none specified and no inject flags provided
 			configFile := ctx.String("config")
 			injectSecrets := ctx.StringSlice("inject")
+			injectFile := ctx.String("inject-file")
 
-			if configFile == "" && len(injectSecrets) == 0 {
-				// Check if vlt.yaml exists in current directory only if no inject flags
-				if _, err := os.Stat("vlt.yaml"); err == nil {
-					configFile = "vlt.yaml"
-				}
+			if configFile == "" && len(injectSecrets) == 0 && injectFile == "" {
+				// Look for vlt.yaml in the current directory and its parents only if no inject flags
+				configFile = findConfigFile(ctx)
 			}
 
 			// Validate that we have either config or inject flags
-			if configFile == "" && len(injectSecrets) == 0 {
-				return fmt.Errorf("either --config, vlt.yaml file, or --inject must be specified")
+			if configFile == "" && len(injectSecrets) == 0 && injectFile == "" {
+				return usageError("either --config, vlt.yaml file, --inject, or --inject-file must be specified")
 			}
 
 			// Get the command to run (everything after --)
 			args := ctx.Args().Slice()
+			procfile := ctx.String("procfile")
+			if procfile != "" {
+				if len(args) > 0 {
+					return usageError("--procfile cannot be combined with a command")
+				}
+				if ctx.Bool("shell") {
+					return usageError("--procfile cannot be combined with --shell (Procfile commands always run through the shell)")
+				}
+				args = []string{""}
+			} else if ctx.Bool("wait-all") {
+				return usageError("--wait-all requires --procfile")
+			}
 			if len(args) == 0 {
-				return fmt.Errorf("command to run is required. Use -- to separate vlt options from the command")
+				return usageError("command to run is required. Use -- to separate vlt options from the command")
+			}
+
+			if ctx.Bool("watch") {
+				switch {
+				case procfile != "":
+					return usageError("--watch cannot be combined with --procfile")
+				case ctx.IsSet("timeout"):
+					return usageError("--watch cannot be combined with --timeout")
+				}
+			} else if ctx.IsSet("watch-interval") || ctx.IsSet("metrics-addr") {
+				return usageError("--watch-interval and --metrics-addr require --watch")
 			}
 
-			appInstance, err := app.New()
+			if ctx.IsSet("allow-env") && !ctx.Bool("isolate") {
+				return usageError("--allow-env requires --isolate")
+			}
+			if ctx.Bool("isolate") && ctx.IsSet("preserve-env") {
+				return usageError("--isolate cannot be combined with --preserve-env")
+			}
+
+			if ctx.Bool("pass-vault-env") && ctx.IsSet("redact-vault-env") && ctx.Bool("redact-vault-env") {
+				return usageError("--pass-vault-env conflicts with --redact-vault-env")
+			}
+			expired, err := expiredFlag(ctx)
+			if err != nil {
+				return err
+			}
+			if ctx.Bool("cleanup") && ctx.String("env-file") == "" {
+				return usageError("--cleanup requires --env-file")
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create app: %w", err)
 			}
 
 			opts := &app.RunOptions{
-				KVMount:       ctx.String("kv-mount"),
-				TransitMount:  ctx.String("transit-mount"),
-				EncryptionKey: ctx.String("encryption-key"),
-				ConfigFile:    configFile,
-				InjectSecrets: injectSecrets,
-				EnvFile:       ctx.String("env-file"),
-				DryRun:        ctx.Bool("dry-run"),
-				PreserveEnv:   ctx.Bool("preserve-env"),
-				Command:       args[0],
-				Args:          args[1:],
+				KVMount:        mountFlag(ctx, "kv-mount"),
+				TransitMount:   mountFlag(ctx, "transit-mount"),
+				EncryptionKey:  ctx.String("encryption-key"),
+				ConfigFile:     configFile,
+				InjectSecrets:  injectSecrets,
+				InjectFile:     injectFile,
+				EnvFile:        ctx.String("env-file"),
+				Identities:     ctx.StringSlice("identity"),
+				Expired:        expired,
+				Cleanup:        ctx.Bool("cleanup"),
+				DryRun:         ctx.Bool("dry-run"),
+				PreserveEnv:    ctx.Bool("preserve-env"),
+				Isolate:        ctx.Bool("isolate"),
+				AllowEnv:       ctx.StringSlice("allow-env"),
+				RedactVaultEnv: ctx.Bool("redact-vault-env") && !ctx.Bool("pass-vault-env"),
+				MaskOutput:     ctx.Bool("mask-output"),
+				Shell:          ctx.Bool("shell"),
+				Timeout:        ctx.Duration("timeout"),
+				KillAfter:      ctx.Duration("kill-after"),
+				Strict:         ctx.Bool("strict"),
+				OnlyTags:       ctx.StringSlice("only"),
+				SkipTags:       ctx.StringSlice("skip"),
+				Namespace:      ctx.String("namespace"),
+				Command:        args[0],
+				Args:           args[1:],
+				Procfile:       procfile,
+				WaitAll:        ctx.Bool("wait-all"),
+				Watch:          ctx.Bool("watch"),
+				WatchInterval:  ctx.Duration("watch-interval"),
+				MetricsAddr:    ctx.String("metrics-addr"),
 			}
 
 			return appInstance.Run(opts)
@@ -349,9 +1425,11 @@ If vlt.yaml exists in the current directory, it will be used automatically if no
 func getJSONCommand() *cli.Command {
 	return &cli.Command{
 		Name:    "json",
-		Usage:   "Encrypt .env file content and output as JSON",
+		Usage:   "Encrypt .env file content and output as JSON, or decrypt it back",
 		Aliases: []string{"j"},
 		Description: `Encrypts environment variables from a .env file using Vault Transit encryption and outputs the result as JSON.
+With --decrypt, reads such a JSON document and outputs it with every vault:vN value decrypted.
+Use - as the file to read standard input in either direction.
 
 This command is useful for converting .env files to encrypted JSON format that can be stored in Vault or other secure storage systems.
 
@@ -383,20 +1461,51 @@ Examples:
   TRANSIT=false ENCRYPTION_KEY=mykey vlt json
   
   # Use custom transit mount
-  TRANSIT=true TRANSIT_MOUNT=custom-transit vlt json example.env`,
-		ArgsUsage: "[env-file]",
+  TRANSIT=true TRANSIT_MOUNT=custom-transit vlt json example.env
+
+  # Encrypt from stdin and decrypt back to dotenv in a pipeline
+  cat .env | vlt json --encryption-key mykey - | vlt json --decrypt --encryption-key mykey --output-format env -`,
+		ArgsUsage: "[env-file | json-file | -]",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:  "encryption-key",
 				Usage: "Transit encryption key name (optional - if not provided, outputs plaintext)",
 			},
+			&cli.BoolFlag{
+				Name:  "decrypt",
+				Usage: "Decrypt vault:vN values in a JSON document (file or - for stdin, the default)",
+			},
+			&cli.StringFlag{
+				Name:    "output-format",
+				Aliases: []string{"format"},
+				Usage:   "With --decrypt, output format: json or env",
+				Value:   "json",
+			},
+			&cli.IntFlag{
+				Name:  "key-version",
+				Usage: "Encrypt with this Transit key version instead of the latest",
+			},
 			&cli.StringFlag{
 				Name:  "transit-mount",
-				Usage: "Transit mount path",
-				Value: "transit",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
 			},
 		},
 		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("decrypt") {
+				appInstance, err := app.New(globalOptions(ctx))
+				if err != nil {
+					return fmt.Errorf("failed to create app: %w", err)
+				}
+
+				return appInstance.JSON(&app.JSONOptions{
+					TransitMount:  mountFlag(ctx, "transit-mount"),
+					EncryptionKey: ctx.String("encryption-key"),
+					EnvFile:       config.NonEmpty(ctx.Args().First(), "-"),
+					Decrypt:       true,
+					OutputFormat:  ctx.String("output-format"),
+				})
+			}
+
 			// Get env file from args or default to .env
 			envFile := ctx.Args().First()
 			if envFile == "" {
@@ -404,24 +1513,28 @@ Examples:
 			}
 
 			// Check if encryption is needed based on encryption key and TRANSIT env var
-			encryptionKey := config.GetEncryptionKey(ctx.String("encryption-key"))
+			encryptionKey := config.GetEncryptionKey(config.NonEmpty(ctx.String("encryption-key"), globalOptions(ctx).EncryptionKey))
 			useEncryption := config.ShouldUseEncryption(encryptionKey)
 
 			if !useEncryption {
+				if ctx.Int("key-version") > 0 {
+					return usageError("--key-version requires an encryption key")
+				}
 				// For plaintext output, don't need vault client
 				return handlePlaintextJSON(envFile)
 			}
 
 			// For encryption, create app with vault client
-			appInstance, err := app.New()
+			appInstance, err := app.New(globalOptions(ctx))
 			if err != nil {
 				return fmt.Errorf("failed to create app: %w", err)
 			}
 
 			opts := &app.JSONOptions{
-				TransitMount:  config.GetTransitMount(ctx.String("transit-mount")),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
 				EncryptionKey: ctx.String("encryption-key"),
 				EnvFile:       envFile,
+				KeyVersion:    ctx.Int("key-version"),
 			}
 
 			return appInstance.JSON(opts)
@@ -429,74 +1542,1520 @@ Examples:
 	}
 }
 
-// handlePlaintextJSON handles JSON output without encryption (no vault client needed)
-func handlePlaintextJSON(envFile string) error {
-	// Check if file exists
-	if _, err := os.Stat(envFile); os.IsNotExist(err) {
-		return fmt.Errorf("env file not found: %s", envFile)
-	}
+func getLoginCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "login",
+		Usage: "Authenticate to Vault and store the token",
+		Description: `Authenticates to Vault, stores the token, and prints its policies and TTL.
 
-	// Load as plaintext
-	data, err := utils.LoadEnvFileAsPlaintext(envFile)
-	if err != nil {
-		return fmt.Errorf("load env file: %w", err)
-	}
+By default the token is stored in the macOS Keychain, the Secret Service
+(secret-tool) on Linux, or the Windows Credential Manager, keyed by the Vault
+address. --store file writes ~/.vault-token like the Vault CLI instead, and
+--store none prints the token. Later commands use the stored token when no
+other credentials are set, so VAULT_TOKEN does not need to live in shell
+profiles.
 
-	// Output as JSON
-	if err := utils.OutputJSON(data); err != nil {
-		return fmt.Errorf("output json: %w", err)
-	}
+Without --method, the configured credentials (VAULT_TOKEN, AppRole, GitHub, or
+Kubernetes) are used, or a token is read from standard input. ldap and userpass
+prompt for the password; oidc opens the identity provider in a browser and
+listens on http://localhost:8250/oidc/callback, which must be an allowed
+redirect URI of the role.
 
-	return nil
-}
+Examples:
+  # Paste a token once
+  VAULT_ADDR=https://vault.example.com vlt login
 
-func getCompletionCommand() *cli.Command {
-	return &cli.Command{
-		Name:  "completion",
-		Usage: "Generate shell completion scripts",
-		Description: `Generate shell completion scripts for various shells.
+  # Log in through the browser
+  vlt login --method oidc --role developer
 
-Supported shells: bash, zsh, fish, powershell
+  # LDAP login, token written to ~/.vault-token
+  vlt login --method ldap --username alice --store file
+
+  # Store the token of an AppRole login
+  VAULT_ROLE_ID=xxx VAULT_SECRET_ID=yyy vlt login --method approle`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "method",
+				Usage: "Auth method: token, approle, github, kubernetes, ldap, userpass, or oidc",
+			},
+			&cli.StringFlag{
+				Name:  "auth-path",
+				Usage: "Mount path of the auth method when it is not the default (e.g. corp-ldap)",
+			},
+			&cli.StringFlag{
+				Name:  "username",
+				Usage: "ldap and userpass: user to log in as (defaults to the local user)",
+			},
+			&cli.StringFlag{
+				Name:  "role",
+				Usage: "oidc and kubernetes: role to log in with",
+			},
+			&cli.StringFlag{
+				Name:  "store",
+				Usage: "Where to keep the token: keychain, file (~/.vault-token), or none (print it)",
+				Value: app.TokenStoreKeychain,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("login takes no arguments")
+			}
+			return app.Login(globalOptions(ctx), &app.LoginOptions{
+				Method:   ctx.String("method"),
+				AuthPath: ctx.String("auth-path"),
+				Username: ctx.String("username"),
+				Role:     ctx.String("role"),
+				Store:    ctx.String("store"),
+			})
+		},
+	}
+}
+
+func getLogoutCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "logout",
+		Usage: "Revoke the stored token and remove it",
+		Description: `Revokes the token stored by 'vlt login' for the Vault address and removes it
+from the OS keychain or ~/.vault-token. A token Vault no longer accepts is
+removed without revoking. Use --no-revoke to only remove the stored token.
+
+Examples:
+  vlt logout
+  vlt logout --no-revoke`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "no-revoke",
+				Usage: "Remove the stored token without revoking it",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("logout takes no arguments")
+			}
+			return app.Logout(globalOptions(ctx), !ctx.Bool("no-revoke"))
+		},
+	}
+}
+
+func getWhoamiCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "whoami",
+		Usage: "Show the identity, policies, and TTL of the current credentials",
+		Description: `Looks up the token the configured credentials produce (auth/token/lookup-self)
+and prints its display name, policies, TTL, entity ID, and namespace, along
+with the auth method and where the credentials came from: a flag, an
+environment variable, the OS keychain, or ~/.vault-token.
+
+Examples:
+  vlt whoami
+  VAULT_NAMESPACE=team-a vlt whoami --json`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output as JSON",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("whoami takes no arguments")
+			}
+			return app.Whoami(globalOptions(ctx), ctx.Bool("json"))
+		},
+	}
+}
+
+func getDevCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "dev",
+		Usage:     "Start a local Vault seeded with placeholders for every configured secret",
+		ArgsUsage: "[-- command [args...]]",
+		Description: `Starts "vault server -dev" in the background (or, when vault is not installed
+or with --embedded, a built-in server that supports KV v2 and transit), enables
+the KV and transit mounts the config uses, creates the transit key, and writes
+a placeholder value for every secret the config reads. It then opens your shell
+with VAULT_ADDR and VAULT_TOKEN pointing at the server, so sync, run, and get
+work unchanged. Exiting the shell stops the server and discards its data.
+
+Placeholders are "dev-" followed by the lowercased key, or the entry's default.
+Entries that load every key of a path get their require_keys, or one key named
+after the last path segment. Entries with their own namespace or server are not
+seeded.
+
+Examples:
+  # Open a shell against a seeded dev server
+  vlt dev
+
+  # Seed another config and run a command instead of a shell
+  vlt dev --config vlt.staging.yaml -- make test
+
+  # Use the built-in server on another port
+  vlt dev --embedded --listen 127.0.0.1:8300`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "config",
+				Aliases: []string{"c"},
+				Usage:   "YAML config file whose secrets are seeded (defaults to the nearest vlt.yaml)",
+			},
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address the server listens on",
+				Value: "127.0.0.1:8200",
+			},
+			&cli.StringFlag{
+				Name:  "token",
+				Usage: "Root token of the server",
+				Value: "root",
+			},
+			&cli.StringFlag{
+				Name:  "vault-bin",
+				Usage: "vault binary to run (default: vault from PATH, else the built-in server)",
+			},
+			&cli.BoolFlag{
+				Name:  "embedded",
+				Usage: "Use the built-in server even when vault is installed",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit key to create and encrypt the placeholders with (default: transit.key of the config)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.IsSet("vault-bin") && ctx.Bool("embedded") {
+				return usageError("--vault-bin and --embedded cannot be combined")
+			}
+			if ctx.String("token") == "" {
+				return usageError("--token cannot be empty")
+			}
+			return app.Dev(&app.DevOptions{
+				ConfigFile:    findConfigFile(ctx),
+				Listen:        ctx.String("listen"),
+				Token:         ctx.String("token"),
+				VaultBin:      ctx.String("vault-bin"),
+				Embedded:      ctx.Bool("embedded"),
+				EncryptionKey: config.NonEmpty(ctx.String("encryption-key"), globalString(ctx, "encryption-key")),
+				Command:       ctx.Args().Slice(),
+			})
+		},
+	}
+}
+
+func getGuardCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "guard",
+		Usage:     "Block commits of secrets, Vault tokens, and generated .env files",
+		ArgsUsage: "[files...]",
+		Description: `Scans the files about to be committed, for use as a git pre-commit hook. Without
+arguments the staged content of every staged file is checked; with arguments the
+given files are read from disk (as the pre-commit framework passes them).
+
+A file is reported when it contains the value of a secret the config resolves to,
+a Vault token (hvs., hvb., hvr.), or when it is a .env file or an output listed in
+the config. Secret values are compared by SHA-256 hash and never printed. Without
+a config or Vault connection, only tokens and .env files are checked. Exits
+non-zero when anything is found, which blocks the commit.
+
+Examples:
+  # Install as a pre-commit hook
+  printf '#!/bin/sh\nexec vlt guard\n' > .git/hooks/pre-commit
+  chmod +x .git/hooks/pre-commit
+
+  # Check specific files without contacting Vault
+  vlt guard --no-vault config/app.yaml .env.local`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config whose secrets and outputs are looked for (default: nearest vlt.yaml)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-vault",
+				Usage: "Do not resolve secrets from Vault; only check tokens and generated files",
+			},
+			&cli.StringFlag{
+				Name:    "encryption-key",
+				Aliases: []string{"key"},
+				Usage:   "Transit encryption key name (defaults to config or ENCRYPTION_KEY)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			return app.Guard(&app.GuardOptions{
+				Files:         ctx.Args().Slice(),
+				ConfigFile:    findConfigFile(ctx),
+				NoVault:       ctx.Bool("no-vault"),
+				AppOptions:    globalOptions(ctx),
+				EncryptionKey: ctx.String("encryption-key"),
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				Namespace:     ctx.String("namespace"),
+			})
+		},
+	}
+}
+
+func getCleanCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "clean",
+		Usage: "Securely delete expired files written by sync --ttl",
+		Description: `Every file sync writes is recorded in a state file
+($XDG_STATE_HOME/vlt/generated.json, or ~/.local/state/vlt/generated.json).
+clean overwrites the recorded files whose --ttl has passed with random data and
+removes them; with --all, every recorded file is removed. Files that no longer
+exist are dropped from the state file.
+
+Overwriting is a best effort: copy-on-write file systems, journals, snapshots,
+and SSDs may keep earlier copies of the data.
+
+Examples:
+  # Remove expired files, e.g. from cron or a CI cleanup step
+  vlt clean
+
+  # List every tracked file that would be removed
+  vlt clean --all --dry-run`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Remove every file sync recorded, not only expired ones",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the files that would be removed without removing them",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("clean takes no arguments")
+			}
+			return app.Clean(&app.CleanOptions{
+				All:    ctx.Bool("all"),
+				DryRun: ctx.Bool("dry-run"),
+			})
+		},
+	}
+}
+
+func getShredCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "shred",
+		Usage:     "Overwrite and remove files written by sync",
+		ArgsUsage: "<file...>",
+		Description: `Overwrites each file with random data, flushes it to disk, and removes it. Only
+files vlt sync has written (recorded in $XDG_STATE_HOME/vlt/generated.json) are
+shredded unless --force is given, so a mistyped path cannot destroy an unrelated
+file. Use it instead of rm in CI cleanup steps, or "vlt run --cleanup" to shred
+the --env-file as soon as the command exits.
+
+Overwriting is a best effort: copy-on-write file systems, journals, snapshots,
+and SSDs may keep earlier copies of the data.
+
+Examples:
+  vlt sync --output .env
+  ./deploy.sh
+  vlt shred .env
+
+  # Same, shredding .env even when the command fails
+  vlt sync --output .env
+  vlt run --env-file .env --cleanup -- ./deploy.sh`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Also shred files vlt sync has no record of writing",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() == 0 {
+				return usageError("at least one file to shred is required")
+			}
+			return app.Shred(&app.ShredOptions{
+				Files: ctx.Args().Slice(),
+				Force: ctx.Bool("force"),
+			})
+		},
+	}
+}
+
+func getRandomCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "random",
+		Usage: "Generate random bytes with Vault, optionally storing them as a secret",
+		Description: `Prints random bytes from Vault's sys/tools/random, encoded as base64 or hex.
+When Vault cannot be reached or the token may not use sys/tools/random, the
+bytes come from the local crypto/rand instead, with a warning.
+
+--store writes the value to --path (under --key) like put, encrypted with the
+transit key when one is configured, without printing it, so a new signing
+secret never appears in a terminal or shell history.
+
+Examples:
+  vlt random
+  vlt random --bytes 64 --format hex
+
+  # Create a session signing key straight in Vault
+  vlt random --bytes 32 --store --path myapp/config --key SESSION_SECRET`,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "bytes",
+				Usage: "Number of random bytes",
+				Value: 32,
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output encoding: base64 or hex",
+				Value: app.RandomBase64,
+			},
+			&cli.BoolFlag{
+				Name:  "store",
+				Usage: "Store the value in Vault instead of printing it (requires --path)",
+			},
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "With --store, secret path within the KV mount",
+			},
+			&cli.StringFlag{
+				Name:  "key",
+				Usage: "With --store, key to set at the path (default: store a single value)",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "With --store, transit encryption key name (optional)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("random takes no arguments")
+			}
+			store := ctx.Bool("store")
+			if store && ctx.String("path") == "" {
+				return usageError("--store requires --path")
+			}
+			if !store && (ctx.String("path") != "" || ctx.String("key") != "") {
+				return usageError("--path and --key require --store")
+			}
+			return app.Random(globalOptions(ctx), &app.RandomOptions{
+				Bytes:         ctx.Int("bytes"),
+				Format:        ctx.String("format"),
+				Store:         store,
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				KVPath:        ctx.String("path"),
+				Key:           ctx.String("key"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Namespace:     ctx.String("namespace"),
+			})
+		},
+	}
+}
+
+func getCheckCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "check",
+		Usage: "Preflight check of the Vault server, token, namespace, and mounts",
+		Description: `Checks that the Vault server is reachable, initialized, unsealed, and active,
+that the token is valid, that the namespace exists, and that the KV mount is a
+KV v2 engine. With an encryption key, the transit mount and key are checked
+too. With a config file (or a vlt.yaml found in the current directory or its
+parents), the servers, namespaces, and mounts of every entry are checked, and
+the expiry of certificates, JWTs, and cloud credentials among the values is
+listed: a warning within --expiry-window (failed with --strict), failed once
+expired.
+
+Each check prints ok, warning, or failed with a hint on how to fix it. The
+command exits 1 when any check fails.
+
+Examples:
+  vlt check
+  vlt check --kv-mount secret --encryption-key app-secrets
+  vlt check --config vlt.yaml --expiry-window 168h --strict`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config file whose entries to check (defaults to the nearest vlt.yaml)",
+			},
+			&cli.DurationFlag{
+				Name:  "expiry-window",
+				Usage: "Warn about credentials in the config's values expiring within this window",
+				Value: app.DefaultExpiryWindow,
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Fail instead of warning about credentials expiring within --expiry-window",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key to check",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("check takes no arguments")
+			}
+			if ctx.Duration("expiry-window") <= 0 {
+				return usageError("--expiry-window must be positive")
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Check(&app.CheckOptions{
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				ConfigFile:    findConfigFile(ctx),
+				ExpiryWindow:  ctx.Duration("expiry-window"),
+				Strict:        ctx.Bool("strict"),
+			})
+		},
+	}
+}
+
+func getTreeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "tree",
+		Usage: "Show the KV path hierarchy with per-secret details",
+		Description: `Recursively lists KV v2 metadata under a path and renders it as a tree.
+
+Each secret is annotated with its key count, encryption status, and last-modified time.
+
+Examples:
+  # Show everything under secrets/
+  vlt tree --path secrets/
+
+  # Show the whole mount
+  vlt tree --kv-mount kv`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "KV path to start from (defaults to the mount root)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+		}, bulkFlags()...),
+		Action: func(ctx *cli.Context) error {
+			opts, err := bulkOptions(ctx)
+			if err != nil {
+				return err
+			}
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Tree(&app.TreeOptions{
+				KVMount: mountFlag(ctx, "kv-mount"),
+				KVPath:  ctx.String("path"),
+			})
+		},
+	}
+}
+
+func getSearchCommand() *cli.Command {
+	return &cli.Command{
+		Name:    "search",
+		Usage:   "Search secret paths for matching keys or values",
+		Aliases: []string{"grep"},
+		Description: `Walks the KV subtree under a path and reports every path and key that matches.
+
+Patterns are Go regular expressions. Matching values are never printed, only
+the path and key they were found under. Searching values reads (and decrypts)
+every secret in the subtree, so it requires --allow-value-search.
+
+Examples:
+  # Find every DB_* key under secrets/
+  vlt search --path secrets/ --key-pattern 'DB_.*'
+
+  # Find secrets that still reference an old hostname
+  vlt search --path secrets/ --value-pattern 'db-old\.internal' --allow-value-search`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "KV path to search under (defaults to the mount root)",
+			},
+			&cli.StringFlag{
+				Name:  "key-pattern",
+				Usage: "Regular expression matched against key names",
+			},
+			&cli.StringFlag{
+				Name:  "value-pattern",
+				Usage: "Regular expression matched against values (requires --allow-value-search)",
+			},
+			&cli.BoolFlag{
+				Name:  "allow-value-search",
+				Usage: "Confirm that secret values may be read and decrypted for --value-pattern",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name (for searching encrypted values)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		}, bulkFlags()...),
+		Action: func(ctx *cli.Context) error {
+			opts, err := bulkOptions(ctx)
+			if err != nil {
+				return err
+			}
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Search(&app.SearchOptions{
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				KVPath:        ctx.String("path"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				KeyPattern:    ctx.String("key-pattern"),
+				ValuePattern:  ctx.String("value-pattern"),
+				AllowValues:   ctx.Bool("allow-value-search"),
+			})
+		},
+	}
+}
+
+func getBrowseCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "browse",
+		Usage: "Browse, copy, edit, and delete secrets in an interactive terminal UI",
+		Description: `Opens a full-screen browser for KV v2 mounts and paths.
+
+Values are masked until revealed with "r". Keys:
+  ↑/↓ or j/k     move            →, enter or l  open
+  ←, h or esc    go back         r              reveal or mask values
+  c              copy value      e              edit value
+  n              add a key       d              delete key or secret
+  q              quit
+
+Edited values are transit-encrypted when the secret is.
+
+Examples:
+  # Start at the list of KV mounts
+  vlt browse
+
+  # Open a directory of the kv mount
+  vlt browse --kv-mount kv --path myapp/ --encryption-key app-secrets`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "Directory or secret to open",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name (needed to view or edit encrypted secrets)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount to open (defaults to the list of mounts)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			kvMount := mountFlag(ctx, "kv-mount")
+			if kvMount == "" && ctx.String("path") != "" {
+				kvMount = "kv"
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Browse(&app.BrowseOptions{
+				KVMount:       kvMount,
+				KVPath:        ctx.String("path"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Namespace:     ctx.String("namespace"),
+			})
+		},
+	}
+}
+func getImportCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "import",
+		Usage: "Import secrets from AWS Secrets Manager or SSM Parameter Store",
+		Description: `Copies every secret under a prefix in AWS into one KV path, merging with existing keys.
+
+Secrets Manager secrets whose value is a JSON object contribute each field as a
+key; other secrets and SSM parameters are keyed by their name below the prefix,
+with / replaced by _. AWS credentials and region are read from the standard
+AWS_* environment variables and ~/.aws files (AWS_PROFILE is honored).
+
+Examples:
+  # Import all Secrets Manager secrets whose name starts with /prod/app
+  vlt import aws-sm --prefix /prod/app --path secrets/app
+
+  # Import an SSM parameter hierarchy, encrypting values with transit
+  vlt import aws-ssm --prefix /prod/app --path secrets/app --encryption-key app-key`,
+		Subcommands: []*cli.Command{
+			getAWSSubcommand(app.AWSSecretsManager, "Import secrets from AWS Secrets Manager", true),
+			getAWSSubcommand(app.AWSParameterStore, "Import parameters from AWS SSM Parameter Store", true),
+		},
+	}
+}
+
+func getExportCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "export",
+		Usage:     "Export secrets to AWS Secrets Manager, SSM Parameter Store, or a SOPS file",
+		ArgsUsage: "[aws-sm | aws-ssm]",
+		Description: `Writes the keys of one KV path outside Vault, decrypting transit-encrypted values first.
+
+With aws-sm the keys are stored as a single JSON secret named by --prefix,
+created if it does not exist. With aws-ssm each key becomes a SecureString
+parameter under --prefix, overwriting existing parameters.
+
+With --format sops the keys are written to a SOPS-encrypted file (YAML, or JSON
+or dotenv by --output extension). Recipients come from --sops-age, --sops-kms,
+or --sops-pgp, or else from the creation rules in .sops.yaml. Requires sops 3.9+.
+
+Examples:
+  # Export secrets/app as the JSON secret /prod/app
+  vlt export aws-sm --prefix /prod/app --path secrets/app
+
+  # Export each key as /prod/app/<KEY> in Parameter Store
+  vlt export aws-ssm --prefix /prod/app --path secrets/app --encryption-key app-key
+
+  # Export to a SOPS file encrypted for an age recipient
+  vlt export --format sops --path secrets/app --output secrets.enc.yaml --sops-age age1...`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "File format to export to: sops",
+			},
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "KV path to export",
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file (.yaml, .json, or .env)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "sops-age",
+				Usage: "age recipient to encrypt for (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "sops-kms",
+				Usage: "AWS KMS key ARN to encrypt with (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "sops-pgp",
+				Usage: "PGP fingerprint to encrypt for (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name (optional)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Subcommands: []*cli.Command{
+			getAWSSubcommand(app.AWSSecretsManager, "Export secrets to AWS Secrets Manager", false),
+			getAWSSubcommand(app.AWSParameterStore, "Export secrets to AWS SSM Parameter Store", false),
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.String("format") == "" {
+				return usageError("a target is required: aws-sm, aws-ssm, or --format sops")
+			}
+			if ctx.String("path") == "" || ctx.String("output") == "" {
+				return usageError("--format %s requires --path and --output", ctx.String("format"))
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.ExportFile(&app.ExportFileOptions{
+				Format:        ctx.String("format"),
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				KVPath:        ctx.String("path"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Namespace:     ctx.String("namespace"),
+				OutputFile:    ctx.String("output"),
+				Recipients: utils.SOPSRecipients{
+					Age: ctx.StringSlice("sops-age"),
+					KMS: ctx.StringSlice("sops-kms"),
+					PGP: ctx.StringSlice("sops-pgp"),
+				},
+			})
+		},
+	}
+}
+
+// getAWSSubcommand builds the aws-sm/aws-ssm subcommands shared by import and export
+func getAWSSubcommand(service, usage string, importing bool) *cli.Command {
+	flags := []cli.Flag{
+		&cli.StringFlag{
+			Name:     "prefix",
+			Usage:    "Secret name prefix (aws-sm) or parameter path (aws-ssm)",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:     "path",
+			Usage:    "KV path to import into or export from",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "region",
+			Usage: "AWS region (defaults to AWS_REGION or the AWS profile)",
+		},
+		&cli.StringFlag{
+			Name:  "encryption-key",
+			Usage: "Transit encryption key name (optional)",
+		},
+		&cli.StringFlag{
+			Name:  "namespace",
+			Usage: "Vault namespace for this command (overrides --vault-namespace)",
+		},
+		&cli.StringFlag{
+			Name:  "kv-mount",
+			Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+		},
+		&cli.StringFlag{
+			Name:  "transit-mount",
+			Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+		},
+	}
+	if importing {
+		flags = append(flags, &cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Show the keys that would be created or updated (masked) without writing them",
+		}, &cli.StringFlag{
+			Name:  "override-policy",
+			Usage: "Import values that violate the value policy anyway, recording this reason in the audit log",
+		})
+	}
+
+	return &cli.Command{
+		Name:         service,
+		Usage:        usage,
+		OnUsageError: onUsageError,
+		Flags:        flags,
+		Action: func(ctx *cli.Context) error {
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			opts := &app.AWSOptions{
+				Service:        service,
+				Prefix:         ctx.String("prefix"),
+				Region:         ctx.String("region"),
+				KVMount:        mountFlag(ctx, "kv-mount"),
+				KVPath:         ctx.String("path"),
+				TransitMount:   mountFlag(ctx, "transit-mount"),
+				EncryptionKey:  ctx.String("encryption-key"),
+				Namespace:      ctx.String("namespace"),
+				DryRun:         importing && ctx.Bool("dry-run"),
+				OverridePolicy: ctx.String("override-policy"),
+			}
+
+			if importing {
+				return appInstance.ImportAWS(opts)
+			}
+			return appInstance.ExportAWS(opts)
+		},
+	}
+}
+
+func getSnapshotCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "snapshot",
+		Usage: "Write the secrets referenced by a config to an age-encrypted snapshot",
+		Description: `Reads every Vault path referenced by the config (only the named key for
+key entries), decrypts transit-encrypted values, and writes them to a single file
+encrypted with age for the given recipients. The plaintext is piped to age and
+never written to disk. Literal and template entries are not included.
+
+Use restore-snapshot on the other side to import the snapshot into Vault.
+Requires the age binary (https://age-encryption.org).
+
+Examples:
+  # Snapshot everything in vlt.yaml for one recipient
+  vlt snapshot --recipients age1... --output secrets.age
+
+  # Snapshot only backend entries, for the recipients listed in a file
+  vlt snapshot --config vlt.yaml --only backend --recipients-file team.txt --output backend.age`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config file listing the secrets to snapshot (default: nearest vlt.yaml)",
+				Value: "vlt.yaml",
+			},
+			&cli.StringSliceFlag{
+				Name:  "recipients",
+				Usage: "age recipient public key (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "recipients-file",
+				Usage: "File of age recipients, one per line (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:     "output",
+				Usage:    "Snapshot file to write",
+				Required: true,
+			},
+			&cli.BoolFlag{
+				Name:  "armor",
+				Usage: "Write PEM-armored (ASCII) output",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name for encrypted secrets (overrides config)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Only include config entries tagged with one of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "skip",
+				Usage: "Skip config entries tagged with any of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		}, bulkFlags()...),
+		Action: func(ctx *cli.Context) error {
+			if len(ctx.StringSlice("recipients")) == 0 && len(ctx.StringSlice("recipients-file")) == 0 {
+				return usageError("--recipients or --recipients-file is required")
+			}
+
+			opts, err := bulkOptions(ctx)
+			if err != nil {
+				return err
+			}
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			configFile := findConfigFile(ctx)
+			if configFile == "" {
+				configFile = ctx.String("config")
+			}
+
+			return appInstance.Snapshot(&app.SnapshotOptions{
+				ConfigFile:     configFile,
+				KVMount:        mountFlag(ctx, "kv-mount"),
+				TransitMount:   mountFlag(ctx, "transit-mount"),
+				EncryptionKey:  ctx.String("encryption-key"),
+				Namespace:      ctx.String("namespace"),
+				OnlyTags:       ctx.StringSlice("only"),
+				SkipTags:       ctx.StringSlice("skip"),
+				Recipients:     ctx.StringSlice("recipients"),
+				RecipientFiles: ctx.StringSlice("recipients-file"),
+				Armor:          ctx.Bool("armor"),
+				OutputFile:     ctx.String("output"),
+			})
+		},
+	}
+}
+
+func getRestoreSnapshotCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "restore-snapshot",
+		Usage: "Import an age-encrypted snapshot into Vault",
+		Description: `Decrypts a snapshot written by "vlt snapshot" in memory and merges its keys
+into the recorded KV paths (and namespaces). Single-value secrets are replaced.
+With --encryption-key the values are transit-encrypted before they are stored.
+
+Examples:
+  # Restore into the same mounts and paths
+  vlt restore-snapshot --identity ~/.config/age/key.txt secrets.age
+
+  # Preview what would be written, then restore with transit encryption
+  vlt restore-snapshot --identity key.txt --dry-run secrets.age
+  vlt restore-snapshot --identity key.txt --encryption-key app-secrets secrets.age`,
+		ArgsUsage: "<snapshot-file>",
+		Flags: append([]cli.Flag{
+			&cli.StringSliceFlag{
+				Name:     "identity",
+				Aliases:  []string{"i"},
+				Usage:    "age identity file (repeatable)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name (optional - stores plaintext when not set)",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the paths and keys that would be written without writing them",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount to restore into (default: the mount recorded in the snapshot)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		}, bulkFlags()...),
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 1 {
+				return usageError("exactly one snapshot file is required")
+			}
+
+			opts, err := bulkOptions(ctx)
+			if err != nil {
+				return err
+			}
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.RestoreSnapshot(&app.RestoreOptions{
+				SnapshotFile:  ctx.Args().First(),
+				Identities:    ctx.StringSlice("identity"),
+				KVMount:       ctx.String("kv-mount"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Namespace:     ctx.String("namespace"),
+				DryRun:        ctx.Bool("dry-run"),
+			})
+		},
+	}
+}
+
+func getDriftCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "drift",
+		Usage: "Compare two secret paths or configs and report differing keys",
+		Description: `Reports keys present on only one side and keys whose values differ.
+Values are compared by SHA-256 hash and never printed.
+
+Compare two KV paths with --left/--right, or the environment variables two
+configs produce with --left-config/--right-config. Exits with code 8 when
+drift is found, so it can gate CI.
+
+Examples:
+  # Compare production and staging secrets
+  vlt drift --left secrets/prod/app --right secrets/staging/app
+
+  # Compare what two configs resolve to, as JSON
+  vlt drift --left-config prod.yaml --right-config staging.yaml --json`,
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "left",
+				Usage: "Left KV path",
+			},
+			&cli.StringFlag{
+				Name:  "right",
+				Usage: "Right KV path",
+			},
+			&cli.StringFlag{
+				Name:  "left-config",
+				Usage: "Left YAML config file",
+			},
+			&cli.StringFlag{
+				Name:  "right-config",
+				Usage: "Right YAML config file",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the report as JSON",
+			},
+			&cli.StringFlag{
+				Name:  "encryption-key",
+				Usage: "Transit encryption key name for encrypted secrets",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		}, bulkFlags()...),
+		Action: func(ctx *cli.Context) error {
+			paths := ctx.String("left") != "" || ctx.String("right") != ""
+			configs := ctx.String("left-config") != "" || ctx.String("right-config") != ""
+			if paths == configs {
+				return usageError("specify --left and --right, or --left-config and --right-config")
+			}
+
+			opts, err := bulkOptions(ctx)
+			if err != nil {
+				return err
+			}
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.Drift(&app.DriftOptions{
+				LeftPath:      ctx.String("left"),
+				RightPath:     ctx.String("right"),
+				LeftConfig:    ctx.String("left-config"),
+				RightConfig:   ctx.String("right-config"),
+				KVMount:       mountFlag(ctx, "kv-mount"),
+				TransitMount:  mountFlag(ctx, "transit-mount"),
+				EncryptionKey: ctx.String("encryption-key"),
+				Namespace:     ctx.String("namespace"),
+				OutputJSON:    ctx.Bool("json"),
+			})
+		},
+	}
+}
+
+func getLintValuesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lint-values",
+		Usage: "Report weak, placeholder, expired, or reused secret values",
+		Description: `Resolves the secrets of a config and reports hygiene problems with their
+values, without printing them:
+
+  error    the value is empty or a placeholder such as "changeme" or "password"
+  error    a certificate, JWT, or cloud credential in the value has expired
+  warning  it expires within --expiry-window (default 30 days)
+  warning  a private key in the value is not protected by a passphrase
+  warning  a password, secret, token, or key is shorter than --min-length
+  warning  the value is the same in a config given with --compare-config
+
+Expiry is read from PEM certificates, JWT exp claims, AWS credential JSON,
+GCP access token JSON, and Azure SAS tokens. PEM values are also recognized
+when base64-encoded (as put --from-file stores them). Exits 1 when any error is
+found, or any warning with --strict.
+
+Examples:
+  # Check the default config
+  vlt lint-values
+
+  # Also make sure production shares no values with staging and dev
+  vlt lint-values --config prod.yaml --compare-config staging.yaml --compare-config dev.yaml
+
+  # Require 20 characters, report as JSON
+  vlt lint-values --min-length 20 --json
+
+  # Fail on anything expiring in the next two weeks
+  vlt lint-values --expiry-window 336h --strict`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "YAML config file (default: nearest vlt.yaml)",
+				Value: "vlt.yaml",
+			},
+			&cli.StringSliceFlag{
+				Name:  "compare-config",
+				Usage: "Config of another environment whose values must differ (repeatable)",
+			},
+			&cli.IntFlag{
+				Name:  "min-length",
+				Usage: "Shortest accepted value for passwords, secrets, tokens, and keys",
+				Value: app.DefaultLintMinLength,
+			},
+			&cli.DurationFlag{
+				Name:  "expiry-window",
+				Usage: "Warn about certificates, tokens, and cloud credentials expiring within this window",
+				Value: app.DefaultExpiryWindow,
+			},
+			&cli.BoolFlag{
+				Name:  "strict",
+				Usage: "Treat warnings as errors",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output the findings as JSON",
+			},
+			&cli.StringFlag{
+				Name:    "encryption-key",
+				Aliases: []string{"key"},
+				Usage:   "Transit encryption key name (defaults to config or ENCRYPTION_KEY)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "only",
+				Usage: "Only check config entries tagged with one of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "skip",
+				Usage: "Skip config entries tagged with any of these tags (repeatable or comma-separated)",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace and config)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+			&cli.StringFlag{
+				Name:  "transit-mount",
+				Usage: "Transit mount path (default: TRANSIT_MOUNT, else the config's transit.mount, else transit)",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.Int("min-length") < 1 {
+				return usageError("--min-length must be at least 1")
+			}
+			if ctx.Duration("expiry-window") <= 0 {
+				return usageError("--expiry-window must be positive")
+			}
+			configFile := findConfigFile(ctx)
+			if configFile == "" {
+				configFile = ctx.String("config")
+			}
+
+			appInstance, err := app.New(globalOptions(ctx))
+			if err != nil {
+				return fmt.Errorf("failed to create app: %w", err)
+			}
+
+			return appInstance.LintValues(&app.LintOptions{
+				ConfigFile:     configFile,
+				CompareConfigs: ctx.StringSlice("compare-config"),
+				MinLength:      ctx.Int("min-length"),
+				ExpiryWindow:   ctx.Duration("expiry-window"),
+				Strict:         ctx.Bool("strict"),
+				EncryptionKey:  ctx.String("encryption-key"),
+				KVMount:        mountFlag(ctx, "kv-mount"),
+				TransitMount:   mountFlag(ctx, "transit-mount"),
+				Namespace:      ctx.String("namespace"),
+				OnlyTags:       ctx.StringSlice("only"),
+				SkipTags:       ctx.StringSlice("skip"),
+				OutputJSON:     ctx.Bool("json"),
+			})
+		},
+	}
+}
+
+// handlePlaintextJSON handles JSON output without encryption (no vault client needed)
+func handlePlaintextJSON(envFile string) error {
+	// Check if file exists
+	if _, err := os.Stat(envFile); envFile != "-" && os.IsNotExist(err) {
+		return usageError("env file not found: %s", envFile)
+	}
+
+	// Load as plaintext
+	data, err := utils.LoadEnvFileAsPlaintext(envFile)
+	if err != nil {
+		return fmt.Errorf("load env file: %w", err)
+	}
+
+	// Output as JSON
+	if err := utils.OutputJSON(data); err != nil {
+		return fmt.Errorf("output json: %w", err)
+	}
+
+	return nil
+}
+
+func getCompletionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "completion",
+		Usage: "Generate shell completion scripts",
+		Description: `Generate shell completion scripts for various shells.
+
+Supported shells: bash, zsh, fish, powershell
 
 To install completions:
 
-Bash:
-  vlt completion bash > /etc/bash_completion.d/vlt
-  # Or for user-only:
-  vlt completion bash > ~/.bash_completion.d/vlt
+Bash:
+  vlt completion bash > /etc/bash_completion.d/vlt
+  # Or for user-only:
+  vlt completion bash > ~/.bash_completion.d/vlt
+
+Zsh:
+  vlt completion zsh > /usr/local/share/zsh/site-functions/_vlt
+  # Or for user-only:
+  vlt completion zsh > ~/.zsh/completions/_vlt
+
+Fish:
+  vlt completion fish > ~/.config/fish/completions/vlt.fish
+
+PowerShell:
+  vlt completion powershell > vlt.ps1
+  # Then source it in your PowerShell profile`,
+		Aliases:   []string{"comp"},
+		ArgsUsage: "[shell]",
+		Action: func(ctx *cli.Context) error {
+			shell := ctx.Args().First()
+			if shell == "" {
+				return usageError("shell argument required. Supported: bash, zsh, fish, powershell")
+			}
+
+			// Generate completion script for the specified shell
+			switch shell {
+			case "bash":
+				return generateBashCompletion(ctx)
+			case "zsh":
+				return generateZshCompletion(ctx)
+			case "fish":
+				return generateFishCompletion(ctx)
+			case "powershell":
+				return generatePowerShellCompletion(ctx)
+			default:
+				return usageError("unsupported shell: %s. Supported: bash, zsh, fish, powershell", shell)
+			}
+		},
+	}
+}
+
+func getDocsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "docs",
+		Usage: "Generate man pages, Markdown, or a JSON spec of the commands and flags",
+		Description: `Generates documentation from the command and flag definitions, so it never
+falls out of date with the binary.
+
+Formats:
+  man        roff man page for section 1 (vlt.1)
+  markdown   Markdown reference of every command and flag
+  json       machine-readable spec of commands, aliases, flags, types,
+             defaults, and environment variables
+
+Examples:
+  vlt docs --output /usr/local/share/man/man1/vlt.1 man
+  vlt docs markdown > docs/cli.md
+  vlt docs json | jq '.commands[].name'`,
+		ArgsUsage: "man|markdown|json",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Write to this file instead of stdout",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() != 1 {
+				return usageError("format argument required. Supported: man, markdown, json")
+			}
+
+			var doc string
+			switch format := ctx.Args().First(); format {
+			case "man":
+				man, err := ctx.App.ToManWithSection(1)
+				if err != nil {
+					return fmt.Errorf("generate man page: %w", err)
+				}
+				doc = man
+			case "markdown", "md":
+				markdown, err := ctx.App.ToMarkdown()
+				if err != nil {
+					return fmt.Errorf("generate markdown: %w", err)
+				}
+				doc = markdown
+			case "json":
+				data, err := json.MarshalIndent(buildCLISpec(ctx.App), "", "  ")
+				if err != nil {
+					return fmt.Errorf("generate json spec: %w", err)
+				}
+				doc = string(data) + "\n"
+			default:
+				return usageError("unsupported format: %s. Supported: man, markdown, json", format)
+			}
+
+			if output := ctx.String("output"); output != "" {
+				if err := os.WriteFile(output, []byte(doc), 0644); err != nil {
+					return fmt.Errorf("write %s: %w", output, err)
+				}
+				return nil
+			}
+			_, err := fmt.Print(doc)
+			return err
+		},
+	}
+}
+
+func getVersionCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "version",
+		Usage: "Show the version, commit, build date, and Go and Vault API versions",
+		Description: `Prints the build metadata of this binary. Release builds embed the version,
+commit, and build date with -ldflags; other builds report the commit Go recorded.
+Vault requests carry the same version and commit in their User-Agent header.
+
+Examples:
+  vlt version
+  vlt version --json`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Output as JSON",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("version takes no arguments")
+			}
+			return app.Version(ctx.Bool("json"))
+		},
+	}
+}
+
+func getSelfUpdateCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "self-update",
+		Usage: "Update vlt to the latest release",
+		Description: `Checks the release feed and, when a newer version exists, downloads the binary
+for this platform, verifies it against the release's Ed25519-signed
+checksums.txt, and atomically replaces the running binary.
+
+With --check nothing is downloaded: the command exits 0 when up to date and 8
+when a newer release is available, so CI can fail on outdated installs.
+
+Examples:
+  vlt self-update
+  vlt self-update --check
+  vlt self-update --feed https://releases.example.com/vlt/latest.json --public-key <base64>`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "check",
+				Usage: "Only check for a newer release (exit 8 when outdated)",
+			},
+			&cli.StringFlag{
+				Name:  "feed",
+				Usage: "URL of the latest release in GitHub release JSON format",
+				Value: app.DefaultReleaseFeed,
+			},
+			&cli.StringFlag{
+				Name:  "public-key",
+				Usage: "Base64 Ed25519 key the release checksums are signed with, for builds without one built in",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() > 0 {
+				return usageError("self-update takes no arguments")
+			}
 
-Zsh:
-  vlt completion zsh > /usr/local/share/zsh/site-functions/_vlt
-  # Or for user-only:
-  vlt completion zsh > ~/.zsh/completions/_vlt
+			return app.SelfUpdate(&app.SelfUpdateOptions{
+				CurrentVersion: ctx.App.Version,
+				FeedURL:        ctx.String("feed"),
+				PublicKey:      ctx.String("public-key"),
+				Check:          ctx.Bool("check"),
+			})
+		},
+	}
+}
 
-Fish:
-  vlt completion fish > ~/.config/fish/completions/vlt.fish
+// completeTimeout bounds each Vault request made while completing, so a slow
+// or unreachable server does not hang the shell
+const completeTimeout = "2"
 
-PowerShell:
-  vlt completion powershell > vlt.ps1
-  # Then source it in your PowerShell profile`,
-		Aliases:   []string{"comp"},
-		ArgsUsage: "[shell]",
+// getCompleteCommand returns the hidden command the completion scripts call
+// to complete --path and --key from Vault
+func getCompleteCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "__complete",
+		Usage:     "Print completion candidates for --path or --key",
+		ArgsUsage: "path|key [word]",
+		Hidden:    true,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "path",
+				Usage: "Secret whose keys are completed",
+			},
+			&cli.StringFlag{
+				Name:  "namespace",
+				Usage: "Vault namespace for this command (overrides --vault-namespace)",
+			},
+			&cli.StringFlag{
+				Name:  "kv-mount",
+				Usage: "KV v2 mount path (default: KV_MOUNT, else the config's kv.mount, else kv)",
+			},
+		},
+		// Completion must never print errors into the shell, so failures
+		// only mean there are no candidates
 		Action: func(ctx *cli.Context) error {
-			shell := ctx.Args().First()
-			if shell == "" {
-				return fmt.Errorf("shell argument required. Supported: bash, zsh, fish, powershell")
+			opts := globalOptions(ctx)
+			opts.Timeout = completeTimeout
+			opts.ConnectTimeout = completeTimeout
+
+			appInstance, err := app.New(opts)
+			if err != nil {
+				return nil
 			}
 
-			// Generate completion script for the specified shell
-			switch shell {
-			case "bash":
-				return generateBashCompletion(ctx)
-			case "zsh":
-				return generateZshCompletion(ctx)
-			case "fish":
-				return generateFishCompletion(ctx)
-			case "powershell":
-				return generatePowerShellCompletion(ctx)
-			default:
-				return fmt.Errorf("unsupported shell: %s. Supported: bash, zsh, fish, powershell", shell)
+			candidates, err := appInstance.Complete(&app.CompleteOptions{
+				Kind:      ctx.Args().First(),
+				Word:      ctx.Args().Get(1),
+				KVMount:   mountFlag(ctx, "kv-mount"),
+				KVPath:    ctx.String("path"),
+				Namespace: ctx.String("namespace"),
+			})
+			if err != nil {
+				return nil
 			}
+			for _, candidate := range candidates {
+				fmt.Println(candidate)
+			}
+			return nil
 		},
 	}
 }
@@ -512,7 +3071,7 @@ _vlt_completion() {
     
     # Complete commands
     if [[ ${COMP_CWORD} -eq 1 ]]; then
-        opts="put get sync run json completion help"
+        opts="put get sync env verify plan apply run json tree search browse import export snapshot restore-snapshot drift lint-values login logout whoami check dev guard clean shred random completion docs version self-update help"
         COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
         return 0
     fi
@@ -520,19 +3079,99 @@ _vlt_completion() {
     # Complete flags based on command
     case "${COMP_WORDS[1]}" in
         put|p)
-            opts="--path --encryption-key --key --value --from-env --from-file --kv-mount --transit-mount --help"
+            opts="--path --encryption-key --key --value --value-file --from-env --from-file --from-stdin --from-stdin-json --from-stdin-env --format --from-k8s-secret --k8s-context --from-sops --encrypt-keys --key-version --dry-run --override-policy --shard --namespace --kv-mount --transit-mount --help"
             ;;
         get|g)
-            opts="--path --config --encryption-key --key --json --kv-mount --transit-mount --help"
+            opts="--path --config --env-file --identity --expired --encryption-key --key --field --json --format --reveal --copy --clipboard-timeout --wrap-ttl --out-file --metadata --raw --strict --only --skip --namespace --kv-mount --transit-mount --help"
+            ;;
+        sync|s|env|verify)
+            opts="--config --output --format --tfvars-map --systemd-dropin --manifest --dry-run --mode --fix-gitignore --insecure-output --encrypt-output --ttl --expiry-window --vars --paths --incremental --identity --encryption-key --key --strict --only --skip --namespace --kv-mount --transit-mount --help"
             ;;
-        sync|s)
-            opts="--config --output --help"
+        plan)
+            opts="--config --env-file --out --encryption-key --key --strict --only --skip --namespace --kv-mount --transit-mount --help"
+            ;;
+        apply)
+            opts="--env-file --help"
             ;;
         run|r)
-            opts="--config --encryption-key --inject --env-file --kv-mount --transit-mount --dry-run --preserve-env --help"
+            opts="--config --encryption-key --inject --inject-file --env-file --identity --expired --cleanup --strict --only --skip --namespace --kv-mount --transit-mount --dry-run --preserve-env --isolate --allow-env --redact-vault-env --pass-vault-env --mask-output --shell --timeout --kill-after --procfile --wait-all --watch --watch-interval --metrics-addr --help"
             ;;
         json|j)
-            opts="--encryption-key --transit-mount --help"
+            opts="--encryption-key --transit-mount --decrypt --output-format --format --key-version --help"
+            ;;
+        tree)
+            opts="--path --kv-mount --rps --burst --max-requests --help"
+            ;;
+        search|grep)
+            opts="--path --key-pattern --value-pattern --allow-value-search --encryption-key --kv-mount --transit-mount --rps --burst --max-requests --help"
+            ;;
+        browse)
+            opts="--path --encryption-key --namespace --kv-mount --transit-mount --help"
+            ;;
+        import|export)
+            if [[ ${COMP_CWORD} -eq 2 ]]; then
+                if [[ "${COMP_WORDS[1]}" == "export" ]]; then
+                    COMPREPLY=( $(compgen -W "aws-sm aws-ssm --format --path --output --sops-age --sops-kms --sops-pgp --encryption-key --namespace --kv-mount --transit-mount --help" -- ${cur}) )
+                else
+                    COMPREPLY=( $(compgen -W "aws-sm aws-ssm" -- ${cur}) )
+                fi
+                return 0
+            fi
+            if [[ "${COMP_WORDS[2]}" == aws-* ]]; then
+                opts="--prefix --path --region --encryption-key --namespace --kv-mount --transit-mount --help"
+                if [[ "${COMP_WORDS[1]}" == "import" ]]; then
+                    opts="${opts} --dry-run --override-policy"
+                fi
+            else
+                opts="--format --path --output --sops-age --sops-kms --sops-pgp --encryption-key --namespace --kv-mount --transit-mount --help"
+            fi
+            ;;
+        snapshot)
+            opts="--config --recipients --recipients-file --output --armor --encryption-key --only --skip --namespace --kv-mount --transit-mount --rps --burst --max-requests --help"
+            ;;
+        restore-snapshot)
+            opts="--identity --encryption-key --dry-run --namespace --kv-mount --transit-mount --rps --burst --max-requests --help"
+            ;;
+        login)
+            opts="--method --auth-path --username --role --store --help"
+            ;;
+        logout)
+            opts="--no-revoke --help"
+            ;;
+        whoami)
+            opts="--json --help"
+            ;;
+        check)
+            opts="--config --expiry-window --strict --encryption-key --kv-mount --transit-mount --help"
+            ;;
+        dev)
+            opts="--config --listen --token --vault-bin --embedded --encryption-key --help"
+            ;;
+        guard)
+            if [[ "$cur" != -* ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+                return 0
+            fi
+            opts="--config --no-vault --encryption-key --key --namespace --kv-mount --transit-mount --help"
+            ;;
+        clean)
+            opts="--all --dry-run --help"
+            ;;
+        shred)
+            if [[ "$cur" != -* ]]; then
+                COMPREPLY=( $(compgen -f -- ${cur}) )
+                return 0
+            fi
+            opts="--force --help"
+            ;;
+        random)
+            opts="--bytes --format --store --path --key --encryption-key --namespace --kv-mount --transit-mount --help"
+            ;;
+        drift)
+            opts="--left --right --left-config --right-config --json --encryption-key --namespace --kv-mount --transit-mount --rps --burst --max-requests --help"
+            ;;
+        lint-values)
+            opts="--config --compare-config --min-length --expiry-window --strict --json --encryption-key --key --only --skip --namespace --kv-mount --transit-mount --help"
             ;;
         completion|comp)
             if [[ ${COMP_CWORD} -eq 2 ]]; then
@@ -540,13 +3179,45 @@ _vlt_completion() {
                 return 0
             fi
             ;;
+        version)
+            opts="--json --help"
+            ;;
+        self-update)
+            opts="--check --feed --public-key --help"
+            ;;
+        docs)
+            if [[ ${COMP_CWORD} -eq 2 ]]; then
+                COMPREPLY=( $(compgen -W "man markdown json" -- ${cur}) )
+                return 0
+            fi
+            opts="--output --help"
+            ;;
         *)
             opts="--help"
             ;;
     esac
     
+    # Complete Vault paths and keys from the server
+    if [[ "$prev" == "--path" || "$prev" == "--key" || "$prev" == "--left" || "$prev" == "--right" ]]; then
+        local i kind=path args=()
+        [[ "$prev" == "--key" ]] && kind=key
+        for ((i=2; i<COMP_CWORD-1; i++)); do
+            case "${COMP_WORDS[i]}" in
+                --kv-mount|--namespace)
+                    args+=("${COMP_WORDS[i]}" "${COMP_WORDS[i+1]}")
+                    ;;
+                --path)
+                    [[ "$kind" == key ]] && args+=(--path "${COMP_WORDS[i+1]}")
+                    ;;
+            esac
+        done
+        COMPREPLY=( $(vlt __complete "${args[@]}" "$kind" "$cur" 2>/dev/null) )
+        [[ "$kind" == path ]] && compopt -o nospace 2>/dev/null
+        return 0
+    fi
+    
     # Complete file paths for certain flags
-    if [[ "$prev" == "--from-env" || "$prev" == "--from-file" || "$prev" == "--config" ]]; then
+    if [[ "$prev" == "--from-env" || "$prev" == "--from-file" || "$prev" == "--from-sops" || "$prev" == "--config" || "$prev" == "--output" || "$prev" == "--recipients-file" || "$prev" == "--identity" || "$prev" == "--left-config" || "$prev" == "--right-config" || "$prev" == "--manifest" || "$prev" == "--env-file" || "$prev" == "--out" || "$prev" == "--value-file" || "$prev" == "--inject-file" ]]; then
         COMPREPLY=( $(compgen -f -- ${cur}) )
         return 0
     fi
@@ -575,69 +3246,432 @@ _vlt() {
             case $words[1] in
                 put|p)
                     _arguments \
-                        '--path=[KV path to store secret(s)]:path:' \
+                        '--path=[KV path to store secret(s)]:path:_vlt_vault_paths' \
                         '--encryption-key=[Transit encryption key name]:key:' \
-                        '--key=[Specific key to update]:key:' \
-                        '--value=[Secret value]:value:' \
+                        '--key=[Specific key to update]:key:_vlt_vault_keys' \
+                        '--value=[Secret value, or - for stdin]:value:' \
+                        '--value-file=[Read the secret value from a file]:file:_files' \
                         '--from-env=[Load from .env file]:file:_files' \
                         '--from-file=[Load file as base64]:file:_files' \
+                        '--from-stdin[Load key-value pairs from stdin]' \
+                        '--from-stdin-json[Load a JSON object from stdin]' \
+                        '--from-stdin-env[Load dotenv content from stdin]' \
+                        '--format=[Payload format for --from-stdin]:format:(auto json env)' \
+                        '--from-k8s-secret=[Import a Kubernetes Secret (namespace/name)]:secret:' \
+                        '--k8s-context=[kubeconfig context]:context:' \
+                        '--from-sops=[Import a SOPS-encrypted file]:file:_files' \
+                        '*--encrypt-keys=[Encrypt only these keys]:key:' \
+                        '--key-version=[Encrypt with this Transit key version]:version:' \
+                        '--dry-run[Show keys that would change without writing]' \
+                        '--override-policy=[Write values violating the value policy, with this reason]:reason:' \
+                        '--shard[Split the keys across PATH/part1..N]' \
+                        '--namespace=[Vault namespace]:namespace:' \
                         '--kv-mount=[KV v2 mount path]:mount:' \
                         '--transit-mount=[Transit mount path]:mount:' \
                         '--help[Show help]'
                     ;;
                 get|g)
                     _arguments \
-                        '--path=[KV path to retrieve secret]:path:' \
+                        '*--path=[KV path to retrieve secret]:path:_vlt_vault_paths' \
                         '--config=[YAML config file]:file:_files' \
+                        '--env-file=[Read a (possibly encrypted) .env file]:file:_files' \
+                        '*--identity=[age identity file]:file:_files' \
+                        '--expired=[When the env file has expired]:policy:(refuse warn resync)' \
                         '--encryption-key=[Transit encryption key name]:key:' \
-                        '--key=[Specific key to retrieve]:key:' \
+                        '--key=[Specific key to retrieve]:key:_vlt_vault_keys' \
+                        '--field=[Specific key to retrieve (same as --key)]:key:_vlt_vault_keys' \
                         '--json[Output as JSON format]' \
+                        '--format=[Output format]:format:(env table json powershell)' \
+                        '--reveal[Print plaintext values on a terminal]' \
+                        '--copy[Copy value to the clipboard]' \
+                        '--clipboard-timeout=[Clear clipboard after duration]:duration:' \
+                        '--wrap-ttl=[Print a single-use wrapping token instead of values]:duration:' \
+                        '--out-file=[Write a file stored with put --from-file here]:file:_files' \
+                        '--metadata[Print the name, size, SHA-256, and content type of a stored file]' \
+                        '--raw[Print a binary stored file even to a terminal]' \
+                        '--strict[Fail on warnings]' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
                         '--kv-mount=[KV v2 mount path]:mount:' \
                         '--transit-mount=[Transit mount path]:mount:' \
                         '--help[Show help]'
                     ;;
-                sync|s)
+                sync|s|env|verify)
                     _arguments \
                         '--config=[YAML config file]:file:_files' \
                         '--output=[Output .env file]:file:_files' \
+                        '--format=[Output format]:format:(env tfvars tfvars-json properties toml systemd docker-args compose powershell)' \
+                        '--tfvars-map=[Nest tfvars values in a map variable]:name:' \
+                        '--systemd-dropin=[Write a systemd drop-in loading the output]:file:_files' \
+                        '--manifest=[SHA-256 manifest of generated files]:file:_files' \
+                        '--dry-run[Show a masked diff without writing (sync)]' \
+                        '--mode=[Permissions of the written files (sync)]:mode:(0600 0640 0400)' \
+                        '--fix-gitignore[Add written files to .gitignore (sync)]' \
+                        '--insecure-output[Skip output directory checks (sync)]' \
+                        '--encrypt-output=[Encrypt written files (age:RECIPIENT or transit:KEY)]:spec:' \
+                        '--ttl=[Expire the written files after this long (sync)]:duration:' \
+                        '--expiry-window=[Warn about credentials expiring within this window (sync)]:duration:' \
+                        '*--vars=[Refresh only the entries setting these variables (sync)]:var:' \
+                        '*--paths=[Refresh only the entries reading these paths (sync)]:path:_vlt_vault_paths' \
+                        '--incremental[Skip decrypting paths unchanged since the last sync (sync)]' \
+                        '*--identity=[age identity file (verify)]:file:_files' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--strict[Fail on warnings]' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                plan)
+                    _arguments \
+                        '--config=[YAML config file]:file:_files' \
+                        '--env-file=[Env file with the desired values]:file:_files' \
+                        '--out=[Save the plan to this file]:file:_files' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--strict[Fail on entries that cannot be planned]' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
                         '--help[Show help]'
                     ;;
+                apply)
+                    _arguments \
+                        '--env-file=[Env file with the planned values]:file:_files' \
+                        '--help[Show help]' \
+                        '1: :_files'
+                    ;;
                 run|r)
                     _arguments \
                         '--config=[YAML config file]:file:_files' \
                         '--encryption-key=[Transit encryption key name]:key:' \
                         '--inject=[Inject specific secret]:inject:' \
+                        '--inject-file=[File of ENV_VAR=vault_path specs]:file:_files' \
                         '--env-file=[Additional .env file]:file:_files' \
+                        '*--identity=[age identity file for an encrypted env file]:file:_files' \
+                        '--expired=[When the env file has expired]:policy:(refuse warn resync)' \
+                        '--cleanup[Shred the env file after the command exits]' \
+                        '--strict[Fail on warnings]' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
                         '--kv-mount=[KV v2 mount path]:mount:' \
                         '--transit-mount=[Transit mount path]:mount:' \
                         '--dry-run[Show env vars without running]' \
                         '--preserve-env[Preserve current environment]' \
+                        '--isolate[Start from an empty environment]' \
+                        '*--allow-env[Host variables to keep with --isolate]:variable:' \
+                        '--redact-vault-env[Remove VAULT_* variables from the command environment]' \
+                        '--pass-vault-env[Pass VAULT_* variables to the command]' \
+                        '--mask-output[Mask secret values in command output]' \
+                        '--shell[Run the command string through $SHELL -c]' \
+                        '--timeout=[Terminate the command after duration]:duration:' \
+                        '--kill-after=[Kill the command this long after the timeout]:duration:' \
+                        '--procfile=[Run the processes of a Procfile]:file:_files' \
+                        '--wait-all[Wait for every Procfile process to exit]' \
+                        '--watch[Restart the command when secrets change]' \
+                        '--watch-interval=[Polling interval without Vault events]:duration:' \
+                        '--metrics-addr=[Serve Prometheus metrics on address]:address:' \
                         '--help[Show help]'
                     ;;
                 json|j)
                     _arguments \
                         '--encryption-key=[Transit encryption key name]:key:' \
                         '--transit-mount=[Transit mount path]:mount:' \
+                        '--decrypt[Decrypt a JSON document]' \
+                        '--output-format=[Output format with --decrypt]:format:(json env)' \
+                        '--format=[Output format with --decrypt (same as --output-format)]:format:(json env)' \
+                        '--key-version=[Encrypt with this Transit key version]:version:' \
+                        '--help[Show help]' \
+                        '1: :_files'
+                    ;;
+                tree)
+                    _arguments \
+                        '--path=[KV path to start from]:path:_vlt_vault_paths' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--rps=[Maximum Vault requests per second]:rps:' \
+                        '--burst=[Requests allowed above the rate]:burst:' \
+                        '--max-requests=[Vault request budget]:count:' \
+                        '--help[Show help]'
+                    ;;
+                search|grep)
+                    _arguments \
+                        '--path=[KV path to search under]:path:_vlt_vault_paths' \
+                        '--key-pattern=[Regex matched against keys]:pattern:' \
+                        '--value-pattern=[Regex matched against values]:pattern:' \
+                        '--allow-value-search[Allow reading values]' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--rps=[Maximum Vault requests per second]:rps:' \
+                        '--burst=[Requests allowed above the rate]:burst:' \
+                        '--max-requests=[Vault request budget]:count:' \
+                        '--help[Show help]'
+                    ;;
+                browse)
+                    _arguments \
+                        '--path=[Directory or secret to open]:path:_vlt_vault_paths' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount to open]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                import)
+                    _arguments \
+                        '1: :(aws-sm aws-ssm)' \
+                        '--prefix=[AWS secret name prefix or parameter path]:prefix:' \
+                        '--path=[KV path]:path:_vlt_vault_paths' \
+                        '--region=[AWS region]:region:' \
+                        '--dry-run[Show keys that would change without writing]' \
+                        '--override-policy=[Write values violating the value policy, with this reason]:reason:' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                export)
+                    _arguments \
+                        '1: :(aws-sm aws-ssm)' \
+                        '--prefix=[AWS secret name prefix or parameter path]:prefix:' \
+                        '--path=[KV path]:path:_vlt_vault_paths' \
+                        '--region=[AWS region]:region:' \
+                        '--format=[File format to export to]:format:(sops)' \
+                        '--output=[Output file]:file:_files' \
+                        '*--sops-age=[age recipient]:recipient:' \
+                        '*--sops-kms=[AWS KMS key ARN]:arn:' \
+                        '*--sops-pgp=[PGP fingerprint]:fingerprint:' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                snapshot)
+                    _arguments \
+                        '--config=[YAML config file]:file:_files' \
+                        '*--recipients=[age recipient]:recipient:' \
+                        '*--recipients-file=[File of age recipients]:file:_files' \
+                        '--output=[Snapshot file]:file:_files' \
+                        '--armor[Write PEM-armored output]' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--rps=[Maximum Vault requests per second]:rps:' \
+                        '--burst=[Requests allowed above the rate]:burst:' \
+                        '--max-requests=[Vault request budget]:count:' \
+                        '--help[Show help]'
+                    ;;
+                restore-snapshot)
+                    _arguments \
+                        '*--identity=[age identity file]:file:_files' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--dry-run[List paths and keys without writing]' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount to restore into]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--rps=[Maximum Vault requests per second]:rps:' \
+                        '--burst=[Requests allowed above the rate]:burst:' \
+                        '--max-requests=[Vault request budget]:count:' \
                         '--help[Show help]' \
                         '1: :_files'
                     ;;
+                login)
+                    _arguments \
+                        '--method=[Auth method]:method:(token approle github kubernetes ldap userpass oidc)' \
+                        '--auth-path=[Mount path of the auth method]:path:' \
+                        '--username=[User for ldap and userpass]:username:' \
+                        '--role=[Role for oidc and kubernetes]:role:' \
+                        '--store=[Where to keep the token]:store:(keychain file none)' \
+                        '--help[Show help]'
+                    ;;
+                logout)
+                    _arguments \
+                        '--no-revoke[Remove the stored token without revoking it]' \
+                        '--help[Show help]'
+                    ;;
+                whoami)
+                    _arguments \
+                        '--json[Output as JSON]' \
+                        '--help[Show help]'
+                    ;;
+                check)
+                    _arguments \
+                        '--config=[YAML config file whose entries to check]:file:_files' \
+                        '--expiry-window=[Warn about credentials expiring within this window]:duration:' \
+                        '--strict[Fail on credentials expiring within the window]' \
+                        '--encryption-key=[Transit encryption key to check]:key:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                dev)
+                    _arguments \
+                        '--config=[YAML config file whose secrets are seeded]:file:_files' \
+                        '--listen=[Address the server listens on]:address:' \
+                        '--token=[Root token of the server]:token:' \
+                        '--vault-bin=[vault binary to run]:file:_files' \
+                        '--embedded[Use the built-in server]' \
+                        '--encryption-key=[Transit key to create]:key:' \
+                        '--help[Show help]'
+                    ;;
+                guard)
+                    _arguments \
+                        '--config=[YAML config whose secrets to look for]:file:_files' \
+                        '--no-vault[Only check tokens and generated files]' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]' \
+                        '*: :_files'
+                    ;;
+                clean)
+                    _arguments \
+                        '--all[Remove every recorded file]' \
+                        '--dry-run[List files without removing them]' \
+                        '--help[Show help]'
+                    ;;
+                shred)
+                    _arguments \
+                        '--force[Also shred files sync did not write]' \
+                        '--help[Show help]' \
+                        '*: :_files'
+                    ;;
+                random)
+                    _arguments \
+                        '--bytes=[Number of random bytes]:bytes:' \
+                        '--format=[Output encoding]:format:(base64 hex)' \
+                        '--store[Store the value in Vault instead of printing it]' \
+                        '--path=[Secret path for --store]:path:_vlt_vault_paths' \
+                        '--key=[Key to set for --store]:key:' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
+                drift)
+                    _arguments \
+                        '--left=[Left KV path]:path:_vlt_vault_paths' \
+                        '--right=[Right KV path]:path:_vlt_vault_paths' \
+                        '--left-config=[Left YAML config file]:file:_files' \
+                        '--right-config=[Right YAML config file]:file:_files' \
+                        '--json[Output the report as JSON]' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--rps=[Maximum Vault requests per second]:rps:' \
+                        '--burst=[Requests allowed above the rate]:burst:' \
+                        '--max-requests=[Vault request budget]:count:' \
+                        '--help[Show help]'
+                    ;;
+                lint-values)
+                    _arguments \
+                        '--config=[YAML config file]:file:_files' \
+                        '*--compare-config=[Config of another environment]:file:_files' \
+                        '--min-length=[Shortest accepted credential value]:length:' \
+                        '--expiry-window=[Warn about credentials expiring within this window]:duration:' \
+                        '--strict[Treat warnings as errors]' \
+                        '--json[Output the findings as JSON]' \
+                        '--encryption-key=[Transit encryption key name]:key:' \
+                        '*--only[Only entries with tag]:tag:' \
+                        '*--skip[Skip entries with tag]:tag:' \
+                        '--namespace=[Vault namespace]:namespace:' \
+                        '--kv-mount=[KV v2 mount path]:mount:' \
+                        '--transit-mount=[Transit mount path]:mount:' \
+                        '--help[Show help]'
+                    ;;
                 completion|comp)
                     _arguments '1: :(bash zsh fish powershell)'
                     ;;
+                version)
+                    _arguments \
+                        '--json[Output as JSON]' \
+                        '--help[Show help]'
+                    ;;
+                self-update)
+                    _arguments \
+                        '--check[Only check for a newer release]' \
+                        '--feed=[URL of the latest release]:url:' \
+                        '--public-key=[Release signing key]:key:' \
+                        '--help[Show help]'
+                    ;;
+                docs)
+                    _arguments \
+                        '1: :(man markdown json)' \
+                        '--output=[Write to this file instead of stdout]:file:_files' \
+                        '--help[Show help]'
+                    ;;
             esac
             ;;
     esac
 }
 
+# Vault paths and keys, listed by the server
+_vlt_vault_args() {
+    [[ -n ${opt_args[--kv-mount]} ]] && reply+=(--kv-mount ${opt_args[--kv-mount]})
+    [[ -n ${opt_args[--namespace]} ]] && reply+=(--namespace ${opt_args[--namespace]})
+}
+
+_vlt_vault_paths() {
+    local -a reply candidates
+    _vlt_vault_args
+    candidates=(${(f)"$(vlt __complete $reply path $PREFIX 2>/dev/null)"})
+    compadd -S '' -- $candidates
+}
+
+_vlt_vault_keys() {
+    local -a reply candidates
+    _vlt_vault_args
+    [[ -n ${opt_args[--path]} ]] && reply+=(--path ${opt_args[--path]})
+    candidates=(${(f)"$(vlt __complete $reply key $PREFIX 2>/dev/null)"})
+    compadd -- $candidates
+}
+
 _vlt_commands() {
     local -a commands
     commands=(
         'put:Store/update secrets in Vault'
         'get:Retrieve and decrypt secrets from Vault'
         'sync:Sync secrets from YAML config to .env file'
+        'env:Sync secrets from YAML config to .env file (legacy)'
+        'verify:Check that generated files still match what sync would produce'
+        'plan:Plan the Vault changes that would make secrets match a .env file'
+        'apply:Apply a plan file written by vlt plan'
         'run:Run command with secrets injected as environment variables'
-        'json:Encrypt .env file content and output as JSON'
+        'json:Encrypt .env file content and output as JSON, or decrypt it back'
+        'tree:Show the KV path hierarchy'
+        'search:Search secret paths for matching keys or values'
+        'browse:Browse secrets in an interactive terminal UI'
+        'import:Import secrets from AWS Secrets Manager or SSM Parameter Store'
+        'export:Export secrets to AWS Secrets Manager, SSM Parameter Store, or a SOPS file'
+        'snapshot:Write secrets to an age-encrypted snapshot'
+        'restore-snapshot:Import an age-encrypted snapshot into Vault'
+        'drift:Compare two secret paths or configs'
+        'lint-values:Report weak, placeholder, expired, or reused secret values'
+        'login:Authenticate to Vault and store the token'
+        'logout:Revoke the stored token and remove it'
+        'whoami:Show the identity, policies, and TTL of the current credentials'
+        'check:Preflight check of the Vault server, token, namespace, and mounts'
+        'dev:Start a local Vault seeded with placeholders for every configured secret'
+        'guard:Block commits of secrets, Vault tokens, and generated .env files'
+        'clean:Securely delete expired files written by sync --ttl'
+        'shred:Overwrite and remove files written by sync'
+        'random:Generate random bytes with Vault, optionally storing them as a secret'
         'completion:Generate shell completion scripts'
+        'docs:Generate man pages, Markdown, or a JSON spec'
+        'version:Show the version and build metadata'
+        'self-update:Update vlt to the latest release'
         'help:Show help'
     )
     _describe 'commands' commands
@@ -651,59 +3685,333 @@ _vlt
 func generateFishCompletion(ctx *cli.Context) error {
 	_, err := fmt.Print(`# vlt fish completion
 
+# Vault paths and keys, listed by the server
+function __vlt_complete
+    set -l tokens (commandline -opc)
+    set -l args
+    for i in (seq (count $tokens))
+        switch $tokens[$i]
+            case --kv-mount --namespace
+                set -a args $tokens[$i] $tokens[(math $i + 1)]
+            case --path
+                test "$argv[1]" = key; and set -a args --path $tokens[(math $i + 1)]
+        end
+    end
+    vlt __complete $args $argv[1] (commandline -ct | string replace -r '^--[a-z-]+=' '') 2>/dev/null
+end
+
 # Commands
 complete -c vlt -f -n '__fish_use_subcommand' -a 'put' -d 'Store/update secrets in Vault'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'get' -d 'Retrieve and decrypt secrets from Vault'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'sync' -d 'Sync secrets from YAML config to .env file'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'verify' -d 'Check that generated files still match what sync would produce'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'plan' -d 'Plan the Vault changes that would make secrets match a .env file'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'apply' -d 'Apply a plan file written by vlt plan'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'run' -d 'Run command with secrets injected as environment variables'
-complete -c vlt -f -n '__fish_use_subcommand' -a 'json' -d 'Encrypt .env file content and output as JSON'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'json' -d 'Encrypt .env file content and output as JSON, or decrypt it back'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'tree' -d 'Show the KV path hierarchy'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'search' -d 'Search secret paths for matching keys or values'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'browse' -d 'Browse secrets in an interactive terminal UI'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'import' -d 'Import secrets from AWS Secrets Manager or SSM Parameter Store'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'export' -d 'Export secrets to AWS Secrets Manager, SSM Parameter Store, or a SOPS file'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'snapshot' -d 'Write secrets to an age-encrypted snapshot'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'restore-snapshot' -d 'Import an age-encrypted snapshot into Vault'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'drift' -d 'Compare two secret paths or configs'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'lint-values' -d 'Report weak, placeholder, expired, or reused secret values'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'login' -d 'Authenticate to Vault and store the token'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'logout' -d 'Revoke the stored token and remove it'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'whoami' -d 'Show the identity, policies, and TTL of the current credentials'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'check' -d 'Preflight check of the Vault server, token, namespace, and mounts'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'dev' -d 'Start a local Vault seeded with placeholders for every configured secret'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'guard' -d 'Block commits of secrets, Vault tokens, and generated .env files'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'clean' -d 'Securely delete expired files written by sync --ttl'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'shred' -d 'Overwrite and remove files written by sync'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'random' -d 'Generate random bytes with Vault, optionally storing them as a secret'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'completion' -d 'Generate shell completion scripts'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'docs' -d 'Generate man pages, Markdown, or a JSON spec'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'version' -d 'Show the version and build metadata'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'self-update' -d 'Update vlt to the latest release'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'help' -d 'Show help'
 
 # Aliases
 complete -c vlt -f -n '__fish_use_subcommand' -a 'p' -d 'Store/update secrets in Vault (alias)'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'g' -d 'Retrieve and decrypt secrets from Vault (alias)'
 complete -c vlt -f -n '__fish_use_subcommand' -a 's' -d 'Sync secrets from YAML config to .env file (alias)'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'env' -d 'Sync secrets from YAML config to .env file (legacy alias)'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'r' -d 'Run command with secrets injected as environment variables (alias)'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'j' -d 'Encrypt .env file content and output as JSON (alias)'
+complete -c vlt -f -n '__fish_use_subcommand' -a 'grep' -d 'Search secret paths for matching keys or values (alias)'
 complete -c vlt -f -n '__fish_use_subcommand' -a 'comp' -d 'Generate shell completion scripts (alias)'
 
 # Put command options
-complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'path' -d 'KV path to store secret(s)'
+complete -c vlt -x -n '__fish_seen_subcommand_from put p' -l 'path' -a '(__vlt_complete path)' -d 'KV path to store secret(s)'
 complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'encryption-key' -d 'Transit encryption key name'
-complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'key' -d 'Specific key to update in multi-value secret'
-complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'value' -d 'Secret value'
+complete -c vlt -x -n '__fish_seen_subcommand_from put p' -l 'key' -a '(__vlt_complete key)' -d 'Specific key to update in multi-value secret'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'value' -d 'Secret value, or - for stdin'
+complete -c vlt -n '__fish_seen_subcommand_from put p' -l 'value-file' -d 'Read the secret value from a file'
 complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-env' -d 'Load multiple key-value pairs from .env file'
 complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-file' -d 'Load file content as base64 encoded value'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-stdin' -d 'Load key-value pairs from stdin'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-stdin-json' -d 'Load a JSON object from stdin'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-stdin-env' -d 'Load dotenv content from stdin'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'format' -d 'Payload format for --from-stdin' -a 'auto json env'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'from-k8s-secret' -d 'Import a Kubernetes Secret (namespace/name)'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'k8s-context' -d 'kubeconfig context'
+complete -c vlt -n '__fish_seen_subcommand_from put p' -l 'from-sops' -d 'Import a SOPS-encrypted file'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'encrypt-keys' -d 'Encrypt only these keys'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'key-version' -d 'Encrypt with this Transit key version'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'dry-run' -d 'Show keys that would change without writing'
+complete -c vlt -x -n '__fish_seen_subcommand_from put p' -l 'override-policy' -d 'Write values violating the value policy, with this reason'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'shard' -d 'Split the keys across PATH/part1..N'
+complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'namespace' -d 'Vault namespace for this command'
 complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'kv-mount' -d 'KV v2 mount path'
 complete -c vlt -f -n '__fish_seen_subcommand_from put p' -l 'transit-mount' -d 'Transit mount path'
 
 # Get command options
-complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'path' -d 'KV path to retrieve secret'
+complete -c vlt -x -n '__fish_seen_subcommand_from get g' -l 'path' -a '(__vlt_complete path)' -d 'KV path to retrieve secret'
 complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'config' -d 'YAML config file with secret definitions'
+complete -c vlt -n '__fish_seen_subcommand_from get g' -l 'env-file' -d 'Read a (possibly encrypted) .env file'
+complete -c vlt -n '__fish_seen_subcommand_from get g run r verify' -l 'identity' -d 'age identity file'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g run r' -l 'expired' -d 'When the env file has expired' -a 'refuse warn resync'
 complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'encryption-key' -d 'Transit encryption key name'
-complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'key' -d 'Specific key to retrieve'
+complete -c vlt -x -n '__fish_seen_subcommand_from get g' -l 'key' -a '(__vlt_complete key)' -d 'Specific key to retrieve'
+complete -c vlt -x -n '__fish_seen_subcommand_from get g' -l 'field' -a '(__vlt_complete key)' -d 'Specific key to retrieve (same as --key)'
 complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'json' -d 'Output as JSON format'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'format' -d 'Output format' -a 'env table json powershell'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'reveal' -d 'Print plaintext values on a terminal'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'copy' -d 'Copy value to the clipboard'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'clipboard-timeout' -d 'Clear clipboard after duration'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'wrap-ttl' -d 'Print a single-use wrapping token instead of values'
+complete -c vlt -r -n '__fish_seen_subcommand_from get g' -l 'out-file' -d 'Write a file stored with put --from-file here'
+complete -c vlt -n '__fish_seen_subcommand_from get g' -l 'metadata' -d 'Print the name, size, SHA-256, and content type of a stored file'
+complete -c vlt -n '__fish_seen_subcommand_from get g' -l 'raw' -d 'Print a binary stored file even to a terminal'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'strict' -d 'Fail on warnings'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'namespace' -d 'Vault namespace for this command'
 complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'kv-mount' -d 'KV v2 mount path'
 complete -c vlt -f -n '__fish_seen_subcommand_from get g' -l 'transit-mount' -d 'Transit mount path'
 
 # Sync command options
-complete -c vlt -f -n '__fish_seen_subcommand_from sync s' -l 'config' -d 'YAML config file'
-complete -c vlt -f -n '__fish_seen_subcommand_from sync s' -l 'output' -d 'Output .env file'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'config' -d 'YAML config file'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'output' -d 'Output .env file'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'format' -d 'Output format' -a 'env tfvars tfvars-json properties toml systemd docker-args compose powershell'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'tfvars-map' -d 'Nest tfvars values in a map variable'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'systemd-dropin' -d 'Write a systemd drop-in loading the output'
+complete -c vlt -n '__fish_seen_subcommand_from sync s env verify' -l 'manifest' -d 'SHA-256 manifest of generated files'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'dry-run' -d 'Show a masked diff without writing'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'mode' -d 'Permissions of the written files' -a '0600 0640 0400'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'fix-gitignore' -d 'Add written files to .gitignore'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'insecure-output' -d 'Skip output directory checks'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'encrypt-output' -d 'Encrypt written files (age:RECIPIENT or transit:KEY)'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'ttl' -d 'Expire the written files after this long'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'expiry-window' -d 'Warn about credentials expiring within this window'
+complete -c vlt -x -n '__fish_seen_subcommand_from sync s env' -l 'vars' -d 'Refresh only the entries setting these variables'
+complete -c vlt -x -n '__fish_seen_subcommand_from sync s env' -l 'paths' -d 'Refresh only the entries reading these paths'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env' -l 'incremental' -d 'Skip decrypting paths unchanged since the last sync'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'strict' -d 'Fail on warnings'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from sync s env verify' -l 'transit-mount' -d 'Transit mount path'
+
+# Plan and apply command options
+complete -c vlt -n '__fish_seen_subcommand_from plan' -l 'config' -d 'YAML config file'
+complete -c vlt -n '__fish_seen_subcommand_from plan apply' -l 'env-file' -d 'Env file with the desired values'
+complete -c vlt -n '__fish_seen_subcommand_from plan' -l 'out' -d 'Save the plan to this file'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'strict' -d 'Fail on entries that cannot be planned'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from plan' -l 'transit-mount' -d 'Transit mount path'
 
 # Run command options
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'config' -d 'YAML config file with secret definitions'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'encryption-key' -d 'Transit encryption key name'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'inject' -d 'Inject specific secret as ENV_VAR=vault_path'
+complete -c vlt -n '__fish_seen_subcommand_from run r' -l 'inject-file' -d 'File of ENV_VAR=vault_path specs'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'env-file' -d 'Load additional environment variables from .env file'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'cleanup' -d 'Shred the env file after the command exits'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'strict' -d 'Fail on warnings'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'namespace' -d 'Vault namespace for this command'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'kv-mount' -d 'KV v2 mount path'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'transit-mount' -d 'Transit mount path'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'dry-run' -d 'Show environment variables without running command'
 complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'preserve-env' -d 'Preserve all current environment variables'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'isolate' -d 'Start from an empty environment'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'allow-env' -d 'Host variables to keep with --isolate'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'redact-vault-env' -d 'Remove VAULT_* variables from the command environment'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'pass-vault-env' -d 'Pass VAULT_* variables to the command'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'mask-output' -d 'Mask secret values in command output'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'shell' -d 'Run the command string through $SHELL -c'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'timeout' -d 'Terminate the command after duration'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'kill-after' -d 'Kill the command this long after the timeout'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'procfile' -d 'Run the processes of a Procfile'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'wait-all' -d 'Wait for every Procfile process to exit'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'watch' -d 'Restart the command when secrets change'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'watch-interval' -d 'Polling interval without Vault events'
+complete -c vlt -f -n '__fish_seen_subcommand_from run r' -l 'metrics-addr' -d 'Serve Prometheus metrics on address'
 
 # JSON command options
 complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'encryption-key' -d 'Transit encryption key name'
 complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'decrypt' -d 'Decrypt a JSON document'
+complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'output-format' -d 'Output format with --decrypt' -a 'json env'
+complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'format' -d 'Output format with --decrypt (same as --output-format)' -a 'json env'
+complete -c vlt -f -n '__fish_seen_subcommand_from json j' -l 'key-version' -d 'Encrypt with this Transit key version'
+
+# Tree command options
+complete -c vlt -x -n '__fish_seen_subcommand_from tree' -l 'path' -a '(__vlt_complete path)' -d 'KV path to start from'
+complete -c vlt -f -n '__fish_seen_subcommand_from tree' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from tree' -l 'rps' -d 'Maximum Vault requests per second'
+complete -c vlt -f -n '__fish_seen_subcommand_from tree' -l 'burst' -d 'Requests allowed above the rate'
+complete -c vlt -f -n '__fish_seen_subcommand_from tree' -l 'max-requests' -d 'Vault request budget'
+
+# Search command options
+complete -c vlt -x -n '__fish_seen_subcommand_from search grep' -l 'path' -a '(__vlt_complete path)' -d 'KV path to search under'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'key-pattern' -d 'Regex matched against keys'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'value-pattern' -d 'Regex matched against values'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'allow-value-search' -d 'Allow reading values'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'rps' -d 'Maximum Vault requests per second'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'burst' -d 'Requests allowed above the rate'
+complete -c vlt -f -n '__fish_seen_subcommand_from search grep' -l 'max-requests' -d 'Vault request budget'
+
+# Browse command options
+complete -c vlt -x -n '__fish_seen_subcommand_from browse' -l 'path' -a '(__vlt_complete path)' -d 'Directory or secret to open'
+complete -c vlt -f -n '__fish_seen_subcommand_from browse' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from browse' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from browse' -l 'kv-mount' -d 'KV v2 mount to open'
+complete -c vlt -f -n '__fish_seen_subcommand_from browse' -l 'transit-mount' -d 'Transit mount path'
+
+# Import/export command options
+complete -c vlt -f -n '__fish_seen_subcommand_from import export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -a 'aws-sm' -d 'AWS Secrets Manager'
+complete -c vlt -f -n '__fish_seen_subcommand_from import export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -a 'aws-ssm' -d 'AWS SSM Parameter Store'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'prefix' -d 'AWS secret name prefix or parameter path'
+complete -c vlt -x -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'path' -a '(__vlt_complete path)' -d 'KV path'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'region' -d 'AWS region'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from aws-sm aws-ssm' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from import; and __fish_seen_subcommand_from aws-sm aws-ssm' -l 'dry-run' -d 'Show keys that would change without writing'
+complete -c vlt -x -n '__fish_seen_subcommand_from import; and __fish_seen_subcommand_from aws-sm aws-ssm' -l 'override-policy' -d 'Write values violating the value policy, with this reason'
+complete -c vlt -f -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'format' -d 'File format to export to' -a 'sops'
+complete -c vlt -x -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'path' -a '(__vlt_complete path)' -d 'KV path to export'
+complete -c vlt -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'output' -d 'Output file'
+complete -c vlt -f -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'sops-age' -d 'age recipient'
+complete -c vlt -f -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'sops-kms' -d 'AWS KMS key ARN'
+complete -c vlt -f -n '__fish_seen_subcommand_from export; and not __fish_seen_subcommand_from aws-sm aws-ssm' -l 'sops-pgp' -d 'PGP fingerprint'
+
+# Snapshot command options
+complete -c vlt -n '__fish_seen_subcommand_from snapshot' -l 'config' -d 'YAML config file'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'recipients' -d 'age recipient'
+complete -c vlt -n '__fish_seen_subcommand_from snapshot' -l 'recipients-file' -d 'File of age recipients'
+complete -c vlt -n '__fish_seen_subcommand_from snapshot' -l 'output' -d 'Snapshot file'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'armor' -d 'Write PEM-armored output'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'rps' -d 'Maximum Vault requests per second'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'burst' -d 'Requests allowed above the rate'
+complete -c vlt -f -n '__fish_seen_subcommand_from snapshot' -l 'max-requests' -d 'Vault request budget'
+
+# Restore-snapshot command options
+complete -c vlt -n '__fish_seen_subcommand_from restore-snapshot' -l 'identity' -s 'i' -d 'age identity file'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'dry-run' -d 'List paths and keys without writing'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'kv-mount' -d 'KV v2 mount to restore into'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'rps' -d 'Maximum Vault requests per second'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'burst' -d 'Requests allowed above the rate'
+complete -c vlt -f -n '__fish_seen_subcommand_from restore-snapshot' -l 'max-requests' -d 'Vault request budget'
+
+# Drift command options
+complete -c vlt -f -n '__fish_seen_subcommand_from login' -l 'method' -a 'token approle github kubernetes ldap userpass oidc' -d 'Auth method'
+complete -c vlt -f -n '__fish_seen_subcommand_from login' -l 'auth-path' -d 'Mount path of the auth method'
+complete -c vlt -f -n '__fish_seen_subcommand_from login' -l 'username' -d 'User for ldap and userpass'
+complete -c vlt -f -n '__fish_seen_subcommand_from login' -l 'role' -d 'Role for oidc and kubernetes'
+complete -c vlt -f -n '__fish_seen_subcommand_from login' -l 'store' -a 'keychain file none' -d 'Where to keep the token'
+complete -c vlt -f -n '__fish_seen_subcommand_from logout' -l 'no-revoke' -d 'Remove the stored token without revoking it'
+complete -c vlt -f -n '__fish_seen_subcommand_from whoami' -l 'json' -d 'Output as JSON'
+complete -c vlt -n '__fish_seen_subcommand_from check' -l 'config' -d 'YAML config file whose entries to check'
+complete -c vlt -f -n '__fish_seen_subcommand_from check' -l 'expiry-window' -d 'Warn about credentials expiring within this window'
+complete -c vlt -f -n '__fish_seen_subcommand_from check' -l 'strict' -d 'Fail on credentials expiring within the window'
+complete -c vlt -f -n '__fish_seen_subcommand_from check' -l 'encryption-key' -d 'Transit encryption key to check'
+complete -c vlt -f -n '__fish_seen_subcommand_from check' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from check' -l 'transit-mount' -d 'Transit mount path'
+
+# Dev command options
+complete -c vlt -n '__fish_seen_subcommand_from dev' -l 'config' -d 'YAML config file whose secrets are seeded'
+complete -c vlt -f -n '__fish_seen_subcommand_from dev' -l 'listen' -d 'Address the server listens on'
+complete -c vlt -f -n '__fish_seen_subcommand_from dev' -l 'token' -d 'Root token of the server'
+complete -c vlt -n '__fish_seen_subcommand_from dev' -l 'vault-bin' -d 'vault binary to run'
+complete -c vlt -f -n '__fish_seen_subcommand_from dev' -l 'embedded' -d 'Use the built-in server'
+complete -c vlt -f -n '__fish_seen_subcommand_from dev' -l 'encryption-key' -d 'Transit key to create'
+
+# Guard command options
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'config' -d 'YAML config whose secrets to look for'
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'no-vault' -d 'Only check tokens and generated files'
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -n '__fish_seen_subcommand_from guard' -l 'transit-mount' -d 'Transit mount path'
+
+# Clean command options
+complete -c vlt -f -n '__fish_seen_subcommand_from clean' -l 'all' -d 'Remove every recorded file'
+complete -c vlt -f -n '__fish_seen_subcommand_from clean' -l 'dry-run' -d 'List files without removing them'
+
+# Shred command options
+complete -c vlt -n '__fish_seen_subcommand_from shred' -l 'force' -d 'Also shred files sync did not write'
+
+# Random command options
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'bytes' -d 'Number of random bytes'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'format' -a 'base64 hex' -d 'Output encoding'
+complete -c vlt -f -n '__fish_seen_subcommand_from random' -l 'store' -d 'Store the value in Vault instead of printing it'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'path' -a '(__vlt_complete path)' -d 'Secret path for --store'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'key' -d 'Key to set for --store'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'namespace' -d 'Vault namespace'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -x -n '__fish_seen_subcommand_from random' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -x -n '__fish_seen_subcommand_from drift' -l 'left' -a '(__vlt_complete path)' -d 'Left KV path'
+complete -c vlt -x -n '__fish_seen_subcommand_from drift' -l 'right' -a '(__vlt_complete path)' -d 'Right KV path'
+complete -c vlt -n '__fish_seen_subcommand_from drift' -l 'left-config' -d 'Left YAML config file'
+complete -c vlt -n '__fish_seen_subcommand_from drift' -l 'right-config' -d 'Right YAML config file'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'json' -d 'Output the report as JSON'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'transit-mount' -d 'Transit mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'rps' -d 'Maximum Vault requests per second'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'burst' -d 'Requests allowed above the rate'
+complete -c vlt -f -n '__fish_seen_subcommand_from drift' -l 'max-requests' -d 'Vault request budget'
+
+# Lint-values command options
+complete -c vlt -n '__fish_seen_subcommand_from lint-values' -l 'config' -d 'YAML config file'
+complete -c vlt -n '__fish_seen_subcommand_from lint-values' -l 'compare-config' -d 'Config of another environment'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'min-length' -d 'Shortest accepted credential value'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'expiry-window' -d 'Warn about credentials expiring within this window'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'strict' -d 'Treat warnings as errors'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'json' -d 'Output the findings as JSON'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'encryption-key' -d 'Transit encryption key name'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'only' -d 'Only entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'skip' -d 'Skip entries with tag'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'namespace' -d 'Vault namespace for this command'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'kv-mount' -d 'KV v2 mount path'
+complete -c vlt -f -n '__fish_seen_subcommand_from lint-values' -l 'transit-mount' -d 'Transit mount path'
 
 # Completion command options
 complete -c vlt -f -n '__fish_seen_subcommand_from completion comp' -a 'bash' -d 'Generate bash completion'
@@ -711,11 +4019,30 @@ complete -c vlt -f -n '__fish_seen_subcommand_from completion comp' -a 'zsh' -d
 complete -c vlt -f -n '__fish_seen_subcommand_from completion comp' -a 'fish' -d 'Generate fish completion'
 complete -c vlt -f -n '__fish_seen_subcommand_from completion comp' -a 'powershell' -d 'Generate PowerShell completion'
 
+# Docs command options
+complete -c vlt -f -n '__fish_seen_subcommand_from docs' -a 'man' -d 'Generate a man page'
+complete -c vlt -f -n '__fish_seen_subcommand_from docs' -a 'markdown' -d 'Generate Markdown'
+complete -c vlt -f -n '__fish_seen_subcommand_from docs' -a 'json' -d 'Generate a JSON spec of commands and flags'
+complete -c vlt -r -n '__fish_seen_subcommand_from docs' -l 'output' -d 'Write to this file instead of stdout'
+
+# Version command options
+complete -c vlt -f -n '__fish_seen_subcommand_from version' -l 'json' -d 'Output as JSON'
+
+# Self-update command options
+complete -c vlt -f -n '__fish_seen_subcommand_from self-update' -l 'check' -d 'Only check for a newer release'
+complete -c vlt -x -n '__fish_seen_subcommand_from self-update' -l 'feed' -d 'URL of the latest release'
+complete -c vlt -x -n '__fish_seen_subcommand_from self-update' -l 'public-key' -d 'Release signing key'
+
 # Global options
 complete -c vlt -f -l 'vault-addr' -d 'Vault server address'
 complete -c vlt -f -l 'vault-token' -d 'Vault authentication token'
 complete -c vlt -f -l 'vault-namespace' -d 'Vault namespace'
+complete -c vlt -x -l 'profile' -d 'Connection profile'
+complete -c vlt -f -l 'read-only' -d 'Refuse operations that write to Vault'
+complete -c vlt -x -l 'mfa' -d 'MFA credential method[:passcode] (repeatable)'
+complete -c vlt -x -l 'control-group-wait' -d 'How long to wait for control group approval'
 complete -c vlt -f -l 'encryption-key' -d 'Default transit encryption key'
+complete -c vlt -f -s 'q' -l 'quiet' -d 'Suppress informational messages and warnings'
 complete -c vlt -f -l 'help' -d 'Show help'
 complete -c vlt -f -l 'version' -d 'Print version'
 `)
@@ -728,12 +4055,26 @@ func generatePowerShellCompletion(ctx *cli.Context) error {
 Register-ArgumentCompleter -Native -CommandName vlt -ScriptBlock {
     param($commandName, $wordToComplete, $cursorPosition)
     
-    $commands = @('put', 'get', 'sync', 'run', 'json', 'completion', 'help')
-    $aliases = @('p', 'g', 's', 'r', 'j', 'comp', 'h')
+    $commands = @('put', 'get', 'sync', 'verify', 'plan', 'apply', 'run', 'json', 'tree', 'search', 'browse', 'import', 'export', 'snapshot', 'restore-snapshot', 'drift', 'lint-values', 'login', 'logout', 'whoami', 'check', 'dev', 'guard', 'clean', 'shred', 'random', 'completion', 'docs', 'version', 'self-update', 'help')
+    $aliases = @('p', 'g', 's', 'env', 'r', 'j', 'grep', 'comp', 'h')
     
     # Split the command line
     $commandElements = $wordToComplete.Split(' ', [System.StringSplitOptions]::RemoveEmptyEntries)
     
+    # Complete Vault paths and keys from the server
+    $current = if ($wordToComplete.EndsWith(' ')) { '' } else { $commandElements[-1] }
+    $previous = if ($current -eq '') { $commandElements[-1] } else { $commandElements[-2] }
+    if ($previous -in @('--path', '--key', '--left', '--right')) {
+        $kind = if ($previous -eq '--key') { 'key' } else { 'path' }
+        $vaultArgs = @()
+        for ($i = 1; $i -lt $commandElements.Count - 1; $i++) {
+            if ($commandElements[$i] -in @('--kv-mount', '--namespace') -or ($kind -eq 'key' -and $commandElements[$i] -eq '--path')) {
+                $vaultArgs += $commandElements[$i], $commandElements[$i + 1]
+            }
+        }
+        return @(vlt __complete @vaultArgs $kind $current 2>$null)
+    }
+    
     # Complete main commands
     if ($commandElements.Count -le 1) {
         return ($commands + $aliases) | Where-Object { $_ -like "$wordToComplete*" }
@@ -742,23 +4083,98 @@ Register-ArgumentCompleter -Native -CommandName vlt -ScriptBlock {
     # Complete based on subcommand
     switch ($commandElements[0]) {
         { $_ -in @('put', 'p') } {
-            return @('--path', '--encryption-key', '--key', '--value', '--from-env', '--from-file', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            return @('--path', '--encryption-key', '--key', '--value', '--value-file', '--from-env', '--from-file', '--from-stdin', '--from-stdin-json', '--from-stdin-env', '--format', '--from-k8s-secret', '--k8s-context', '--from-sops', '--encrypt-keys', '--key-version', '--dry-run', '--override-policy', '--shard', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
         }
         { $_ -in @('get', 'g') } {
-            return @('--path', '--config', '--encryption-key', '--key', '--json', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            return @('--path', '--config', '--env-file', '--identity', '--expired', '--encryption-key', '--key', '--field', '--json', '--format', '--reveal', '--copy', '--clipboard-timeout', '--wrap-ttl', '--out-file', '--metadata', '--raw', '--strict', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        { $_ -in @('sync', 's', 'env', 'verify') } {
+            return @('--config', '--output', '--format', '--tfvars-map', '--systemd-dropin', '--manifest', '--dry-run', '--mode', '--fix-gitignore', '--insecure-output', '--encrypt-output', '--ttl', '--expiry-window', '--vars', '--paths', '--incremental', '--identity', '--encryption-key', '--key', '--strict', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'plan' {
+            return @('--config', '--env-file', '--out', '--encryption-key', '--key', '--strict', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
         }
-        { $_ -in @('sync', 's') } {
-            return @('--config', '--output', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        'apply' {
+            return @('--env-file', '--help') | Where-Object { $_ -like "$wordToComplete*" }
         }
         { $_ -in @('run', 'r') } {
-            return @('--config', '--encryption-key', '--inject', '--env-file', '--kv-mount', '--transit-mount', '--dry-run', '--preserve-env', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            return @('--config', '--encryption-key', '--inject', '--inject-file', '--env-file', '--identity', '--expired', '--cleanup', '--strict', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--dry-run', '--preserve-env', '--isolate', '--allow-env', '--redact-vault-env', '--pass-vault-env', '--mask-output', '--shell', '--timeout', '--kill-after', '--procfile', '--wait-all', '--watch', '--watch-interval', '--metrics-addr', '--help') | Where-Object { $_ -like "$wordToComplete*" }
         }
         { $_ -in @('json', 'j') } {
-            return @('--encryption-key', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            return @('--encryption-key', '--transit-mount', '--decrypt', '--output-format', '--format', '--key-version', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'tree' {
+            return @('--path', '--kv-mount', '--rps', '--burst', '--max-requests', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        { $_ -in @('search', 'grep') } {
+            return @('--path', '--key-pattern', '--value-pattern', '--allow-value-search', '--encryption-key', '--kv-mount', '--transit-mount', '--rps', '--burst', '--max-requests', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'browse' {
+            return @('--path', '--encryption-key', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        { $_ -in @('import', 'export') } {
+            if ($commandElements.Count -le 2) {
+                return @('aws-sm', 'aws-ssm') | Where-Object { $_ -like "$wordToComplete*" }
+            }
+            if ($commandElements[0] -eq 'export' -and $commandElements[1] -notlike 'aws-*') {
+                return @('--format', '--path', '--output', '--sops-age', '--sops-kms', '--sops-pgp', '--encryption-key', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            }
+            if ($commandElements[0] -eq 'import') {
+                return @('--prefix', '--path', '--region', '--encryption-key', '--dry-run', '--override-policy', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+            }
+            return @('--prefix', '--path', '--region', '--encryption-key', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'snapshot' {
+            return @('--config', '--recipients', '--recipients-file', '--output', '--armor', '--encryption-key', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--rps', '--burst', '--max-requests', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'restore-snapshot' {
+            return @('--identity', '--encryption-key', '--dry-run', '--namespace', '--kv-mount', '--transit-mount', '--rps', '--burst', '--max-requests', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'login' {
+            return @('--method', '--auth-path', '--username', '--role', '--store', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'logout' {
+            return @('--no-revoke', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'whoami' {
+            return @('--json', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'check' {
+            return @('--config', '--expiry-window', '--strict', '--encryption-key', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'dev' {
+            return @('--config', '--listen', '--token', '--vault-bin', '--embedded', '--encryption-key', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'guard' {
+            return @('--config', '--no-vault', '--encryption-key', '--key', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'clean' {
+            return @('--all', '--dry-run', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'shred' {
+            return @('--force', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'random' {
+            return @('--bytes', '--format', '--store', '--path', '--key', '--encryption-key', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'drift' {
+            return @('--left', '--right', '--left-config', '--right-config', '--json', '--encryption-key', '--namespace', '--kv-mount', '--transit-mount', '--rps', '--burst', '--max-requests', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'lint-values' {
+            return @('--config', '--compare-config', '--min-length', '--expiry-window', '--strict', '--json', '--encryption-key', '--key', '--only', '--skip', '--namespace', '--kv-mount', '--transit-mount', '--help') | Where-Object { $_ -like "$wordToComplete*" }
         }
         { $_ -in @('completion', 'comp') } {
             return @('bash', 'zsh', 'fish', 'powershell') | Where-Object { $_ -like "$wordToComplete*" }
         }
+        'version' {
+            return @('--json', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'self-update' {
+            return @('--check', '--feed', '--public-key', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
+        'docs' {
+            return @('man', 'markdown', 'json', '--output', '--help') | Where-Object { $_ -like "$wordToComplete*" }
+        }
     }
     
     return @()
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// CLISpec is the machine-readable description of the commands and flags
+// printed by "vlt docs json"
+type CLISpec struct {
+	Name        string        `json:"name"`
+	Version     string        `json:"version"`
+	Usage       string        `json:"usage"`
+	Description string        `json:"description,omitempty"`
+	Flags       []FlagSpec    `json:"flags"`
+	Commands    []CommandSpec `json:"commands"`
+}
+
+// CommandSpec describes a command and its subcommands
+type CommandSpec struct {
+	Name        string        `json:"name"`
+	Aliases     []string      `json:"aliases,omitempty"`
+	Usage       string        `json:"usage"`
+	Description string        `json:"description,omitempty"`
+	ArgsUsage   string        `json:"args_usage,omitempty"`
+	Flags       []FlagSpec    `json:"flags"`
+	Subcommands []CommandSpec `json:"subcommands,omitempty"`
+}
+
+// FlagSpec describes a flag
+type FlagSpec struct {
+	Name     string   `json:"name"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Type     string   `json:"type"`
+	Usage    string   `json:"usage"`
+	Default  string   `json:"default,omitempty"`
+	EnvVars  []string `json:"env_vars,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Multiple bool     `json:"multiple,omitempty"`
+}
+
+// buildCLISpec describes the app, leaving out hidden commands and flags
+func buildCLISpec(app *cli.App) *CLISpec {
+	return &CLISpec{
+		Name:        app.Name,
+		Version:     app.Version,
+		Usage:       app.Usage,
+		Description: app.Description,
+		Flags:       flagSpecs(app.VisibleFlags()),
+		Commands:    commandSpecs(app.VisibleCommands()),
+	}
+}
+
+func commandSpecs(commands []*cli.Command) []CommandSpec {
+	specs := make([]CommandSpec, 0, len(commands))
+	for _, cmd := range commands {
+		if cmd.Name == "help" {
+			continue
+		}
+		specs = append(specs, CommandSpec{
+			Name:        cmd.Name,
+			Aliases:     cmd.Aliases,
+			Usage:       cmd.Usage,
+			Description: cmd.Description,
+			ArgsUsage:   cmd.ArgsUsage,
+			Flags:       flagSpecs(cmd.VisibleFlags()),
+			Subcommands: commandSpecs(cmd.VisibleCommands()),
+		})
+	}
+	return specs
+}
+
+var flagTypeWords = regexp.MustCompile(`[a-z0-9][A-Z]`)
+
+func flagSpecs(flags []cli.Flag) []FlagSpec {
+	specs := make([]FlagSpec, 0, len(flags))
+	for _, flag := range flags {
+		names := flag.Names()
+		if len(names) == 0 || names[0] == "help" {
+			continue
+		}
+
+		// *cli.StringSliceFlag becomes "string-slice"
+		typeName := strings.TrimSuffix(strings.TrimPrefix(fmt.Sprintf("%T", flag), "*cli."), "Flag")
+		typeName = flagTypeWords.ReplaceAllStringFunc(typeName, func(s string) string {
+			return s[:1] + "-" + s[1:]
+		})
+
+		spec := FlagSpec{
+			Name:    names[0],
+			Aliases: names[1:],
+			Type:    strings.ToLower(typeName),
+		}
+		if f, ok := flag.(cli.DocGenerationFlag); ok {
+			spec.Usage = f.GetUsage()
+			spec.EnvVars = f.GetEnvVars()
+			if f.TakesValue() {
+				spec.Default = f.GetValue()
+			}
+		}
+		if f, ok := flag.(cli.RequiredFlag); ok {
+			spec.Required = f.IsRequired()
+		}
+		if f, ok := flag.(cli.DocGenerationSliceFlag); ok {
+			spec.Multiple = f.IsSliceFlag()
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
@@ -5,25 +5,66 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Version int `yaml:"version"`
+	Version int      `yaml:"version"`
+	Include []string `yaml:"include,omitempty"` // files layered underneath this one, in order
 	Vault   struct {
-		Addr       string `yaml:"addr"`
-		Namespace  string `yaml:"namespace"`
-		SkipVerify bool   `yaml:"skip_verify"`
-		CACert     string `yaml:"ca_cert"`
+		Addr          AddrList `yaml:"addr"`
+		Namespace     string   `yaml:"namespace"`
+		SkipVerify    bool     `yaml:"skip_verify"`
+		CACert        string   `yaml:"ca_cert"`
+		CAPath        string   `yaml:"ca_path,omitempty"`
+		TLSServerName string   `yaml:"tls_server_name,omitempty"`
+		TLSMinVersion string   `yaml:"tls_min_version,omitempty"`
+		AuthMethod    string   `yaml:"auth_method,omitempty"`
+		Proxy         string   `yaml:"proxy,omitempty"`
+
+		// Headers are added to every Vault request, e.g. for a gateway in front of Vault
+		Headers map[string]string `yaml:"headers,omitempty"`
 	} `yaml:"vault"`
 	Transit *struct {
 		Mount string `yaml:"mount"`
 		Key   string `yaml:"key"`
+
+		// Values encrypted with a key version below MinKeyVersion fail get/sync
+		// and run, or only warn when MinKeyVersionAction is "warn"
+		MinKeyVersion       int    `yaml:"min_key_version,omitempty"`
+		MinKeyVersionAction string `yaml:"min_key_version_action,omitempty"`
 	} `yaml:"transit,omitempty"`
 	KV struct {
 		Mount string `yaml:"mount"`
 	} `yaml:"kv"`
 	Secrets []SecretEntry `yaml:"secrets"`
+
+	// Encryption selects how values are encrypted: EncryptionTransit (the
+	// default) or EncryptionLocal, client-side as LocalEncryption configures
+	Encryption      string           `yaml:"encryption,omitempty"`
+	LocalEncryption *LocalEncryption `yaml:"local_encryption,omitempty"`
+
+	// Outputs lists named artifacts written by sync, e.g. api: {file: api/.env, only: [api]}
+	Outputs map[string]OutputSpec `yaml:"outputs,omitempty"`
+
+	// Auth lists named ways of logging in that entries select with auth: <name>
+	Auth map[string]AuthProfile `yaml:"auth,omitempty"`
+
+	// Strict turns warnings (skipped entries, unknown fields, ambiguous values) into errors
+	Strict bool `yaml:"strict,omitempty"`
+
+	// ValuePolicy is a file of rules that put and import check values against
+	ValuePolicy string `yaml:"value_policy,omitempty"`
+
+	// MaxSecretSize is the largest secret, in bytes, put writes to one KV path
+	// (default: 1 MiB, the integrated storage limit)
+	MaxSecretSize int `yaml:"max_secret_size,omitempty"`
+
+	// Default naming policy for env vars derived from KV keys
+	NamingPolicy `yaml:",inline"`
 }
 
 // SecretEntry represents a secret configuration entry
@@ -32,6 +73,8 @@ type Config struct {
 // 2. New format: all keys from path (path only)
 // 3. Selective format: single key from path (path + key)
 // 4. Mapped format: single key from path with custom env name (path + key + env_key)
+// 5. Template format: value composed from several secret fields (env_var + template)
+// 6. Literal format: fixed value not stored in Vault (env_var + value)
 type SecretEntry struct {
 	// Old format - individual secret mapping
 	Name     string `yaml:"name,omitempty"`
@@ -43,16 +86,285 @@ type SecretEntry struct {
 	Path   string `yaml:"path,omitempty"`    // vault path
 	Key    string `yaml:"key,omitempty"`     // specific key to extract (optional)
 	EnvKey string `yaml:"env_key,omitempty"` // custom env var name (optional, requires key)
+
+	// Template format - e.g. "postgres://{{ secret \"db\" \"USER\" }}@db:5432/app"
+	Template string `yaml:"template,omitempty"`
+
+	// Literal format and fallbacks
+	Value   *string `yaml:"value,omitempty"`   // literal value, no Vault lookup
+	Default *string `yaml:"default,omitempty"` // used when an optional secret or key is absent
+
+	// Tags group entries so a command can select a subset with --only/--skip
+	Tags []string `yaml:"tags,omitempty"`
+
+	// RequireKeys lists keys that must be present when loading all keys from a path
+	RequireKeys []string `yaml:"require_keys,omitempty"`
+
+	// EncryptKeys limits Transit encryption to these keys when plan/apply or dev
+	// write the path; the other keys stay readable in the Vault UI
+	EncryptKeys []string `yaml:"encrypt_keys,omitempty"`
+
+	// Transform lists transformers applied in order to each value the entry
+	// resolves, e.g. "base64-decode", "json-extract: .password", or
+	// "exec: ./decode.sh" (the value is passed on stdin)
+	Transform TransformList `yaml:"transform,omitempty"`
+
+	// Source is the config file (or vault:// or https:// reference) the entry
+	// was read from; exec transformers run in its directory
+	Source string `yaml:"-" json:"-"`
+
+	// Naming policy for env vars derived from KV keys (overrides the config-level policy)
+	NamingPolicy `yaml:",inline"`
+
+	// Per-entry overrides
+	Namespace    string   `yaml:"namespace,omitempty"`     // Vault Enterprise namespace for this entry
+	KVMount      string   `yaml:"kv_mount,omitempty"`      // KV mount for this entry (overrides kv.mount)
+	TransitMount string   `yaml:"transit_mount,omitempty"` // transit mount for this entry (overrides transit.mount)
+	Vault        AddrList `yaml:"vault,omitempty"`         // Vault server(s) for this entry, in failover order
+	Auth         string   `yaml:"auth,omitempty"`          // auth profile to read this entry with (a key of auth)
+}
+
+// AuthProfile is an alternative login for the entries that name it, e.g. the
+// AppRole of one team in a monorepo. Credentials never appear in the config:
+// the profile names the environment variables holding them.
+type AuthProfile struct {
+	Method         string `yaml:"method"`                     // token, approle, github, kubernetes, ldap, or userpass
+	Path           string `yaml:"path,omitempty"`             // auth mount path (defaults to the method name)
+	Namespace      string `yaml:"namespace,omitempty"`        // namespace to log in to and read from
+	Role           string `yaml:"role,omitempty"`             // kubernetes role
+	TokenEnv       string `yaml:"token_env,omitempty"`        // token: variable holding the token
+	RoleIDEnv      string `yaml:"role_id_env,omitempty"`      // approle: variable holding the role ID
+	SecretIDEnv    string `yaml:"secret_id_env,omitempty"`    // approle: variable holding the secret ID
+	GitHubTokenEnv string `yaml:"github_token_env,omitempty"` // github: variable holding the token
+	UsernameEnv    string `yaml:"username_env,omitempty"`     // ldap, userpass: variable holding the username
+	PasswordEnv    string `yaml:"password_env,omitempty"`     // ldap, userpass: variable holding the password
+	TokenRole      string `yaml:"token_role,omitempty"`       // create the working token against this token role
+	BatchToken     bool   `yaml:"batch_token,omitempty"`      // use a batch working token
+}
+
+// ApplyTo replaces the auth method and credentials of cfg with the
+// profile's, read from the environment. The server, TLS, and timeout
+// settings of cfg are kept.
+func (p *AuthProfile) ApplyTo(cfg *VaultConfig) error {
+	cfg.Token, cfg.RoleID, cfg.SecretID, cfg.GitHubToken = "", "", "", ""
+	cfg.K8sRole, cfg.Username, cfg.Password, cfg.OIDCRole = "", "", "", ""
+	cfg.AuthMethod = strings.ToLower(p.Method)
+	cfg.AuthPath = p.Path
+	cfg.TokenRole = p.TokenRole
+	cfg.BatchToken = p.BatchToken
+	cfg.Namespace = NonEmpty(p.Namespace, cfg.Namespace)
+
+	var missing []string
+	env := func(field, name string) string {
+		value := os.Getenv(name)
+		if name == "" {
+			missing = append(missing, field+" is not set")
+		} else if value == "" {
+			missing = append(missing, name+" is empty")
+		}
+		return value
+	}
+	switch cfg.AuthMethod {
+	case "token":
+		cfg.Token = env("token_env", p.TokenEnv)
+	case "approle":
+		cfg.RoleID = env("role_id_env", p.RoleIDEnv)
+		cfg.SecretID = env("secret_id_env", p.SecretIDEnv)
+	case "github":
+		cfg.GitHubToken = env("github_token_env", p.GitHubTokenEnv)
+	case "kubernetes":
+		cfg.K8sRole = p.Role
+		cfg.K8sAuthPath = NonEmpty(p.Path, "kubernetes")
+		if p.Role == "" {
+			missing = append(missing, "role is not set")
+		}
+	case "ldap", "userpass":
+		cfg.Username = env("username_env", p.UsernameEnv)
+		cfg.Password = env("password_env", p.PasswordEnv)
+	default:
+		return fmt.Errorf("unsupported auth method %q (expected token, approle, github, kubernetes, ldap, or userpass)", p.Method)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s", strings.Join(missing, "; "))
+	}
+	return nil
+}
+
+// OutputSpec describes a file written by sync from a subset of the secrets
+type OutputSpec struct {
+	File   string   `yaml:"file"`             // output path, relative to the config file
+	Format string   `yaml:"format,omitempty"` // output format (default env)
+	Only   []string `yaml:"only,omitempty"`   // include only entries with one of these tags
+	Skip   []string `yaml:"skip,omitempty"`   // exclude entries with any of these tags
+	Map    string   `yaml:"map,omitempty"`    // tfvars formats: nest values in this map variable; toml: table
+}
+
+// NamingPolicy controls how KV keys are mapped to environment variable names.
+// It applies to names derived from keys, not to explicit env_key/env_var names.
+type NamingPolicy struct {
+	Prefix            string `yaml:"prefix,omitempty"`              // prepended to every derived name
+	Case              string `yaml:"case,omitempty"`                // upper (default), lower, or preserve
+	InvalidCharPolicy string `yaml:"invalid_char_policy,omitempty"` // underscore or error (unset keeps names as-is)
+}
+
+// Merge returns the policy with empty fields filled in from fallback
+func (p NamingPolicy) Merge(fallback NamingPolicy) NamingPolicy {
+	return NamingPolicy{
+		Prefix:            NonEmpty(p.Prefix, fallback.Prefix),
+		Case:              NonEmpty(p.Case, fallback.Case),
+		InvalidCharPolicy: NonEmpty(p.InvalidCharPolicy, fallback.InvalidCharPolicy),
+	}
+}
+
+// EnvName maps a KV key to an environment variable name
+func (p NamingPolicy) EnvName(key string) (string, error) {
+	name := key
+	switch strings.ToLower(p.Case) {
+	case "", "upper":
+		name = strings.ToUpper(name)
+	case "lower":
+		name = strings.ToLower(name)
+	case "preserve":
+	default:
+		return "", fmt.Errorf("invalid case %q: expected upper, lower, or preserve", p.Case)
+	}
+	name = p.Prefix + name
+
+	switch strings.ToLower(p.InvalidCharPolicy) {
+	case "":
+	case "underscore":
+		name = SanitizeEnvName(name)
+	case "error":
+		if !IsValidEnvName(name) {
+			return "", fmt.Errorf("key %q maps to invalid environment variable name %q", key, name)
+		}
+	default:
+		return "", fmt.Errorf("invalid invalid_char_policy %q: expected underscore or error", p.InvalidCharPolicy)
+	}
+
+	return name, nil
+}
+
+// IsValidEnvName reports whether name is a portable environment variable name
+func IsValidEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, r := range name {
+		if !isEnvNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// SanitizeEnvName replaces characters that are not valid in environment
+// variable names with underscores
+func SanitizeEnvName(name string) string {
+	var b strings.Builder
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		b.WriteByte('_')
+	}
+	for _, r := range name {
+		if isEnvNameRune(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func isEnvNameRune(r rune) bool {
+	return r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+}
+
+// AddrList is a list of Vault addresses in failover order. In YAML it may be
+// written as a single (optionally comma-separated) string or as a sequence.
+type AddrList []string
+
+// UnmarshalYAML accepts either a scalar or a sequence of addresses
+func (l *AddrList) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.ScalarNode:
+		*l = SplitAddrs(value.Value)
+	case yaml.SequenceNode:
+		var addrs []string
+		if err := value.Decode(&addrs); err != nil {
+			return err
+		}
+		*l = SplitAddrs(strings.Join(addrs, ","))
+	default:
+		return fmt.Errorf("vault address must be a string or a list of strings")
+	}
+	return nil
+}
+
+// TransformList is the transform steps of a secret entry. In YAML a step
+// with an argument may be written as a string ("json-extract: .password") or
+// as a single-key mapping (json-extract: .password).
+type TransformList []string
+
+// UnmarshalYAML accepts a sequence of strings and single-key mappings
+func (l *TransformList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		return fmt.Errorf("transform must be a list of steps")
+	}
+	steps := make([]string, 0, len(value.Content))
+	for _, step := range value.Content {
+		switch {
+		case step.Kind == yaml.ScalarNode:
+			steps = append(steps, step.Value)
+		case step.Kind == yaml.MappingNode && len(step.Content) == 2 && step.Content[1].Kind == yaml.ScalarNode:
+			steps = append(steps, step.Content[0].Value+": "+step.Content[1].Value)
+		default:
+			return fmt.Errorf("line %d: a transform step must be a string or name: argument", step.Line)
+		}
+	}
+	*l = steps
+	return nil
+}
+
+// SplitAddrs splits a comma-separated address list, dropping empty entries
+func SplitAddrs(value string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// JoinAddrs joins addresses into the comma-separated form used by VAULT_ADDR
+func JoinAddrs(addrs []string) string {
+	return strings.Join(addrs, ",")
 }
 
 // VaultConfig holds Vault client configuration
 type VaultConfig struct {
-	Addr       string
-	Token      string
-	Namespace  string
-	CACert     string
-	SkipVerify bool
-	Timeout    int // seconds
+	Addr           string // one address, or several comma-separated for failover
+	AgentAddr      string // Vault Agent listener, used instead of Addr when set
+	AgentTokenFile string // Vault Agent sink file holding the token; re-read when it changes
+	Token          string
+	Namespace      string
+	CACert         string
+	CAPath         string // directory of PEM CA certificates
+	SkipVerify     bool
+	TLSServerName  string // SNI and certificate name to verify, when it differs from the address host
+	TLSMinVersion  string // "1.2" (default) or "1.3"
+	Timeout        int    // seconds; bounds each Vault request
+	ConnectTimeout int    // seconds; bounds dialing, the TLS handshake, and failover health checks
+	Proxy          string // http(s):// or socks5:// proxy URL; empty uses HTTPS_PROXY/NO_PROXY
+
+	// Request metadata
+	Headers       map[string]string // extra headers sent with every request
+	CorrelationID string            // sent as X-Correlation-ID to tie one invocation's requests together
+
+	// Client-side request limits for bulk operations
+	RateLimit   float64 // requests per second; 0 means unlimited
+	Burst       int     // requests allowed at once above RateLimit
+	MaxRequests int     // total requests per client; 0 means unlimited
 	
 	// Authentication methods
 	AuthMethod string // auto-detected or explicitly set
@@ -68,16 +380,79 @@ type VaultConfig struct {
 	K8sRole        string
 	K8sJWTPath     string // defaults to /var/run/secrets/kubernetes.io/serviceaccount/token
 	K8sAuthPath    string // defaults to kubernetes
+
+	// LDAP and userpass auth
+	Username string
+	Password string
+
+	// OIDC auth
+	OIDCRole string // defaults to the mount's default role
+
+	// AuthPath overrides the mount path of the approle, ldap, userpass, or oidc method
+	AuthPath string
+
+	// Working token created from the login token, for constrained execution
+	TokenRole  string // create it against this token role (auth/token/create/<role>)
+	BatchToken bool   // create a batch token: non-renewable and never persisted by Vault
+
+	// Step-up authorization (Vault Enterprise MFA and control groups)
+	MFA              []string                            // "method[:passcode]" credentials sent as X-Vault-MFA with every request
+	MFAPrompt        func(method string) (string, error) // asks for the passcode of a login MFA method; nil fails the login instead
+	ControlGroupWait int                                 // seconds to wait for control group approval of a request; 0 fails at once
+
+	// Fixtures for tests without Vault access
+	Record string // write sanitized responses to this fixture file
+	Replay string // answer requests from this fixture file instead of Vault
+}
+
+// Default Vault client timeouts, in seconds
+const (
+	DefaultTimeout          = 15
+	DefaultConnectTimeout   = 5
+	DefaultControlGroupWait = 300 // control group approval
+)
+
+// ParseTimeout parses a positive timeout given in seconds ("30") or as a
+// duration ("1m30s"), rounding up to whole seconds
+func ParseTimeout(value string) (int, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0, fmt.Errorf("invalid timeout %q: must be positive", value)
+		}
+		return seconds, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q (expected seconds or a duration like 90s or 2m)", value)
+	}
+	return int((d + time.Second - 1) / time.Second), nil
+}
+
+// ParseWait parses a wait like ParseTimeout, also accepting 0 for no waiting
+func ParseWait(value string) (int, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	return ParseTimeout(value)
 }
 
 // GetVaultConfigFromEnv creates VaultConfig from environment variables
 func GetVaultConfigFromEnv() *VaultConfig {
 	cfg := &VaultConfig{
-		Addr:      os.Getenv("VAULT_ADDR"),
-		Token:     os.Getenv("VAULT_TOKEN"),
-		Namespace: os.Getenv("VAULT_NAMESPACE"),
-		CACert:    os.Getenv("VAULT_CACERT"),
-		Timeout:   15, // default timeout
+		Addr:           os.Getenv("VAULT_ADDR"),
+		AgentAddr:      os.Getenv("VAULT_AGENT_ADDR"),
+		AgentTokenFile: os.Getenv("VAULT_AGENT_TOKEN_FILE"),
+		Token:          os.Getenv("VAULT_TOKEN"),
+		Namespace:      os.Getenv("VAULT_NAMESPACE"),
+		CACert:         os.Getenv("VAULT_CACERT"),
+		CAPath:         os.Getenv("VAULT_CAPATH"),
+		TLSServerName:  os.Getenv("VAULT_TLS_SERVER_NAME"),
+		TLSMinVersion:  os.Getenv("VAULT_TLS_MIN_VERSION"),
+		Proxy:          os.Getenv("VAULT_PROXY"),
+		Timeout:        DefaultTimeout,
+		ConnectTimeout: DefaultConnectTimeout,
+
+		ControlGroupWait: DefaultControlGroupWait,
 		
 		// Auth method (explicit or auto-detected)
 		AuthMethod: strings.ToLower(os.Getenv("VAULT_AUTH_METHOD")),
@@ -93,17 +468,38 @@ func GetVaultConfigFromEnv() *VaultConfig {
 		K8sRole:     os.Getenv("VAULT_K8S_ROLE"),
 		K8sJWTPath:  os.Getenv("VAULT_K8S_JWT_PATH"),
 		K8sAuthPath: os.Getenv("VAULT_K8S_AUTH_PATH"),
+
+		// Working token
+		TokenRole: os.Getenv("VAULT_TOKEN_ROLE"),
 	}
 
 	if skip := os.Getenv("VAULT_SKIP_VERIFY"); skip == "1" || skip == "true" {
 		cfg.SkipVerify = true
 	}
+	if batch := os.Getenv("VAULT_BATCH_TOKEN"); batch == "1" || batch == "true" {
+		cfg.BatchToken = true
+	}
 
 	if timeout := os.Getenv("VAULT_TIMEOUT"); timeout != "" {
-		if t, err := strconv.Atoi(timeout); err == nil && t > 0 {
+		if t, err := ParseTimeout(timeout); err == nil {
 			cfg.Timeout = t
 		}
 	}
+	if timeout := os.Getenv("VAULT_CONNECT_TIMEOUT"); timeout != "" {
+		if t, err := ParseTimeout(timeout); err == nil {
+			cfg.ConnectTimeout = t
+		}
+	}
+	if wait := os.Getenv("VAULT_CONTROL_GROUP_WAIT"); wait != "" {
+		if t, err := ParseWait(wait); err == nil {
+			cfg.ControlGroupWait = t
+		}
+	}
+	for _, cred := range strings.Split(os.Getenv("VAULT_MFA"), ",") {
+		if cred = strings.TrimSpace(cred); cred != "" {
+			cfg.MFA = append(cfg.MFA, cred)
+		}
+	}
 	
 	// Set defaults for Kubernetes auth
 	if cfg.K8sJWTPath == "" {
@@ -116,9 +512,18 @@ func GetVaultConfigFromEnv() *VaultConfig {
 	return cfg
 }
 
+// Addresses returns the configured Vault addresses in failover order
+func (c *VaultConfig) Addresses() []string {
+	// A local Vault Agent caches responses and may add its auto-auth token
+	if c.AgentAddr != "" {
+		return []string{c.AgentAddr}
+	}
+	return SplitAddrs(c.Addr)
+}
+
 // Validate checks if the configuration is valid
 func (c *VaultConfig) Validate() error {
-	if c.Addr == "" {
+	if len(c.Addresses()) == 0 {
 		return ErrMissingVaultAddr
 	}
 	
@@ -130,7 +535,7 @@ func (c *VaultConfig) Validate() error {
 	// Validate based on auth method
 	switch c.AuthMethod {
 	case "token":
-		if c.Token == "" {
+		if c.Token == "" && !c.UsesAgentToken() {
 			return ErrMissingVaultToken
 		}
 	case "approle":
@@ -148,13 +553,25 @@ func (c *VaultConfig) Validate() error {
 		if c.K8sRole == "" {
 			return fmt.Errorf("VAULT_K8S_ROLE is required for Kubernetes auth")
 		}
+	case "ldap", "userpass":
+		if c.Username == "" || c.Password == "" {
+			return fmt.Errorf("a username and password are required for %s auth", c.AuthMethod)
+		}
+	case "oidc":
+		// The browser flow needs no stored credentials
 	default:
-		return fmt.Errorf("unsupported or auto-detected auth method: %s. Supported: token, approle, github, kubernetes", c.AuthMethod)
+		return fmt.Errorf("unsupported or auto-detected auth method: %s. Supported: token, approle, github, kubernetes, ldap, userpass, oidc", c.AuthMethod)
 	}
 	
 	return nil
 }
 
+// UsesAgentToken reports whether token auth without a token relies on Vault
+// Agent: a token read from its sink file, or one its listener adds itself
+func (c *VaultConfig) UsesAgentToken() bool {
+	return c.Token == "" && (c.AgentTokenFile != "" || c.AgentAddr != "")
+}
+
 // DetectAuthMethod auto-detects the auth method based on available credentials
 func (c *VaultConfig) DetectAuthMethod() string {
 	// Priority order for auto-detection
@@ -216,13 +633,12 @@ func GetTransitMount(flagValue string) string {
 		return flagValue
 	}
 	
-	envMount := os.Getenv("TRANSIT_MOUNT")
+	envMount := os.Getenv(TransitMountEnv)
 	if envMount != "" {
 		return envMount
 	}
 	
-	// Default to "transit" (this is already the default in CLI flags, but good to be explicit)
-	return "transit"
+	return DefaultTransitMount
 }
 
 // ShouldUseEncryption determines if encryption should be used based on encryption key and TRANSIT env var
@@ -266,16 +682,84 @@ func (s *SecretEntry) IsIndividual() bool {
 	return s.KVPath != "" && s.EnvVar != ""
 }
 
+// IsLiteral returns true if this entry sets a fixed value without reading Vault
+func (s *SecretEntry) IsLiteral() bool {
+	return s.Value != nil && s.EnvVar != "" && s.Path == "" && s.KVPath == ""
+}
+
+// IsTemplate returns true if this entry composes its value from a template
+func (s *SecretEntry) IsTemplate() bool {
+	return s.Template != "" && s.EnvVar != ""
+}
+
 // IsPathAllKeys returns true if this loads all keys from the path
 func (s *SecretEntry) IsPathAllKeys() bool {
 	return s.Path != "" && s.Key == ""
 }
 
+// IsPathGlob returns true if the path ends in a "*" or "**" wildcard
+func (s *SecretEntry) IsPathGlob() bool {
+	_, _, ok := s.PathGlob()
+	return ok
+}
+
+// PathGlob splits a wildcard path into the directory to list and whether to
+// descend into its sub-directories: "app/*" loads the secrets directly under
+// app/, "app/**" every secret below it
+func (s *SecretEntry) PathGlob() (base string, recursive, ok bool) {
+	switch {
+	case s.Path == "**" || strings.HasSuffix(s.Path, "/**"):
+		return strings.TrimSuffix(s.Path, "**"), true, true
+	case s.Path == "*" || strings.HasSuffix(s.Path, "/*"):
+		return strings.TrimSuffix(s.Path, "*"), false, true
+	}
+	return "", false, false
+}
+
 // IsPathSingleKey returns true if this loads a single key from the path
 func (s *SecretEntry) IsPathSingleKey() bool {
 	return s.Path != "" && s.Key != ""
 }
 
+// Describe returns a short human readable description of the entry for messages
+func (s *SecretEntry) Describe() string {
+	switch {
+	case s.IsLiteral():
+		return fmt.Sprintf("literal %s", s.EnvVar)
+	case s.IsTemplate():
+		return fmt.Sprintf("template %s", s.EnvVar)
+	case s.IsPathSingleKey():
+		return fmt.Sprintf("path %s key %s", s.Path, s.Key)
+	case s.IsPathBased():
+		return fmt.Sprintf("path %s", s.Path)
+	case s.Name != "":
+		return fmt.Sprintf("secret %s (%s)", s.Name, s.KVPath)
+	default:
+		return fmt.Sprintf("secret %s", NonEmpty(s.KVPath, s.EnvVar, "<invalid>"))
+	}
+}
+
+// MatchesTags reports whether the entry carries one of the only tags (or only
+// is empty) and none of the skip tags
+func (s *SecretEntry) MatchesTags(only, skip []string) bool {
+	if len(only) > 0 && !s.HasAnyTag(only) {
+		return false
+	}
+	return !s.HasAnyTag(skip)
+}
+
+// HasAnyTag reports whether the entry carries at least one of tags
+func (s *SecretEntry) HasAnyTag(tags []string) bool {
+	for _, tag := range tags {
+		for _, own := range s.Tags {
+			if own == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // GetEnvKeyName returns the environment variable name for this secret
 func (s *SecretEntry) GetEnvKeyName() string {
 	if s.EnvKey != "" {
@@ -287,6 +771,19 @@ func (s *SecretEntry) GetEnvKeyName() string {
 	return ""
 }
 
+// Built-in mounts used when neither a flag, the environment, nor a config sets one
+const (
+	DefaultKVMount      = "kv"
+	DefaultTransitMount = "transit"
+)
+
+// KVMountEnv and TransitMountEnv name the environment variables that set the
+// mounts of every command, below the --kv-mount/--transit-mount flags
+const (
+	KVMountEnv      = "KV_MOUNT"
+	TransitMountEnv = "TRANSIT_MOUNT"
+)
+
 // GetTransitMount returns the transit mount path, with fallback
 func (c *Config) GetTransitMount(defaultMount string) string {
 	if c.Transit != nil && c.Transit.Mount != "" {
@@ -295,10 +792,139 @@ func (c *Config) GetTransitMount(defaultMount string) string {
 	return defaultMount
 }
 
-// GetTransitKey returns the transit encryption key
+// KVMount resolves the KV mount of a command: the mount given by flag or
+// KV_MOUNT, else the config's kv.mount, else DefaultKVMount
+func (c *Config) KVMount(explicit string) string {
+	return NonEmpty(explicit, c.KV.Mount, DefaultKVMount)
+}
+
+// TransitMount resolves the Transit mount of a command: the mount given by
+// flag or TRANSIT_MOUNT, else the config's transit.mount, else
+// DefaultTransitMount
+func (c *Config) TransitMount(explicit string) string {
+	return NonEmpty(explicit, c.GetTransitMount(DefaultTransitMount))
+}
+
+// GetKVMountFor returns the KV mount for a secret entry: its own kv_mount,
+// else the command's mount as resolved by KVMount
+func (c *Config) GetKVMountFor(s *SecretEntry, mount string) string {
+	return NonEmpty(s.KVMount, c.KVMount(mount))
+}
+
+// GetTransitMountFor returns the transit mount for a secret entry: its own
+// transit_mount, else the command's mount as resolved by TransitMount
+func (c *Config) GetTransitMountFor(s *SecretEntry, mount string) string {
+	return NonEmpty(s.TransitMount, c.TransitMount(mount))
+}
+
+// EnvNameFor maps a KV key of a secret entry to an environment variable
+// name using the entry's naming policy merged with the config-level policy
+func (c *Config) EnvNameFor(s *SecretEntry, key string) (string, error) {
+	return s.NamingPolicy.Merge(c.NamingPolicy).EnvName(key)
+}
+
+// FilterByTags keeps the secret entries that carry one of the only tags (all
+// entries if only is empty) and none of the skip tags
+func (c *Config) FilterByTags(only, skip []string) {
+	if len(only) == 0 && len(skip) == 0 {
+		return
+	}
+
+	var kept []SecretEntry
+	for _, secret := range c.Secrets {
+		if secret.MatchesTags(only, skip) {
+			kept = append(kept, secret)
+		}
+	}
+	c.Secrets = kept
+}
+
+// Merge layers overlay on top of c. Non-empty settings in overlay win, secret
+// lists are concatenated (so later entries win for the same variable), and
+// boolean switches are enabled if any layer enables them.
+func (c *Config) Merge(overlay *Config) {
+	if overlay.Version > c.Version {
+		c.Version = overlay.Version
+	}
+
+	if len(overlay.Vault.Addr) > 0 {
+		c.Vault.Addr = overlay.Vault.Addr
+	}
+	c.Vault.Namespace = NonEmpty(overlay.Vault.Namespace, c.Vault.Namespace)
+	c.Vault.CACert = NonEmpty(overlay.Vault.CACert, c.Vault.CACert)
+	c.Vault.CAPath = NonEmpty(overlay.Vault.CAPath, c.Vault.CAPath)
+	c.Vault.TLSServerName = NonEmpty(overlay.Vault.TLSServerName, c.Vault.TLSServerName)
+	c.Vault.TLSMinVersion = NonEmpty(overlay.Vault.TLSMinVersion, c.Vault.TLSMinVersion)
+	c.Vault.AuthMethod = NonEmpty(overlay.Vault.AuthMethod, c.Vault.AuthMethod)
+	c.Vault.Proxy = NonEmpty(overlay.Vault.Proxy, c.Vault.Proxy)
+	c.Vault.SkipVerify = c.Vault.SkipVerify || overlay.Vault.SkipVerify
+	for name, value := range overlay.Vault.Headers {
+		if c.Vault.Headers == nil {
+			c.Vault.Headers = make(map[string]string)
+		}
+		c.Vault.Headers[name] = value
+	}
+
+	if overlay.Transit != nil {
+		transit := *overlay.Transit
+		if c.Transit != nil {
+			transit.Mount = NonEmpty(transit.Mount, c.Transit.Mount)
+			transit.Key = NonEmpty(transit.Key, c.Transit.Key)
+			if transit.MinKeyVersion == 0 {
+				transit.MinKeyVersion = c.Transit.MinKeyVersion
+			}
+			transit.MinKeyVersionAction = NonEmpty(transit.MinKeyVersionAction, c.Transit.MinKeyVersionAction)
+		}
+		c.Transit = &transit
+	}
+	c.KV.Mount = NonEmpty(overlay.KV.Mount, c.KV.Mount)
+	c.Encryption = NonEmpty(overlay.Encryption, c.Encryption)
+	c.LocalEncryption = c.LocalEncryption.merge(overlay.LocalEncryption)
+
+	c.Secrets = append(c.Secrets, overlay.Secrets...)
+	for name, output := range overlay.Outputs {
+		if c.Outputs == nil {
+			c.Outputs = make(map[string]OutputSpec)
+		}
+		c.Outputs[name] = output
+	}
+	for name, profile := range overlay.Auth {
+		if c.Auth == nil {
+			c.Auth = make(map[string]AuthProfile)
+		}
+		c.Auth[name] = profile
+	}
+	c.Strict = c.Strict || overlay.Strict
+	c.ValuePolicy = NonEmpty(overlay.ValuePolicy, c.ValuePolicy)
+	if overlay.MaxSecretSize > 0 {
+		c.MaxSecretSize = overlay.MaxSecretSize
+	}
+	c.NamingPolicy = overlay.NamingPolicy.Merge(c.NamingPolicy)
+}
+
+// GetTransitKey returns the transit encryption key, or LocalKeyName when
+// values are encrypted client-side and no transit key is configured
 func (c *Config) GetTransitKey() string {
-	if c.Transit != nil {
+	if c.Transit != nil && c.Transit.Key != "" {
 		return c.Transit.Key
 	}
+	if c.UsesLocalEncryption() {
+		return LocalKeyName
+	}
 	return ""
 }
+
+// Actions for values encrypted with a key version below transit.min_key_version
+const (
+	KeyVersionFail = "fail"
+	KeyVersionWarn = "warn"
+)
+
+// KeyVersionPolicy returns transit.min_key_version (0 when unset) and what
+// to do about older ciphertext: KeyVersionFail or KeyVersionWarn
+func (c *Config) KeyVersionPolicy() (int, string) {
+	if c.Transit == nil {
+		return 0, KeyVersionFail
+	}
+	return c.Transit.MinKeyVersion, NonEmpty(c.Transit.MinKeyVersionAction, KeyVersionFail)
+}
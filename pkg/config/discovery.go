@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is the project config file name looked up when --config is not given
+const DefaultConfigFile = "vlt.yaml"
+
+// FindConfigFile searches the current directory and its parents for name,
+// like git does for .git. It returns the path and whether it was found.
+func FindConfigFile(name string) (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// UserDefaultsPath returns the location of the user-level defaults file,
+// $XDG_CONFIG_HOME/vault-env/config.yaml or ~/.config/vault-env/config.yaml
+func UserDefaultsPath() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "vault-env", "config.yaml")
+}
+
+// LoadUserDefaults reads the user-level defaults file. A missing file yields
+// an empty config.
+func LoadUserDefaults() (*Config, error) {
+	cfg := &Config{}
+
+	path := UserDefaultsPath()
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read user defaults: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parse user defaults %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ApplyVaultDefaults fills Vault client settings that are not set by flags
+// or environment variables from the config's vault section
+func (c *Config) ApplyVaultDefaults(cfg *VaultConfig) {
+	if cfg.Addr == "" && len(c.Vault.Addr) > 0 {
+		cfg.Addr = JoinAddrs(c.Vault.Addr)
+	}
+	cfg.Namespace = NonEmpty(cfg.Namespace, c.Vault.Namespace)
+	cfg.AuthMethod = NonEmpty(cfg.AuthMethod, c.Vault.AuthMethod)
+	cfg.CACert = NonEmpty(cfg.CACert, c.Vault.CACert)
+	cfg.CAPath = NonEmpty(cfg.CAPath, c.Vault.CAPath)
+	cfg.TLSServerName = NonEmpty(cfg.TLSServerName, c.Vault.TLSServerName)
+	cfg.TLSMinVersion = NonEmpty(cfg.TLSMinVersion, c.Vault.TLSMinVersion)
+	cfg.Proxy = NonEmpty(cfg.Proxy, c.Vault.Proxy)
+	cfg.SkipVerify = cfg.SkipVerify || c.Vault.SkipVerify
+	for name, value := range c.Vault.Headers {
+		cfg.SetHeader(name, value, false)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"os"
+)
+
+// Values of the config's encryption setting
+const (
+	EncryptionTransit = "transit"
+	EncryptionLocal   = "local"
+)
+
+// LocalKeyName stands in for the transit key name when values are encrypted
+// client-side, so commands that need an encryption key encrypt by default
+const LocalKeyName = "local"
+
+// DefaultPassphraseEnv holds the passphrase of local encryption without age
+const DefaultPassphraseEnv = "VLT_PASSPHRASE"
+
+// LocalEncryption configures client-side encryption (encryption: local).
+// Values are encrypted to AgeRecipients when any are set, else with a key
+// derived from the passphrase in PassphraseEnv.
+type LocalEncryption struct {
+	AgeRecipients []string `yaml:"age_recipients,omitempty"`
+	AgeIdentity   string   `yaml:"age_identity,omitempty"`   // identity file that decrypts age-encrypted values
+	PassphraseEnv string   `yaml:"passphrase_env,omitempty"` // default VLT_PASSPHRASE
+}
+
+// UsesLocalEncryption reports whether values are encrypted client-side
+func (c *Config) UsesLocalEncryption() bool {
+	return c.Encryption == EncryptionLocal
+}
+
+// ValidateEncryption checks the encryption setting
+func (c *Config) ValidateEncryption() error {
+	switch c.Encryption {
+	case "", EncryptionTransit, EncryptionLocal:
+		return nil
+	}
+	return fmt.Errorf("encryption must be %q or %q, got %q", EncryptionTransit, EncryptionLocal, c.Encryption)
+}
+
+// Passphrase returns the passphrase of local encryption and the name of the
+// environment variable it is read from
+func (l *LocalEncryption) Passphrase() (string, string) {
+	name := DefaultPassphraseEnv
+	if l != nil && l.PassphraseEnv != "" {
+		name = l.PassphraseEnv
+	}
+	return os.Getenv(name), name
+}
+
+// merge returns l with the non-empty settings of overlay applied
+func (l *LocalEncryption) merge(overlay *LocalEncryption) *LocalEncryption {
+	if overlay == nil {
+		return l
+	}
+	merged := *overlay
+	if l != nil {
+		if len(merged.AgeRecipients) == 0 {
+			merged.AgeRecipients = l.AgeRecipients
+		}
+		merged.AgeIdentity = NonEmpty(merged.AgeIdentity, l.AgeIdentity)
+		merged.PassphraseEnv = NonEmpty(merged.PassphraseEnv, l.PassphraseEnv)
+	}
+	return &merged
+}
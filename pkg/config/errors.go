@@ -7,7 +7,7 @@ var (
 	ErrMissingVaultAddr = errors.New("VAULT_ADDR environment variable is required")
 
 	// ErrMissingVaultToken is returned when VAULT_TOKEN is not set
-	ErrMissingVaultToken = errors.New("VAULT_TOKEN environment variable is required")
+	ErrMissingVaultToken = errors.New("VAULT_TOKEN environment variable is required (or store a token with 'vlt login')")
 
 	// ErrInvalidConfig is returned when configuration is invalid
 	ErrInvalidConfig = errors.New("invalid configuration")
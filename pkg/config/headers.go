@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// CorrelationHeader carries the ID shared by all Vault requests of one invocation
+const CorrelationHeader = "X-Correlation-Id"
+
+// reservedHeaders are set by the Vault client itself; overriding them would
+// change the identity or namespace of requests
+var reservedHeaders = map[string]bool{
+	"X-Vault-Token":     true,
+	"X-Vault-Namespace": true,
+	"X-Vault-Wrap-Ttl":  true,
+	"X-Vault-Request":   true,
+}
+
+// ParseHeader splits a "Name: value" header as given on the command line
+func ParseHeader(header string) (string, string, error) {
+	name, value, ok := strings.Cut(header, ":")
+	if !ok {
+		return "", "", fmt.Errorf("invalid header %q (expected \"Name: value\")", header)
+	}
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if err := checkHeader(name, value); err != nil {
+		return "", "", err
+	}
+	return name, value, nil
+}
+
+// checkHeader rejects header names and values that are not valid HTTP, and
+// the headers the Vault client sets itself
+func checkHeader(name, value string) error {
+	if !httpguts.ValidHeaderFieldName(name) {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	if !httpguts.ValidHeaderFieldValue(value) {
+		return fmt.Errorf("invalid value for header %s", name)
+	}
+	if reservedHeaders[http.CanonicalHeaderKey(name)] {
+		return fmt.Errorf("header %s is set by vlt and cannot be overridden", name)
+	}
+	return nil
+}
+
+// SetHeader adds a header sent with every Vault request. Unless override is
+// set, a header that is already configured keeps its value, so command-line
+// headers win over those of the defaults file.
+func (c *VaultConfig) SetHeader(name, value string, override bool) {
+	name = http.CanonicalHeaderKey(name)
+	if c.Headers == nil {
+		c.Headers = make(map[string]string)
+	}
+	if _, ok := c.Headers[name]; ok && !override {
+		return
+	}
+	c.Headers[name] = value
+}
+
+// ValidateHeaders checks the configured headers, including those read from
+// config files
+func (c *VaultConfig) ValidateHeaders() error {
+	for name, value := range c.Headers {
+		if err := checkHeader(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfilesPath returns the location of the connection profiles file, next
+// to the user defaults: ~/.config/vault-env/profiles.yaml
+func ProfilesPath() string {
+	defaults := UserDefaultsPath()
+	if defaults == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(defaults), "profiles.yaml")
+}
+
+// LoadProfile reads a named connection profile from the profiles file. Only
+// its vault, kv, and transit settings are kept.
+func LoadProfile(name string) (*Config, error) {
+	path := ProfilesPath()
+	if path == "" {
+		return nil, fmt.Errorf("profile %q: cannot locate the profiles file", name)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("profile %q: %s does not exist", name, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read profiles: %w", err)
+	}
+
+	var file struct {
+		Profiles map[string]Config `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse profiles %s: %w", path, err)
+	}
+	profile, ok := file.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(file.Profiles))
+		for n := range file.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile %q is not defined in %s (available: %s)", name, path, strings.Join(names, ", "))
+	}
+
+	return &Config{Vault: profile.Vault, KV: profile.KV, Transit: profile.Transit}, nil
+}
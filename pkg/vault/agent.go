@@ -0,0 +1,98 @@
+package vault
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// agentSink reads the token a Vault Agent auto-auth file sink writes, and
+// reads it again when the agent replaces the file with a renewed token
+type agentSink struct {
+	path string
+
+	mu      sync.Mutex
+	current string
+	modTime time.Time
+	size    int64
+	issued  map[string]bool // every token read from the file so far
+}
+
+func newAgentSink(path string) *agentSink {
+	return &agentSink{path: path, issued: make(map[string]bool)}
+}
+
+// token returns the sink's token, re-reading the file when its size or
+// modification time changed. Once a token has been read, a missing or empty
+// file (the agent is rewriting it) keeps the previous one.
+func (s *agentSink) token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		if s.current != "" {
+			return s.current, nil
+		}
+		return "", fmt.Errorf("read Vault Agent token file: %w", err)
+	}
+	if s.current != "" && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return s.current, nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if s.current != "" {
+			return s.current, nil
+		}
+		return "", fmt.Errorf("read Vault Agent token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	switch {
+	case token == "" && s.current != "":
+		return s.current, nil
+	case token == "":
+		return "", fmt.Errorf("Vault Agent token file %s is empty", s.path)
+	case strings.HasPrefix(token, "{"):
+		return "", fmt.Errorf("Vault Agent token file %s holds a wrapped or encrypted token; configure a plain file sink", s.path)
+	}
+
+	s.current, s.modTime, s.size = token, info.ModTime(), info.Size()
+	s.issued[token] = true
+	return token, nil
+}
+
+// wasIssued reports whether token was read from the sink at some point
+func (s *agentSink) wasIssued(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued[token]
+}
+
+// agentSinkTransport sends the sink's current token in place of an older one
+// it issued, so long-running commands follow the agent's token rotation.
+// Requests with other tokens, such as a working token created from it, are
+// passed through unchanged.
+type agentSinkTransport struct {
+	next http.RoundTripper
+	sink *agentSink
+}
+
+func (t *agentSinkTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	sent := req.Header.Get("X-Vault-Token")
+	if sent != "" && t.sink.wasIssued(sent) {
+		if token, err := t.sink.token(); err == nil && token != sent {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Vault-Token", token)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Unwrap returns the wrapped transport
+func (t *agentSinkTransport) Unwrap() http.RoundTripper {
+	return t.next
+}
@@ -0,0 +1,40 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrRequestBudget is returned once a client has made its maximum number of
+// Vault requests
+var ErrRequestBudget = errors.New("vault request budget exhausted")
+
+// budgetTransport fails requests beyond a fixed number
+type budgetTransport struct {
+	base  http.RoundTripper
+	limit int64
+	used  atomic.Int64
+}
+
+// limitRequests returns base wrapped so that at most limit requests are sent
+func limitRequests(base http.RoundTripper, limit int) http.RoundTripper {
+	return &budgetTransport{base: base, limit: int64(limit)}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *budgetTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.used.Add(1) > t.limit {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %d requests made", ErrRequestBudget, t.limit)
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Unwrap returns the wrapped transport
+func (t *budgetTransport) Unwrap() http.RoundTripper {
+	return t.base
+}
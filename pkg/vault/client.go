@@ -2,11 +2,12 @@ package vault
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -14,55 +15,142 @@ import (
 	vaultapi "github.com/hashicorp/vault/api"
 	// Auth methods implemented directly
 
+	"github.com/razzkumar/vlt/internal/audit"
+	"github.com/razzkumar/vlt/internal/buildinfo"
+	"github.com/razzkumar/vlt/internal/metrics"
 	"github.com/razzkumar/vlt/pkg/config"
 )
 
 // Client wraps the Vault API client with our specific functionality
 type Client struct {
-	client *vaultapi.Client
-	config *config.VaultConfig
+	client     *vaultapi.Client
+	config     *config.VaultConfig
+	keyVersion int // transit key version to encrypt with; 0 for the latest
+
+	// Client-side encryption in place of Transit (see WithLocalCipher)
+	local        LocalCipher
+	localEncrypt bool
 }
 
 // NewClient creates a new Vault client
 func NewClient(cfg *config.VaultConfig) (*Client, error) {
+	if cfg.Replay != "" {
+		if err := applyReplayDefaults(cfg); err != nil {
+			return nil, err
+		}
+	}
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
 
+	addrs := cfg.Addresses()
+	socket, isSocket := unixSocket(addrs[0])
+	if isSocket {
+		if len(addrs) > 1 {
+			return nil, fmt.Errorf("a unix:// vault address cannot be combined with failover addresses")
+		}
+		addrs = []string{unixSocketAddr}
+	}
+
 	vaultConfig := vaultapi.DefaultConfig()
-	vaultConfig.Address = cfg.Addr
+	vaultConfig.Address = addrs[0]
 	vaultConfig.Timeout = time.Duration(cfg.Timeout) * time.Second
 
-	if cfg.CACert != "" || cfg.SkipVerify {
-		err := vaultConfig.ConfigureTLS(&vaultapi.TLSConfig{
-			CACert:   cfg.CACert,
-			Insecure: cfg.SkipVerify,
-		})
+	// TLS and proxy settings are applied before the first request, which may
+	// be a health check or an auth login
+	if err := configureTLS(vaultConfig, cfg); err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+	if tr, ok := vaultConfig.HttpClient.Transport.(*http.Transport); ok {
+		if err := configureTransport(tr, cfg, socket); err != nil {
+			return nil, err
+		}
+	}
+	// A Vault Agent sink file stands in for VAULT_TOKEN
+	var sink *agentSink
+	if cfg.AuthMethod == "token" && cfg.Token == "" && cfg.AgentTokenFile != "" {
+		sink = newAgentSink(cfg.AgentTokenFile)
+		if _, err := sink.token(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+		}
+		vaultConfig.HttpClient.Transport = &agentSinkTransport{next: vaultConfig.HttpClient.Transport, sink: sink}
+	}
+	switch {
+	case cfg.Replay != "":
+		replay, err := loadReplayer(cfg.Replay)
 		if err != nil {
-			return nil, fmt.Errorf("failed to configure TLS: %w", err)
+			return nil, err
 		}
+		vaultConfig.HttpClient.Transport = replay
+		// Without a network, a retry gets the same answer
+		vaultConfig.MaxRetries = 0
+	case cfg.Record != "":
+		vaultConfig.HttpClient.Transport = recordTransport(vaultConfig.HttpClient.Transport, cfg.Record, addrs[0])
 	}
+	if cfg.MaxRequests > 0 {
+		vaultConfig.HttpClient.Transport = limitRequests(vaultConfig.HttpClient.Transport, cfg.MaxRequests)
+		// Retrying would only spend more of the budget
+		vaultConfig.CheckRetry = func(ctx context.Context, resp *http.Response, err error) (bool, error) {
+			if errors.Is(err, ErrRequestBudget) {
+				return false, err
+			}
+			return vaultapi.DefaultRetryPolicy(ctx, resp, err)
+		}
+	}
+	vaultConfig.HttpClient.Transport = audit.InstrumentTransport(metrics.InstrumentTransport(vaultConfig.HttpClient.Transport))
 
 	client, err := vaultapi.NewClient(vaultConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create vault client: %w", err)
 	}
+	// Lets Vault audit logs attribute requests to vlt and its version
+	client.AddHeader("User-Agent", buildinfo.UserAgent())
+	// Gateways in front of Vault may route or enrich audit records on these
+	headers := client.Headers()
+	if cfg.CorrelationID != "" {
+		headers.Set(config.CorrelationHeader, cfg.CorrelationID)
+	}
+	for name, value := range cfg.Headers {
+		headers.Set(name, value)
+	}
+	client.SetHeaders(headers)
 
 	if cfg.Namespace != "" {
 		client.SetNamespace(cfg.Namespace)
 	}
+	// Step-up MFA credentials, answered by login MFA and path-level MFA alike
+	if len(cfg.MFA) > 0 {
+		client.SetMFACreds(cfg.MFA)
+	}
+	if cfg.RateLimit > 0 {
+		client.SetLimiter(cfg.RateLimit, max(cfg.Burst, 1))
+	}
+
+	// Fail over to the first healthy server when several are configured
+	if err := selectHealthyAddress(client, addrs, connectTimeout(cfg)); err != nil {
+		return nil, err
+	}
 
 	// Authenticate and get token
 	token, err := authenticateVault(client, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("authentication failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+	}
+	if sink != nil {
+		if token, err = sink.token(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuth, err)
+		}
 	}
 
 	client.SetToken(token)
 
-	// Configure TLS properly
-	if tr, ok := vaultConfig.HttpClient.Transport.(*http.Transport); ok && tr.TLSClientConfig == nil {
-		tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	// Trade the login token for a scoped one for the actual KV and Transit calls
+	if cfg.TokenRole != "" || cfg.BatchToken {
+		working, err := createWorkingToken(client, cfg)
+		if err != nil {
+			return nil, err
+		}
+		client.SetToken(working)
 	}
 
 	return &Client{
@@ -71,11 +159,117 @@ func NewClient(cfg *config.VaultConfig) (*Client, error) {
 	}, nil
 }
 
+// WithNamespace returns a copy of the client that sends requests to the given namespace
+func (c *Client) WithNamespace(namespace string) *Client {
+	if namespace == "" {
+		return c
+	}
+	scoped := *c
+	scoped.client = c.client.WithNamespace(namespace)
+	return &scoped
+}
+
+// WithKeyVersion returns a copy of the client that encrypts with the given
+// transit key version instead of the latest one
+func (c *Client) WithKeyVersion(version int) *Client {
+	if version == 0 {
+		return c
+	}
+	scoped := *c
+	scoped.keyVersion = version
+	return &scoped
+}
+
+// WithAuth returns a new client for the same servers, namespace, and
+// connection settings that logs in with an auth profile instead of the
+// client's own credentials. A namespace set on the profile takes precedence.
+func (c *Client) WithAuth(profile *config.AuthProfile) (*Client, error) {
+	cfg := *c.config
+	cfg.Namespace = c.client.Namespace()
+	if err := profile.ApplyTo(&cfg); err != nil {
+		return nil, err
+	}
+	client, err := NewClient(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	client.keyVersion, client.local, client.localEncrypt = c.keyVersion, c.local, c.localEncrypt
+	return client, nil
+}
+
+// WithAddress returns a copy of the client that talks to the first healthy
+// server in addrs, reusing the current token and namespace
+func (c *Client) WithAddress(addrs []string) (*Client, error) {
+	if len(addrs) == 0 {
+		return c, nil
+	}
+	for _, addr := range addrs {
+		if _, ok := unixSocket(addr); ok {
+			return nil, fmt.Errorf("unix:// vault addresses are only supported in VAULT_ADDR, not for %s", addr)
+		}
+	}
+
+	clone, err := c.client.CloneWithHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone vault client: %w", err)
+	}
+	clone.SetToken(c.client.Token())
+
+	if err := selectHealthyAddress(clone, addrs, connectTimeout(c.config)); err != nil {
+		return nil, err
+	}
+
+	scoped := *c
+	scoped.client = clone
+	return &scoped, nil
+}
+
+// selectHealthyAddress points client at the first address whose sys/health
+// reports an initialized, unsealed, active (or performance standby) node.
+// A single address is used as-is without a health check.
+func selectHealthyAddress(client *vaultapi.Client, addrs []string, timeout time.Duration) error {
+	if len(addrs) == 1 {
+		return client.SetAddress(addrs[0])
+	}
+
+	var failures []string
+	for _, addr := range addrs {
+		if err := client.SetAddress(addr); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", addr, err))
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		health, err := client.Sys().HealthWithContext(ctx)
+		cancel()
+
+		switch {
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", addr, err))
+		case !health.Initialized:
+			failures = append(failures, fmt.Sprintf("%s: not initialized", addr))
+		case health.Sealed:
+			failures = append(failures, fmt.Sprintf("%s: sealed", addr))
+		case health.ReplicationDRMode == "secondary":
+			failures = append(failures, fmt.Sprintf("%s: DR secondary", addr))
+		case health.Standby && !health.PerformanceStandby:
+			failures = append(failures, fmt.Sprintf("%s: standby", addr))
+		default:
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no healthy vault server available: %s", strings.Join(failures, "; "))
+}
+
 // TransitEncrypt encrypts plaintext using Vault's Transit secrets engine
 func (c *Client) TransitEncrypt(transitMount, keyName string, plaintext []byte) (string, error) {
 	if keyName == "" {
 		return "", errors.New("transit key name required")
 	}
+	if c.localEncrypt {
+		return c.local.Encrypt(plaintext)
+	}
 
 	b64 := base64.StdEncoding.EncodeToString(plaintext)
 	path := fmt.Sprintf("%s/encrypt/%s", strings.TrimSuffix(transitMount, "/"), keyName)
@@ -83,11 +277,13 @@ func (c *Client) TransitEncrypt(transitMount, keyName string, plaintext []byte)
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
 	defer cancel()
 
-	secret, err := c.client.Logical().WriteWithContext(ctx, path, map[string]interface{}{
-		"plaintext": b64,
-	})
+	body := map[string]interface{}{"plaintext": b64}
+	if c.keyVersion > 0 {
+		body["key_version"] = c.keyVersion
+	}
+	secret, err := c.client.Logical().WriteWithContext(ctx, path, body)
 	if err != nil {
-		return "", fmt.Errorf("transit encrypt failed: %w", err)
+		return "", fmt.Errorf("transit encrypt failed: %w", classify(err))
 	}
 
 	ciphertext, ok := secret.Data["ciphertext"].(string)
@@ -103,6 +299,12 @@ func (c *Client) TransitDecrypt(transitMount, keyName, ciphertext string) ([]byt
 	if keyName == "" {
 		return nil, errors.New("transit key name required")
 	}
+	if IsLocalCiphertext(ciphertext) {
+		if c.local == nil {
+			return nil, fmt.Errorf("%w: value is encrypted client-side; configure local_encryption to read it", ErrDecryptFailed)
+		}
+		return c.local.Decrypt(ciphertext)
+	}
 
 	path := fmt.Sprintf("%s/decrypt/%s", strings.TrimSuffix(transitMount, "/"), keyName)
 
@@ -113,22 +315,87 @@ func (c *Client) TransitDecrypt(transitMount, keyName, ciphertext string) ([]byt
 		"ciphertext": ciphertext,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("transit decrypt failed: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrDecryptFailed, classify(err))
+	}
+	if secret, err = c.approved(path, secret); err != nil {
+		return nil, err
 	}
 
 	b64, ok := secret.Data["plaintext"].(string)
 	if !ok || b64 == "" {
-		return nil, errors.New("plaintext missing in transit response")
+		return nil, fmt.Errorf("%w: plaintext missing in transit response", ErrDecryptFailed)
 	}
 
 	dec, err := base64.StdEncoding.DecodeString(b64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode plaintext: %w", err)
+		return nil, fmt.Errorf("%w: failed to decode plaintext: %w", ErrDecryptFailed, err)
 	}
 
 	return dec, nil
 }
 
+// TransitDataKey generates a new data key under a transit key and returns it
+// both in plaintext, for encrypting locally, and wrapped by the transit key,
+// for storing next to the data
+func (c *Client) TransitDataKey(transitMount, keyName string) ([]byte, string, error) {
+	if keyName == "" {
+		return nil, "", errors.New("transit key name required")
+	}
+
+	path := fmt.Sprintf("%s/datakey/plaintext/%s", strings.TrimSuffix(transitMount, "/"), keyName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().WriteWithContext(ctx, path, map[string]interface{}{
+		"bits": 256,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("transit datakey failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, "", errors.New("empty transit datakey response")
+	}
+
+	b64, _ := secret.Data["plaintext"].(string)
+	ciphertext, _ := secret.Data["ciphertext"].(string)
+	if b64 == "" || ciphertext == "" {
+		return nil, "", errors.New("plaintext or ciphertext missing in transit datakey response")
+	}
+	key, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode data key: %w", err)
+	}
+	return key, ciphertext, nil
+}
+
+// TransitKeyVersion returns the latest version of a transit key, the one
+// new ciphertext is encrypted with
+func (c *Client) TransitKeyVersion(transitMount, keyName string) (int, error) {
+	path := fmt.Sprintf("%s/keys/%s", strings.TrimSuffix(transitMount, "/"), keyName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return 0, fmt.Errorf("transit key read failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return 0, ErrSecretNotFound
+	}
+
+	v, ok := secret.Data["latest_version"].(json.Number)
+	if !ok {
+		return 0, errors.New("unexpected transit key format: missing 'latest_version' field")
+	}
+	n, err := v.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("transit key latest_version: %w", err)
+	}
+	return int(n), nil
+}
+
 // KVPut stores data in Vault's KV v2 secrets engine
 func (c *Client) KVPut(mount, path string, data map[string]interface{}) error {
 	apiPath := fmt.Sprintf("%s/data/%s", strings.TrimSuffix(mount, "/"), strings.TrimPrefix(path, "/"))
@@ -139,7 +406,42 @@ func (c *Client) KVPut(mount, path string, data map[string]interface{}) error {
 
 	_, err := c.client.Logical().WriteWithContext(ctx, apiPath, payload)
 	if err != nil {
-		return fmt.Errorf("kv put failed: %w", err)
+		return fmt.Errorf("kv put failed: %w", classify(err))
+	}
+
+	return nil
+}
+
+// KVPutCAS stores data like KVPut, but only if the secret's current version
+// is still version (0 means the secret must not exist yet)
+func (c *Client) KVPutCAS(mount, path string, data map[string]interface{}, version int) error {
+	apiPath := fmt.Sprintf("%s/data/%s", strings.TrimSuffix(mount, "/"), strings.TrimPrefix(path, "/"))
+	payload := map[string]interface{}{
+		"data":    data,
+		"options": map[string]interface{}{"cas": version},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	_, err := c.client.Logical().WriteWithContext(ctx, apiPath, payload)
+	if err != nil {
+		return fmt.Errorf("kv put failed: %w", classify(err))
+	}
+
+	return nil
+}
+
+// KVDelete deletes the latest version of a secret in Vault's KV v2 secrets
+// engine. Earlier versions and the metadata are kept.
+func (c *Client) KVDelete(mount, path string) error {
+	apiPath := fmt.Sprintf("%s/data/%s", strings.TrimSuffix(mount, "/"), strings.TrimPrefix(path, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := c.client.Logical().DeleteWithContext(ctx, apiPath); err != nil {
+		return fmt.Errorf("kv delete failed: %w", classify(err))
 	}
 
 	return nil
@@ -154,26 +456,143 @@ func (c *Client) KVGet(mount, path string) (map[string]interface{}, error) {
 
 	secret, err := c.client.Logical().ReadWithContext(ctx, apiPath)
 	if err != nil {
-		return nil, fmt.Errorf("kv get failed: %w", err)
+		return nil, fmt.Errorf("kv get failed: %w", classify(err))
+	}
+	if secret, err = c.approved(apiPath, secret); err != nil {
+		return nil, err
 	}
 
 	if secret == nil || secret.Data == nil {
-		return nil, errors.New("no data returned from vault")
+		return nil, ErrSecretNotFound
 	}
 
 	inner, ok := secret.Data["data"].(map[string]interface{})
 	if !ok {
-		return nil, errors.New("unexpected kv v2 format: missing 'data' field")
+		return nil, fmt.Errorf("%w: %s/ answered without a 'data' field", ErrNotKVv2, strings.TrimSuffix(mount, "/"))
 	}
 
 	return inner, nil
 }
 
+// KVGetVersion retrieves data like KVGet, together with the version it was
+// read at, for a later KVPutCAS. When the secret does not exist or its latest
+// version is deleted, it returns ErrSecretNotFound and the version a
+// check-and-set write has to name: 0 for a secret that never existed.
+func (c *Client) KVGetVersion(mount, path string) (map[string]interface{}, int, error) {
+	apiPath := fmt.Sprintf("%s/data/%s", strings.TrimSuffix(mount, "/"), strings.TrimPrefix(path, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, apiPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("kv get failed: %w", classify(err))
+	}
+	if secret, err = c.approved(apiPath, secret); err != nil {
+		return nil, 0, err
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, 0, ErrSecretNotFound
+	}
+
+	version := 0
+	metadata, hasMetadata := secret.Data["metadata"].(map[string]interface{})
+	if v, ok := metadata["version"].(json.Number); ok {
+		if n, err := v.Int64(); err == nil {
+			version = int(n)
+		}
+	}
+
+	inner, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		if hasMetadata {
+			// Vault answers a deleted or destroyed version with its metadata
+			return nil, version, ErrSecretNotFound
+		}
+		return nil, 0, fmt.Errorf("%w: %s/ answered without a 'data' field", ErrNotKVv2, strings.TrimSuffix(mount, "/"))
+	}
+
+	return inner, version, nil
+}
+
+// KVList lists the entries under a path in Vault's KV v2 secrets engine.
+// Entries ending in "/" are sub-paths; the rest are secrets.
+func (c *Client) KVList(mount, path string) ([]string, error) {
+	apiPath := fmt.Sprintf("%s/metadata/%s", strings.TrimSuffix(mount, "/"), strings.Trim(path, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ListWithContext(ctx, apiPath)
+	if err != nil {
+		return nil, fmt.Errorf("kv list failed: %w", classify(err))
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	rawKeys, ok := secret.Data["keys"].([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected kv list format: missing 'keys' field")
+	}
+
+	keys := make([]string, 0, len(rawKeys))
+	for _, k := range rawKeys {
+		if key, ok := k.(string); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// KVMetadata holds version metadata for a KV v2 secret
+type KVMetadata struct {
+	CurrentVersion int
+	CreatedTime    time.Time
+	UpdatedTime    time.Time
+}
+
+// KVGetMetadata retrieves version metadata for a secret in Vault's KV v2 secrets engine
+func (c *Client) KVGetMetadata(mount, path string) (*KVMetadata, error) {
+	apiPath := fmt.Sprintf("%s/metadata/%s", strings.TrimSuffix(mount, "/"), strings.Trim(path, "/"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, apiPath)
+	if err != nil {
+		return nil, fmt.Errorf("kv metadata failed: %w", classify(err))
+	}
+
+	if secret == nil || secret.Data == nil {
+		return nil, ErrSecretNotFound
+	}
+
+	meta := &KVMetadata{}
+	if v, ok := secret.Data["current_version"].(json.Number); ok {
+		if n, err := v.Int64(); err == nil {
+			meta.CurrentVersion = int(n)
+		}
+	}
+	if v, ok := secret.Data["created_time"].(string); ok {
+		meta.CreatedTime, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	if v, ok := secret.Data["updated_time"].(string); ok {
+		meta.UpdatedTime, _ = time.Parse(time.RFC3339Nano, v)
+	}
+
+	return meta, nil
+}
+
 // authenticateVault performs authentication based on the configured method
 func authenticateVault(client *vaultapi.Client, cfg *config.VaultConfig) (string, error) {
 	switch cfg.AuthMethod {
 	case "token":
-		if cfg.Token == "" {
+		// Without a token, a Vault Agent sink or listener supplies it
+		if cfg.Token == "" && !cfg.UsesAgentToken() {
 			return "", fmt.Errorf("token is required for token auth")
 		}
 		return cfg.Token, nil
@@ -187,11 +606,43 @@ func authenticateVault(client *vaultapi.Client, cfg *config.VaultConfig) (string
 	case "kubernetes":
 		return authenticateKubernetes(client, cfg)
 
+	case "ldap", "userpass":
+		return authenticateUserpass(client, cfg)
+
+	case "oidc":
+		return authenticateOIDC(client, cfg)
+
 	default:
 		return "", fmt.Errorf("unsupported auth method: %s", cfg.AuthMethod)
 	}
 }
 
+// createWorkingToken creates a child of the client's token against
+// cfg.TokenRole, whose settings bound its policies and TTL, and as a batch
+// token when cfg.BatchToken is set
+func createWorkingToken(client *vaultapi.Client, cfg *config.VaultConfig) (string, error) {
+	path := "auth/token/create"
+	if cfg.TokenRole != "" {
+		path += "/" + strings.Trim(cfg.TokenRole, "/")
+	}
+	data := map[string]interface{}{}
+	if cfg.BatchToken {
+		data["type"] = "batch"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return "", fmt.Errorf("create working token at %s: %w", path, classify(err))
+	}
+	if secret == nil || secret.Auth == nil || secret.Auth.ClientToken == "" {
+		return "", fmt.Errorf("create working token at %s: no token was returned", path)
+	}
+	return secret.Auth.ClientToken, nil
+}
+
 // authenticateAppRole performs AppRole authentication
 func authenticateAppRole(client *vaultapi.Client, cfg *config.VaultConfig) (string, error) {
 	data := map[string]interface{}{
@@ -202,15 +653,12 @@ func authenticateAppRole(client *vaultapi.Client, cfg *config.VaultConfig) (stri
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
 	defer cancel()
 
-	secret, err := client.Logical().WriteWithContext(ctx, "auth/approle/login", data)
+	path := fmt.Sprintf("auth/%s/login", config.NonEmpty(cfg.AuthPath, "approle"))
+	secret, err := client.Logical().WriteWithContext(ctx, path, data)
 	if err != nil {
 		return "", fmt.Errorf("unable to login to AppRole auth method: %w", err)
 	}
-	if secret == nil || secret.Auth == nil {
-		return "", fmt.Errorf("no auth info was returned after login")
-	}
-
-	return secret.Auth.ClientToken, nil
+	return loginToken(client, cfg, secret)
 }
 
 // authenticateGitHub performs GitHub personal access token authentication
@@ -226,11 +674,25 @@ func authenticateGitHub(client *vaultapi.Client, cfg *config.VaultConfig) (strin
 	if err != nil {
 		return "", fmt.Errorf("unable to login to GitHub auth method: %w", err)
 	}
-	if secret == nil || secret.Auth == nil {
-		return "", fmt.Errorf("no auth info was returned after login")
+	return loginToken(client, cfg, secret)
+}
+
+// authenticateUserpass performs LDAP or userpass authentication, which share
+// the same login endpoint
+func authenticateUserpass(client *vaultapi.Client, cfg *config.VaultConfig) (string, error) {
+	data := map[string]interface{}{
+		"password": cfg.Password,
 	}
 
-	return secret.Auth.ClientToken, nil
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	path := fmt.Sprintf("auth/%s/login/%s", config.NonEmpty(cfg.AuthPath, cfg.AuthMethod), url.PathEscape(cfg.Username))
+	secret, err := client.Logical().WriteWithContext(ctx, path, data)
+	if err != nil {
+		return "", fmt.Errorf("unable to login to %s auth method: %w", cfg.AuthMethod, err)
+	}
+	return loginToken(client, cfg, secret)
 }
 
 // authenticateKubernetes performs Kubernetes service account authentication
@@ -255,9 +717,5 @@ func authenticateKubernetes(client *vaultapi.Client, cfg *config.VaultConfig) (s
 	if err != nil {
 		return "", fmt.Errorf("unable to login to Kubernetes auth method: %w", err)
 	}
-	if secret == nil || secret.Auth == nil {
-		return "", fmt.Errorf("no auth info was returned after login")
-	}
-
-	return secret.Auth.ClientToken, nil
+	return loginToken(client, cfg, secret)
 }
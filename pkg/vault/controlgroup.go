@@ -0,0 +1,104 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// controlGroupPollInterval is how often a pending control group request is
+// checked for approval
+const controlGroupPollInterval = 5 * time.Second
+
+// controlGroupStatus is the answer of sys/control-group/request
+type controlGroupStatus struct {
+	Approved  bool
+	Approvers []string // names (or entity IDs) of the entities that authorized it so far
+}
+
+// approved returns the response to a request on path. A path protected by a
+// Vault Enterprise control group answers with a wrapping token instead of
+// data; the request is then polled until enough approvers authorized it,
+// for up to ControlGroupWait, and the original response unwrapped.
+func (c *Client) approved(path string, secret *vaultapi.Secret) (*vaultapi.Secret, error) {
+	if secret == nil || secret.Data != nil || secret.WrapInfo == nil || secret.WrapInfo.Accessor == "" {
+		return secret, nil
+	}
+	accessor := secret.WrapInfo.Accessor
+	wait := time.Duration(c.config.ControlGroupWait) * time.Second
+
+	fmt.Fprintf(os.Stderr, "%s is protected by a control group. Ask an approver to run:\n\n    vault write sys/control-group/authorize accessor=%s\n\n", path, accessor)
+	if wait <= 0 {
+		return nil, NewError(ErrPermissionDenied, fmt.Errorf("%s requires control group approval (request accessor %s); set VAULT_CONTROL_GROUP_WAIT to wait for it", path, accessor))
+	}
+	fmt.Fprintf(os.Stderr, "Waiting up to %s for approval...\n", wait)
+
+	deadline := time.Now().Add(wait)
+	reported := make(map[string]bool)
+	var approvers []string
+	for {
+		status, err := c.controlGroupStatus(accessor)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range status.Approvers {
+			if !reported[name] {
+				reported[name] = true
+				approvers = append(approvers, name)
+				fmt.Fprintf(os.Stderr, "Approved by %s\n", name)
+			}
+		}
+		if status.Approved {
+			break
+		}
+		if time.Now().After(deadline) {
+			approvedBy := "nobody yet"
+			if len(approvers) > 0 {
+				approvedBy = strings.Join(approvers, ", ")
+			}
+			return nil, NewError(ErrPermissionDenied, fmt.Errorf("%s: control group request %s was not approved within %s (approved by %s); raise VAULT_CONTROL_GROUP_WAIT or run again", path, accessor, wait, approvedBy))
+		}
+		time.Sleep(controlGroupPollInterval)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+	unwrapped, err := c.client.Logical().UnwrapWithContext(ctx, secret.WrapInfo.Token)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap approved control group response: %w", classify(err))
+	}
+	return unwrapped, nil
+}
+
+// controlGroupStatus returns the approval state of a control group request
+func (c *Client) controlGroupStatus(accessor string) (*controlGroupStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().WriteWithContext(ctx, "sys/control-group/request", map[string]interface{}{
+		"accessor": accessor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("check control group request: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("check control group request: Vault returned no status")
+	}
+	status := &controlGroupStatus{}
+	status.Approved, _ = secret.Data["approved"].(bool)
+	authorizations, _ := secret.Data["authorizations"].([]interface{})
+	for _, raw := range authorizations {
+		auth, _ := raw.(map[string]interface{})
+		name, _ := auth["entity_name"].(string)
+		id, _ := auth["entity_id"].(string)
+		if name = config.NonEmpty(name, id); name != "" {
+			status.Approvers = append(status.Approvers, name)
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,180 @@
+package vault
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// Errors returned by the client, matched with errors.Is. Failed requests
+// keep Vault's own message; these only classify them.
+var (
+	// ErrAuth is returned when authenticating to Vault fails, or Vault
+	// answers 401
+	ErrAuth = errors.New("authentication failed")
+
+	// ErrSecretNotFound is returned when a KV path or other object holds no
+	// data, or Vault answers 404
+	ErrSecretNotFound = errors.New("no data returned from vault")
+
+	// ErrPermissionDenied is returned when Vault answers 403
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrDecryptFailed is returned when transit decryption fails
+	ErrDecryptFailed = errors.New("transit decrypt failed")
+
+	// ErrCASMismatch is returned when a KV v2 write is rejected because the
+	// check-and-set version no longer matches the secret
+	ErrCASMismatch = errors.New("check-and-set version mismatch")
+
+	// ErrNotKVv2 is returned when a mount does not answer like a KV v2
+	// secrets engine
+	ErrNotKVv2 = errors.New("not a KV v2 secrets engine")
+)
+
+// Older names of the errors above
+var (
+	// Deprecated: use ErrSecretNotFound
+	ErrNotFound = ErrSecretNotFound
+
+	// Deprecated: use ErrDecryptFailed
+	ErrDecrypt = ErrDecryptFailed
+)
+
+// Error is an error classified by one of the Err values above. Its message
+// is that of Err; errors.Is matches both Kind and whatever Err wraps.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// NewError classifies err as kind
+func NewError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// classify attaches the matching Err value to an error from the Vault API,
+// based on the response status
+func classify(err error) error {
+	switch code := statusCode(err); {
+	case code == http.StatusUnauthorized:
+		return NewError(ErrAuth, err)
+	case code == http.StatusForbidden:
+		return NewError(ErrPermissionDenied, err)
+	case code == http.StatusNotFound:
+		return NewError(ErrSecretNotFound, err)
+	case isCASMismatch(err):
+		return NewError(ErrCASMismatch, err)
+	}
+	return err
+}
+
+// IsPermissionDenied reports whether err is a 403 response from Vault
+func IsPermissionDenied(err error) bool {
+	return errors.Is(err, ErrPermissionDenied) || statusCode(err) == http.StatusForbidden
+}
+
+// DeniedRequest describes a request Vault refused with a 403
+type DeniedRequest struct {
+	Method       string   // HTTP method, or LIST
+	Path         string   // API path without the /v1/ prefix, e.g. kv/data/app
+	Namespace    string   // namespace the request was sent to ("" for root)
+	Capabilities []string // capabilities the request needs (create and update for writes)
+}
+
+// PermissionDenied returns the request behind a 403 response in err
+func PermissionDenied(err error) (*DeniedRequest, bool) {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusForbidden {
+		return nil, false
+	}
+	u, parseErr := url.Parse(respErr.URL)
+	if parseErr != nil {
+		return nil, false
+	}
+	path, ok := strings.CutPrefix(u.Path, "/v1/")
+	if !ok {
+		return nil, false
+	}
+
+	method := respErr.HTTPMethod
+	if u.Query().Get("list") == "true" {
+		method = "LIST"
+	}
+	namespace := strings.Trim(respErr.NamespacePath, "/")
+	if namespace == "root" {
+		namespace = ""
+	}
+
+	// As vault -output-policy maps methods to capabilities
+	var capabilities []string
+	switch method {
+	case http.MethodGet, http.MethodHead, "":
+		capabilities = []string{"read"}
+	case http.MethodPost, http.MethodPut:
+		capabilities = []string{"create", "update"}
+	case http.MethodPatch:
+		capabilities = []string{"patch"}
+	case http.MethodDelete:
+		capabilities = []string{"delete"}
+	case "LIST":
+		capabilities = []string{"list"}
+	}
+	if vaultapi.IsSudoPath("/" + path) {
+		capabilities = append(capabilities, "sudo")
+	}
+
+	return &DeniedRequest{Method: method, Path: path, Namespace: namespace, Capabilities: capabilities}, true
+}
+
+// IsNotFound reports whether err means the requested secret does not exist
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrSecretNotFound) || statusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a 401 response from Vault
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrAuth) || statusCode(err) == http.StatusUnauthorized
+}
+
+// IsCASMismatch reports whether err is a KV v2 write rejected because the
+// check-and-set version no longer matches the secret
+func IsCASMismatch(err error) bool {
+	return errors.Is(err, ErrCASMismatch) || isCASMismatch(err)
+}
+
+func isCASMismatch(err error) bool {
+	var respErr *vaultapi.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	for _, msg := range respErr.Errors {
+		if strings.Contains(msg, "check-and-set") {
+			return true
+		}
+	}
+	return false
+}
+
+// statusCode returns the HTTP status of a Vault API error, or 0
+func statusCode(err error) int {
+	var respErr *vaultapi.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode
+	}
+	return 0
+}
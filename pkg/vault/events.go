@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+// EventKVWrite is the event type Vault emits for KV v2 writes
+const EventKVWrite = "kv-v2/data-write"
+
+// Event is a notification received from Vault's event subscription API
+type Event struct {
+	Type string // e.g. "kv-v2/data-write"
+	Path string // API path of the affected secret, e.g. "kv/data/myapp/config"
+}
+
+// eventMessage is the subset of the CloudEvents envelope Vault sends
+type eventMessage struct {
+	Data struct {
+		EventType string `json:"event_type"`
+		Event     struct {
+			Metadata struct {
+				Path string `json:"path"`
+			} `json:"metadata"`
+		} `json:"event"`
+	} `json:"data"`
+}
+
+// SubscribeEvents subscribes to Vault events of eventType (Vault 1.13+) over
+// a WebSocket and sends them to events until ctx is cancelled or the
+// connection fails. It returns an error right away when the server does not
+// offer the events API, so callers can fall back to polling.
+func (c *Client) SubscribeEvents(ctx context.Context, eventType string, events chan<- Event) error {
+	location, err := url.Parse(c.client.Address())
+	if err != nil {
+		return fmt.Errorf("parse vault address: %w", err)
+	}
+	origin := *location
+	switch location.Scheme {
+	case "https":
+		location.Scheme = "wss"
+	default:
+		location.Scheme = "ws"
+	}
+	location.Path = strings.TrimSuffix(location.Path, "/") + "/v1/sys/events/subscribe/" + eventType
+	location.RawQuery = "json=true"
+
+	wsConfig, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return fmt.Errorf("configure event subscription: %w", err)
+	}
+	wsConfig.Header = c.client.Headers()
+	wsConfig.Header.Set("X-Vault-Token", c.client.Token())
+	if ns := c.client.Namespace(); ns != "" {
+		wsConfig.Header.Set("X-Vault-Namespace", ns)
+	}
+	transport := c.client.CloneConfig().HttpClient.Transport
+	for {
+		wrapped, ok := transport.(interface{ Unwrap() http.RoundTripper })
+		if !ok {
+			break
+		}
+		transport = wrapped.Unwrap()
+	}
+	var conn *websocket.Conn
+	if tr, ok := transport.(*http.Transport); ok {
+		if tr.TLSClientConfig != nil {
+			wsConfig.TlsConfig = tr.TLSClientConfig.Clone()
+		}
+		// Connect the way Vault requests do: over a Unix socket or a proxy
+		conn, err = dialWebsocket(ctx, tr, wsConfig)
+	} else {
+		conn, err = wsConfig.DialContext(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("subscribe to %s events: %w", eventType, err)
+	}
+	defer conn.Close()
+
+	// Unblock Receive when the caller is done
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	for {
+		var payload []byte
+		if err := websocket.Message.Receive(conn, &payload); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("receive %s events: %w", eventType, err)
+		}
+
+		var msg eventMessage
+		if err := json.Unmarshal(payload, &msg); err != nil {
+			continue
+		}
+		event := Event{Type: msg.Data.EventType, Path: msg.Data.Event.Metadata.Path}
+		if event.Type == "" {
+			event.Type = eventType
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// dialWebsocket opens the WebSocket connection of wsConfig through tr's
+// dialer or proxy
+func dialWebsocket(ctx context.Context, tr *http.Transport, wsConfig *websocket.Config) (*websocket.Conn, error) {
+	raw, err := dialThrough(ctx, tr, wsConfig.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	if wsConfig.Location.Scheme == "wss" {
+		tlsConfig := wsConfig.TlsConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = wsConfig.Location.Hostname()
+		}
+		tlsConn := tls.Client(raw, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, err
+		}
+		raw = tlsConn
+	}
+
+	conn, err := websocket.NewClient(wsConfig, raw)
+	if err != nil {
+		raw.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,383 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// fixtureVersion is the format version of fixture files
+const fixtureVersion = 1
+
+// replayToken is the token used during replay when none is configured
+const replayToken = "replay"
+
+// fixtureFile is a recording of Vault responses, written with --record and
+// served with --replay
+type fixtureFile struct {
+	Version      int           `json:"version"`
+	Addr         string        `json:"addr"` // server the responses were recorded from
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is one request and its sanitized response. Request bodies are
+// not stored, only their hash.
+type interaction struct {
+	Method     string          `json:"method"`
+	Path       string          `json:"path"` // URL path and sorted query, e.g. /v1/kv/metadata/app?list=true
+	Namespace  string          `json:"namespace,omitempty"`
+	BodySHA256 string          `json:"body_sha256,omitempty"`
+	Status     int             `json:"status"`
+	Body       json.RawMessage `json:"body,omitempty"`
+}
+
+func (i *interaction) key() string {
+	return i.Method + " " + i.Path + " " + i.Namespace
+}
+
+// Recorders and replayers are shared by every client of the process that
+// names the same file
+var (
+	fixturesMu sync.Mutex
+	recordings = map[string]*recording{}
+	replayers  = map[string]*replayer{}
+)
+
+// recorder is a transport that passes requests to Vault and appends the
+// sanitized responses to a fixture file
+type recorder struct {
+	base http.RoundTripper
+	rec  *recording
+}
+
+// recording is the fixture file a recorder writes, rewritten after every
+// response so it is complete whenever the process exits
+type recording struct {
+	mu      sync.Mutex
+	path    string
+	fixture fixtureFile
+	names   map[string]string // ciphertext -> name of the KV key it was read from
+}
+
+// recordTransport returns base wrapped so that responses are recorded to
+// path. The file is replaced on the first request of the process.
+func recordTransport(base http.RoundTripper, path, addr string) http.RoundTripper {
+	fixturesMu.Lock()
+	defer fixturesMu.Unlock()
+	rec, ok := recordings[path]
+	if !ok {
+		rec = &recording{
+			path:    path,
+			fixture: fixtureFile{Version: fixtureVersion, Addr: addr, Interactions: []interaction{}},
+			names:   map[string]string{},
+		}
+		recordings[path] = rec
+	}
+	return &recorder{base: base, rec: rec}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := t.rec.add(req, reqBody, resp.StatusCode, respBody); err != nil {
+		return nil, fmt.Errorf("record vault response: %w", err)
+	}
+	return resp, nil
+}
+
+// Unwrap returns the wrapped transport
+func (t *recorder) Unwrap() http.RoundTripper {
+	return t.base
+}
+
+func (r *recording) add(req *http.Request, reqBody []byte, status int, respBody []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	in := newInteraction(req, reqBody)
+	in.Status = status
+	in.Body = r.sanitize(in.Path, reqBody, respBody)
+	r.fixture.Interactions = append(r.fixture.Interactions, in)
+
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), ".vlt-fixture-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// Keys whose values are credentials, redacted in auth responses
+var credentialKeys = map[string]bool{
+	"client_token":       true,
+	"accessor":           true,
+	"token":              true,
+	"secret_id":          true,
+	"secret_id_accessor": true,
+	"wrapped_accessor":   true,
+}
+
+// sanitize returns a response body with credentials redacted and secret
+// values replaced by placeholders: "fixture-" followed by the lowercased KV
+// key. Ciphertext is kept, and its decryption is replaced by the same
+// placeholder the plaintext key would get.
+func (r *recording) sanitize(path string, reqBody, body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	var resp map[string]any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&resp); err != nil {
+		// Not a Vault response, e.g. an error page from a proxy
+		quoted, _ := json.Marshal(string(body))
+		return quoted
+	}
+
+	for _, key := range []string{"request_id", "lease_id", "wrap_info"} {
+		if _, ok := resp[key]; ok {
+			resp[key] = nil
+		}
+	}
+	redactCredentials(resp["auth"])
+
+	apiPath, _, _ := strings.Cut(strings.TrimPrefix(path, "/v1/"), "?")
+	data, _ := resp["data"].(map[string]any)
+	switch {
+	case data == nil:
+	case strings.HasPrefix(apiPath, "auth/"):
+		// Token lookups and auth method responses
+		redactCredentials(data)
+		if _, ok := data["id"]; ok && strings.HasPrefix(apiPath, "auth/token/lookup") {
+			data["id"] = "redacted"
+		}
+	case data["metadata"] != nil && data["data"] != nil:
+		// KV v2 read
+		if secret, ok := data["data"].(map[string]any); ok {
+			segments := strings.Split(apiPath, "/")
+			for key, value := range secret {
+				name := key
+				if key == "value" || key == "ciphertext" {
+					name = segments[len(segments)-1]
+				}
+				if s, ok := value.(string); ok && strings.HasPrefix(s, "vault:v") {
+					r.names[s] = name
+					continue
+				}
+				secret[key] = "fixture-" + strings.ToLower(name)
+			}
+		}
+	case strings.Contains(apiPath, "/decrypt/"):
+		var in struct {
+			Ciphertext string `json:"ciphertext"`
+		}
+		_ = json.Unmarshal(reqBody, &in)
+		placeholder := "fixture-" + strings.ToLower(config.NonEmpty(r.names[in.Ciphertext], "value"))
+		data["plaintext"] = base64.StdEncoding.EncodeToString([]byte(placeholder))
+	case strings.Contains(apiPath, "/datakey/"):
+		data["plaintext"] = base64.StdEncoding.EncodeToString(make([]byte, 32))
+	case strings.HasPrefix(apiPath, "sys/tools/random"):
+		// Generated values are often stored as secrets; keep only their length
+		if random, ok := data["random_bytes"].(string); ok {
+			if decoded, err := base64.StdEncoding.DecodeString(random); err == nil {
+				data["random_bytes"] = base64.StdEncoding.EncodeToString(make([]byte, len(decoded)))
+			} else {
+				data["random_bytes"] = strings.Repeat("0", len(random))
+			}
+		}
+	}
+
+	out, err := json.Marshal(resp)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// redactCredentials replaces credential values anywhere in v
+func redactCredentials(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && credentialKeys[key] && s != "" {
+				v[key] = "redacted"
+				continue
+			}
+			redactCredentials(value)
+		}
+	case []any:
+		for _, value := range v {
+			redactCredentials(value)
+		}
+	}
+}
+
+// replayer is a transport that answers requests from a fixture file without
+// touching the network
+type replayer struct {
+	path    string
+	fixture fixtureFile
+	mu      sync.Mutex
+	byKey   map[string][]*interaction
+	next    map[string]int
+}
+
+// loadReplayer returns the replayer of a fixture file, reading it once per
+// process
+func loadReplayer(path string) (*replayer, error) {
+	fixturesMu.Lock()
+	defer fixturesMu.Unlock()
+	if r, ok := replayers[path]; ok {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read replay fixture: %w", err)
+	}
+	r := &replayer{path: path, byKey: map[string][]*interaction{}, next: map[string]int{}}
+	if err := json.Unmarshal(data, &r.fixture); err != nil {
+		return nil, fmt.Errorf("parse replay fixture %s: %w", path, err)
+	}
+	if r.fixture.Version != fixtureVersion {
+		return nil, fmt.Errorf("replay fixture %s has version %d; this vlt reads version %d", path, r.fixture.Version, fixtureVersion)
+	}
+	for i := range r.fixture.Interactions {
+		in := &r.fixture.Interactions[i]
+		r.byKey[in.key()] = append(r.byKey[in.key()], in)
+	}
+	replayers[path] = r
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper. A request with the same method,
+// path, namespace, and body as a recorded one gets its response; otherwise
+// responses recorded for the method, path, and namespace are served in
+// order, repeating the last. Unrecorded requests fail.
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	want := newInteraction(req, body)
+
+	r.mu.Lock()
+	candidates := r.byKey[want.key()]
+	var match *interaction
+	for _, in := range candidates {
+		if in.BodySHA256 == want.BodySHA256 {
+			match = in
+			break
+		}
+	}
+	if match == nil && len(candidates) > 0 {
+		n := min(r.next[want.key()], len(candidates)-1)
+		r.next[want.key()] = n + 1
+		match = candidates[n]
+	}
+	r.mu.Unlock()
+
+	if match == nil {
+		return nil, fmt.Errorf("no recorded response in %s for %s %s", r.path, want.Method, want.Path)
+	}
+	status, respBody := match.Status, []byte(match.Body)
+	var text string
+	if json.Unmarshal(respBody, &text) == nil {
+		respBody = []byte(text)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// applyReplayDefaults fills in the address recorded in the fixture and a
+// placeholder token, so replay works without any Vault configuration
+func applyReplayDefaults(cfg *config.VaultConfig) error {
+	r, err := loadReplayer(cfg.Replay)
+	if err != nil {
+		return err
+	}
+	if len(cfg.Addresses()) == 0 {
+		cfg.Addr = r.fixture.Addr
+	}
+	if cfg.Token == "" && config.NonEmpty(cfg.AuthMethod, cfg.DetectAuthMethod()) == "token" {
+		cfg.Token = replayToken
+	}
+	return nil
+}
+
+// newInteraction returns the request half of an interaction
+func newInteraction(req *http.Request, body []byte) interaction {
+	path := req.URL.Path
+	if query := req.URL.Query().Encode(); query != "" {
+		path += "?" + query
+	}
+	in := interaction{Method: req.Method, Path: path, Namespace: strings.Trim(req.Header.Get("X-Vault-Namespace"), "/")}
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		in.BodySHA256 = hex.EncodeToString(sum[:])
+	}
+	return in
+}
+
+// readRequestBody returns a copy of req whose body can still be sent, and
+// the body
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	return req, body, nil
+}
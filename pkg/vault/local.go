@@ -0,0 +1,32 @@
+package vault
+
+import "strings"
+
+// LocalCiphertextPrefix starts values encrypted client-side instead of by
+// Transit, e.g. "vlt:local:v1:age:..."
+const LocalCiphertextPrefix = "vlt:local:"
+
+// LocalCipher encrypts values client-side, for installs without the Transit
+// engine. Decrypt must fail with ErrDecryptFailed for values it cannot open.
+type LocalCipher interface {
+	Encrypt(plaintext []byte) (string, error)
+	Decrypt(ciphertext string) ([]byte, error)
+}
+
+// IsLocalCiphertext reports whether value was encrypted by a LocalCipher
+func IsLocalCiphertext(value string) bool {
+	return strings.HasPrefix(value, LocalCiphertextPrefix)
+}
+
+// WithLocalCipher returns a copy of the client whose TransitDecrypt opens
+// values encrypted by cipher. With encrypt set, TransitEncrypt also encrypts
+// with cipher instead of calling Transit; the mount and key name are then
+// only recorded by callers.
+func (c *Client) WithLocalCipher(cipher LocalCipher, encrypt bool) *Client {
+	if cipher == nil {
+		return c
+	}
+	scoped := *c
+	scoped.local, scoped.localEncrypt = cipher, encrypt
+	return &scoped
+}
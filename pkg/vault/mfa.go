@@ -0,0 +1,85 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// mfaValidateTimeout bounds sys/mfa/validate, which waits for push methods
+// (Okta, Duo, PingID) until the user approves on their device
+const mfaValidateTimeout = 2 * time.Minute
+
+// loginToken returns the token of a login response. When the auth method
+// answered with a login MFA requirement (Vault 1.10+) instead of a token, the
+// requirement is satisfied first: passcode methods ask cfg.MFAPrompt, push
+// methods wait for the approval on the user's device.
+func loginToken(client *vaultapi.Client, cfg *config.VaultConfig, secret *vaultapi.Secret) (string, error) {
+	if secret == nil || secret.Auth == nil {
+		return "", fmt.Errorf("no auth info was returned after login")
+	}
+	requirement := secret.Auth.MFARequirement
+	if requirement == nil {
+		return secret.Auth.ClientToken, nil
+	}
+
+	names := make([]string, 0, len(requirement.MFAConstraints))
+	for name := range requirement.MFAConstraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	payload := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		constraint := requirement.MFAConstraints[name]
+		if constraint == nil || len(constraint.Any) == 0 {
+			continue
+		}
+		method := constraint.Any[0]
+		label := mfaMethodLabel(method)
+		if !method.UsesPasscode {
+			fmt.Fprintf(os.Stderr, "Approve the %s login request on your device...\n", label)
+			payload[method.ID] = []string{""}
+			continue
+		}
+		if cfg.MFAPrompt == nil {
+			return "", fmt.Errorf("login requires MFA with %s; pass --mfa %s:<passcode> (or set VAULT_MFA)", label, mfaMethodRef(method))
+		}
+		passcode, err := cfg.MFAPrompt(label)
+		if err != nil {
+			return "", fmt.Errorf("read MFA passcode: %w", err)
+		}
+		payload[method.ID] = []string{strings.TrimSpace(passcode)}
+	}
+
+	// Push methods hold the request open until the user answers
+	client.SetClientTimeout(mfaValidateTimeout)
+	defer client.SetClientTimeout(time.Duration(cfg.Timeout) * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), mfaValidateTimeout)
+	defer cancel()
+
+	validated, err := client.Sys().MFAValidateWithContext(ctx, requirement.MFARequestID, payload)
+	if err != nil {
+		return "", fmt.Errorf("MFA validation failed: %w", err)
+	}
+	if validated == nil || validated.Auth == nil || validated.Auth.ClientToken == "" {
+		return "", fmt.Errorf("MFA validation returned no token")
+	}
+	return validated.Auth.ClientToken, nil
+}
+
+// mfaMethodLabel describes an MFA method to the user, e.g. totp method "corp-totp"
+func mfaMethodLabel(method *vaultapi.MFAMethodID) string {
+	return fmt.Sprintf("%s method %q", method.Type, mfaMethodRef(method))
+}
+
+// mfaMethodRef returns the name of an MFA method, or its ID when it has none
+func mfaMethodRef(method *vaultapi.MFAMethodID) string {
+	return config.NonEmpty(method.Name, method.ID)
+}
@@ -0,0 +1,149 @@
+package vault
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+const (
+	// oidcCallbackAddr matches the Vault CLI default, so roles configured
+	// for 'vault login -method=oidc' accept vlt's redirect URI as well
+	oidcCallbackAddr = "localhost:8250"
+	oidcCallbackPath = "/oidc/callback"
+
+	// oidcLoginTimeout bounds how long the browser login may take
+	oidcLoginTimeout = 5 * time.Minute
+)
+
+// oidcCallback is the query of the redirect back from the identity provider
+type oidcCallback struct {
+	state, code string
+	err         error
+}
+
+// authenticateOIDC performs the OIDC browser flow: it asks Vault for the
+// provider's authorization URL, opens it in a browser, and exchanges the
+// code delivered to a local callback server for a Vault token
+func authenticateOIDC(client *vaultapi.Client, cfg *config.VaultConfig) (string, error) {
+	mount := config.NonEmpty(cfg.AuthPath, "oidc")
+
+	listener, err := net.Listen("tcp", oidcCallbackAddr)
+	if err != nil {
+		return "", fmt.Errorf("start oidc callback listener on %s: %w", oidcCallbackAddr, err)
+	}
+	defer listener.Close()
+
+	nonce, err := randomHex(20)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), oidcLoginTimeout)
+	defer cancel()
+
+	redirectURI := "http://" + oidcCallbackAddr + oidcCallbackPath
+	secret, err := client.Logical().WriteWithContext(ctx, fmt.Sprintf("auth/%s/oidc/auth_url", mount), map[string]interface{}{
+		"role":         cfg.OIDCRole,
+		"redirect_uri": redirectURI,
+		"client_nonce": nonce,
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to start oidc login: %w", err)
+	}
+	authURL, _ := secret.Data["auth_url"].(string)
+	if authURL == "" {
+		return "", fmt.Errorf("vault returned no oidc authorization URL; check the role and that %s is an allowed redirect URI", redirectURI)
+	}
+
+	callbacks := make(chan oidcCallback, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != oidcCallbackPath {
+				http.NotFound(w, r)
+				return
+			}
+			query := r.URL.Query()
+			result := oidcCallback{state: query.Get("state"), code: query.Get("code")}
+			if msg := query.Get("error_description"); msg != "" {
+				result.err = errors.New(msg)
+			} else if msg := query.Get("error"); msg != "" {
+				result.err = errors.New(msg)
+			}
+			if result.err != nil {
+				fmt.Fprintf(w, "Vault login failed: %s. You can close this window.\n", result.err)
+			} else {
+				fmt.Fprintln(w, "Vault login complete. You can close this window and return to the terminal.")
+			}
+			select {
+			case callbacks <- result:
+			default:
+			}
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	fmt.Fprintf(os.Stderr, "Complete the login in your browser. If it does not open, visit:\n\n    %s\n\n", authURL)
+	_ = openBrowser(authURL)
+
+	var result oidcCallback
+	select {
+	case result = <-callbacks:
+	case <-ctx.Done():
+		return "", fmt.Errorf("timed out after %s waiting for the oidc login to complete", oidcLoginTimeout)
+	}
+	if result.err != nil {
+		return "", fmt.Errorf("oidc login failed: %w", result.err)
+	}
+
+	secret, err = client.Logical().ReadWithDataWithContext(ctx, fmt.Sprintf("auth/%s/oidc/callback", mount), map[string][]string{
+		"state":        {result.state},
+		"code":         {result.code},
+		"client_nonce": {nonce},
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to complete oidc login: %w", err)
+	}
+	return loginToken(client, cfg, secret)
+}
+
+// openBrowser opens url in the default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,233 @@
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// ServerStatus describes the Vault server the client talks to, as reported
+// by sys/health
+type ServerStatus struct {
+	Version            string
+	ClusterName        string
+	Initialized        bool
+	Sealed             bool
+	Standby            bool
+	PerformanceStandby bool
+	ReplicationDRMode  string
+}
+
+// MountInfo describes a secrets engine mount
+type MountInfo struct {
+	Path    string
+	Type    string // e.g. "kv" or "transit"
+	Version string // KV version option: "1" or "2"
+}
+
+// TransitKeyInfo describes a transit key
+type TransitKeyInfo struct {
+	Name                 string
+	Type                 string
+	LatestVersion        int
+	MinDecryptionVersion int
+}
+
+// Health returns the status of the Vault server from sys/health
+func (c *Client) Health() (*ServerStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout(c.config))
+	defer cancel()
+
+	health, err := c.client.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("health check failed: %w", err)
+	}
+
+	return &ServerStatus{
+		Version:            health.Version,
+		ClusterName:        health.ClusterName,
+		Initialized:        health.Initialized,
+		Sealed:             health.Sealed,
+		Standby:            health.Standby,
+		PerformanceStandby: health.PerformanceStandby,
+		ReplicationDRMode:  health.ReplicationDRMode,
+	}, nil
+}
+
+// Mount returns the secrets engine mounted at path, from
+// sys/internal/ui/mounts. Vault answers 403 both for a mount the token
+// cannot access and for a path that is not mounted.
+func (c *Client) Mount(path string) (*MountInfo, error) {
+	path = strings.Trim(path, "/")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, "sys/internal/ui/mounts/"+path)
+	if err != nil {
+		return nil, fmt.Errorf("mount lookup failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("mount lookup failed: %w", ErrSecretNotFound)
+	}
+
+	info := &MountInfo{Path: path}
+	info.Type, _ = secret.Data["type"].(string)
+	if options, ok := secret.Data["options"].(map[string]interface{}); ok {
+		info.Version, _ = options["version"].(string)
+	}
+	return info, nil
+}
+
+// Mounts returns the secrets engine mounts visible to the token, from
+// sys/internal/ui/mounts
+func (c *Client) Mounts() ([]MountInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, "sys/internal/ui/mounts")
+	if err != nil {
+		return nil, fmt.Errorf("mount listing failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	engines, _ := secret.Data["secret"].(map[string]interface{})
+	mounts := make([]MountInfo, 0, len(engines))
+	for path, raw := range engines {
+		engine, _ := raw.(map[string]interface{})
+		info := MountInfo{Path: strings.TrimSuffix(path, "/")}
+		info.Type, _ = engine["type"].(string)
+		if options, ok := engine["options"].(map[string]interface{}); ok {
+			info.Version, _ = options["version"].(string)
+		}
+		mounts = append(mounts, info)
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Path < mounts[j].Path })
+	return mounts, nil
+}
+
+// TransitKey returns the configuration of a transit key
+func (c *Client) TransitKey(transitMount, keyName string) (*TransitKeyInfo, error) {
+	path := fmt.Sprintf("%s/keys/%s", strings.TrimSuffix(transitMount, "/"), keyName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("transit key lookup failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("transit key lookup failed: %w", ErrSecretNotFound)
+	}
+
+	info := &TransitKeyInfo{Name: keyName}
+	info.Type, _ = secret.Data["type"].(string)
+	if v, ok := secret.Data["latest_version"].(json.Number); ok {
+		if n, err := v.Int64(); err == nil {
+			info.LatestVersion = int(n)
+		}
+	}
+	if v, ok := secret.Data["min_decryption_version"].(json.Number); ok {
+		if n, err := v.Int64(); err == nil {
+			info.MinDecryptionVersion = int(n)
+		}
+	}
+	return info, nil
+}
+
+// EnableMount mounts a secrets engine of engineType at path, with options
+// such as {"version": "2"} for KV v2
+func (c *Client) EnableMount(path, engineType string, options map[string]string) error {
+	path = strings.Trim(path, "/")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	input := &vaultapi.MountInput{Type: engineType, Options: options}
+	if err := c.client.Sys().MountWithContext(ctx, path, input); err != nil {
+		return fmt.Errorf("enable %s mount %s/: %w", engineType, path, classify(err))
+	}
+	return nil
+}
+
+// CreateTransitKey creates a transit key. Creating a key that already exists
+// succeeds without changing it.
+func (c *Client) CreateTransitKey(transitMount, keyName string) error {
+	path := fmt.Sprintf("%s/keys/%s", strings.TrimSuffix(transitMount, "/"), keyName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	if _, err := c.client.Logical().WriteWithContext(ctx, path, nil); err != nil {
+		return fmt.Errorf("create transit key %s: %w", keyName, classify(err))
+	}
+	return nil
+}
+
+// WrapInfo describes a response-wrapping token
+type WrapInfo struct {
+	Token        string
+	Accessor     string
+	TTL          time.Duration
+	CreationTime time.Time
+}
+
+// Wrap stores data in Vault's cubbyhole behind a new single-use wrapping
+// token that expires after ttl, using sys/wrapping/wrap. Whoever holds the
+// token can read data once with sys/wrapping/unwrap (vault unwrap).
+func (c *Client) Wrap(data map[string]interface{}, ttl time.Duration) (*WrapInfo, error) {
+	wrapTTL := strconv.Itoa(int(ttl.Seconds())) + "s"
+	client := c.client.WithRequestCallbacks(func(r *vaultapi.Request) { r.WrapTTL = wrapTTL })
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := client.Logical().WriteWithContext(ctx, "sys/wrapping/wrap", data)
+	if err != nil {
+		return nil, fmt.Errorf("wrap failed: %w", classify(err))
+	}
+	if secret == nil || secret.WrapInfo == nil || secret.WrapInfo.Token == "" {
+		return nil, errors.New("wrap failed: Vault returned no wrapping token")
+	}
+
+	return &WrapInfo{
+		Token:        secret.WrapInfo.Token,
+		Accessor:     secret.WrapInfo.Accessor,
+		TTL:          time.Duration(secret.WrapInfo.TTL) * time.Second,
+		CreationTime: secret.WrapInfo.CreationTime,
+	}, nil
+}
+
+// RandomBytes returns n random bytes generated by Vault's sys/tools/random
+func (c *Client) RandomBytes(n int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Logical().WriteWithContext(ctx, fmt.Sprintf("sys/tools/random/%d", n), map[string]interface{}{
+		"format": "base64",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("random bytes failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("empty sys/tools/random response")
+	}
+
+	b64, _ := secret.Data["random_bytes"].(string)
+	random, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil || len(random) != n {
+		return nil, fmt.Errorf("unexpected sys/tools/random response for %d bytes", n)
+	}
+	return random, nil
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TokenInfo describes the client token, as returned by auth/token/lookup-self
+type TokenInfo struct {
+	Accessor    string
+	DisplayName string
+	Type        string // "service" or "batch"
+	Policies    []string
+	TTL         time.Duration
+	Renewable   bool
+	EntityID    string
+	Path        string // auth path that created the token, e.g. "auth/approle/login"
+	Namespace   string // namespace the token belongs to
+	ExpireTime  time.Time
+}
+
+// Token returns the token the client authenticates with
+func (c *Client) Token() string {
+	return c.client.Token()
+}
+
+// Address returns the address of the Vault server the client talks to
+func (c *Client) Address() string {
+	return c.client.Address()
+}
+
+// Namespace returns the namespace the client sends requests to, or "" for
+// the root namespace
+func (c *Client) Namespace() string {
+	return c.client.Namespace()
+}
+
+// LookupSelf returns information about the client token
+func (c *Client) LookupSelf() (*TokenInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	secret, err := c.client.Auth().Token().LookupSelfWithContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("token lookup failed: %w", classify(err))
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("token lookup failed: no data returned from vault")
+	}
+
+	info := &TokenInfo{}
+	info.Accessor, _ = secret.TokenAccessor()
+	info.Policies, _ = secret.TokenPolicies()
+	info.TTL, _ = secret.TokenTTL()
+	info.Renewable, _ = secret.TokenIsRenewable()
+	info.DisplayName, _ = secret.Data["display_name"].(string)
+	info.Type, _ = secret.Data["type"].(string)
+	info.EntityID, _ = secret.Data["entity_id"].(string)
+	info.Path, _ = secret.Data["path"].(string)
+	if ns, ok := secret.Data["namespace_path"].(string); ok {
+		info.Namespace = ns
+	}
+	if expire, ok := secret.Data["expire_time"].(string); ok && expire != "" {
+		info.ExpireTime, _ = time.Parse(time.RFC3339Nano, expire)
+	}
+
+	return info, nil
+}
+
+// CapabilitiesSelf returns the capabilities of the client token on an API
+// path, as sys/capabilities-self reports them (["deny"] for none)
+func (c *Client) CapabilitiesSelf(path string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	capabilities, err := c.client.Sys().CapabilitiesSelfWithContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("capabilities lookup failed: %w", classify(err))
+	}
+	return capabilities, nil
+}
+
+// RevokeSelf revokes the client token
+func (c *Client) RevokeSelf() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.config.Timeout)*time.Second)
+	defer cancel()
+
+	if err := c.client.Auth().Token().RevokeSelfWithContext(ctx, ""); err != nil {
+		return fmt.Errorf("token revoke failed: %w", classify(err))
+	}
+	return nil
+}
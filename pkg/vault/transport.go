@@ -0,0 +1,174 @@
+package vault
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+
+	"github.com/razzkumar/vlt/pkg/config"
+)
+
+// unixSocketAddr is the HTTP address requests over a Unix socket are sent to
+const unixSocketAddr = "http://localhost"
+
+// unixSocket returns the socket path of a unix:// Vault address, such as a
+// local Vault Agent listener (unix:///run/vault-agent.sock)
+func unixSocket(addr string) (string, bool) {
+	socket, ok := strings.CutPrefix(addr, "unix://")
+	if !ok {
+		return "", false
+	}
+	return socket, true
+}
+
+// connectTimeout returns the timeout for dialing, the TLS handshake, and
+// health checks
+func connectTimeout(cfg *config.VaultConfig) time.Duration {
+	if cfg.ConnectTimeout > 0 {
+		return time.Duration(cfg.ConnectTimeout) * time.Second
+	}
+	return time.Duration(config.DefaultConnectTimeout) * time.Second
+}
+
+// configureTransport routes requests over the Unix socket when one is
+// given, and otherwise through the configured proxy. Without a proxy,
+// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY apply.
+func configureTransport(tr *http.Transport, cfg *config.VaultConfig, socket string) error {
+	dialer := &net.Dialer{Timeout: connectTimeout(cfg), KeepAlive: 30 * time.Second}
+	tr.TLSHandshakeTimeout = connectTimeout(cfg)
+
+	if socket != "" {
+		tr.Proxy = nil
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		return nil
+	}
+	tr.DialContext = dialer.DialContext
+
+	if cfg.Proxy == "" {
+		tr.Proxy = http.ProxyFromEnvironment
+		return nil
+	}
+
+	proxyURL, err := url.Parse(cfg.Proxy)
+	if err != nil || proxyURL.Host == "" {
+		return fmt.Errorf("invalid proxy URL %q (expected e.g. http://proxy:3128 or socks5://bastion:1080)", cfg.Proxy)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q (expected http, https, socks5, or socks5h)", proxyURL.Scheme)
+	}
+
+	// The explicit proxy still honors NO_PROXY
+	proxyFunc := (&httpproxy.Config{
+		HTTPProxy:  cfg.Proxy,
+		HTTPSProxy: cfg.Proxy,
+		NoProxy:    config.NonEmpty(os.Getenv("NO_PROXY"), os.Getenv("no_proxy")),
+	}).ProxyFunc()
+	tr.Proxy = func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}
+	return nil
+}
+
+// configureTLS applies the CA certificates, server name, verification
+// setting, and minimum TLS version of cfg to the client configuration
+func configureTLS(vaultConfig *vaultapi.Config, cfg *config.VaultConfig) error {
+	minVersion, err := tlsVersion(cfg.TLSMinVersion)
+	if err != nil {
+		return err
+	}
+
+	if cfg.CACert != "" || cfg.CAPath != "" || cfg.TLSServerName != "" || cfg.SkipVerify {
+		err := vaultConfig.ConfigureTLS(&vaultapi.TLSConfig{
+			CACert:        cfg.CACert,
+			CAPath:        cfg.CAPath,
+			TLSServerName: cfg.TLSServerName,
+			Insecure:      cfg.SkipVerify,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	tr, ok := vaultConfig.HttpClient.Transport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{}
+	}
+	tr.TLSClientConfig.MinVersion = minVersion
+	return nil
+}
+
+// tlsVersion parses a minimum TLS version; TLS 1.2 is the default and the
+// lowest accepted
+func tlsVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(version), "tls") {
+	case "", "1.2", "12":
+		return tls.VersionTLS12, nil
+	case "1.3", "13":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS minimum version %q (expected 1.2 or 1.3)", version)
+	}
+}
+
+// dialThrough opens a TCP connection to location (a Vault URL) the way tr
+// would: over its Unix socket dialer or through a SOCKS5 proxy. HTTP proxies
+// are not supported for raw connections.
+func dialThrough(ctx context.Context, tr *http.Transport, location *url.URL) (net.Conn, error) {
+	host := location.Host
+	if location.Port() == "" {
+		port := "80"
+		if location.Scheme == "https" || location.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(location.Hostname(), port)
+	}
+
+	if tr.Proxy != nil {
+		target := *location
+		if target.Scheme == "wss" {
+			target.Scheme = "https"
+		} else if target.Scheme == "ws" {
+			target.Scheme = "http"
+		}
+		proxyURL, err := tr.Proxy(&http.Request{URL: &target})
+		if err != nil {
+			return nil, fmt.Errorf("resolve proxy: %w", err)
+		}
+		if proxyURL != nil {
+			if proxyURL.Scheme != "socks5" && proxyURL.Scheme != "socks5h" {
+				return nil, fmt.Errorf("%s proxies are not supported for this connection", proxyURL.Scheme)
+			}
+			dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
+			if err != nil {
+				return nil, fmt.Errorf("configure proxy: %w", err)
+			}
+			if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+				return contextDialer.DialContext(ctx, "tcp", host)
+			}
+			return dialer.Dial("tcp", host)
+		}
+	}
+
+	if tr.DialContext != nil {
+		return tr.DialContext(ctx, "tcp", host)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", host)
+}
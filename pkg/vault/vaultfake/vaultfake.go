@@ -0,0 +1,762 @@
+// Package vaultfake is an in-memory Vault server for tests. It serves the
+// parts of the Vault HTTP API that vlt uses, over a local listener, so the
+// real vault.Client, the app layer, and the vlt binary all run against it
+// unchanged:
+//
+//   - KV v2 reads, writes, deletes, lists, and metadata, with versions and
+//     check-and-set
+//   - Transit encrypt, decrypt, data keys, key reads, and rotation, with
+//     deterministic ciphertext
+//   - sys/health, sys/mounts, sys/internal/ui/mounts, sys/capabilities-self,
+//     and token creation (service or batch, with any role name), lookup, and
+//     revocation
+//   - Response wrapping through sys/wrapping/wrap and sys/wrapping/unwrap
+//
+// Namespaces, policies, token roles, and auth methods other than tokens are
+// not modelled; every request with the server's token or a token created from
+// it is allowed and every other token is denied.
+package vaultfake
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/razzkumar/vlt/pkg/config"
+	"github.com/razzkumar/vlt/pkg/vault"
+)
+
+// DefaultToken is the token New accepts
+const DefaultToken = "root"
+
+// Server is an in-memory Vault server. Its methods are safe for concurrent
+// use, also while requests are served.
+type Server struct {
+	URL   string // base address, e.g. http://127.0.0.1:41234
+	Token string // the only token requests are accepted with
+
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	kv      map[string]map[string]*kvSecret // KV v2 mount -> path -> secret
+	transit map[string]map[string]int       // transit mount -> key -> latest version
+	wrapped map[string]wrappedResponse      // wrapping token -> response
+	tokens  map[string]childToken           // tokens created with auth/token/create
+}
+
+type childToken struct {
+	batch bool
+	path  string // creation path, e.g. auth/token/create/ci
+}
+
+type wrappedResponse struct {
+	data    map[string]interface{}
+	expires time.Time
+}
+
+type kvSecret struct {
+	created  time.Time
+	versions []kvVersion // versions[i] is version i+1
+}
+
+type kvVersion struct {
+	data    map[string]interface{}
+	created time.Time
+	deleted bool
+}
+
+// New starts a server on a random local port with KV v2 mounts at kv/ and
+// secret/, a transit mount at transit/, and DefaultToken. Call Close when
+// done.
+func New() *Server {
+	s := newServer()
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Listen starts a server like New on addr, e.g. 127.0.0.1:8200
+func Listen(addr string) (*Server, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := newServer()
+	s.srv = httptest.NewUnstartedServer(http.HandlerFunc(s.serveHTTP))
+	s.srv.Listener.Close()
+	s.srv.Listener = l
+	s.srv.Start()
+	s.URL = s.srv.URL
+	return s, nil
+}
+
+func newServer() *Server {
+	return &Server{
+		Token:   DefaultToken,
+		kv:      map[string]map[string]*kvSecret{"kv": {}, "secret": {}},
+		transit: map[string]map[string]int{"transit": {}},
+		wrapped: map[string]wrappedResponse{},
+		tokens:  map[string]childToken{},
+	}
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Env returns VAULT_ADDR and VAULT_TOKEN for the server, in the KEY=value
+// form of exec.Cmd.Env, for running the vlt binary against it
+func (s *Server) Env() []string {
+	return []string{"VAULT_ADDR=" + s.URL, "VAULT_TOKEN=" + s.Token}
+}
+
+// Client returns a vault.Client authenticated to the server
+func (s *Server) Client() (*vault.Client, error) {
+	return vault.NewClient(&config.VaultConfig{
+		Addr:           s.URL,
+		Token:          s.Token,
+		AuthMethod:     "token",
+		Timeout:        5,
+		ConnectTimeout: 5,
+	})
+}
+
+// MountKV adds an empty KV v2 mount at path
+func (s *Server) MountKV(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.kv[strings.Trim(path, "/")]; !ok {
+		s.kv[strings.Trim(path, "/")] = map[string]*kvSecret{}
+	}
+}
+
+// MountTransit adds a transit mount without keys at path
+func (s *Server) MountTransit(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.transit[strings.Trim(path, "/")]; !ok {
+		s.transit[strings.Trim(path, "/")] = map[string]int{}
+	}
+}
+
+// CreateTransitKey creates a transit key, adding the mount if needed
+func (s *Server) CreateTransitKey(mount, name string) {
+	s.MountTransit(mount)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if keys := s.transit[strings.Trim(mount, "/")]; keys[name] == 0 {
+		keys[name] = 1
+	}
+}
+
+// Put writes a new version of a KV v2 secret, adding the mount if needed,
+// and returns the version number
+func (s *Server) Put(mount, path string, data map[string]interface{}) int {
+	s.MountKV(mount)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.put(strings.Trim(mount, "/"), strings.Trim(path, "/"), data)
+}
+
+// Get returns the latest version of a KV v2 secret, or false when it does
+// not exist or is deleted
+func (s *Server) Get(mount, path string) (map[string]interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := s.version(strings.Trim(mount, "/"), strings.Trim(path, "/"), 0)
+	if v == nil {
+		return nil, false
+	}
+	return copyData(v.data), true
+}
+
+// Encrypt returns the ciphertext Transit would return for plaintext under
+// key, for seeding encrypted values. The key is created if needed.
+func (s *Server) Encrypt(mount, key string, plaintext []byte) string {
+	s.CreateTransitKey(mount, key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return encrypt(key, s.transit[strings.Trim(mount, "/")][key], plaintext)
+}
+
+// Seed loads secrets and transit keys from YAML:
+//
+//	kv:
+//	  kv:                  # mount
+//	    myapp/db:          # path
+//	      password: s3cr3t
+//	transit:
+//	  transit:             # mount
+//	    - app-secrets      # key names
+//
+// Every value of a secret is stored as given (strings, numbers, or nested
+// maps). Seeding a path again writes a new version.
+func (s *Server) Seed(r io.Reader) error {
+	var seed struct {
+		KV      map[string]map[string]map[string]interface{} `yaml:"kv"`
+		Transit map[string][]string                          `yaml:"transit"`
+	}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&seed); err != nil && err != io.EOF {
+		return fmt.Errorf("parse seed: %w", err)
+	}
+
+	for mount, secrets := range seed.KV {
+		s.MountKV(mount)
+		for path, data := range secrets {
+			s.Put(mount, path, data)
+		}
+	}
+	for mount, keys := range seed.Transit {
+		s.MountTransit(mount)
+		for _, key := range keys {
+			s.CreateTransitKey(mount, key)
+		}
+	}
+	return nil
+}
+
+// SeedFile loads a YAML file in the format of Seed
+func (s *Server) SeedFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := s.Seed(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path, ok := strings.CutPrefix(r.URL.Path, "/v1/")
+	if !ok {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	method := r.Method
+	if method == http.MethodGet && r.URL.Query().Get("list") == "true" {
+		method = "LIST"
+	}
+
+	if path == "sys/health" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"initialized": true, "sealed": false, "standby": false, "version": "1.15.0+fake",
+		})
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		data, _ := io.ReadAll(r.Body)
+		if len(bytes.TrimSpace(data)) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.UseNumber()
+			if err := dec.Decode(&body); err != nil {
+				writeErrors(w, http.StatusBadRequest, "failed to parse JSON input: "+err.Error())
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if path == "sys/wrapping/unwrap" {
+		// Authorized by the wrapping token itself, given as the request
+		// token or in the body
+		s.unwrap(w, r.Header.Get("X-Vault-Token"), body)
+		return
+	}
+	token := r.Header.Get("X-Vault-Token")
+	child, isChild := s.tokens[token]
+	if token != s.Token && !isChild {
+		writeErrors(w, http.StatusForbidden, "permission denied")
+		return
+	}
+
+	switch {
+	case path == "sys/wrapping/wrap":
+		s.wrap(w, r.Header.Get("X-Vault-Wrap-TTL"), body)
+	case path == "auth/token/create" || strings.HasPrefix(path, "auth/token/create/"):
+		if isChild && child.batch {
+			writeErrors(w, http.StatusBadRequest, "batch tokens cannot create more tokens")
+			return
+		}
+		s.createToken(w, path, body)
+	case path == "auth/token/lookup-self":
+		info := map[string]interface{}{
+			"display_name": "token", "policies": []string{"root"}, "ttl": 0, "renewable": false,
+			"path": "auth/token/create", "accessor": "fake-accessor", "type": "service",
+		}
+		if isChild {
+			info["path"] = child.path
+			info["ttl"] = 3600
+			if child.batch {
+				info["type"] = "batch"
+			} else {
+				info["renewable"] = true
+			}
+		}
+		writeData(w, info)
+	case path == "auth/token/revoke-self":
+		delete(s.tokens, token)
+		w.WriteHeader(http.StatusNoContent)
+	case path == "sys/capabilities-self":
+		paths := []interface{}{body["path"]}
+		if list, ok := body["paths"].([]interface{}); ok {
+			paths = list
+		}
+		resp := map[string]interface{}{"capabilities": []string{"root"}}
+		for _, p := range paths {
+			if p, ok := p.(string); ok {
+				resp[p] = []string{"root"}
+			}
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case strings.HasPrefix(path, "sys/tools/random"):
+		s.random(w, strings.Trim(strings.TrimPrefix(path, "sys/tools/random"), "/"), body)
+	case path == "sys/internal/ui/mounts":
+		writeData(w, map[string]interface{}{"secret": s.mountTable()})
+	case strings.HasPrefix(path, "sys/mounts/") && (method == http.MethodPost || method == http.MethodPut):
+		s.enableMount(w, strings.Trim(strings.TrimPrefix(path, "sys/mounts/"), "/"), body)
+	case strings.HasPrefix(path, "sys/internal/ui/mounts/"):
+		s.serveMount(w, strings.Trim(strings.TrimPrefix(path, "sys/internal/ui/mounts/"), "/"))
+	default:
+		if mount, rest, ok := findMount(path, s.kv); ok {
+			s.serveKV(w, r, method, mount, rest, body)
+			return
+		}
+		if mount, rest, ok := findMount(path, s.transit); ok {
+			s.serveTransit(w, method, mount, rest, body)
+			return
+		}
+		writeErrors(w, http.StatusNotFound, "no handler for route \""+path+"\"")
+	}
+}
+
+func (s *Server) serveKV(w http.ResponseWriter, r *http.Request, method, mount, rest string, body map[string]interface{}) {
+	kind, path, _ := strings.Cut(rest, "/")
+	path = strings.Trim(path, "/")
+
+	switch {
+	case kind == "data" && method == http.MethodGet:
+		versionNum, _ := strconv.Atoi(r.URL.Query().Get("version"))
+		v := s.version(mount, path, versionNum)
+		if v == nil {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		secret := s.kv[mount][path]
+		num := versionNum
+		if num == 0 {
+			num = len(secret.versions)
+		}
+		writeData(w, map[string]interface{}{
+			"data": v.data,
+			"metadata": map[string]interface{}{
+				"version": num, "created_time": v.created.Format(time.RFC3339Nano),
+				"deletion_time": "", "destroyed": false,
+			},
+		})
+	case kind == "data" && (method == http.MethodPut || method == http.MethodPost):
+		data, _ := body["data"].(map[string]interface{})
+		if data == nil {
+			writeErrors(w, http.StatusBadRequest, "no data provided")
+			return
+		}
+		if options, ok := body["options"].(map[string]interface{}); ok && options["cas"] != nil {
+			cas, _ := strconv.Atoi(fmt.Sprint(options["cas"]))
+			current := 0
+			if secret := s.kv[mount][path]; secret != nil {
+				current = len(secret.versions)
+			}
+			if cas != current {
+				writeErrors(w, http.StatusBadRequest, "check-and-set parameter did not match the current version")
+				return
+			}
+		}
+		num := s.put(mount, path, data)
+		writeData(w, map[string]interface{}{"version": num, "created_time": time.Now().UTC().Format(time.RFC3339Nano)})
+	case kind == "data" && method == http.MethodDelete:
+		if v := s.version(mount, path, 0); v != nil {
+			v.deleted = true
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case kind == "metadata" && method == "LIST":
+		keys := s.list(mount, path)
+		if len(keys) == 0 {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		writeData(w, map[string]interface{}{"keys": keys})
+	case kind == "metadata" && method == http.MethodGet:
+		secret := s.kv[mount][path]
+		if secret == nil {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		versions := map[string]interface{}{}
+		for i, v := range secret.versions {
+			versions[strconv.Itoa(i+1)] = map[string]interface{}{
+				"created_time": v.created.Format(time.RFC3339Nano), "deletion_time": "", "destroyed": false,
+			}
+		}
+		last := secret.versions[len(secret.versions)-1]
+		writeData(w, map[string]interface{}{
+			"current_version": len(secret.versions),
+			"created_time":    secret.created.Format(time.RFC3339Nano),
+			"updated_time":    last.created.Format(time.RFC3339Nano),
+			"versions":        versions,
+		})
+	case kind == "metadata" && method == http.MethodDelete:
+		delete(s.kv[mount], path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeErrors(w, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+func (s *Server) serveTransit(w http.ResponseWriter, method, mount, rest string, body map[string]interface{}) {
+	op, key, _ := strings.Cut(rest, "/")
+	keys := s.transit[mount]
+
+	switch {
+	case op == "encrypt":
+		plaintext, err := base64.StdEncoding.DecodeString(fmt.Sprint(body["plaintext"]))
+		if err != nil {
+			writeErrors(w, http.StatusBadRequest, "failed to base64-decode plaintext")
+			return
+		}
+		if keys[key] == 0 {
+			keys[key] = 1 // as Vault, encrypt creates a missing key
+		}
+		version := keys[key]
+		if requested, _ := strconv.Atoi(fmt.Sprint(body["key_version"])); requested > 0 {
+			if requested > version {
+				writeErrors(w, http.StatusBadRequest, "requested version for encryption is higher than the latest key version")
+				return
+			}
+			version = requested
+		}
+		writeData(w, map[string]interface{}{"ciphertext": encrypt(key, version, plaintext), "key_version": version})
+	case op == "decrypt":
+		if keys[key] == 0 {
+			writeErrors(w, http.StatusBadRequest, "encryption key not found")
+			return
+		}
+		plaintext, err := decrypt(key, fmt.Sprint(body["ciphertext"]))
+		if err != nil {
+			writeErrors(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		writeData(w, map[string]interface{}{"plaintext": base64.StdEncoding.EncodeToString(plaintext)})
+	case op == "datakey":
+		key = strings.TrimPrefix(key, "plaintext/")
+		if keys[key] == 0 {
+			writeErrors(w, http.StatusBadRequest, "encryption key not found")
+			return
+		}
+		dataKey := make([]byte, 32)
+		_, _ = rand.Read(dataKey)
+		writeData(w, map[string]interface{}{
+			"plaintext":  base64.StdEncoding.EncodeToString(dataKey),
+			"ciphertext": encrypt(key, keys[key], dataKey),
+		})
+	case op == "keys" && strings.HasSuffix(key, "/rotate"):
+		key = strings.TrimSuffix(key, "/rotate")
+		if keys[key] == 0 {
+			writeErrors(w, http.StatusBadRequest, "encryption key not found")
+			return
+		}
+		keys[key]++
+		w.WriteHeader(http.StatusNoContent)
+	case op == "keys" && (method == http.MethodPost || method == http.MethodPut):
+		if keys[key] == 0 {
+			keys[key] = 1
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case op == "keys" && method == http.MethodGet:
+		if keys[key] == 0 {
+			writeErrors(w, http.StatusNotFound)
+			return
+		}
+		versions := map[string]interface{}{}
+		for i := 1; i <= keys[key]; i++ {
+			versions[strconv.Itoa(i)] = time.Now().Unix()
+		}
+		writeData(w, map[string]interface{}{
+			"name": key, "type": "aes256-gcm96", "latest_version": keys[key],
+			"min_decryption_version": 1, "min_encryption_version": 0, "keys": versions,
+			"supports_encryption": true, "supports_decryption": true,
+		})
+	default:
+		writeErrors(w, http.StatusMethodNotAllowed, "unsupported operation")
+	}
+}
+
+// serveMount answers sys/internal/ui/mounts/<path> for the mount at path.
+// Like Vault, an unknown mount is a 403.
+// random answers sys/tools/random[/<bytes>] with base64 or hex random bytes
+func (s *Server) random(w http.ResponseWriter, rest string, body map[string]interface{}) {
+	n := 32
+	if rest != "" {
+		var err error
+		if n, err = strconv.Atoi(rest); err != nil || n <= 0 {
+			writeErrors(w, http.StatusBadRequest, "invalid number of bytes")
+			return
+		}
+	}
+	random := make([]byte, n)
+	if _, err := rand.Read(random); err != nil {
+		writeErrors(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	encoded := base64.StdEncoding.EncodeToString(random)
+	if body["format"] == "hex" {
+		encoded = hex.EncodeToString(random)
+	}
+	writeData(w, map[string]interface{}{"random_bytes": encoded})
+}
+
+// createToken creates a child token, a batch token when the body asks for
+// one
+func (s *Server) createToken(w http.ResponseWriter, path string, body map[string]interface{}) {
+	batch := body["type"] == "batch"
+	id := make([]byte, 12)
+	_, _ = rand.Read(id)
+	prefix := "hvs."
+	if batch {
+		prefix = "hvb."
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(id)
+	s.tokens[token] = childToken{batch: batch, path: path}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"auth": map[string]interface{}{
+			"client_token":   token,
+			"accessor":       "accessor-" + token[len(token)-6:],
+			"policies":       []string{"root"},
+			"lease_duration": 3600,
+			"renewable":      !batch,
+		},
+	})
+}
+
+// wrap stores body under a new single-use wrapping token
+func (s *Server) wrap(w http.ResponseWriter, ttlHeader string, body map[string]interface{}) {
+	ttl, err := time.ParseDuration(ttlHeader)
+	if err != nil {
+		seconds, serr := strconv.Atoi(ttlHeader)
+		if serr != nil || seconds <= 0 {
+			writeErrors(w, http.StatusBadRequest, "wrap ttl must be provided via the X-Vault-Wrap-TTL header")
+			return
+		}
+		ttl = time.Duration(seconds) * time.Second
+	}
+
+	id := make([]byte, 12)
+	_, _ = rand.Read(id)
+	token := "hvs.wrap" + base64.RawURLEncoding.EncodeToString(id)
+	now := time.Now().UTC()
+	s.wrapped[token] = wrappedResponse{data: copyData(body), expires: now.Add(ttl)}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"wrap_info": map[string]interface{}{
+			"token":         token,
+			"accessor":      "wrap-accessor-" + token[len(token)-6:],
+			"ttl":           int(ttl.Seconds()),
+			"creation_time": now.Format(time.RFC3339Nano),
+			"creation_path": "sys/wrapping/wrap",
+		},
+	})
+}
+
+// unwrap returns the response stored under a wrapping token and forgets it
+func (s *Server) unwrap(w http.ResponseWriter, token string, body map[string]interface{}) {
+	if t, ok := body["token"].(string); ok && t != "" {
+		token = t
+	}
+	resp, ok := s.wrapped[token]
+	delete(s.wrapped, token)
+	if !ok || time.Now().After(resp.expires) {
+		writeErrors(w, http.StatusBadRequest, "wrapping token is not valid or does not exist")
+		return
+	}
+	writeData(w, resp.data)
+}
+
+func (s *Server) serveMount(w http.ResponseWriter, path string) {
+	for mount, info := range s.mountTable() {
+		if strings.TrimSuffix(mount, "/") == path {
+			info := info.(map[string]interface{})
+			info["path"] = mount
+			writeData(w, info)
+			return
+		}
+	}
+	writeErrors(w, http.StatusForbidden, "preflight capability check returned 403, please ensure client's policies grant access to path \""+path+"/\"")
+}
+
+// enableMount answers sys/mounts/<path>. KV mounts are always version 2.
+func (s *Server) enableMount(w http.ResponseWriter, path string, body map[string]interface{}) {
+	_, isKV := s.kv[path]
+	_, isTransit := s.transit[path]
+	if isKV || isTransit {
+		writeErrors(w, http.StatusBadRequest, "path is already in use at "+path+"/")
+		return
+	}
+	switch body["type"] {
+	case "kv", "kv-v2":
+		s.kv[path] = map[string]*kvSecret{}
+	case "transit":
+		s.transit[path] = map[string]int{}
+	default:
+		writeErrors(w, http.StatusBadRequest, fmt.Sprintf("plugin not found in the catalog: %v", body["type"]))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) mountTable() map[string]interface{} {
+	table := map[string]interface{}{}
+	for mount := range s.kv {
+		table[mount+"/"] = map[string]interface{}{"type": "kv", "options": map[string]interface{}{"version": "2"}}
+	}
+	for mount := range s.transit {
+		table[mount+"/"] = map[string]interface{}{"type": "transit", "options": nil}
+	}
+	return table
+}
+
+// findMount splits path into the longest mount of mounts it starts with and
+// the rest
+func findMount[T any](path string, mounts map[string]T) (string, string, bool) {
+	best := ""
+	for mount := range mounts {
+		if strings.HasPrefix(path, mount+"/") && len(mount) > len(best) {
+			best = mount
+		}
+	}
+	if best == "" {
+		return "", "", false
+	}
+	return best, strings.TrimPrefix(path, best+"/"), true
+}
+
+func (s *Server) put(mount, path string, data map[string]interface{}) int {
+	now := time.Now().UTC()
+	secret := s.kv[mount][path]
+	if secret == nil {
+		secret = &kvSecret{created: now}
+		s.kv[mount][path] = secret
+	}
+	secret.versions = append(secret.versions, kvVersion{data: copyData(data), created: now})
+	return len(secret.versions)
+}
+
+// version returns version num of a secret (0 for the latest), or nil when
+// it does not exist or is deleted
+func (s *Server) version(mount, path string, num int) *kvVersion {
+	secret := s.kv[mount][path]
+	if secret == nil {
+		return nil
+	}
+	if num == 0 {
+		num = len(secret.versions)
+	}
+	if num < 1 || num > len(secret.versions) || secret.versions[num-1].deleted {
+		return nil
+	}
+	return &secret.versions[num-1]
+}
+
+// list returns the entries directly under dir: secret names, and
+// sub-directories with a trailing slash
+func (s *Server) list(mount, dir string) []string {
+	prefix := ""
+	if dir != "" {
+		prefix = dir + "/"
+	}
+	seen := map[string]bool{}
+	for path := range s.kv[mount] {
+		rest, ok := strings.CutPrefix(path, prefix)
+		if !ok || rest == "" {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i >= 0 {
+			rest = rest[:i+1]
+		}
+		seen[rest] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// encrypt returns deterministic ciphertext in Transit's vault:v<N>: format.
+// The key name is bound into it so decrypting with another key fails.
+func encrypt(key string, version int, plaintext []byte) string {
+	payload := append([]byte(key+"\x00"), plaintext...)
+	return fmt.Sprintf("vault:v%d:%s", version, base64.StdEncoding.EncodeToString(payload))
+}
+
+func decrypt(key, ciphertext string) ([]byte, error) {
+	parts := strings.SplitN(ciphertext, ":", 3)
+	if len(parts) != 3 || parts[0] != "vault" || !strings.HasPrefix(parts[1], "v") {
+		return nil, fmt.Errorf("invalid ciphertext: no prefix")
+	}
+	payload, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ciphertext: %v", err)
+	}
+	plaintext, ok := bytes.CutPrefix(payload, []byte(key+"\x00"))
+	if !ok {
+		return nil, fmt.Errorf("cipher: message authentication failed")
+	}
+	return plaintext, nil
+}
+
+func copyData(data map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		out[k] = v
+	}
+	return out
+}
+
+func writeData(w http.ResponseWriter, data map[string]interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...string) {
+	if errs == nil {
+		errs = []string{}
+	}
+	writeJSON(w, status, map[string]interface{}{"errors": errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,127 @@
+package vaultfake_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/razzkumar/vlt/pkg/vault"
+	"github.com/razzkumar/vlt/pkg/vault/vaultfake"
+)
+
+func newClient(t *testing.T) (*vaultfake.Server, *vault.Client) {
+	t.Helper()
+	s := vaultfake.New()
+	t.Cleanup(s.Close)
+	client, err := s.Client()
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	return s, client
+}
+
+func TestKVVersionsAndCAS(t *testing.T) {
+	_, client := newClient(t)
+
+	// Check-and-set 0 only creates a missing secret
+	if err := client.KVPutCAS("kv", "app/db", map[string]interface{}{"USER": "app"}, 0); err != nil {
+		t.Fatalf("create with cas 0: %v", err)
+	}
+	if err := client.KVPutCAS("kv", "app/db", map[string]interface{}{"USER": "other"}, 0); !errors.Is(err, vault.ErrCASMismatch) {
+		t.Fatalf("second create with cas 0: got %v, want ErrCASMismatch", err)
+	}
+
+	if err := client.KVPut("kv", "app/db", map[string]interface{}{"USER": "app", "PASSWORD": "v2"}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	data, version, err := client.KVGetVersion("kv", "app/db")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if version != 2 || data["PASSWORD"] != "v2" {
+		t.Fatalf("got version %d with %v, want version 2 with PASSWORD=v2", version, data)
+	}
+
+	// A stale version is refused and leaves the secret alone
+	if err := client.KVPutCAS("kv", "app/db", map[string]interface{}{"PASSWORD": "stale"}, 1); !errors.Is(err, vault.ErrCASMismatch) {
+		t.Fatalf("put with stale cas: got %v, want ErrCASMismatch", err)
+	}
+	if err := client.KVPutCAS("kv", "app/db", map[string]interface{}{"PASSWORD": "v3"}, 2); err != nil {
+		t.Fatalf("put with current cas: %v", err)
+	}
+
+	meta, err := client.KVGetMetadata("kv", "app/db")
+	if err != nil {
+		t.Fatalf("metadata: %v", err)
+	}
+	if meta.CurrentVersion != 3 {
+		t.Fatalf("current version %d, want 3", meta.CurrentVersion)
+	}
+
+	if err := client.KVDelete("kv", "app/db"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := client.KVGet("kv", "app/db"); !errors.Is(err, vault.ErrSecretNotFound) {
+		t.Fatalf("get after delete: got %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestTransitRoundTrip(t *testing.T) {
+	s, client := newClient(t)
+	s.CreateTransitKey("transit", "app")
+	s.CreateTransitKey("transit", "other")
+
+	ciphertext, err := client.TransitEncrypt("transit", "app", []byte("s3cret"))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(ciphertext, "vault:v1:") {
+		t.Fatalf("ciphertext %q does not look like Transit's", ciphertext)
+	}
+	plaintext, err := client.TransitDecrypt("transit", "app", ciphertext)
+	if err != nil || string(plaintext) != "s3cret" {
+		t.Fatalf("decrypt: got %q, %v", plaintext, err)
+	}
+	if _, err := client.TransitDecrypt("transit", "other", ciphertext); err == nil {
+		t.Fatal("decrypting with another key succeeded")
+	}
+
+	// Ciphertext made for seeding decrypts like the server's own
+	plaintext, err = client.TransitDecrypt("transit", "app", s.Encrypt("transit", "app", []byte("seeded")))
+	if err != nil || string(plaintext) != "seeded" {
+		t.Fatalf("decrypt seeded ciphertext: got %q, %v", plaintext, err)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	s, client := newClient(t)
+	err := s.Seed(strings.NewReader(`
+kv:
+  team:
+    myapp/db:
+      password: s3cr3t
+      port: 5432
+transit:
+  transit:
+    - app-secrets
+`))
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	data, ok := s.Get("team", "myapp/db")
+	if !ok || data["password"] != "s3cr3t" {
+		t.Fatalf("seeded secret: got %v, %v", data, ok)
+	}
+	read, err := client.KVGet("team", "myapp/db")
+	if err != nil || read["password"] != "s3cr3t" {
+		t.Fatalf("read seeded secret: got %v, %v", read, err)
+	}
+	if version, err := client.TransitKeyVersion("transit", "app-secrets"); err != nil || version != 1 {
+		t.Fatalf("seeded transit key: got version %d, %v", version, err)
+	}
+
+	if err := s.Seed(strings.NewReader("secrets: {}\n")); err == nil {
+		t.Fatal("seed with an unknown field succeeded")
+	}
+}